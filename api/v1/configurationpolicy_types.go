@@ -78,6 +78,18 @@ type Target struct {
 	// Exclude is an array of filepath expressions to exclude objects by name.
 	Exclude []NonEmptyString `json:"exclude,omitempty"`
 
+	// MatchAnnotations is a map of annotations that an object must have, with the exact values, to
+	// be selected.
+	MatchAnnotations map[string]string `json:"matchAnnotations,omitempty"`
+
+	// MinAge is the minimum amount of time since an object was created for it to be selected, in the
+	// format of a Go duration, such as `1h` or `30m`.
+	MinAge *metav1.Duration `json:"minAge,omitempty"`
+
+	// ContainsObjects is a list of objects that a namespace must contain to be selected. A namespace
+	// must contain at least one matching object for each entry in the list.
+	ContainsObjects []ContainedObjectSelector `json:"containsObjects,omitempty"`
+
 	// TerminatingInclusion adjusts whether terminating objects should be included in the selection.
 	// Use 'IfMatch' to include them if they match the other filters, or use 'Never' to always skip
 	// terminating objects. 'Default' uses the controller's default behavior (which defaults to
@@ -88,20 +100,97 @@ type Target struct {
 	TerminatingInclusion string `json:"terminatingInclusion,omitempty"`
 }
 
+// ContainedObjectSelector selects the objects of a kind in a namespace.
+type ContainedObjectSelector struct {
+	// APIVersion is the API version of the objects, such as `apps/v1`.
+	//
+	// +kubebuilder:validation:MinLength=1
+	APIVersion string `json:"apiVersion"`
+
+	// Kind is the kind of the objects, such as `Deployment`.
+	//
+	// +kubebuilder:validation:MinLength=1
+	Kind string `json:"kind"`
+
+	// Selector is a label selector for the objects. When it is not set, any object of the kind is
+	// matched.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
 // IsEmpty returns whether the defined Target would always return no objects.
 func (t Target) IsEmpty() bool {
-	return t.LabelSelector == nil && len(t.Include) == 0
+	return t.LabelSelector == nil && len(t.Include) == 0 && len(t.MatchAnnotations) == 0 && t.MinAge == nil &&
+		len(t.ContainsObjects) == 0
 }
 
 // Define String() so that the LabelSelector is dereferenced in the logs
 func (t Target) String() string {
-	fmtSelectorStr := "{include:%s,exclude:%s,matchLabels:%+v,matchExpressions:%+v,terminatingInclusion:%s}"
+	fmtSelectorStr := "{include:%s,exclude:%s,matchLabels:%+v,matchExpressions:%+v,matchAnnotations:%v," +
+		"minAge:%s,containsObjects:%s,terminatingInclusion:%s}"
+
+	var matchLabels map[string]string
+	var matchExpressions []metav1.LabelSelectorRequirement
+
+	if t.LabelSelector != nil {
+		matchLabels = t.MatchLabels
+		matchExpressions = t.MatchExpressions
+	}
 
-	if t.LabelSelector == nil {
-		return fmt.Sprintf(fmtSelectorStr, t.Include, t.Exclude, nil, nil, t.TerminatingInclusion)
+	minAge := ""
+	if t.MinAge != nil {
+		minAge = t.MinAge.Duration.String()
 	}
 
-	return fmt.Sprintf(fmtSelectorStr, t.Include, t.Exclude, t.MatchLabels, t.MatchExpressions, t.TerminatingInclusion)
+	containsObjects := make([]string, 0, len(t.ContainsObjects))
+
+	for _, contained := range t.ContainsObjects {
+		containsObjects = append(containsObjects, contained.String())
+	}
+
+	return fmt.Sprintf(fmtSelectorStr, t.Include, t.Exclude, matchLabels, matchExpressions, t.MatchAnnotations,
+		minAge, containsObjects, t.TerminatingInclusion)
+}
+
+// Define String() so that the Selector is dereferenced in the logs
+func (c ContainedObjectSelector) String() string {
+	if c.Selector == nil {
+		return fmt.Sprintf("{apiVersion:%s,kind:%s,selector:%+v}", c.APIVersion, c.Kind, nil)
+	}
+
+	return fmt.Sprintf("{apiVersion:%s,kind:%s,selector:%+v}", c.APIVersion, c.Kind, *c.Selector)
+}
+
+// ObjectSelector selects the objects for an object template. An object must match all of the
+// specified criteria to be selected. An empty object selector selects all objects.
+type ObjectSelector struct {
+	*metav1.LabelSelector `json:",inline"`
+
+	// Include is an array of filepath expressions to include objects by name.
+	Include []NonEmptyString `json:"include,omitempty"`
+
+	// Exclude is an array of filepath expressions to exclude objects by name.
+	Exclude []NonEmptyString `json:"exclude,omitempty"`
+
+	// FieldSelector is a comma separated list of field requirements, such as
+	// `spec.type=LoadBalancer,status.phase!=Pending`. Each field is a dot separated path in the object,
+	// and the supported operators are `=`, `==`, and `!=`. A field that is not set on an object matches
+	// an empty value.
+	FieldSelector string `json:"fieldSelector,omitempty"`
+}
+
+// Define String() so that the LabelSelector is dereferenced in the logs
+func (o *ObjectSelector) String() string {
+	if o == nil {
+		return "nil"
+	}
+
+	fmtSelectorStr := "{include:%s,exclude:%s,matchLabels:%+v,matchExpressions:%+v,fieldSelector:%s}"
+
+	if o.LabelSelector == nil {
+		return fmt.Sprintf(fmtSelectorStr, o.Include, o.Exclude, nil, nil, o.FieldSelector)
+	}
+
+	return fmt.Sprintf(fmtSelectorStr, o.Include, o.Exclude, o.MatchLabels, o.MatchExpressions, o.FieldSelector)
 }
 
 // EvaluationInterval configures the minimum elapsed time before a configuration policy is
@@ -250,9 +339,58 @@ type ObjectTemplate struct {
 	// references sensitive data. For all other kinds, the default value is `InStatus`.
 	RecordDiff RecordDiff `json:"recordDiff,omitempty"`
 
-	// ObjectSelector defines the label selector for objects defined in the `objectDefinition`. If
-	// there is an object name defined in the `objectDefinition`, the `objectSelector` is ignored.
-	ObjectSelector *metav1.LabelSelector `json:"objectSelector,omitempty"`
+	// ObjectSelector defines the label selector, name patterns, and field selector for objects
+	// defined in the `objectDefinition`. If there is an object name defined in the `objectDefinition`,
+	// the `objectSelector` is ignored.
+	ObjectSelector *ObjectSelector `json:"objectSelector,omitempty"`
+
+	// DependentWorkloads is a list of workloads that consume the object defined in the
+	// `objectDefinition`, such as a Deployment mounting a ConfigMap or Secret. When the policy is
+	// enforced and the object is updated, a rollout restart is triggered on each dependent workload by
+	// setting the `kubectl.kubernetes.io/restartedAt` annotation on its pod template. This parameter
+	// has no effect when the `remediationAction` is set to `inform`.
+	DependentWorkloads []DependentWorkload `json:"dependentWorkloads,omitempty"`
+
+	// ObjectCount defines the minimum and maximum number of objects on the cluster that match the
+	// object template in each selected namespace. The objects are matched using the `objectSelector`
+	// and the fields in the `objectDefinition`. When the object count is set, the object template is
+	// compliant only if the number of matching objects is within the range. This parameter is ignored
+	// if there is an object name defined in the `objectDefinition` or if the `complianceType` is
+	// `mustnothave`.
+	ObjectCount *ObjectCount `json:"objectCount,omitempty"`
+}
+
+// ObjectCount defines a range for the number of objects matching an object template.
+type ObjectCount struct {
+	// Min is the minimum number of matching objects. The default value is 0.
+	//
+	// +kubebuilder:validation:Minimum=0
+	Min int32 `json:"min,omitempty"`
+
+	// Max is the maximum number of matching objects. When it is not set, there is no maximum.
+	//
+	// +kubebuilder:validation:Minimum=0
+	Max *int32 `json:"max,omitempty"`
+}
+
+// DependentWorkload identifies one or more workloads to restart when the object in the object
+// template is updated. Exactly one of `name` and `selector` must be set.
+type DependentWorkload struct {
+	// Kind is the kind of the workload. The supported options are `Deployment`, `StatefulSet`, and
+	// `DaemonSet`.
+	//
+	// +kubebuilder:validation:Enum=Deployment;StatefulSet;DaemonSet
+	Kind string `json:"kind"`
+
+	// Name is the name of the workload.
+	Name string `json:"name,omitempty"`
+
+	// Namespace is the namespace of the workload. The default value is the namespace of the object
+	// that was updated.
+	Namespace string `json:"namespace,omitempty"`
+
+	// Selector is a label selector for the workloads of the specified kind in the namespace.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
 }
 
 // RecordDiffWithDefault parses the `objectDefinition` in the policy for the kind and returns the
@@ -317,6 +455,34 @@ type ConfigurationPolicySpec struct {
 	// `object-templates` and `object-templates-raw` can be set in a configuration policy. For more on
 	// the Go templates, see https://github.com/stolostron/go-template-utils/blob/main/README.md.
 	ObjectTemplatesRaw string `json:"object-templates-raw,omitempty"`
+
+	// TargetCluster overrides the cluster where the policy is evaluated and enforced. By default, the
+	// policy is evaluated on the cluster the controller is configured to manage.
+	TargetCluster *TargetCluster `json:"targetCluster,omitempty"`
+}
+
+// TargetCluster specifies an alternative cluster to evaluate and enforce a policy on.
+type TargetCluster struct {
+	// KubeconfigSecretRef references a Secret in the namespace of the policy with a kubeconfig to
+	// connect to the target cluster.
+	//
+	// +kubebuilder:validation:Required
+	KubeconfigSecretRef KubeconfigSecretReference `json:"kubeconfigSecretRef"`
+}
+
+// KubeconfigSecretReference references a key in a Secret that contains a kubeconfig.
+type KubeconfigSecretReference struct {
+	// Name is the name of the Secret.
+	//
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Key is the key in the Secret with the kubeconfig. The default value is `kubeconfig`. The kubeconfig
+	// must have inline credentials; exec plugins, auth providers, and file paths such as `tokenFile` are
+	// rejected.
+	//
+	// +kubebuilder:default:=kubeconfig
+	Key string `json:"key,omitempty"`
 }
 
 // ComplianceState reports the observed status from the definitions of the policy.
@@ -431,6 +597,10 @@ type ObjectProperties struct {
 	// there was an initial mismatch between the policy and object, but the dry run update produced
 	// a compliant result.
 	MatchesAfterDryRun bool `json:"matchesAfterDryRun,omitempty"`
+
+	// PendingRestart indicates that the object was updated but the rollout restart of its dependent
+	// workloads failed. The restart is retried on each evaluation until it succeeds.
+	PendingRestart bool `json:"pendingRestart,omitempty"`
 }
 
 // RelatedObject contains the details of an object matched by the policy.
@@ -463,6 +633,11 @@ type ConfigurationPolicyStatus struct {
 	// evaluated.
 	LastEvaluatedGeneration int64 `json:"lastEvaluatedGeneration,omitempty"`
 
+	// LastEvaluationTrigger is the value of the `policy.open-cluster-management.io/trigger-evaluation`
+	// annotation when the policy was last evaluated on demand. When the annotation is set to a different value,
+	// such as the current timestamp, the policy is evaluated once regardless of the evaluation interval.
+	LastEvaluationTrigger string `json:"lastEvaluationTrigger,omitempty"`
+
 	// RelatedObjects is a list of objects processed by the configuration policy due to its
 	// `object-templates`.
 	RelatedObjects []RelatedObject `json:"relatedObjects,omitempty"`
@@ -470,6 +645,13 @@ type ConfigurationPolicyStatus struct {
 	// History is a list of the most recent compliance messages for this configuration policy.
 	// The first entry is the most recent, and the list is limited to 10 entries.
 	History []HistoryEvent `json:"history,omitempty"`
+
+	// Conditions are the standard conditions of the configuration policy, which are Compliant, Enforced,
+	// TemplateResolved, Progressing, and Degraded.
+	//
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // HistoryEvent is a timestamped message representing the policy compliance state at that time.
@@ -14,9 +14,9 @@ import (
 )
 
 // RemovalAction is the behavior when the operator policy is removed. The supported options are
-// `Keep`, `Delete`, or `DeleteIfUnused`.
+// `Keep`, `Delete`, `DeleteIfUnused`, or `DeleteAll`.
 //
-// +kubebuilder:validation:Enum=Keep;Delete;DeleteIfUnused
+// +kubebuilder:validation:Enum=Keep;Delete;DeleteIfUnused;DeleteAll
 type RemovalAction string
 
 const (
@@ -29,6 +29,9 @@ const (
 	// DeleteIfUnused is a RemovalBehavior indicating that the controller may delete a type only if it
 	// is not being used by another subscription.
 	DeleteIfUnused RemovalAction = "DeleteIfUnused"
+
+	// DeleteAll is a RemovalBehavior indicating that the controller may delete all objects of a type.
+	DeleteAll RemovalAction = "DeleteAll"
 )
 
 func (ra RemovalAction) IsKeep() bool {
@@ -43,6 +46,10 @@ func (ra RemovalAction) IsDeleteIfUnused() bool {
 	return strings.EqualFold(string(ra), string(DeleteIfUnused))
 }
 
+func (ra RemovalAction) IsDeleteAll() bool {
+	return strings.EqualFold(string(ra), string(DeleteAll))
+}
+
 type RemovalBehavior struct {
 	// Use the `operatorGroups` parameter to specify whether to delete the OperatorGroup. The default
 	// value is `DeleteIfUnused`, which only deletes the OperatorGroup if there is not another
@@ -52,6 +59,21 @@ type RemovalBehavior struct {
 	//+kubebuilder:validation:Enum=Keep;DeleteIfUnused
 	OperatorGroups RemovalAction `json:"operatorGroups,omitempty"`
 
+	// Use the `catalogSources` parameter to specify whether to delete the CatalogSource specified in
+	// `spec.catalogSource`. The default value is `DeleteIfUnused`, which only deletes the CatalogSource
+	// if there is not another Subscription using it.
+	//
+	//+kubebuilder:default=DeleteIfUnused
+	//+kubebuilder:validation:Enum=Keep;DeleteIfUnused
+	CatalogSources RemovalAction `json:"catalogSources,omitempty"`
+
+	// Use the `clusterExtensions` parameter to specify whether to delete the ClusterExtension
+	// specified in `spec.clusterExtension`. The default value is `Delete`.
+	//
+	//+kubebuilder:default=Delete
+	//+kubebuilder:validation:Enum=Keep;Delete
+	ClusterExtensions RemovalAction `json:"clusterExtensions,omitempty"`
+
 	// Use the `subscriptions` parameter to specify whether to delete the Subscription. The default
 	// value is `Delete`.
 	//
@@ -73,6 +95,23 @@ type RemovalBehavior struct {
 	//+kubebuilder:default=Keep
 	//+kubebuilder:validation:Enum=Keep;Delete
 	CRDs RemovalAction `json:"customResourceDefinitions,omitempty"`
+
+	// Use the `operands` parameter to specify whether to delete the custom resources of the
+	// CustomResourceDefinitions owned by the operator. The default value is `Keep`. When set to
+	// `DeleteAll`, all of the custom resources are deleted while the operator is still running, and the
+	// Subscription, ClusterServiceVersion, and CustomResourceDefinitions are only removed after the
+	// custom resources are gone or the `operandDeletionTimeout` elapses.
+	//
+	//+kubebuilder:default=Keep
+	//+kubebuilder:validation:Enum=Keep;DeleteAll
+	Operands RemovalAction `json:"operands,omitempty"`
+
+	// Use the `operandDeletionTimeout` parameter to specify how long to wait for the operands to be
+	// deleted, such as for their finalizers to be handled by the operator, before removing the operator
+	// anyway. The value is a duration such as `10m`, which is the default value.
+	//
+	//+kubebuilder:default=10m
+	OperandDeletionTimeout string `json:"operandDeletionTimeout,omitempty"`
 }
 
 // ApplyDefaults ensures that unset fields in a RemovalBehavior behave as if they were set to the
@@ -85,6 +124,14 @@ func (rb RemovalBehavior) ApplyDefaults() RemovalBehavior {
 		withDefaults.OperatorGroups = DeleteIfUnused
 	}
 
+	if withDefaults.CatalogSources == "" {
+		withDefaults.CatalogSources = DeleteIfUnused
+	}
+
+	if withDefaults.ClusterExtensions == "" {
+		withDefaults.ClusterExtensions = Delete
+	}
+
 	if withDefaults.Subscriptions == "" {
 		withDefaults.Subscriptions = Delete
 	}
@@ -97,6 +144,14 @@ func (rb RemovalBehavior) ApplyDefaults() RemovalBehavior {
 		withDefaults.CRDs = Keep
 	}
 
+	if withDefaults.Operands == "" {
+		withDefaults.Operands = Keep
+	}
+
+	if withDefaults.OperandDeletionTimeout == "" {
+		withDefaults.OperandDeletionTimeout = "10m"
+	}
+
 	return withDefaults
 }
 
@@ -141,9 +196,92 @@ type ComplianceConfig struct {
 	//
 	// +kubebuilder:default=Compliant
 	DeprecationsPresent ComplianceConfigAction `json:"deprecationsPresent,omitempty"`
+	// PodsUnhealthy enables the PodsHealthy typed condition, which reports pods of the operator
+	// Deployments that are in CrashLoopBackOff or that have restarted at least PodRestartThreshold times.
+	// When unset, the pods are not checked. When set to `NonCompliant`, unhealthy pods set the policy
+	// compliance to `NonCompliant`.
+	PodsUnhealthy ComplianceConfigAction `json:"podsUnhealthy,omitempty"`
+	// PodRestartThreshold is the number of restarts of a container in an operator pod at which the pod
+	// is considered unhealthy by the PodsUnhealthy check. The default value is 5.
+	//
+	//+kubebuilder:validation:Minimum=1
+	PodRestartThreshold int32 `json:"podRestartThreshold,omitempty"`
+	// CSVRequirementsNotMet enables the CSVRequirementsMet typed condition, which reports entries in the
+	// ClusterServiceVersion `status.requirementStatus` that are not present. When unset, the requirements
+	// are not checked. When set to `NonCompliant`, unmet requirements set the policy compliance to
+	// `NonCompliant`.
+	CSVRequirementsNotMet ComplianceConfigAction `json:"csvRequirementsNotMet,omitempty"`
+	// OwnedAPIsUnavailable enables the OwnedAPIsAvailable typed condition, which reports webhooks and
+	// APIServices owned by the ClusterServiceVersion that are unavailable. When unset, they are not
+	// checked. When set to `NonCompliant`, unavailable webhooks or APIServices set the policy compliance
+	// to `NonCompliant`.
+	OwnedAPIsUnavailable ComplianceConfigAction `json:"ownedAPIsUnavailable,omitempty"`
+}
+
+// AllowedDependency is an operator package, and optionally a range of its versions, that can be installed as a
+// dependency of the operator.
+type AllowedDependency struct {
+	// Package is the name of the operator package of the dependency.
+	//
+	//+kubebuilder:validation:MinLength=1
+	Package string `json:"package"`
+
+	// VersionRange is a semantic version constraint, such as `>=1.2.0 <2.0.0`, that the bundle version of the
+	// dependency must satisfy. When unset, any version of the package is allowed.
+	VersionRange string `json:"versionRange,omitempty"`
+}
+
+// Operand is a custom resource of the operator, such as a `StorageCluster` or an `ArgoCD` instance, that is
+// applied after the operator is installed.
+type Operand struct {
+	// ComplianceType describes how the object on the cluster is compared with the object definition of the
+	// operand. The supported options are `MustHave` or `MustOnlyHave`. The default value is `MustHave`.
+	//
+	// +kubebuilder:default=musthave
+	// +kubebuilder:validation:Enum=MustHave;Musthave;musthave;MustOnlyHave;Mustonlyhave;mustonlyhave
+	ComplianceType policyv1.ComplianceType `json:"complianceType,omitempty"`
+
+	// ObjectDefinition is the templatable definition of the operand. It must include the `apiVersion`,
+	// `kind`, and `metadata.name` fields. When the operand is namespaced and the namespace is not set,
+	// the namespace of the Subscription is used.
+	//
+	//+kubebuilder:validation:Required
+	//+kubebuilder:pruning:PreserveUnknownFields
+	ObjectDefinition runtime.RawExtension `json:"objectDefinition"`
+}
+
+// Weekday is a day of the week, such as `Monday`.
+//
+// +kubebuilder:validation:Enum=Sunday;Monday;Tuesday;Wednesday;Thursday;Friday;Saturday
+type Weekday string
+
+// UpgradeApprovalWindow is a recurring window of time when the controller can approve 'upgrade'
+// InstallPlans.
+type UpgradeApprovalWindow struct {
+	// Days is the list of the days of the week when the window opens. When empty, the window opens
+	// every day.
+	Days []Weekday `json:"days,omitempty"`
+
+	// Start is the time of the day when the window opens, in the 24-hour `HH:MM` format.
+	//
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	Start string `json:"start"`
+
+	// Duration is how long the window stays open, such as `4h` or `90m`. It can't be longer than
+	// `24h`.
+	//
+	//+kubebuilder:validation:Required
+	Duration string `json:"duration"`
+
+	// TimeZone is the IANA time zone of the start time, such as `America/New_York`. The default
+	// value is `UTC`.
+	TimeZone string `json:"timeZone,omitempty"`
 }
 
 // OperatorPolicySpec defines the desired state of a particular operator on the cluster.
+//
+// +kubebuilder:validation:XValidation:rule="has(self.subscription) != has(self.clusterExtension)",message="exactly one of subscription or clusterExtension is required"
 type OperatorPolicySpec struct {
 	Severity policyv1.Severity `json:"severity,omitempty"`
 	// +kubebuilder:default=inform
@@ -170,14 +308,50 @@ type OperatorPolicySpec struct {
 	OperatorGroup *runtime.RawExtension `json:"operatorGroup,omitempty"`
 
 	// Subscription specifies which operator `Subscription` resource to inspect. Include the
-	// namespace, and any `spec` fields for the Subscription.
+	// namespace, and any `spec` fields for the Subscription. Exactly one of `subscription` or
+	// `clusterExtension` is required.
 	//
 	// For more info, see `kubectl explain subscriptions.operators.coreos.com.spec` or view
 	// https://olm.operatorframework.io/docs/concepts/crds/subscription/.
 	//
-	//+kubebuilder:validation:Required
 	//+kubebuilder:pruning:PreserveUnknownFields
-	Subscription runtime.RawExtension `json:"subscription"`
+	//+optional
+	Subscription runtime.RawExtension `json:"subscription,omitempty"`
+
+	// ClusterExtension specifies an OLM v1 `ClusterExtension` resource to manage instead of the OLM v0
+	// Subscription, for clusters that use OLM v1. Include the name, and any `spec` fields for the
+	// ClusterExtension, such as `namespace`, `serviceAccount`, and `source.catalog.packageName`. The
+	// `source.catalog.version` field is prohibited, since the controller sets it from `versions`,
+	// `versionRange`, and `upgradeApproval`: when `upgradeApproval` is `None`, the version is pinned to
+	// the installed bundle after the initial installation. The `removalBehavior.clusterExtensions`
+	// setting applies when the policy is `mustnothave`. The health of the Deployments of the
+	// ClusterExtension, the serving state of its ClusterCatalogs, and its deprecations are reported
+	// like for a Subscription. The `operatorGroup`, `catalogSource`, `operands`,
+	// `upgradeApprovalWindows`, `upgradeSoakTime`, and `upgradeFailurePolicy: Rollback` settings are
+	// not supported with a ClusterExtension, and `complianceConfig.upgradesAvailable` has no effect.
+	//
+	// For more info, see `kubectl explain clusterextensions.spec` or view
+	// https://operator-framework.github.io/operator-controller/.
+	//
+	//+kubebuilder:pruning:PreserveUnknownFields
+	//+optional
+	ClusterExtension *runtime.RawExtension `json:"clusterExtension,omitempty"`
+
+	// CatalogSource specifies a `CatalogSource` resource that the controller manages for the operator,
+	// such as for a disconnected cluster. Include the name, the namespace, and any `spec` fields for the
+	// CatalogSource, such as the `image`, the `updateStrategy.registryPoll.interval`, and the
+	// `grpcPodConfig`. The namespace defaults to the namespace of the Subscription, and the `sourceType`
+	// defaults to `grpc`. When set, the `source` and `sourceNamespace` of the Subscription default to this
+	// CatalogSource, and the `channel` and `namespace` of the Subscription are required since they can't
+	// be determined before the CatalogSource exists. The CatalogSource is enforced like the OperatorGroup,
+	// and its health is reported in the `CatalogSourcesUnhealthy` condition.
+	//
+	// For more info, see `kubectl explain catalogsources.spec` or view
+	// https://olm.operatorframework.io/docs/concepts/crds/catalogsource/.
+	//
+	//+kubebuilder:pruning:PreserveUnknownFields
+	//+optional
+	CatalogSource *runtime.RawExtension `json:"catalogSource,omitempty"`
 
 	// Versions is a list of templatable strings that specifies which installed ClusterServiceVersion names are
 	// compliant when in `inform` mode and which `InstallPlans` are approved when in `enforce` mode. Empty or whitespace
@@ -185,6 +359,25 @@ type OperatorPolicySpec struct {
 	// An empty list approves all ClusterServiceVersion names. The default value is empty.
 	Versions []string `json:"versions,omitempty"`
 
+	// VersionRange is a templatable semantic version constraint, such as `>=1.12.0 <1.14.0` or `~2.3`, that is
+	// evaluated against the bundle version of the ClusterServiceVersion. A constraint with only an upper bound, such
+	// as `<=1.14.2`, acts as a maximum version. Versions with a pre-release suffix, such as `4.16.3-rhodf`, are
+	// compared like any other version. A ClusterServiceVersion is allowed when its name is in `versions` or
+	// its version satisfies the range. When unset, only `versions` is used.
+	VersionRange string `json:"versionRange,omitempty"`
+
+	// AllowedDependencies is a list of the operator packages that can be installed as dependencies of the
+	// operator when an InstallPlan is approved. An InstallPlan that would install a dependency that is not
+	// listed, or with a version outside of the listed range, is not approved, and the dependencies are reported
+	// in the InstallPlan condition and related object. When empty, all dependencies are allowed.
+	AllowedDependencies []AllowedDependency `json:"allowedDependencies,omitempty"`
+
+	// Operands is a list of custom resources of the operator to apply when the policy is `musthave`.
+	// The operands are only handled after the ClusterServiceVersion has succeeded and the
+	// CustomResourceDefinitions of the operands are established. They are reported in the
+	// `OperandsCompliant` condition.
+	Operands []Operand `json:"operands,omitempty"`
+
 	// Use RemovalBehavior to define what resources need to be removed when enforcing `mustnothave`
 	// policies. When in `inform` mode, any resources that are deleted if the policy is set to
 	// `enforce` makes the policy noncompliant, but resources that are kept are compliant.
@@ -195,18 +388,49 @@ type OperatorPolicySpec struct {
 	// UpgradeApproval determines whether 'upgrade' InstallPlans for the operator will be approved
 	// by the controller when the policy is enforced and in 'musthave' mode. The initial InstallPlan
 	// approval is not affected by this setting. This setting has no effect when the policy is in
-	// 'mustnothave' mode. Allowed values are "None" or "Automatic".
+	// 'mustnothave' mode. Allowed values are "None" or "Automatic". On OpenShift, an upgrade InstallPlan
+	// is not approved when one of its bundles has an `olm.maxOpenShiftVersion` property that would block
+	// the cluster from upgrading to the next minor version, and the policy reports `UpgradeBlocked`.
 	//
 	//+kubebuilder:validation:Required
 	//+kubebuilder:validation:Enum=None;Automatic
 	UpgradeApproval string `json:"upgradeApproval"`
 
+	// UpgradeApprovalWindows is a list of recurring windows of time when 'upgrade' InstallPlans can
+	// be approved when `upgradeApproval` is `Automatic`. An InstallPlan that is otherwise approved is
+	// kept pending until the next window opens. When empty, InstallPlans can be approved at any time.
+	// The initial InstallPlan approval is not affected by this setting.
+	UpgradeApprovalWindows []UpgradeApprovalWindow `json:"upgradeApprovalWindows,omitempty"`
+
+	// UpgradeSoakTime is the minimum time, such as `72h`, since an upgrade was first available before
+	// its 'upgrade' InstallPlan can be approved when `upgradeApproval` is `Automatic`. The soak time
+	// counts from when OLM created the InstallPlan, not from when the bundle was first published in
+	// the catalog. The initial InstallPlan approval is not affected by this setting.
+	UpgradeSoakTime string `json:"upgradeSoakTime,omitempty"`
+
+	// UpgradeFailurePolicy determines what the controller does when an upgrade of the operator fails,
+	// which is when OLM reports the ClusterServiceVersion of the upgrade as `Failed`. Deployments that
+	// stay unavailable only trigger a rollback once OLM marks the ClusterServiceVersion as `Failed` after
+	// its install check times out; the Deployment status alone is not checked. The supported options are
+	// `None` and `Rollback`. When set to `Rollback` and the policy is enforced, the failed
+	// ClusterServiceVersion and the Subscription are deleted, the Subscription is recreated with the
+	// previous version as the `startingCSV`, and the failed version is blocked from being approved
+	// again. The default value is `None`, which only reports the failure.
+	//
+	//+kubebuilder:default=None
+	//+kubebuilder:validation:Enum=None;Rollback
+	UpgradeFailurePolicy string `json:"upgradeFailurePolicy,omitempty"`
+
 	// ComplianceConfig defines how resource statuses affect the OperatorPolicy status and compliance.
 	// When set to Compliant, the condition does not impact the OperatorPolicy compliance. When set to
 	// NonCompliant, the condition causes the OperatorPolicy to become NonCompliant.
 	//
 	//+kubebuilder:default={}
 	ComplianceConfig ComplianceConfig `json:"complianceConfig,omitempty"`
+
+	// TargetCluster overrides the cluster where the operator is managed. By default, the operator is
+	// managed on the cluster the controller is configured to manage.
+	TargetCluster *policyv1.TargetCluster `json:"targetCluster,omitempty"`
 }
 
 // OperatorPolicyStatus is the observed state of the operators from the specifications given in the
@@ -243,6 +467,27 @@ type OperatorPolicyStatus struct {
 	// the policy may update the status of the Subscription.
 	SubscriptionInterventionTime *metav1.Time `json:"subscriptionInterventionTime,omitempty"`
 
+	// PendingUpgrade is the upgrade of the operator that is waiting for the approval of its
+	// InstallPlan.
+	PendingUpgrade *PendingUpgrade `json:"pendingUpgrade,omitempty"`
+
+	// OperandDeletionDeadline is when the policy stops waiting for the operands to be deleted
+	// before removing the operator. It is set while the operands are being deleted with the
+	// `operands: DeleteAll` removal behavior.
+	OperandDeletionDeadline *metav1.Time `json:"operandDeletionDeadline,omitempty"`
+
+	// LastSucceededVersion is the name of the most recent ClusterServiceVersion of the operator that
+	// succeeded. It is the version that is restored when an upgrade fails and the policy has the
+	// `upgradeFailurePolicy: Rollback` setting.
+	LastSucceededVersion string `json:"lastSucceededVersion,omitempty"`
+
+	// BlockedVersions are the names of the ClusterServiceVersions that failed to upgrade and were
+	// rolled back. Their InstallPlans are not approved, even if the versions are allowed by the policy.
+	BlockedVersions []string `json:"blockedVersions,omitempty"`
+
+	// Rollback is the rollback of a failed upgrade of the operator that is in progress.
+	Rollback *UpgradeRollback `json:"rollback,omitempty"`
+
 	// History is a list of the most recent compliance messages for this operator policy.
 	// The first entry is the most recent, and the list is limited to 10 entries.
 	History []policyv1.HistoryEvent `json:"history,omitempty"`
@@ -285,6 +530,30 @@ func (status OperatorPolicyStatus) SubscriptionInterventionExpired() bool {
 	return status.SubscriptionInterventionTime.Time.Before(time.Now().Add(-10 * time.Second))
 }
 
+// PendingUpgrade describes an upgrade of the operator that is waiting for the approval of its
+// InstallPlan.
+type PendingUpgrade struct {
+	// Version is the name of the ClusterServiceVersion that the operator would be upgraded to.
+	Version string `json:"version"`
+
+	// FirstSeen is when the upgrade was first available, which is used for `upgradeSoakTime`.
+	FirstSeen metav1.Time `json:"firstSeen"`
+
+	// NextApprovalTime is the next time when the controller can approve the InstallPlan, based on
+	// `upgradeApprovalWindows` and `upgradeSoakTime`. It is unset when the policy can't approve
+	// the InstallPlan.
+	NextApprovalTime *metav1.Time `json:"nextApprovalTime,omitempty"`
+}
+
+// UpgradeRollback describes the rollback of a failed upgrade of the operator.
+type UpgradeRollback struct {
+	// FailedVersion is the name of the ClusterServiceVersion of the upgrade that failed.
+	FailedVersion string `json:"failedVersion"`
+
+	// Version is the name of the ClusterServiceVersion that the operator is being rolled back to.
+	Version string `json:"version"`
+}
+
 // Returns true if the SubscriptionInterventionTime is in the future.
 func (status OperatorPolicyStatus) SubscriptionInterventionWaiting() bool {
 	if status.SubscriptionInterventionTime == nil {
@@ -294,6 +563,15 @@ func (status OperatorPolicyStatus) SubscriptionInterventionWaiting() bool {
 	return status.SubscriptionInterventionTime.Time.After(time.Now())
 }
 
+// Returns true if the operands are being deleted and the OperandDeletionDeadline is in the future.
+func (status OperatorPolicyStatus) OperandDeletionWaiting() bool {
+	if status.OperandDeletionDeadline == nil {
+		return false
+	}
+
+	return status.OperandDeletionDeadline.Time.After(time.Now())
+}
+
 // OperatorPolicy is the schema for the operatorpolicies API. You can use the operator policy to
 // manage operators by providing automation for their management and reporting on the status across
 // the various operator objects.
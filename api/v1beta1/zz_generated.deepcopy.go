@@ -13,6 +13,21 @@ import (
 	apiv1 "open-cluster-management.io/config-policy-controller/api/v1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AllowedDependency) DeepCopyInto(out *AllowedDependency) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllowedDependency.
+func (in *AllowedDependency) DeepCopy() *AllowedDependency {
+	if in == nil {
+		return nil
+	}
+	out := new(AllowedDependency)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ComplianceConfig) DeepCopyInto(out *ComplianceConfig) {
 	*out = *in
@@ -28,6 +43,22 @@ func (in *ComplianceConfig) DeepCopy() *ComplianceConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Operand) DeepCopyInto(out *Operand) {
+	*out = *in
+	in.ObjectDefinition.DeepCopyInto(&out.ObjectDefinition)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Operand.
+func (in *Operand) DeepCopy() *Operand {
+	if in == nil {
+		return nil
+	}
+	out := new(Operand)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OperatorPolicy) DeepCopyInto(out *OperatorPolicy) {
 	*out = *in
@@ -96,13 +127,47 @@ func (in *OperatorPolicySpec) DeepCopyInto(out *OperatorPolicySpec) {
 		(*in).DeepCopyInto(*out)
 	}
 	in.Subscription.DeepCopyInto(&out.Subscription)
+	if in.ClusterExtension != nil {
+		in, out := &in.ClusterExtension, &out.ClusterExtension
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CatalogSource != nil {
+		in, out := &in.CatalogSource, &out.CatalogSource
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Versions != nil {
 		in, out := &in.Versions, &out.Versions
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.AllowedDependencies != nil {
+		in, out := &in.AllowedDependencies, &out.AllowedDependencies
+		*out = make([]AllowedDependency, len(*in))
+		copy(*out, *in)
+	}
+	if in.Operands != nil {
+		in, out := &in.Operands, &out.Operands
+		*out = make([]Operand, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	out.RemovalBehavior = in.RemovalBehavior
+	if in.UpgradeApprovalWindows != nil {
+		in, out := &in.UpgradeApprovalWindows, &out.UpgradeApprovalWindows
+		*out = make([]UpgradeApprovalWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	out.ComplianceConfig = in.ComplianceConfig
+	if in.TargetCluster != nil {
+		in, out := &in.TargetCluster, &out.TargetCluster
+		*out = new(apiv1.TargetCluster)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorPolicySpec.
@@ -141,6 +206,25 @@ func (in *OperatorPolicyStatus) DeepCopyInto(out *OperatorPolicyStatus) {
 		in, out := &in.SubscriptionInterventionTime, &out.SubscriptionInterventionTime
 		*out = (*in).DeepCopy()
 	}
+	if in.PendingUpgrade != nil {
+		in, out := &in.PendingUpgrade, &out.PendingUpgrade
+		*out = new(PendingUpgrade)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OperandDeletionDeadline != nil {
+		in, out := &in.OperandDeletionDeadline, &out.OperandDeletionDeadline
+		*out = (*in).DeepCopy()
+	}
+	if in.BlockedVersions != nil {
+		in, out := &in.BlockedVersions, &out.BlockedVersions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Rollback != nil {
+		in, out := &in.Rollback, &out.Rollback
+		*out = new(UpgradeRollback)
+		**out = **in
+	}
 	if in.History != nil {
 		in, out := &in.History, &out.History
 		*out = make([]apiv1.HistoryEvent, len(*in))
@@ -160,6 +244,26 @@ func (in *OperatorPolicyStatus) DeepCopy() *OperatorPolicyStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PendingUpgrade) DeepCopyInto(out *PendingUpgrade) {
+	*out = *in
+	in.FirstSeen.DeepCopyInto(&out.FirstSeen)
+	if in.NextApprovalTime != nil {
+		in, out := &in.NextApprovalTime, &out.NextApprovalTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PendingUpgrade.
+func (in *PendingUpgrade) DeepCopy() *PendingUpgrade {
+	if in == nil {
+		return nil
+	}
+	out := new(PendingUpgrade)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RemovalBehavior) DeepCopyInto(out *RemovalBehavior) {
 	*out = *in
@@ -174,3 +278,38 @@ func (in *RemovalBehavior) DeepCopy() *RemovalBehavior {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeApprovalWindow) DeepCopyInto(out *UpgradeApprovalWindow) {
+	*out = *in
+	if in.Days != nil {
+		in, out := &in.Days, &out.Days
+		*out = make([]Weekday, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeApprovalWindow.
+func (in *UpgradeApprovalWindow) DeepCopy() *UpgradeApprovalWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeApprovalWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeRollback) DeepCopyInto(out *UpgradeRollback) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeRollback.
+func (in *UpgradeRollback) DeepCopy() *UpgradeRollback {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeRollback)
+	in.DeepCopyInto(out)
+	return out
+}
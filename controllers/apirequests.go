@@ -0,0 +1,111 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type policyRequestKey struct{}
+
+// namespaceSubresources are the subresources of the namespaces resource, which can't be distinguished from
+// namespaced resources by the number of path segments.
+var namespaceSubresources = map[string]bool{"status": true, "finalize": true}
+
+// policyRequestInfo identifies the policy that Kubernetes API requests are made for.
+type policyRequestInfo struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+// withPolicyRequests returns a context that attributes the Kubernetes API requests made with it to the
+// policy in the policy_api_requests_total metric.
+func withPolicyRequests(ctx context.Context, kind, namespace, name string) context.Context {
+	return context.WithValue(ctx, policyRequestKey{}, policyRequestInfo{kind, namespace, name})
+}
+
+// apiRequestCounter is an http.RoundTripper that counts the Kubernetes API requests in the
+// policy_api_requests_total metric.
+type apiRequestCounter struct {
+	next http.RoundTripper
+}
+
+// CountAPIRequests wraps the transport of a Kubernetes client so that its requests are counted in the
+// policy_api_requests_total metric. It is meant to be passed to rest.Config.Wrap.
+func CountAPIRequests(rt http.RoundTripper) http.RoundTripper {
+	return &apiRequestCounter{next: rt}
+}
+
+func (c *apiRequestCounter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if group, version, resource, verb, ok := parseAPIRequest(req); ok {
+		policy, _ := req.Context().Value(policyRequestKey{}).(policyRequestInfo)
+
+		apiRequestsCounter.WithLabelValues(
+			policy.kind, policy.name, policy.namespace, verb, group, version, resource,
+		).Inc()
+	}
+
+	return c.next.RoundTrip(req)
+}
+
+// parseAPIRequest determines the requested resource and the Kubernetes API verb from the request. It
+// returns false if the request is not for a resource, such as for discovery.
+func parseAPIRequest(req *http.Request) (group, version, resource, verb string, ok bool) {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+
+	switch {
+	case len(parts) >= 3 && parts[0] == "api":
+		version = parts[1]
+		parts = parts[2:]
+	case len(parts) >= 4 && parts[0] == "apis":
+		group = parts[1]
+		version = parts[2]
+		parts = parts[3:]
+	default:
+		return "", "", "", "", false
+	}
+
+	// Skip the namespace of namespaced resources, but not for the namespaces resource itself and its
+	// subresources
+	if len(parts) >= 3 && parts[0] == "namespaces" && !(len(parts) == 3 && namespaceSubresources[parts[2]]) {
+		parts = parts[2:]
+	}
+
+	resource = parts[0]
+	hasName := len(parts) >= 2
+
+	if len(parts) >= 3 {
+		resource += "/" + strings.Join(parts[2:], "/")
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		switch {
+		case req.URL.Query().Get("watch") == "true" || req.URL.Query().Get("watch") == "1":
+			verb = "watch"
+		case hasName:
+			verb = "get"
+		default:
+			verb = "list"
+		}
+	case http.MethodPost:
+		verb = "create"
+	case http.MethodPut:
+		verb = "update"
+	case http.MethodPatch:
+		verb = "patch"
+	case http.MethodDelete:
+		if hasName {
+			verb = "delete"
+		} else {
+			verb = "deletecollection"
+		}
+	default:
+		verb = strings.ToLower(req.Method)
+	}
+
+	return group, version, resource, verb, true
+}
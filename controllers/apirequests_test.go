@@ -0,0 +1,111 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAPIRequest(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		method   string
+		path     string
+		group    string
+		version  string
+		resource string
+		verb     string
+		notAPI   bool
+	}{
+		"core list": {
+			http.MethodGet, "/api/v1/namespaces/default/configmaps", "", "v1", "configmaps", "list", false,
+		},
+		"core get": {
+			http.MethodGet, "/api/v1/namespaces/default/configmaps/cm", "", "v1", "configmaps", "get", false,
+		},
+		"core watch": {
+			http.MethodGet, "/api/v1/configmaps?watch=true", "", "v1", "configmaps", "watch", false,
+		},
+		"namespace get": {
+			http.MethodGet, "/api/v1/namespaces/default", "", "v1", "namespaces", "get", false,
+		},
+		"namespace list": {
+			http.MethodGet, "/api/v1/namespaces", "", "v1", "namespaces", "list", false,
+		},
+		"namespace status": {
+			http.MethodPut, "/api/v1/namespaces/default/status", "", "v1", "namespaces/status", "update", false,
+		},
+		"group create": {
+			http.MethodPost, "/apis/apps/v1/namespaces/ns/deployments", "apps", "v1", "deployments", "create", false,
+		},
+		"group patch": {
+			http.MethodPatch, "/apis/apps/v1/namespaces/ns/deployments/d", "apps", "v1", "deployments", "patch", false,
+		},
+		"cluster scoped": {
+			http.MethodDelete, "/apis/rbac.authorization.k8s.io/v1/clusterroles/r",
+			"rbac.authorization.k8s.io", "v1", "clusterroles", "delete", false,
+		},
+		"subresource": {
+			http.MethodPut, "/apis/apps/v1/namespaces/ns/deployments/d/status",
+			"apps", "v1", "deployments/status", "update", false,
+		},
+		"delete collection": {
+			http.MethodDelete, "/api/v1/namespaces/ns/pods", "", "v1", "pods", "deletecollection", false,
+		},
+		"core discovery": {
+			http.MethodGet, "/api/v1", "", "", "", "", true,
+		},
+		"group discovery": {
+			http.MethodGet, "/apis/apps/v1", "", "", "", "", true,
+		},
+		"non-resource request": {
+			http.MethodGet, "/version", "", "", "", "", true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(test.method, test.path, nil)
+
+			group, version, resource, verb, ok := parseAPIRequest(req)
+			assert.Equal(t, !test.notAPI, ok)
+			assert.Equal(t, test.group, group)
+			assert.Equal(t, test.version, version)
+			assert.Equal(t, test.resource, resource)
+			assert.Equal(t, test.verb, verb)
+		})
+	}
+}
+
+type noopRoundTripper struct{}
+
+func (noopRoundTripper) RoundTrip(*http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func TestCountAPIRequests(t *testing.T) {
+	t.Parallel()
+
+	rt := CountAPIRequests(noopRoundTripper{})
+	ctx := withPolicyRequests(context.TODO(), "ConfigurationPolicy", "policies", "api-requests-policy")
+
+	for range 2 {
+		req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/api/v1/namespaces/default/secrets/s", nil)
+
+		_, err := rt.RoundTrip(req)
+		assert.NoError(t, err)
+	}
+
+	assert.InDelta(t, 2, testutil.ToFloat64(apiRequestsCounter.WithLabelValues(
+		"ConfigurationPolicy", "api-requests-policy", "policies", "get", "", "v1", "secrets",
+	)), 0)
+}
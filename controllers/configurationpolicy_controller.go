@@ -22,6 +22,7 @@ import (
 	gocmp "github.com/google/go-cmp/cmp"
 	templates "github.com/stolostron/go-template-utils/v7/pkg/templates"
 	depclient "github.com/stolostron/kubernetes-dependency-watches/client"
+	"go.opentelemetry.io/otel/attribute"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	extensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -48,7 +49,9 @@ import (
 	yaml "sigs.k8s.io/yaml"
 
 	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+	"open-cluster-management.io/config-policy-controller/pkg/audit"
 	common "open-cluster-management.io/config-policy-controller/pkg/common"
+	"open-cluster-management.io/config-policy-controller/pkg/notifications"
 )
 
 const (
@@ -56,10 +59,16 @@ const (
 	CRDName                    = "configurationpolicies.policy.open-cluster-management.io"
 	pruneObjectFinalizer       = "policy.open-cluster-management.io/delete-related-objects"
 	disableTemplatesAnnotation = "policy.open-cluster-management.io/disable-templates"
+	// EvaluationTriggerAnnotation triggers an evaluation of the policy when it is set to a new value, such as the
+	// current timestamp.
+	EvaluationTriggerAnnotation = "policy.open-cluster-management.io/trigger-evaluation"
 
 	reasonWantFoundExists    = "Resource found as expected"
 	reasonWantFoundCreated   = "K8s creation success"
 	reasonUpdateSuccess      = "K8s update success"
+	reasonUpdateRestarted    = "K8s update success, rollout restart triggered"
+	reasonRestartError       = "K8s rollout restart error"
+	reasonRolloutRestarted   = "Rollout restart triggered"
 	reasonDeleteSuccess      = "K8s deletion success"
 	reasonWantFoundNoMatch   = "Resource found but does not match"
 	reasonWantFoundDNE       = "Resource not found but should exist"
@@ -68,6 +77,8 @@ const (
 	reasonCleanupError       = "Error cleaning up child objects"
 	reasonFoundNotApplicable = "Resource found but will not be handled in mustnothave mode"
 	reasonTemplateError      = "Error processing template"
+	reasonObjectCountMatch   = "Object count as expected"
+	reasonObjectCountNoMatch = "Object count not as expected"
 )
 
 var (
@@ -120,6 +131,7 @@ func (r *ConfigurationPolicyReconciler) SetupWithManager(
 					// These are the options that change evaluation behavior that aren't in the spec.
 					specialAnnoChanged := oldAnnos[IVAnnotation] != newAnnos[IVAnnotation] ||
 						oldAnnos[disableTemplatesAnnotation] != newAnnos[disableTemplatesAnnotation] ||
+						oldAnnos[EvaluationTriggerAnnotation] != newAnnos[EvaluationTriggerAnnotation] ||
 						oldAnnos[common.UninstallingAnnotation] != newAnnos[common.UninstallingAnnotation]
 
 					if specialAnnoChanged {
@@ -181,8 +193,24 @@ type ConfigurationPolicyReconciler struct {
 	TargetK8sClient        kubernetes.Interface
 	TargetK8sDynamicClient dynamic.Interface
 	SelectorReconciler     common.SelectorReconciler
+	// TargetClusters provides the clients for policies that set spec.targetCluster. When nil, those
+	// policies are reported as noncompliant.
+	TargetClusters *TargetClusterManager
+	// targetClients has the ConfigurationPolicy ObjectIdentifier as the key and the *TargetClusterClients
+	// resolved from its spec.targetCluster field as the value.
+	targetClients sync.Map
 	// Whether custom metrics collection is enabled
 	EnableMetrics bool
+	// ObjectMetrics reports the compliance of the related objects when the opt-in metric is enabled
+	ObjectMetrics *ObjectComplianceMetrics
+	// PolicyReporter writes the compliance of the related objects to PolicyReports when enabled
+	PolicyReporter *PolicyReporter
+	// Notifier sends CloudEvents notifications to the configured sinks when enabled
+	Notifier *notifications.Notifier
+	// AuditLog records the changes made by the policies when enabled
+	AuditLog *audit.Logger
+	// ObjectEvents emits events on the affected objects when enabled
+	ObjectEvents *ObjectEventRecorder
 	// When true, the controller has detected it is being uninstalled and only basic cleanup should be performed before
 	// exiting.
 	UninstallMode bool
@@ -194,6 +222,8 @@ type ConfigurationPolicyReconciler struct {
 	// This is a workaround to account for race conditions where the status is updated but the controller-runtime cache
 	// has not updated yet.
 	lastEvaluatedCache sync.Map
+	// evaluations has the evaluation state of the policies for the debug endpoint.
+	evaluations evaluationTracker
 	// for standalone hub templating
 	HubDynamicWatcher depclient.DynamicWatcher
 	HubClient         *kubernetes.Clientset
@@ -206,23 +236,78 @@ type ConfigurationPolicyReconciler struct {
 	TemplateFuncDenylist []string
 }
 
-//+kubebuilder:rbac:groups=*,resources=*,verbs=*
+// target returns the clients to use when evaluating and enforcing the policy. This is the cluster in the
+// policy's spec.targetCluster field if set, and otherwise the default target cluster of the controller.
+func (r *ConfigurationPolicyReconciler) target(plc *policyv1.ConfigurationPolicy) *TargetClusterClients {
+	return r.targetFor(plc.ObjectIdentifier())
+}
 
-// Reconcile is responsible for evaluating and rescheduling ConfigurationPolicy evaluations.
-func (r *ConfigurationPolicyReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	log := ctrl.LoggerFrom(ctx)
+// targetFor returns the clients of the ConfigurationPolicy with the object identifier, as described in target.
+func (r *ConfigurationPolicyReconciler) targetFor(objID depclient.ObjectIdentifier) *TargetClusterClients {
+	if clients, ok := r.targetClients.Load(objID); ok {
+		return clients.(*TargetClusterClients)
+	}
 
-	if r.ItemLimiters != nil {
-		limiter := r.ItemLimiters.GetLimiter(request)
+	return &TargetClusterClients{
+		K8sClient:          r.TargetK8sClient,
+		DynamicClient:      r.TargetK8sDynamicClient,
+		DynamicWatcher:     r.DynamicWatcher,
+		SelectorReconciler: r.SelectorReconciler,
+	}
+}
 
-		// Check if a token is available; if so, `Allow` will spend it and return true
-		if limiter.Tokens() < 1.0 || !limiter.Allow() {
-			log.V(2).Info("Throttling policy evaluation")
+// resolveTargetCluster determines the clients returned by target for the policy. When the policy
+// switches to another cluster, its watches and namespace selection on the previous cluster are stopped.
+func (r *ConfigurationPolicyReconciler) resolveTargetCluster(
+	ctx context.Context, plc *policyv1.ConfigurationPolicy,
+) error {
+	objID := plc.ObjectIdentifier()
 
-			return reconcile.Result{RequeueAfter: time.Second * time.Duration(r.EvalBackoffSeconds)}, nil
+	clients, err := resolveTargetCluster(ctx, r.TargetClusters, objID, plc.Namespace, plc.Spec.TargetCluster)
+	if err != nil {
+		return err
+	}
+
+	if clients == nil {
+		r.targetClients.Delete(objID)
+
+		return nil
+	}
+
+	if _, loaded := r.targetClients.Swap(objID, clients); !loaded {
+		// The policy was previously evaluated on the default cluster
+		if err := r.DynamicWatcher.RemoveWatcher(objID); err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, "Failed to remove the watches on the default cluster. Will ignore.")
 		}
+
+		r.SelectorReconciler.Stop(plc.Namespace, plc.Name)
 	}
 
+	return nil
+}
+
+//+kubebuilder:rbac:groups=*,resources=*,verbs=*
+
+// Reconcile is responsible for evaluating and rescheduling ConfigurationPolicy evaluations.
+func (r *ConfigurationPolicyReconciler) Reconcile(
+	ctx context.Context, request ctrl.Request,
+) (result ctrl.Result, err error) {
+	ctx, span := startReconcileSpan(ctx, "ConfigurationPolicy", request)
+	defer span.End()
+
+	var evaluatedAt time.Time
+	var deleted bool
+
+	defer func() {
+		if !deleted {
+			r.evaluations.reconciled(request.NamespacedName, evaluatedAt, result, err)
+		}
+	}()
+
+	ctx = withPolicyRequests(ctx, "ConfigurationPolicy", request.Namespace, request.Name)
+
+	log := ctrl.LoggerFrom(ctx)
+
 	policy := &policyv1.ConfigurationPolicy{}
 
 	cleanup, err := r.cleanupImmediately(ctx)
@@ -234,13 +319,22 @@ func (r *ConfigurationPolicyReconciler) Reconcile(ctx context.Context, request c
 
 	err = r.Get(ctx, request.NamespacedName, policy)
 	if k8serrors.IsNotFound(err) {
+		deleted = true
+
 		if cleanup {
 			return reconcile.Result{}, nil
 		}
 
 		log.V(1).Info("Handling a deleted policy")
 		removeConfigPolicyMetrics(request)
+		r.ObjectMetrics.Remove("ConfigurationPolicy", request.Namespace, request.Name)
+
+		if err := r.PolicyReporter.Remove(ctx, "ConfigurationPolicy", request.Namespace, request.Name); err != nil {
+			log.Error(err, "Failed to remove the policy from the policy reports. Will ignore.")
+		}
+
 		r.SelectorReconciler.Stop(request.Namespace, request.Name)
+		r.evaluations.remove(request.NamespacedName)
 
 		objID := depclient.ObjectIdentifier{
 			Group:     policyv1.GroupVersion.Group,
@@ -255,6 +349,12 @@ func (r *ConfigurationPolicyReconciler) Reconcile(ctx context.Context, request c
 			log.Error(err, "Failed to remove any watches from this deleted ConfigurationPolicy. Will ignore.")
 		}
 
+		if r.TargetClusters != nil {
+			r.TargetClusters.Release(objID)
+		}
+
+		r.targetClients.Delete(objID)
+
 		return reconcile.Result{}, nil
 	}
 
@@ -262,6 +362,20 @@ func (r *ConfigurationPolicyReconciler) Reconcile(ctx context.Context, request c
 		return reconcile.Result{}, err
 	}
 
+	// An evaluation triggered on demand bypasses the rate limiter once
+	if trigger, triggered := evaluationTrigger(policy); triggered {
+		log.Info("The policy evaluation was triggered on demand", "trigger", trigger)
+	} else if r.ItemLimiters != nil {
+		limiter := r.ItemLimiters.GetLimiter(request)
+
+		// Check if a token is available; if so, `Allow` will spend it and return true
+		if limiter.Tokens() < 1.0 || !limiter.Allow() {
+			log.V(2).Info("Throttling policy evaluation")
+
+			return reconcile.Result{RequeueAfter: time.Second * time.Duration(r.EvalBackoffSeconds)}, nil
+		}
+	}
+
 	// Account for a change in evaluation interval either due to a spec change or compliance state change.
 	defer func() {
 		compliantWithWatch := policy.Status.ComplianceState == policyv1.Compliant &&
@@ -270,15 +384,17 @@ func (r *ConfigurationPolicyReconciler) Reconcile(ctx context.Context, request c
 			policy.Spec.EvaluationInterval.IsWatchForNonCompliant()
 
 		if !(compliantWithWatch || nonCompliantWithWatch) && !cleanup {
-			err := r.DynamicWatcher.RemoveWatcher(policy.ObjectIdentifier())
+			err := r.target(policy).DynamicWatcher.RemoveWatcher(policy.ObjectIdentifier())
 			if err != nil {
 				log.Error(err, "Failed to remove any watches related to this ConfigurationPolicy. Will ignore.")
 			}
 		}
 	}()
 
-	// If the ConfigurationPolicy's spec field was updated, clear the cache of evaluated objects.
-	if policy.Status.LastEvaluatedGeneration != policy.Generation {
+	// If the ConfigurationPolicy's spec field was updated or an evaluation was triggered on demand, clear the
+	// cache of evaluated objects.
+	_, triggered := evaluationTrigger(policy)
+	if triggered || policy.Status.LastEvaluatedGeneration != policy.Generation {
 		r.processedPolicyCache.Delete(policy.GetUID())
 	}
 
@@ -307,6 +423,26 @@ func (r *ConfigurationPolicyReconciler) Reconcile(ctx context.Context, request c
 		return reconcile.Result{}, nil
 	}
 
+	if err := r.resolveTargetCluster(ctx, policy); err != nil {
+		log.Error(err, "Failed to get the clients for the target cluster")
+
+		// The objects on a target cluster that can't be resolved can't be pruned, so don't block the
+		// deletion of the policy on them.
+		if policy.DeletionTimestamp != nil {
+			return reconcile.Result{}, r.removeDeletionFinalizer(ctx, policy)
+		}
+
+		statusChanged := addConditionToStatus(policy, -1, false, "Target cluster error", err.Error())
+
+		if statusChanged {
+			r.recordInfoEvent(policy, true)
+		}
+
+		r.addForUpdate(ctx, policy, statusChanged)
+
+		return reconcile.Result{RequeueAfter: targetClusterRetryInterval}, nil
+	}
+
 	shouldEvaluate, durationLeft := r.shouldEvaluatePolicy(policy, log)
 	if !shouldEvaluate {
 		// Requeue based on the remaining time for the evaluation interval to be met.
@@ -317,7 +453,8 @@ func (r *ConfigurationPolicyReconciler) Reconcile(ctx context.Context, request c
 
 	handleErr := r.handleObjectTemplates(ctx, policy)
 
-	duration := time.Now().UTC().Sub(before)
+	evaluatedAt = time.Now().UTC()
+	duration := evaluatedAt.Sub(before)
 	seconds := float64(duration) / float64(time.Second)
 
 	policyStatusGauge.WithLabelValues(
@@ -327,12 +464,13 @@ func (r *ConfigurationPolicyReconciler) Reconcile(ctx context.Context, request c
 	)
 	policyEvalSecondsCounter.WithLabelValues(policy.Name).Add(seconds)
 	policyEvalCounter.WithLabelValues(policy.Name).Inc()
+	policyEvalDurationHistogram.WithLabelValues("ConfigurationPolicy").Observe(seconds)
 
 	if handleErr != nil {
 		// If the policy is invalid, don't bother requeueing since we need to wait for a spec change.
 		if errors.Is(handleErr, ErrPolicyInvalid) {
 			// Remove any watches on the policy in case the policy used to be valid and specified watches.
-			err := r.DynamicWatcher.RemoveWatcher(policy.ObjectIdentifier())
+			err := r.target(policy).DynamicWatcher.RemoveWatcher(policy.ObjectIdentifier())
 			if err != nil {
 				log.Error(err, "Failed to remove any watches related to this ConfigurationPolicy. Will ignore.")
 			}
@@ -351,6 +489,14 @@ func (r *ConfigurationPolicyReconciler) Reconcile(ctx context.Context, request c
 		return reconcile.Result{}, handleErr
 	}
 
+	// A failed rollout restart doesn't change any watched object, so retry it even when the policy only
+	// evaluates on watch events
+	if hasPendingRestarts(policy) && policy.Spec.EvaluationInterval.IsWatchForNonCompliant() {
+		log.Info("Requeuing the policy to be reevaluated in 10 seconds to retry the dependent workload restarts")
+
+		return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
 	var requeueAfter time.Duration
 	var getIntervalErr error
 
@@ -378,7 +524,7 @@ func (r *ConfigurationPolicyReconciler) Reconcile(ctx context.Context, request c
 
 	// At this point, we know the evaluation interval isn't set to watch so remove any potential watches for this
 	// policy.
-	removeWatcherErr := r.DynamicWatcher.RemoveWatcher(policy.ObjectIdentifier())
+	removeWatcherErr := r.target(policy).DynamicWatcher.RemoveWatcher(policy.ObjectIdentifier())
 	if removeWatcherErr != nil {
 		log.Error(err, "Failed to remove any watches related to this ConfigurationPolicy. Will ignore.")
 	}
@@ -449,6 +595,12 @@ func (r *ConfigurationPolicyReconciler) shouldEvaluatePolicy(
 		return true, 0
 	}
 
+	if _, triggered := evaluationTrigger(policy); triggered {
+		log.V(1).Info("The policy evaluation was triggered on demand. Will evaluate it now.")
+
+		return true, 0
+	}
+
 	if policy.Status.LastEvaluated == "" {
 		log.V(1).Info("The policy's status.lastEvaluated field is not set. Will evaluate it now.")
 
@@ -478,10 +630,9 @@ func (r *ConfigurationPolicyReconciler) shouldEvaluatePolicy(
 		}
 	}
 
-	usesSelector := policy.Spec.NamespaceSelector.LabelSelector != nil ||
-		len(policy.Spec.NamespaceSelector.Include) != 0
+	usesSelector := !policy.Spec.NamespaceSelector.IsEmpty()
 
-	if usesSelector && r.SelectorReconciler.HasUpdate(policy.Namespace, policy.Name) {
+	if usesSelector && r.target(policy).SelectorReconciler.HasUpdate(policy.Namespace, policy.Name) {
 		log.V(1).Info("There was an update for this policy's namespaces. Will evaluate it now.")
 
 		return true, 0
@@ -579,13 +730,20 @@ func (r *ConfigurationPolicyReconciler) cleanUpChildObjects(
 		objsToDelete = objShouldRemoved
 	}
 
+	target := r.target(plc)
+
 	for _, object := range objsToDelete {
+		// Dependent workloads that were restarted are only referenced by the policy and are never pruned
+		if object.Reason == reasonRolloutRestarted {
+			continue
+		}
+
 		// set up client for object deletion
 		gvk := schema.FromAPIVersionAndKind(object.Object.APIVersion, object.Object.Kind)
 
 		log := ctrl.LoggerFrom(ctx, "groupVersionKind", gvk.String())
 
-		scopedGVR, err := r.DynamicWatcher.GVKToGVR(gvk)
+		scopedGVR, err := target.DynamicWatcher.GVKToGVR(gvk)
 		if err != nil && !errors.Is(err, depclient.ErrResourceUnwatchable) {
 			log.Error(err, "Could not get resource mapping for child object")
 
@@ -600,7 +758,7 @@ func (r *ConfigurationPolicyReconciler) cleanUpChildObjects(
 		var existing *unstructured.Unstructured
 
 		if usingWatch {
-			existing, err = r.DynamicWatcher.Get(
+			existing, err = target.DynamicWatcher.Get(
 				plc.ObjectIdentifier(),
 				gvk,
 				object.Object.Metadata.Namespace,
@@ -613,7 +771,7 @@ func (r *ConfigurationPolicyReconciler) cleanUpChildObjects(
 					object.Object.Metadata.Namespace,
 					object.Object.Metadata.Name,
 					scopedGVR,
-					r.TargetK8sDynamicClient,
+					target.DynamicClient,
 				)
 			}
 		} else {
@@ -622,7 +780,7 @@ func (r *ConfigurationPolicyReconciler) cleanUpChildObjects(
 				object.Object.Metadata.Namespace,
 				object.Object.Metadata.Name,
 				scopedGVR,
-				r.TargetK8sDynamicClient,
+				target.DynamicClient,
 			)
 		}
 
@@ -668,11 +826,11 @@ func (r *ConfigurationPolicyReconciler) cleanUpChildObjects(
 
 			var res dynamic.ResourceInterface
 			if scopedGVR.Namespaced {
-				res = r.TargetK8sDynamicClient.Resource(scopedGVR.GroupVersionResource).Namespace(
+				res = target.DynamicClient.Resource(scopedGVR.GroupVersionResource).Namespace(
 					object.Object.Metadata.Namespace,
 				)
 			} else {
-				res = r.TargetK8sDynamicClient.Resource(scopedGVR.GroupVersionResource)
+				res = target.DynamicClient.Resource(scopedGVR.GroupVersionResource)
 			}
 
 			deleted, err := deleteObject(ctx, res, object.Object.Metadata.Name, object.Object.Metadata.Namespace)
@@ -682,6 +840,14 @@ func (r *ConfigurationPolicyReconciler) cleanUpChildObjects(
 
 				log.Error(err, "Error: Failed to delete object during child object pruning")
 			} else {
+				r.recordEnforced(ctx, plc, "deleted", enforcedObject{
+					apiVersion: object.Object.APIVersion,
+					kind:       object.Object.Kind,
+					namespace:  object.Object.Metadata.Namespace,
+					name:       object.Object.Metadata.Name,
+					uid:        string(existing.GetUID()),
+				}, nil, "The object was pruned")
+
 				// Don't use the cache here to avoid race conditions since this is to verify that the deletion was
 				// successful. The cache is dependent on the watch updating.
 				obj, err := getObject(
@@ -689,7 +855,7 @@ func (r *ConfigurationPolicyReconciler) cleanUpChildObjects(
 					object.Object.Metadata.Namespace,
 					object.Object.Metadata.Name,
 					scopedGVR,
-					r.TargetK8sDynamicClient,
+					target.DynamicClient,
 				)
 				if err != nil {
 					// Note: a NotFound error is handled specially in `getObject`, so this is something different
@@ -788,7 +954,10 @@ func (r *ConfigurationPolicyReconciler) resolveObjectTemplatesRaw(
 	plc *policyv1.ConfigurationPolicy,
 	tmplResolver *templates.TemplateResolver,
 	resolveOptions *templates.ResolveOptions,
-) error {
+) (err error) {
+	ctx, span := startConfigPolicySpan(ctx, "resolveObjectTemplatesRaw", plc)
+	defer func() { endSpan(span, err) }()
+
 	objRawBytes := []byte(plc.Spec.ObjectTemplatesRaw)
 	plc.Spec.ObjectTemplates = []*policyv1.ObjectTemplate{}
 	resolveOptions.InputIsYAML = true
@@ -876,16 +1045,18 @@ func (r *ConfigurationPolicyReconciler) getTemplateResolver(ctx context.Context,
 		DenylistFunctions: r.TemplateFuncDenylist,
 	}
 
+	target := r.target(plc)
+
 	if currentlyUsingWatch(plc) {
 		tmplResolver, err = templates.NewResolverWithDynamicWatcher(
-			r.DynamicWatcher, templates.Config{SkipBatchManagement: true},
+			target.DynamicWatcher, templates.Config{SkipBatchManagement: true},
 		)
 		objID := plc.ObjectIdentifier()
 
 		resolveOptions.Watcher = &objID
 	} else {
 		tmplResolver, err = templates.NewResolverWithClients(
-			r.TargetK8sDynamicClient, r.TargetK8sClient.Discovery(), templates.Config{},
+			target.DynamicClient, target.K8sClient.Discovery(), templates.Config{},
 		)
 	}
 
@@ -925,7 +1096,10 @@ func (r *ConfigurationPolicyReconciler) getTemplateResolver(ctx context.Context,
 // missing on the policy (excluding objectDefinition), an error of type ErrPolicyInvalid is returned.
 func (r *ConfigurationPolicyReconciler) handleObjectTemplates(
 	ctx context.Context, plc *policyv1.ConfigurationPolicy,
-) error {
+) (err error) {
+	ctx, span := startConfigPolicySpan(ctx, "handleObjectTemplates", plc)
+	defer func() { endSpan(span, err) }()
+
 	log := ctrl.LoggerFrom(ctx)
 	log.V(1).Info("Processing object templates")
 
@@ -934,11 +1108,12 @@ func (r *ConfigurationPolicyReconciler) handleObjectTemplates(
 	}
 
 	usingWatch := currentlyUsingWatch(plc)
+	target := r.target(plc)
 
-	if usingWatch && r.DynamicWatcher != nil {
+	if usingWatch && target.DynamicWatcher != nil {
 		watcherObj := plc.ObjectIdentifier()
 
-		err := r.DynamicWatcher.StartQueryBatch(watcherObj)
+		err := target.DynamicWatcher.StartQueryBatch(watcherObj)
 		if err != nil {
 			log.Error(
 				err,
@@ -950,7 +1125,7 @@ func (r *ConfigurationPolicyReconciler) handleObjectTemplates(
 		}
 
 		defer func() {
-			err := r.DynamicWatcher.EndQueryBatch(watcherObj)
+			err := target.DynamicWatcher.EndQueryBatch(watcherObj)
 			if err != nil {
 				log.Error(err, "Failed to stop the query batch using the dynamic watcher", "watcher", watcherObj)
 			}
@@ -1227,6 +1402,29 @@ func (r *ConfigurationPolicyReconciler) manageDeletionFinalizer(
 	return nil
 }
 
+// removeDeletionFinalizer removes the finalizer from the ConfigurationPolicy without cleaning up the
+// child objects. This is used when the policy is deleted but the child objects can't be reached.
+func (r *ConfigurationPolicyReconciler) removeDeletionFinalizer(
+	ctx context.Context, plc *policyv1.ConfigurationPolicy,
+) error {
+	if !objHasFinalizer(plc, pruneObjectFinalizer) {
+		return nil
+	}
+
+	ctrl.LoggerFrom(ctx).Info(
+		"The target cluster is unavailable, removing the finalizer without cleaning up the child objects",
+	)
+
+	patch := removeObjFinalizerPatch(plc, pruneObjectFinalizer)
+
+	err := r.Patch(ctx, plc, client.RawPatch(types.JSONPatchType, patch))
+	if err != nil {
+		return fmt.Errorf("failed to remove finalizer for configuration policy: %w", err)
+	}
+
+	return nil
+}
+
 // handleDeletion cleans up the child objects, based on the pruneObjectBehavior setting. If all of
 // the required child objects are fully removed, it will remove the finalizer.
 func (r *ConfigurationPolicyReconciler) handleDeletion(
@@ -1329,6 +1527,7 @@ func (r *ConfigurationPolicyReconciler) determineDesiredObjects(
 	error,
 ) {
 	log := ctrl.LoggerFrom(ctx, "index", index)
+	target := r.target(plc)
 
 	// Unmarshal the objectDefinition into a minimal struct with only metadata to
 	// determine whether it's a known API and to handle the namespace and name.
@@ -1403,6 +1602,25 @@ func (r *ConfigurationPolicyReconciler) determineDesiredObjects(
 		return nil, nil, nil, errEvent, err
 	}
 
+	// When counting objects, the objects are matched per namespace in handleObjects rather than being
+	// evaluated individually.
+	countObjects := objectT.ObjectCount != nil && parsedMinMetadata.Metadata.Name == "" &&
+		!objectT.ComplianceType.IsMustNotHave()
+
+	if countObjects && objectT.ObjectCount.Max != nil && objectT.ObjectCount.Min > *objectT.ObjectCount.Max {
+		errEvent := &objectTmplEvalEvent{
+			compliant: false,
+			reason:    "objectCount error",
+			message: fmt.Sprintf(
+				"The objectCount in the object-template at index [%d] has a min of %d that is greater than "+
+					"the max of %d",
+				index, objectT.ObjectCount.Min, *objectT.ObjectCount.Max,
+			),
+		}
+
+		return nil, &scopedGVR, nil, errEvent, nil
+	}
+
 	// Set up relevant object namespace-name-objects map to populate with the
 	// namespaceSelector and objectSelector
 	relevantNsNames := map[string]map[string]unstructured.Unstructured{}
@@ -1428,7 +1646,7 @@ func (r *ConfigurationPolicyReconciler) determineDesiredObjects(
 	objectSelector := objectT.ObjectSelector
 
 	getDefaultNamesPerNs := func() map[string]unstructured.Unstructured {
-		if desiredName != "" || objectSelector == nil {
+		if desiredName != "" || objectSelector == nil || countObjects {
 			return map[string]unstructured.Unstructured{
 				desiredName: {},
 			}
@@ -1447,7 +1665,7 @@ func (r *ConfigurationPolicyReconciler) determineDesiredObjects(
 	case scopedGVR.Namespaced && desiredNs == "":
 		nsSelector := plc.Spec.NamespaceSelector
 
-		selectedNamespaces, err := r.SelectorReconciler.Get(plc.Namespace, plc.Name, nsSelector)
+		selectedNamespaces, err := target.SelectorReconciler.Get(plc.Namespace, plc.Name, nsSelector)
 		if err != nil {
 			log.Error(err, "Failed to select the namespaces", "namespaceSelector", nsSelector.String())
 			msg := fmt.Sprintf("Error filtering namespaces with provided namespaceSelector: %v", err)
@@ -1474,7 +1692,7 @@ func (r *ConfigurationPolicyReconciler) determineDesiredObjects(
 					existingObj, _ = r.getObjectFromCache(plc, log, ns, desiredName, objGVK)
 				} else {
 					// We can ignore errors here because if we can't fetch the object, we just won't include it.
-					existingObj, _ = getObject(ctx, ns, desiredName, scopedGVR, r.TargetK8sDynamicClient)
+					existingObj, _ = getObject(ctx, ns, desiredName, scopedGVR, target.DynamicClient)
 				}
 
 				if existingObj != nil {
@@ -1549,7 +1767,7 @@ func (r *ConfigurationPolicyReconciler) determineDesiredObjects(
 				return nil, &scopedGVR, nil, errEvent, err
 			}
 		} else {
-			existingObj, _ = getObject(ctx, desiredNs, desiredName, scopedGVR, r.TargetK8sDynamicClient)
+			existingObj, _ = getObject(ctx, desiredNs, desiredName, scopedGVR, target.DynamicClient)
 		}
 
 		if existingObj != nil {
@@ -1557,9 +1775,9 @@ func (r *ConfigurationPolicyReconciler) determineDesiredObjects(
 		}
 
 	// If no name or a templated name, populate the names from the objectSelector
-	case desiredName == "" && objectSelector != nil:
+	case desiredName == "" && objectSelector != nil && !countObjects:
 		// Parse the objectSelector to determine whether it's valid
-		objSelector, err := metav1.LabelSelectorAsSelector(objectSelector)
+		objSelection, err := common.ParseObjectSelector(objectSelector)
 		if err != nil {
 			log.Error(err, "Failed to select the resources",
 				"objectSelector", objectT.ObjectSelector.String())
@@ -1579,7 +1797,7 @@ func (r *ConfigurationPolicyReconciler) determineDesiredObjects(
 		}
 
 		listOpts := metav1.ListOptions{
-			LabelSelector: objSelector.String(),
+			LabelSelector: objSelection.Labels.String(),
 		}
 
 		// Has a valid objectSelector, so list the names for each namespace using the objectSelector
@@ -1589,10 +1807,12 @@ func (r *ConfigurationPolicyReconciler) determineDesiredObjects(
 
 			// If watch is enabled, use the dynamic watcher, otherwise use the controller dynamic client
 			if usingWatch {
-				filteredObjects, err = r.DynamicWatcher.List(plc.ObjectIdentifier(), objGVK, ns, objSelector)
+				filteredObjects, err = target.DynamicWatcher.List(
+					plc.ObjectIdentifier(), objGVK, ns, objSelection.Labels,
+				)
 			} else {
 				var filteredObjectList *unstructured.UnstructuredList
-				filteredObjectList, err = r.TargetK8sDynamicClient.Resource(
+				filteredObjectList, err = target.DynamicClient.Resource(
 					scopedGVR.GroupVersionResource,
 				).Namespace(ns).List(ctx, listOpts)
 
@@ -1601,6 +1821,11 @@ func (r *ConfigurationPolicyReconciler) determineDesiredObjects(
 				}
 			}
 
+			// Apply the name patterns and field selector, which can't be used when listing
+			if err == nil {
+				filteredObjects, err = objSelection.Filter(filteredObjects)
+			}
+
 			if err != nil {
 				log.Error(err, "Failed to fetch the resources",
 					"objectSelector", objectT.ObjectSelector.String())
@@ -2153,7 +2378,7 @@ func (r *ConfigurationPolicyReconciler) handleObjects(
 			}
 		} else {
 			existingObj, getErr = getObject(
-				ctx, desiredObjNamespace, desiredObjName, scopedGVR, r.TargetK8sDynamicClient,
+				ctx, desiredObjNamespace, desiredObjName, scopedGVR, r.target(policy).DynamicClient,
 			)
 		}
 
@@ -2181,7 +2406,9 @@ func (r *ConfigurationPolicyReconciler) handleObjects(
 		if len(objNames) == 0 {
 			exists = false
 		} else if len(objNames) == 1 {
-			existingObj, getErr = getObject(ctx, desiredObjNamespace, objNames[0], scopedGVR, r.TargetK8sDynamicClient)
+			existingObj, getErr = getObject(
+				ctx, desiredObjNamespace, objNames[0], scopedGVR, r.target(policy).DynamicClient,
+			)
 			exists = existingObj != nil
 		}
 	}
@@ -2207,6 +2434,36 @@ func (r *ConfigurationPolicyReconciler) handleObjects(
 
 	objShouldExist := !objectT.ComplianceType.IsMustNotHave()
 
+	if desiredObjName == "" && objectT.ObjectCount != nil && objShouldExist {
+		log.V(2).Info("Counting the objects matching the object template", "count", len(objNames))
+
+		resultEvent := evaluateObjectCount(scopedGVR.Resource, desiredObjNamespace, objNames, objectT.ObjectCount)
+
+		result = objectTmplEvalResult{
+			objectNames: objNames,
+			namespace:   desiredObjNamespace,
+			events:      []objectTmplEvalEvent{resultEvent},
+		}
+
+		if len(objNames) == 0 {
+			relatedObjects = addCondensedRelatedObjs(
+				scopedGVR, resultEvent.compliant, desiredObjKind, desiredObjNamespace, resultEvent.reason,
+			)
+		} else {
+			relatedObjects = addRelatedObjects(
+				resultEvent.compliant,
+				scopedGVR,
+				desiredObjKind,
+				desiredObjNamespace,
+				objNames,
+				resultEvent.reason,
+				nil,
+			)
+		}
+
+		return relatedObjects, result
+	}
+
 	shouldAddCondensedRelatedObj := false
 
 	if len(objNames) == 1 {
@@ -2239,6 +2496,24 @@ func (r *ConfigurationPolicyReconciler) handleObjects(
 				event.reason,
 				objectProperties,
 			)
+
+			// Only the final state of the object is reported in the event, so an object that was fixed in this
+			// evaluation doesn't get a violation event
+			if !event.compliant && existingObj != nil && policy.Spec.TargetCluster == nil {
+				details := event.message
+				if details == "" {
+					details = event.reason
+				}
+
+				r.ObjectEvents.Violation(
+					"ConfigurationPolicy", policy.Namespace, policy.Name,
+					singleObjectReference(singObj, existingObj), details,
+				)
+			}
+		}
+
+		for _, restarted := range result.restartedWorkloads {
+			relatedObjects = addOrUpdateRelatedObject(relatedObjects, restarted)
 		}
 	} else { // This case only occurs when the desired object is not named
 		resultEvent := objectTmplEvalEvent{}
@@ -2320,6 +2595,8 @@ type objectTmplEvalResult struct {
 	namespace   string
 	events      []objectTmplEvalEvent
 	apiErr      error
+	// restartedWorkloads are the dependent workloads that had a rollout restart triggered after an update
+	restartedWorkloads []policyv1.RelatedObject
 }
 
 type objectTmplEvalEvent struct {
@@ -2345,6 +2622,9 @@ func (r *ConfigurationPolicyReconciler) handleSingleObj(
 	result objectTmplEvalResult,
 	objectProperties *policyv1.ObjectProperties,
 ) {
+	ctx, span := startConfigPolicySpan(ctx, "handleSingleObj", obj.policy, objectAttributes(obj)...)
+	defer span.End()
+
 	objLog := ctrl.LoggerFrom(ctx, "objName", obj.name, "index", obj.index)
 
 	result = objectTmplEvalResult{
@@ -2431,6 +2711,7 @@ func (r *ConfigurationPolicyReconciler) handleSingleObj(
 
 		created := false
 		uid := string(obj.existingObj.GetUID())
+		pendingRestart := len(objectT.DependentWorkloads) != 0 && restartPending(obj.policy, uid)
 
 		if evaluated, compliant, cachedMsg := r.alreadyEvaluated(obj.policy, obj.existingObj); evaluated {
 			objLog.V(1).Info("Skipping object comparison since the resourceVersion hasn't changed")
@@ -2477,7 +2758,30 @@ func (r *ConfigurationPolicyReconciler) handleSingleObj(
 		} else {
 			// it is a must have and it does exist, so it is compliant
 			if remediation.IsEnforce() {
-				if updatedObj != nil {
+				// A restart that failed in a previous evaluation is retried even though the object now matches
+				if (updatedObj != nil || pendingRestart) && len(objectT.DependentWorkloads) != 0 {
+					restarted, err := restartDependentWorkloads(
+						ctx, r.target(obj.policy).DynamicClient, objectT.DependentWorkloads, obj.namespace,
+					)
+
+					result.restartedWorkloads = restarted
+					pendingRestart = err != nil
+
+					if err != nil {
+						objLog.Error(err, "Could not restart the dependent workloads")
+
+						restartMsg := fmt.Sprintf("%s was updated successfully, but the rollout restart of the "+
+							"dependent workloads failed: %v", getMsgPrefix(&obj), err)
+
+						result.events = append(
+							result.events, objectTmplEvalEvent{false, reasonRestartError, restartMsg},
+						)
+					} else if len(restarted) != 0 {
+						result.events = append(result.events, objectTmplEvalEvent{true, reasonUpdateRestarted, ""})
+					} else {
+						result.events = append(result.events, objectTmplEvalEvent{true, reasonUpdateSuccess, ""})
+					}
+				} else if updatedObj != nil {
 					result.events = append(result.events, objectTmplEvalEvent{true, reasonUpdateSuccess, ""})
 				} else {
 					result.events = append(result.events, objectTmplEvalEvent{true, reasonWantFoundExists, ""})
@@ -2492,12 +2796,37 @@ func (r *ConfigurationPolicyReconciler) handleSingleObj(
 			UID:                uid,
 			Diff:               diff,
 			MatchesAfterDryRun: matchesAfterDryRun,
+			PendingRestart:     pendingRestart,
 		}
 	}
 
 	return result, objectProperties
 }
 
+// restartPending returns whether the related object with the given UID has a rollout restart of its dependent
+// workloads that failed in a previous evaluation.
+func restartPending(policy *policyv1.ConfigurationPolicy, uid string) bool {
+	for _, relatedObj := range policy.Status.RelatedObjects {
+		if relatedObj.Properties != nil && relatedObj.Properties.UID == uid {
+			return relatedObj.Properties.PendingRestart
+		}
+	}
+
+	return false
+}
+
+// hasPendingRestarts returns whether any related object of the policy has a rollout restart of its dependent
+// workloads that still needs to be retried.
+func hasPendingRestarts(policy *policyv1.ConfigurationPolicy) bool {
+	for _, relatedObj := range policy.Status.RelatedObjects {
+		if relatedObj.Properties != nil && relatedObj.Properties.PendingRestart {
+			return true
+		}
+	}
+
+	return false
+}
+
 // getMapping takes in a raw object, decodes it, and maps it to an existing group/kind
 func (r *ConfigurationPolicyReconciler) getMapping(
 	log logr.Logger, gvk schema.GroupVersionKind, policy *policyv1.ConfigurationPolicy, index int,
@@ -2510,7 +2839,7 @@ func (r *ConfigurationPolicyReconciler) getMapping(
 		return depclient.ScopedGVR{}, err
 	}
 
-	scopedGVR, err := r.DynamicWatcher.GVKToGVR(gvk)
+	scopedGVR, err := r.target(policy).DynamicWatcher.GVKToGVR(gvk)
 	if err != nil && !errors.Is(err, depclient.ErrResourceUnwatchable) {
 		if !errors.Is(err, depclient.ErrNoVersionedResource) {
 			log.Error(err, "Could not identify mapping error from raw object", "gvk", gvk)
@@ -2594,23 +2923,26 @@ func (r *ConfigurationPolicyReconciler) getMatchingNames(
 
 	ns := desiredObj.GetNamespace()
 
-	sel, err := metav1.LabelSelectorAsSelector(objectT.ObjectSelector)
+	objSelection, err := common.ParseObjectSelector(objectT.ObjectSelector)
 	if err != nil {
 		// This error should have already been handled in `determineDesiredObjects`,
 		// but as a fail-safe, select nothing.
-		sel = labels.Nothing()
+		objSelection = common.ObjectSelection{Labels: labels.Nothing()}
 	}
 
+	sel := objSelection.Labels
+	target := r.target(plc)
+
 	switch {
 	case currentlyUsingWatch(plc):
 		var returnedItems []unstructured.Unstructured
-		returnedItems, err = r.DynamicWatcher.List(plc.ObjectIdentifier(), desiredObj.GroupVersionKind(), ns, sel)
+		returnedItems, err = target.DynamicWatcher.List(plc.ObjectIdentifier(), desiredObj.GroupVersionKind(), ns, sel)
 		resList = &unstructured.UnstructuredList{Items: returnedItems}
 	case scopedGVR.Namespaced:
-		res := r.TargetK8sDynamicClient.Resource(scopedGVR.GroupVersionResource).Namespace(ns)
+		res := target.DynamicClient.Resource(scopedGVR.GroupVersionResource).Namespace(ns)
 		resList, err = res.List(ctx, metav1.ListOptions{LabelSelector: sel.String()})
 	default:
-		res := r.TargetK8sDynamicClient.Resource(scopedGVR.GroupVersionResource)
+		res := target.DynamicClient.Resource(scopedGVR.GroupVersionResource)
 		resList, err = res.List(ctx, metav1.ListOptions{LabelSelector: sel.String()})
 	}
 
@@ -2622,6 +2954,13 @@ func (r *ConfigurationPolicyReconciler) getMatchingNames(
 		return kindNameList, allResourceList
 	}
 
+	resList.Items, err = objSelection.Filter(resList.Items)
+	if err != nil {
+		log.Error(err, "Could not filter resources with the objectSelector", "rsrc", scopedGVR.Resource)
+
+		return kindNameList, allResourceList
+	}
+
 	for _, res := range resList.Items {
 		allResourceList = append(allResourceList, res.GetName())
 	}
@@ -2640,11 +2979,13 @@ func (r *ConfigurationPolicyReconciler) enforceByCreating(ctx context.Context, o
 		"objTemplateIndex", obj.index)
 	idStr := identifierStr([]string{obj.name}, obj.namespace)
 
+	dynamicClient := r.target(obj.policy).DynamicClient
+
 	var res dynamic.ResourceInterface
 	if obj.scopedGVR.Namespaced {
-		res = r.TargetK8sDynamicClient.Resource(obj.scopedGVR.GroupVersionResource).Namespace(obj.namespace)
+		res = dynamicClient.Resource(obj.scopedGVR.GroupVersionResource).Namespace(obj.namespace)
 	} else {
-		res = r.TargetK8sDynamicClient.Resource(obj.scopedGVR.GroupVersionResource)
+		res = dynamicClient.Resource(obj.scopedGVR.GroupVersionResource)
 	}
 
 	log.Info("Enforcing the policy by creating the object")
@@ -2685,6 +3026,12 @@ func (r *ConfigurationPolicyReconciler) enforceByCreating(ctx context.Context, o
 		reason = reasonWantFoundCreated
 		msg = fmt.Sprintf("%v %v was created successfully", obj.scopedGVR.Resource, idStr)
 
+		createdCopy := createdObj.DeepCopy()
+		removeFieldsForComparison(createdCopy)
+
+		r.recordEnforced(ctx, obj.policy, "created", singleObjectReference(obj, createdObj),
+			auditDiff(log, objectTemplateRecordDiff(obj), nil, createdCopy, r.FullDiffs), msg)
+
 		uid = string(createdObj.GetUID())
 		completed = true
 	}
@@ -2702,11 +3049,13 @@ func (r *ConfigurationPolicyReconciler) enforceByDeleting(ctx context.Context, o
 		"objTemplateIndex", obj.index)
 	idStr := identifierStr([]string{obj.name}, obj.namespace)
 
+	dynamicClient := r.target(obj.policy).DynamicClient
+
 	var res dynamic.ResourceInterface
 	if obj.scopedGVR.Namespaced {
-		res = r.TargetK8sDynamicClient.Resource(obj.scopedGVR.GroupVersionResource).Namespace(obj.namespace)
+		res = dynamicClient.Resource(obj.scopedGVR.GroupVersionResource).Namespace(obj.namespace)
 	} else {
-		res = r.TargetK8sDynamicClient.Resource(obj.scopedGVR.GroupVersionResource)
+		res = dynamicClient.Resource(obj.scopedGVR.GroupVersionResource)
 	}
 
 	log.Info("Enforcing the policy by deleting the object")
@@ -2719,6 +3068,16 @@ func (r *ConfigurationPolicyReconciler) enforceByDeleting(ctx context.Context, o
 	} else {
 		reason = reasonDeleteSuccess
 		msg = fmt.Sprintf("%v %v was deleted successfully", obj.scopedGVR.Resource, idStr)
+
+		var existingCopy *unstructured.Unstructured
+
+		if obj.existingObj != nil {
+			existingCopy = obj.existingObj.DeepCopy()
+			removeFieldsForComparison(existingCopy)
+		}
+
+		r.recordEnforced(ctx, obj.policy, "deleted", singleObjectReference(obj, obj.existingObj),
+			auditDiff(log, objectTemplateRecordDiff(obj), existingCopy, nil, r.FullDiffs), msg)
 	}
 
 	return completed, reason, msg, err
@@ -2776,7 +3135,7 @@ func (r *ConfigurationPolicyReconciler) getObjectFromCache(
 
 	watcher := plc.ObjectIdentifier()
 
-	rv, err := r.DynamicWatcher.Get(watcher, objGVK, objNamespace, objName)
+	rv, err := r.target(plc).DynamicWatcher.Get(watcher, objGVK, objNamespace, objName)
 	if err != nil {
 		objLog.V(2).Error(err, "Could not retrieve object from the API server")
 
@@ -3210,6 +3569,15 @@ func (r *ConfigurationPolicyReconciler) checkAndUpdateResource(
 	updatedObj *unstructured.Unstructured,
 	matchesAfterDryRun bool,
 ) {
+	ctx, span := startConfigPolicySpan(ctx, "checkAndUpdateResource", obj.policy, objectAttributes(obj)...)
+	defer func() {
+		span.SetAttributes(
+			attribute.Bool("object.compliant", !throwViolation),
+			attribute.Bool("object.updateNeeded", updateNeeded),
+		)
+		span.End()
+	}()
+
 	log := ctrl.LoggerFrom(ctx, "objName", obj.name, "objNamespace", obj.namespace, "resource", obj.scopedGVR.Resource)
 
 	// Time the function, and record it in a metric
@@ -3227,6 +3595,7 @@ func (r *ConfigurationPolicyReconciler) checkAndUpdateResource(
 			obj.namespace,
 			fmt.Sprintf("%s.%s", obj.scopedGVR.Resource, obj.name),
 		).Inc()
+		compareObjDurationHistogram.Observe(seconds)
 	}()
 
 	if obj.existingObj == nil {
@@ -3235,11 +3604,13 @@ func (r *ConfigurationPolicyReconciler) checkAndUpdateResource(
 		return false, "", "", false, nil, false
 	}
 
+	dynamicClient := r.target(obj.policy).DynamicClient
+
 	var res dynamic.ResourceInterface
 	if obj.scopedGVR.Namespaced {
-		res = r.TargetK8sDynamicClient.Resource(obj.scopedGVR.GroupVersionResource).Namespace(obj.namespace)
+		res = dynamicClient.Resource(obj.scopedGVR.GroupVersionResource).Namespace(obj.namespace)
 	} else {
-		res = r.TargetK8sDynamicClient.Resource(obj.scopedGVR.GroupVersionResource)
+		res = dynamicClient.Resource(obj.scopedGVR.GroupVersionResource)
 	}
 
 	// Use a copy since some values can be directly assigned to mergedObj in handleSingleKey.
@@ -3461,6 +3832,12 @@ func (r *ConfigurationPolicyReconciler) checkAndUpdateResource(
 		return true, message, diff, updateNeeded, nil, false
 	}
 
+	updatedCopy := updatedObj.DeepCopy()
+	removeFieldsForComparison(updatedCopy)
+
+	r.recordEnforced(ctx, obj.policy, action+"d", singleObjectReference(obj, updatedObj),
+		auditDiff(log, recordDiff, existingObjectCopy, updatedCopy, r.FullDiffs), "")
+
 	if !statusMismatch {
 		r.setEvaluatedObject(obj.policy, updatedObj, true, message)
 	}
@@ -3669,6 +4046,14 @@ func (r *ConfigurationPolicyReconciler) setEvaluatedObject(
 	)
 }
 
+// evaluationTrigger returns the value of the trigger-evaluation annotation of the policy and whether it
+// requests an evaluation that hasn't been done yet.
+func evaluationTrigger(policy *policyv1.ConfigurationPolicy) (string, bool) {
+	trigger := policy.GetAnnotations()[EvaluationTriggerAnnotation]
+
+	return trigger, trigger != "" && trigger != policy.Status.LastEvaluationTrigger
+}
+
 // alreadyEvaluated will determine if this ConfigurationPolicy has already evaluated this object at its current
 // resourceVersion.
 func (r *ConfigurationPolicyReconciler) alreadyEvaluated(
@@ -3751,6 +4136,11 @@ func (r *ConfigurationPolicyReconciler) addForUpdate(
 		sendEvent = true
 	}
 
+	// Always send an event for an evaluation triggered on demand so that its result is recorded
+	if _, triggered := evaluationTrigger(policy); triggered {
+		sendEvent = true
+	}
+
 	policy.Status.LastEvaluated = time.Now().UTC().Format(time.RFC3339)
 	policy.Status.LastEvaluatedGeneration = policy.Generation
 
@@ -3783,7 +4173,10 @@ func (r *ConfigurationPolicyReconciler) addForUpdate(
 // on the parent policy and configuration policy with the compliance decision if the sendEvent argument is true.
 func (r *ConfigurationPolicyReconciler) updatePolicyStatus(
 	ctx context.Context, policy *policyv1.ConfigurationPolicy, sendEvent bool,
-) error {
+) (err error) {
+	ctx, span := startConfigPolicySpan(ctx, "updatePolicyStatus", policy)
+	defer func() { endSpan(span, err) }()
+
 	log := ctrl.LoggerFrom(ctx)
 	updateTime := time.Now()
 	message := r.customComplianceMessage(policy, log)
@@ -3804,12 +4197,40 @@ func (r *ConfigurationPolicyReconciler) updatePolicyStatus(
 
 			return err
 		}
+
+		r.Notifier.ComplianceChanged(notifications.ComplianceChangedData{
+			Policy:     configPolicyReference(policy),
+			Severity:   string(policy.Spec.Severity),
+			Compliance: string(policy.Status.ComplianceState),
+			Message:    message,
+		})
 	}
 
 	log.V(1).Info(
 		"Updating configurationPolicy status", "status", policy.Status.ComplianceState, "policy", policy.GetName(),
 	)
 
+	setStatusConditions(policy, message)
+
+	// Record the trigger before the result of the evaluation in the history, which makes the result of the
+	// evaluation be recorded after it even if the compliance message didn't change
+	if trigger, triggered := evaluationTrigger(policy); triggered {
+		triggerMessage := fmt.Sprintf(
+			"The evaluation was triggered on demand by the %s annotation with the value %s",
+			EvaluationTriggerAnnotation, trigger,
+		)
+
+		triggerEvent := policyv1.HistoryEvent{
+			LastTimestamp: metav1.NewMicroTime(updateTime),
+			Message:       triggerMessage,
+		}
+
+		policy.Status.History = append([]policyv1.HistoryEvent{triggerEvent}, policy.Status.History...)
+		policy.Status.LastEvaluationTrigger = trigger
+
+		r.Recorder.Eventf(policy, nil, corev1.EventTypeNormal, "EvaluationTriggered", "Evaluate", triggerMessage)
+	}
+
 	var latestEvent policyv1.HistoryEvent
 
 	if len(policy.Status.History) > 0 {
@@ -3868,6 +4289,12 @@ func (r *ConfigurationPolicyReconciler) updatePolicyStatus(
 		}
 	}
 
+	r.ObjectMetrics.Update("ConfigurationPolicy", policy.Namespace, policy.Name, policy.Status.RelatedObjects)
+
+	if reportErr := r.PolicyReporter.Update(ctx, policy); reportErr != nil {
+		log.Error(reportErr, "Failed to update the policy reports")
+	}
+
 	if sendEvent {
 		log.V(1).Info("Sending policy status update event")
 
@@ -1505,6 +1505,53 @@ func TestShouldEvaluatePolicy(t *testing.T) {
 	}
 }
 
+func TestShouldEvaluatePolicyTrigger(t *testing.T) {
+	t.Parallel()
+
+	policy := &policyv1.ConfigurationPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "policy",
+			Namespace:       "managed",
+			Generation:      1,
+			ResourceVersion: "2",
+			UID:             uuid.NewUUID(),
+		},
+		Spec: policyv1.ConfigurationPolicySpec{
+			EvaluationInterval: policyv1.EvaluationInterval{Compliant: "never", NonCompliant: "never"},
+		},
+		Status: policyv1.ConfigurationPolicyStatus{
+			ComplianceState:         policyv1.Compliant,
+			LastEvaluated:           time.Now().UTC().Format(time.RFC3339),
+			LastEvaluatedGeneration: 1,
+			LastEvaluationTrigger:   "2024-01-02T03:04:05Z",
+		},
+	}
+
+	r := &ConfigurationPolicyReconciler{SelectorReconciler: &fakeSR{}}
+
+	// The trigger was already handled
+	policy.SetAnnotations(map[string]string{EvaluationTriggerAnnotation: "2024-01-02T03:04:05Z"})
+
+	shouldEvaluate, _ := r.shouldEvaluatePolicy(policy, logr.Discard())
+	assert.False(t, shouldEvaluate)
+
+	policy.SetAnnotations(map[string]string{EvaluationTriggerAnnotation: "2024-02-03T04:05:06Z"})
+
+	shouldEvaluate, duration := r.shouldEvaluatePolicy(policy, logr.Discard())
+	assert.True(t, shouldEvaluate)
+	assert.Zero(t, duration)
+
+	trigger, triggered := evaluationTrigger(policy)
+	assert.True(t, triggered)
+	assert.Equal(t, "2024-02-03T04:05:06Z", trigger)
+
+	policy.SetAnnotations(nil)
+	policy.Status.LastEvaluationTrigger = ""
+
+	_, triggered = evaluationTrigger(policy)
+	assert.False(t, triggered)
+}
+
 type fakeSR struct{}
 
 func (r *fakeSR) Get(_ string, _ string, _ policyv1.Target) ([]string, error) {
@@ -0,0 +1,193 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+)
+
+// The types of the standard conditions in the status of a ConfigurationPolicy.
+const (
+	ConfigPolicyCompliantCondition        = "Compliant"
+	ConfigPolicyEnforcedCondition         = "Enforced"
+	ConfigPolicyTemplateResolvedCondition = "TemplateResolved"
+	ConfigPolicyProgressingCondition      = "Progressing"
+	ConfigPolicyDegradedCondition         = "Degraded"
+)
+
+// templateErrorReasons are the compliancyDetails reasons for templates that couldn't be resolved.
+var templateErrorReasons = map[string]bool{
+	reasonTemplateError:                       true,
+	"Hub template resolution failure":         true,
+	"Template encryption configuration error": true,
+}
+
+// degradedReasons are the compliancyDetails reasons for errors that prevent the controller from fully evaluating
+// or enforcing the policy, in addition to the template errors.
+var degradedReasons = map[string]bool{
+	"api error":                 true,
+	"unwatchable resource":      true,
+	"Invalid spec":              true,
+	"Target cluster error":      true,
+	"K8s creation error":        true,
+	"K8s deletion error":        true,
+	"K8s update template error": true,
+	reasonRestartError:          true,
+	reasonCleanupError:          true,
+}
+
+// compliancyDetailsReasons returns the reasons of the compliancyDetails conditions, splitting the reasons that
+// were combined for several objects.
+func compliancyDetailsReasons(policy *policyv1.ConfigurationPolicy) []string {
+	reasons := []string{}
+
+	for _, details := range policy.Status.CompliancyDetails {
+		for _, cond := range details.Conditions {
+			reasons = append(reasons, strings.Split(cond.Reason, "; ")...)
+		}
+	}
+
+	return reasons
+}
+
+// setStatusConditions sets the standard conditions in the status of the policy from its compliance. The message
+// is the compliance message of the policy.
+func setStatusConditions(policy *policyv1.ConfigurationPolicy, message string) {
+	var templateErrors, degradedErrors, statusUnchecked bool
+
+	for _, reason := range compliancyDetailsReasons(policy) {
+		switch {
+		case templateErrorReasons[reason]:
+			templateErrors = true
+		case degradedReasons[reason]:
+			degradedErrors = true
+		case strings.HasSuffix(reason, ", status unchecked"):
+			statusUnchecked = true
+		}
+	}
+
+	conditions := []metav1.Condition{
+		complianceCondition(policy, message),
+		enforcedCondition(policy, message),
+	}
+
+	if templateErrors {
+		conditions = append(conditions, metav1.Condition{
+			Type:    ConfigPolicyTemplateResolvedCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  "TemplateError",
+			Message: message,
+		})
+	} else {
+		conditions = append(conditions, metav1.Condition{
+			Type:    ConfigPolicyTemplateResolvedCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  "TemplatesResolved",
+			Message: "The templates of the policy, if any, were resolved",
+		})
+	}
+
+	switch {
+	case policy.Status.ComplianceState == policyv1.Terminating:
+		conditions = append(conditions, metav1.Condition{
+			Type:    ConfigPolicyProgressingCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  "Terminating",
+			Message: "The objects of the policy are being cleaned up",
+		})
+	case statusUnchecked:
+		conditions = append(conditions, metav1.Condition{
+			Type:    ConfigPolicyProgressingCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  "StatusUnchecked",
+			Message: "The status of the created objects will be verified in the next evaluation",
+		})
+	case policy.Status.ComplianceState == policyv1.UnknownCompliancy:
+		conditions = append(conditions, metav1.Condition{
+			Type:    ConfigPolicyProgressingCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  "Evaluating",
+			Message: "The compliance of the policy is not determined yet",
+		})
+	default:
+		conditions = append(conditions, metav1.Condition{
+			Type:    ConfigPolicyProgressingCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  "Evaluated",
+			Message: "The policy was evaluated",
+		})
+	}
+
+	if templateErrors || degradedErrors {
+		conditions = append(conditions, metav1.Condition{
+			Type:    ConfigPolicyDegradedCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  "EvaluationError",
+			Message: message,
+		})
+	} else {
+		conditions = append(conditions, metav1.Condition{
+			Type:    ConfigPolicyDegradedCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  "NoErrors",
+			Message: "There were no errors evaluating the policy",
+		})
+	}
+
+	for _, cond := range conditions {
+		cond.ObservedGeneration = policy.Generation
+
+		meta.SetStatusCondition(&policy.Status.Conditions, cond)
+	}
+}
+
+func complianceCondition(policy *policyv1.ConfigurationPolicy, message string) metav1.Condition {
+	cond := metav1.Condition{Type: ConfigPolicyCompliantCondition, Message: message}
+
+	switch policy.Status.ComplianceState {
+	case policyv1.Compliant:
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = "Compliant"
+	case policyv1.NonCompliant:
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "NonCompliant"
+	case policyv1.Terminating:
+		cond.Status = metav1.ConditionUnknown
+		cond.Reason = "Terminating"
+	default:
+		cond.Status = metav1.ConditionUnknown
+		cond.Reason = "ComplianceUnknown"
+	}
+
+	return cond
+}
+
+func enforcedCondition(policy *policyv1.ConfigurationPolicy, message string) metav1.Condition {
+	cond := metav1.Condition{Type: ConfigPolicyEnforcedCondition}
+
+	switch {
+	case !policy.Spec.RemediationAction.IsEnforce():
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "InformOnly"
+		cond.Message = "The policy is in inform mode, so it doesn't change the cluster"
+	case policy.Status.ComplianceState == policyv1.Compliant:
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = "Enforced"
+		cond.Message = "The policy is enforced on the cluster"
+	case policy.Status.ComplianceState == policyv1.NonCompliant:
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "EnforcementFailed"
+		cond.Message = message
+	default:
+		cond.Status = metav1.ConditionUnknown
+		cond.Reason = "EnforcementUnknown"
+		cond.Message = "The policy is being evaluated or cleaned up"
+	}
+
+	return cond
+}
@@ -0,0 +1,125 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+)
+
+func TestSetStatusConditions(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		remediationAction policyv1.RemediationAction
+		complianceState   policyv1.ComplianceState
+		reasons           []string
+		expected          map[string]metav1.ConditionStatus
+		expectedReasons   map[string]string
+	}{
+		"compliant in enforce mode": {
+			remediationAction: policyv1.Enforce,
+			complianceState:   policyv1.Compliant,
+			reasons:           []string{"K8s `must have` object already exists; K8s update success"},
+			expected: map[string]metav1.ConditionStatus{
+				ConfigPolicyCompliantCondition:        metav1.ConditionTrue,
+				ConfigPolicyEnforcedCondition:         metav1.ConditionTrue,
+				ConfigPolicyTemplateResolvedCondition: metav1.ConditionTrue,
+				ConfigPolicyProgressingCondition:      metav1.ConditionFalse,
+				ConfigPolicyDegradedCondition:         metav1.ConditionFalse,
+			},
+			expectedReasons: map[string]string{ConfigPolicyEnforcedCondition: "Enforced"},
+		},
+		"noncompliant in inform mode": {
+			remediationAction: policyv1.Inform,
+			complianceState:   policyv1.NonCompliant,
+			reasons:           []string{"K8s does not have a `must have` object"},
+			expected: map[string]metav1.ConditionStatus{
+				ConfigPolicyCompliantCondition:        metav1.ConditionFalse,
+				ConfigPolicyEnforcedCondition:         metav1.ConditionFalse,
+				ConfigPolicyTemplateResolvedCondition: metav1.ConditionTrue,
+				ConfigPolicyProgressingCondition:      metav1.ConditionFalse,
+				ConfigPolicyDegradedCondition:         metav1.ConditionFalse,
+			},
+			expectedReasons: map[string]string{ConfigPolicyEnforcedCondition: "InformOnly"},
+		},
+		"template error": {
+			remediationAction: policyv1.Enforce,
+			complianceState:   policyv1.NonCompliant,
+			reasons:           []string{"Error processing template"},
+			expected: map[string]metav1.ConditionStatus{
+				ConfigPolicyCompliantCondition:        metav1.ConditionFalse,
+				ConfigPolicyEnforcedCondition:         metav1.ConditionFalse,
+				ConfigPolicyTemplateResolvedCondition: metav1.ConditionFalse,
+				ConfigPolicyProgressingCondition:      metav1.ConditionFalse,
+				ConfigPolicyDegradedCondition:         metav1.ConditionTrue,
+			},
+			expectedReasons: map[string]string{ConfigPolicyTemplateResolvedCondition: "TemplateError"},
+		},
+		"created with the status unchecked and an API error": {
+			remediationAction: policyv1.Enforce,
+			complianceState:   policyv1.NonCompliant,
+			reasons:           []string{"K8s creation success, status unchecked", "api error"},
+			expected: map[string]metav1.ConditionStatus{
+				ConfigPolicyCompliantCondition:        metav1.ConditionFalse,
+				ConfigPolicyEnforcedCondition:         metav1.ConditionFalse,
+				ConfigPolicyTemplateResolvedCondition: metav1.ConditionTrue,
+				ConfigPolicyProgressingCondition:      metav1.ConditionTrue,
+				ConfigPolicyDegradedCondition:         metav1.ConditionTrue,
+			},
+			expectedReasons: map[string]string{ConfigPolicyProgressingCondition: "StatusUnchecked"},
+		},
+		"terminating": {
+			remediationAction: policyv1.Enforce,
+			complianceState:   policyv1.Terminating,
+			reasons:           []string{reasonCleanupError},
+			expected: map[string]metav1.ConditionStatus{
+				ConfigPolicyCompliantCondition:        metav1.ConditionUnknown,
+				ConfigPolicyEnforcedCondition:         metav1.ConditionUnknown,
+				ConfigPolicyTemplateResolvedCondition: metav1.ConditionTrue,
+				ConfigPolicyProgressingCondition:      metav1.ConditionTrue,
+				ConfigPolicyDegradedCondition:         metav1.ConditionTrue,
+			},
+			expectedReasons: map[string]string{ConfigPolicyProgressingCondition: "Terminating"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			policy := &policyv1.ConfigurationPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "managed", Generation: 3},
+				Spec:       policyv1.ConfigurationPolicySpec{RemediationAction: test.remediationAction},
+				Status:     policyv1.ConfigurationPolicyStatus{ComplianceState: test.complianceState},
+			}
+
+			for _, reason := range test.reasons {
+				policy.Status.CompliancyDetails = append(policy.Status.CompliancyDetails, policyv1.TemplateStatus{
+					Conditions: []policyv1.Condition{{Reason: reason}},
+				})
+			}
+
+			setStatusConditions(policy, "the compliance message")
+
+			assert.Len(t, policy.Status.Conditions, len(test.expected))
+
+			for condType, status := range test.expected {
+				cond := meta.FindStatusCondition(policy.Status.Conditions, condType)
+				if assert.NotNil(t, cond, condType) {
+					assert.Equal(t, status, cond.Status, condType)
+					assert.Equal(t, int64(3), cond.ObservedGeneration)
+				}
+			}
+
+			for condType, reason := range test.expectedReasons {
+				assert.Equal(t, reason, meta.FindStatusCondition(policy.Status.Conditions, condType).Reason)
+			}
+		})
+	}
+}
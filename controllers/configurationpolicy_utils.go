@@ -491,6 +491,44 @@ func identifierStr(names []string, namespace string) (nameStr string) {
 	return nameStr
 }
 
+// evaluateObjectCount determines whether the number of objects matching an unnamed object template in a
+// namespace is within the range of the object count. resourceName indicates the name of the resource (e.g.
+// networkpolicies), and the namespace is empty for cluster scoped objects.
+func evaluateObjectCount(
+	resourceName string, namespace string, names []string, objectCount *policyv1.ObjectCount,
+) objectTmplEvalEvent {
+	var expected string
+
+	switch {
+	case objectCount.Max == nil:
+		expected = fmt.Sprintf("at least %d", objectCount.Min)
+	case objectCount.Min == 0:
+		expected = fmt.Sprintf("at most %d", *objectCount.Max)
+	default:
+		expected = fmt.Sprintf("between %d and %d", objectCount.Min, *objectCount.Max)
+	}
+
+	count := len(names)
+	compliant := count >= int(objectCount.Min) && (objectCount.Max == nil || count <= int(*objectCount.Max))
+
+	reason := reasonObjectCountMatch
+	if !compliant {
+		reason = reasonObjectCountNoMatch
+	}
+
+	identifier := resourceName
+
+	if idStr := identifierStr(slices.Clone(names), namespace); idStr != "" {
+		identifier += " " + idStr
+	}
+
+	return objectTmplEvalEvent{
+		compliant: compliant,
+		reason:    reason,
+		message:   fmt.Sprintf("%d matching %s found, expected %s", count, identifier, expected),
+	}
+}
+
 // createStatus generates the status reason and message for the object template after processing. resourceName indicates
 // the name of the resource (e.g. namespaces), and not the kind (e.g. Namespace).
 func createStatus(
@@ -530,6 +568,7 @@ func createStatus(
 		reasonWantFoundExists,
 		reasonWantFoundCreated,
 		reasonUpdateSuccess,
+		reasonUpdateRestarted,
 		reasonDeleteSuccess,
 		reasonWantFoundDNE,
 		reasonWantFoundNoMatch,
@@ -673,6 +712,9 @@ func generateMessageWithReason(sortedObjectNamesStrs []string,
 		case reasonUpdateSuccess:
 			generatedReason = reasonUpdateSuccess
 			msgTemplate = "%s%s was updated successfully"
+		case reasonUpdateRestarted:
+			generatedReason = reasonUpdateRestarted
+			msgTemplate = "%s%s was updated successfully and a rollout restart of its dependent workloads was triggered"
 		case reasonDeleteSuccess:
 			generatedReason = reasonDeleteSuccess
 			msgTemplate = "%s%s was deleted successfully"
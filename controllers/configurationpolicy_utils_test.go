@@ -619,3 +619,67 @@ func TestGetTemplateResolver_DenylistFunctions(t *testing.T) {
 		})
 	}
 }
+
+func TestEvaluateObjectCount(t *testing.T) {
+	t.Parallel()
+
+	two := int32(2)
+
+	tests := map[string]struct {
+		resource        string
+		namespace       string
+		names           []string
+		objectCount     policyv1.ObjectCount
+		expectCompliant bool
+		expectMsg       string
+	}{
+		"minimum not met": {
+			resource:        "networkpolicies",
+			namespace:       "tenant-a",
+			names:           []string{},
+			objectCount:     policyv1.ObjectCount{Min: 1},
+			expectCompliant: false,
+			expectMsg:       "0 matching networkpolicies in namespace tenant-a found, expected at least 1",
+		},
+		"minimum met": {
+			resource:        "networkpolicies",
+			namespace:       "tenant-a",
+			names:           []string{"deny-all", "allow-dns"},
+			objectCount:     policyv1.ObjectCount{Min: 1},
+			expectCompliant: true,
+			expectMsg: "2 matching networkpolicies [allow-dns, deny-all] in namespace tenant-a found, " +
+				"expected at least 1",
+		},
+		"maximum exceeded": {
+			resource:        "storageclasses",
+			names:           []string{"gp2", "gp3", "standard"},
+			objectCount:     policyv1.ObjectCount{Max: &two},
+			expectCompliant: false,
+			expectMsg:       "3 matching storageclasses [gp2, gp3, standard] found, expected at most 2",
+		},
+		"within range": {
+			resource:        "storageclasses",
+			names:           []string{"gp3"},
+			objectCount:     policyv1.ObjectCount{Min: 1, Max: &two},
+			expectCompliant: true,
+			expectMsg:       "1 matching storageclasses [gp3] found, expected between 1 and 2",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			event := evaluateObjectCount(test.resource, test.namespace, test.names, &test.objectCount)
+
+			assert.Equal(t, test.expectCompliant, event.compliant)
+			assert.Equal(t, test.expectMsg, event.message)
+
+			if test.expectCompliant {
+				assert.Equal(t, reasonObjectCountMatch, event.reason)
+			} else {
+				assert.Equal(t, reasonObjectCountNoMatch, event.reason)
+			}
+		})
+	}
+}
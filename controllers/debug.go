@@ -0,0 +1,378 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	depclient "github.com/stolostron/kubernetes-dependency-watches/client"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+	"open-cluster-management.io/config-policy-controller/pkg/common"
+)
+
+// DebugPath is the path of the debug endpoint that serves the evaluation state of the policies.
+const DebugPath = "/debug/policies"
+
+// evaluationState is the last evaluation and the next scheduled evaluation of a policy.
+type evaluationState struct {
+	lastEvaluation time.Time
+	// nextEvaluation is zero when the policy is only evaluated on watch events or spec changes.
+	nextEvaluation time.Time
+	lastError      string
+}
+
+// evaluationTracker keeps the evaluation state of the policies for the debug endpoint. The zero value is
+// ready to use.
+type evaluationTracker struct {
+	states map[types.NamespacedName]evaluationState
+	lock   sync.RWMutex
+}
+
+// reconciled records the result of a reconcile of the policy. The evaluatedAt time is zero if the policy
+// wasn't evaluated in the reconcile, such as when it was throttled.
+func (t *evaluationTracker) reconciled(
+	name types.NamespacedName, evaluatedAt time.Time, result reconcile.Result, err error,
+) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.states == nil {
+		t.states = map[types.NamespacedName]evaluationState{}
+	}
+
+	state := t.states[name]
+
+	if !evaluatedAt.IsZero() {
+		state.lastEvaluation = evaluatedAt
+	}
+
+	now := time.Now().UTC()
+
+	switch {
+	case err != nil:
+		// The requeue is determined by the exponential backoff of the controller
+		state.nextEvaluation = time.Time{}
+		state.lastError = err.Error()
+	case result.RequeueAfter > 0:
+		state.nextEvaluation = now.Add(result.RequeueAfter)
+		state.lastError = ""
+	case result.Requeue: //nolint:staticcheck // The ConfigurationPolicy controller still sets Requeue
+		state.nextEvaluation = now
+		state.lastError = ""
+	default:
+		state.nextEvaluation = time.Time{}
+		state.lastError = ""
+	}
+
+	t.states[name] = state
+}
+
+func (t *evaluationTracker) remove(name types.NamespacedName) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	delete(t.states, name)
+}
+
+// snapshot returns a copy of the evaluation states.
+func (t *evaluationTracker) snapshot() map[types.NamespacedName]evaluationState {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	states := make(map[types.NamespacedName]evaluationState, len(t.states))
+	for name, state := range t.states {
+		states[name] = state
+	}
+
+	return states
+}
+
+// selectionGetter is implemented by the SelectorReconcilers that expose their cached selections.
+type selectionGetter interface {
+	Selection(namespace string, name string) (common.SelectionState, bool)
+}
+
+type debugObject struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+type debugNamespaceSelection struct {
+	Include    []string   `json:"include,omitempty"`
+	Exclude    []string   `json:"exclude,omitempty"`
+	Namespaces []string   `json:"namespaces"`
+	RecheckAt  *time.Time `json:"recheckAt,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+type debugPolicy struct {
+	Kind           string     `json:"kind"`
+	Namespace      string     `json:"namespace"`
+	Name           string     `json:"name"`
+	LastEvaluation *time.Time `json:"lastEvaluation,omitempty"`
+	// NextEvaluation is omitted when the policy is only evaluated on watch events or spec changes.
+	NextEvaluation     *time.Time               `json:"nextEvaluation,omitempty"`
+	LastError          string                   `json:"lastError,omitempty"`
+	RateLimiter        *RateLimiterState        `json:"rateLimiter,omitempty"`
+	Watches            []debugObject            `json:"watches"`
+	WatchesError       string                   `json:"watchesError,omitempty"`
+	NamespaceSelection *debugNamespaceSelection `json:"namespaceSelection,omitempty"`
+}
+
+type debugResponse struct {
+	// WatchCount is the number of watches in the dynamic watcher of the default target cluster of each controller.
+	WatchCount map[string]uint `json:"watchCount"`
+	Policies   []debugPolicy   `json:"policies"`
+}
+
+// DebugHandler serves the evaluation state of the policies as JSON. The OperatorPolicy reconciler is optional.
+type DebugHandler struct {
+	ConfigPolicies   *ConfigurationPolicyReconciler
+	OperatorPolicies *OperatorPolicyReconciler
+}
+
+func (h *DebugHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	response := debugResponse{WatchCount: map[string]uint{}, Policies: []debugPolicy{}}
+
+	if h.ConfigPolicies != nil {
+		response.WatchCount["ConfigurationPolicy"] = h.ConfigPolicies.DynamicWatcher.GetWatchCount()
+		response.Policies = append(response.Policies, h.ConfigPolicies.debugPolicies()...)
+	}
+
+	if h.OperatorPolicies != nil {
+		response.WatchCount["OperatorPolicy"] = h.OperatorPolicies.DynamicWatcher.GetWatchCount()
+		response.Policies = append(response.Policies, h.OperatorPolicies.debugPolicies()...)
+	}
+
+	slices.SortFunc(response.Policies, func(a, b debugPolicy) int {
+		return strings.Compare(a.Kind+"/"+a.Namespace+"/"+a.Name, b.Kind+"/"+b.Namespace+"/"+b.Name)
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(response); err != nil {
+		ctrl.Log.WithName("debug").Error(err, "Failed to write the debug response")
+	}
+}
+
+// newDebugPolicy returns the debug state of a policy with the evaluation state and watches filled in.
+func newDebugPolicy(
+	kind string, name types.NamespacedName, state evaluationState, watcher depclient.DynamicWatcher,
+	objID depclient.ObjectIdentifier,
+) debugPolicy {
+	policy := debugPolicy{
+		Kind:      kind,
+		Namespace: name.Namespace,
+		Name:      name.Name,
+		LastError: state.lastError,
+		Watches:   []debugObject{},
+	}
+
+	if !state.lastEvaluation.IsZero() {
+		policy.LastEvaluation = &state.lastEvaluation
+	}
+
+	if !state.nextEvaluation.IsZero() {
+		policy.NextEvaluation = &state.nextEvaluation
+	}
+
+	watched, err := watcher.ListWatchedFromCache(objID)
+	if err != nil {
+		policy.WatchesError = err.Error()
+	}
+
+	for _, obj := range watched {
+		policy.Watches = append(policy.Watches, debugObject{
+			APIVersion: obj.GetAPIVersion(),
+			Kind:       obj.GetKind(),
+			Namespace:  obj.GetNamespace(),
+			Name:       obj.GetName(),
+		})
+	}
+
+	return policy
+}
+
+func (r *ConfigurationPolicyReconciler) debugPolicies() []debugPolicy {
+	states := r.evaluations.snapshot()
+	policies := make([]debugPolicy, 0, len(states))
+
+	for name, state := range states {
+		objID := depclient.ObjectIdentifier{
+			Group:     policyv1.GroupVersion.Group,
+			Version:   policyv1.GroupVersion.Version,
+			Kind:      "ConfigurationPolicy",
+			Namespace: name.Namespace,
+			Name:      name.Name,
+		}
+		target := r.targetFor(objID)
+
+		policy := newDebugPolicy("ConfigurationPolicy", name, state, target.DynamicWatcher, objID)
+
+		if r.ItemLimiters != nil {
+			if limiter, ok := r.ItemLimiters.State(reconcile.Request{NamespacedName: name}); ok {
+				policy.RateLimiter = &limiter
+			}
+		}
+
+		if getter, ok := target.SelectorReconciler.(selectionGetter); ok {
+			if selection, ok := getter.Selection(name.Namespace, name.Name); ok {
+				policy.NamespaceSelection = newDebugNamespaceSelection(selection)
+			}
+		}
+
+		policies = append(policies, policy)
+	}
+
+	return policies
+}
+
+func newDebugNamespaceSelection(selection common.SelectionState) *debugNamespaceSelection {
+	debugSelection := &debugNamespaceSelection{Namespaces: selection.Namespaces}
+
+	for _, include := range selection.Target.Include {
+		debugSelection.Include = append(debugSelection.Include, string(include))
+	}
+
+	for _, exclude := range selection.Target.Exclude {
+		debugSelection.Exclude = append(debugSelection.Exclude, string(exclude))
+	}
+
+	if !selection.RecheckAt.IsZero() {
+		debugSelection.RecheckAt = &selection.RecheckAt
+	}
+
+	if selection.Err != nil {
+		debugSelection.Error = selection.Err.Error()
+	}
+
+	return debugSelection
+}
+
+func (r *OperatorPolicyReconciler) debugPolicies() []debugPolicy {
+	states := r.evaluations.snapshot()
+	policies := make([]debugPolicy, 0, len(states))
+
+	for name, state := range states {
+		objID := opPolIdentifier(name.Namespace, name.Name)
+
+		watcher := r.DynamicWatcher
+		if clients, ok := r.targetClients.Load(objID); ok {
+			watcher = clients.(*TargetClusterClients).DynamicWatcher
+		}
+
+		policies = append(policies, newDebugPolicy("OperatorPolicy", name, state, watcher, objID))
+	}
+
+	return policies
+}
+
+// DebugServer serves the debug endpoint on its own address. It implements manager.Runnable.
+type DebugServer struct {
+	BindAddress string
+	Handler     http.Handler
+	// CertDir has the tls.crt and tls.key files for serving over HTTPS. HTTP is used when it is empty.
+	CertDir string
+	// Filter protects the endpoint, such as with the authentication and authorization of the secure metrics
+	// endpoint. It is optional.
+	Filter metricsserver.Filter
+}
+
+// NeedLeaderElection returns false so that the debug endpoint is served by all replicas.
+func (s *DebugServer) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *DebugServer) Start(ctx context.Context) error {
+	log := ctrl.Log.WithName("debug-server")
+
+	handler := s.Handler
+
+	if s.Filter != nil {
+		var err error
+
+		handler, err = s.Filter(log.WithValues("path", DebugPath), handler)
+		if err != nil {
+			return fmt.Errorf("failed to add the filter to the debug endpoint: %w", err)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(DebugPath, handler)
+
+	listener, err := (&net.ListenConfig{}).Listen(ctx, "tcp", s.BindAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen on the debug address %s: %w", s.BindAddress, err)
+	}
+
+	if s.CertDir != "" {
+		certWatcher, err := certwatcher.New(
+			filepath.Join(s.CertDir, "tls.crt"), filepath.Join(s.CertDir, "tls.key"),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to load the certificate for the debug endpoint: %w", err)
+		}
+
+		go func() {
+			if err := certWatcher.Start(ctx); err != nil {
+				log.Error(err, "Failed to watch the certificate for the debug endpoint")
+			}
+		}()
+
+		listener = tls.NewListener(listener, &tls.Config{
+			GetCertificate: certWatcher.GetCertificate,
+			MinVersion:     tls.VersionTLS12,
+			NextProtos:     []string{"http/1.1"},
+		})
+	}
+
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 30 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Error(err, "Failed to shut down the debug server")
+		}
+	}()
+
+	log.Info("Serving the debug endpoint", "bindAddress", s.BindAddress, "secure", s.CertDir != "")
+
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
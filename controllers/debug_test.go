@@ -0,0 +1,152 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	depclient "github.com/stolostron/kubernetes-dependency-watches/client"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+	"open-cluster-management.io/config-policy-controller/pkg/common"
+)
+
+// debugWatcher only implements the methods of the DynamicWatcher used by the debug endpoint.
+type debugWatcher struct {
+	depclient.DynamicWatcher
+	watched map[depclient.ObjectIdentifier][]unstructured.Unstructured
+}
+
+func (w *debugWatcher) GetWatchCount() uint {
+	return uint(len(w.watched))
+}
+
+func (w *debugWatcher) ListWatchedFromCache(watcher depclient.ObjectIdentifier) ([]unstructured.Unstructured, error) {
+	watched, ok := w.watched[watcher]
+	if !ok {
+		return nil, errors.New("the watcher is not found")
+	}
+
+	return watched, nil
+}
+
+type debugSelector struct {
+	common.SelectorReconciler
+}
+
+func (s *debugSelector) Selection(namespace string, name string) (common.SelectionState, bool) {
+	if name != "selected" {
+		return common.SelectionState{}, false
+	}
+
+	return common.SelectionState{
+		Target:     policyv1.Target{Include: []policyv1.NonEmptyString{"app-*"}},
+		Namespaces: []string{"app-1", "app-2"},
+	}, true
+}
+
+func TestEvaluationTracker(t *testing.T) {
+	t.Parallel()
+
+	tracker := evaluationTracker{}
+	name := types.NamespacedName{Namespace: "policies", Name: "policy"}
+	evaluatedAt := time.Now().UTC()
+
+	tracker.reconciled(name, evaluatedAt, reconcile.Result{RequeueAfter: time.Hour}, nil)
+
+	state := tracker.snapshot()[name]
+	assert.Equal(t, evaluatedAt, state.lastEvaluation)
+	assert.WithinDuration(t, evaluatedAt.Add(time.Hour), state.nextEvaluation, time.Minute)
+
+	// A throttled reconcile keeps the last evaluation
+	tracker.reconciled(name, time.Time{}, reconcile.Result{}, errors.New("some error"))
+
+	state = tracker.snapshot()[name]
+	assert.Equal(t, evaluatedAt, state.lastEvaluation)
+	assert.True(t, state.nextEvaluation.IsZero())
+	assert.Equal(t, "some error", state.lastError)
+
+	tracker.remove(name)
+	assert.Empty(t, tracker.snapshot())
+}
+
+func TestDebugHandler(t *testing.T) {
+	t.Parallel()
+
+	watchedID := depclient.ObjectIdentifier{
+		Group:     policyv1.GroupVersion.Group,
+		Version:   policyv1.GroupVersion.Version,
+		Kind:      "ConfigurationPolicy",
+		Namespace: "policies",
+		Name:      "selected",
+	}
+	configMap := unstructured.Unstructured{}
+	configMap.SetAPIVersion("v1")
+	configMap.SetKind("ConfigMap")
+	configMap.SetNamespace("app-1")
+	configMap.SetName("cm")
+
+	reconciler := &ConfigurationPolicyReconciler{
+		DynamicWatcher: &debugWatcher{
+			watched: map[depclient.ObjectIdentifier][]unstructured.Unstructured{
+				watchedID: {configMap},
+			},
+		},
+		SelectorReconciler: &debugSelector{},
+		ItemLimiters:       NewPerItemRateLimiter[reconcile.Request](2, 1),
+	}
+
+	evaluatedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	reconciler.evaluations.reconciled(
+		types.NamespacedName{Namespace: "policies", Name: "selected"}, evaluatedAt, reconcile.Result{}, nil,
+	)
+	reconciler.evaluations.reconciled(
+		types.NamespacedName{Namespace: "policies", Name: "other"}, time.Time{}, reconcile.Result{}, nil,
+	)
+	reconciler.ItemLimiters.GetLimiter(reconcile.Request{
+		NamespacedName: types.NamespacedName{Namespace: "policies", Name: "selected"},
+	})
+
+	handler := &DebugHandler{ConfigPolicies: reconciler}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, DebugPath, nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"watchCount": {"ConfigurationPolicy": 1},
+		"policies": [
+			{
+				"kind": "ConfigurationPolicy",
+				"namespace": "policies",
+				"name": "other",
+				"watches": [],
+				"watchesError": "the watcher is not found"
+			},
+			{
+				"kind": "ConfigurationPolicy",
+				"namespace": "policies",
+				"name": "selected",
+				"lastEvaluation": "2024-01-02T03:04:05Z",
+				"rateLimiter": {"tokens": 1, "burst": 1, "tokensPerSecond": 0.5},
+				"watches": [{"apiVersion": "v1", "kind": "ConfigMap", "namespace": "app-1", "name": "cm"}],
+				"namespaceSelection": {"include": ["app-*"], "namespaces": ["app-1", "app-2"]}
+			}
+		]
+	}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, DebugPath, nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
@@ -0,0 +1,288 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+	policyv1beta1 "open-cluster-management.io/config-policy-controller/api/v1beta1"
+	"open-cluster-management.io/config-policy-controller/pkg/audit"
+	"open-cluster-management.io/config-policy-controller/pkg/notifications"
+)
+
+// enforcedObject identifies an object that a policy created, updated, deleted, or approved.
+type enforcedObject struct {
+	apiVersion string
+	kind       string
+	namespace  string
+	name       string
+	uid        string
+}
+
+func (o enforcedObject) notificationReference() notifications.ObjectReference {
+	return notifications.ObjectReference{
+		APIVersion: o.apiVersion,
+		Kind:       o.kind,
+		Namespace:  o.namespace,
+		Name:       o.name,
+	}
+}
+
+func (o enforcedObject) auditReference() audit.Reference {
+	return audit.Reference{
+		APIVersion: o.apiVersion,
+		Kind:       o.kind,
+		Namespace:  o.namespace,
+		Name:       o.name,
+		UID:        o.uid,
+	}
+}
+
+// configPolicyReference identifies the ConfigurationPolicy in notifications.
+func configPolicyReference(plc *policyv1.ConfigurationPolicy) notifications.PolicyReference {
+	return notifications.PolicyReference{
+		APIVersion: policyv1.GroupVersion.String(),
+		Kind:       "ConfigurationPolicy",
+		Namespace:  plc.Namespace,
+		Name:       plc.Name,
+		UID:        string(plc.UID),
+	}
+}
+
+// operatorPolicyReference identifies the OperatorPolicy in notifications.
+func operatorPolicyReference(policy *policyv1beta1.OperatorPolicy) notifications.PolicyReference {
+	return notifications.PolicyReference{
+		APIVersion: policyv1beta1.GroupVersion.String(),
+		Kind:       "OperatorPolicy",
+		Namespace:  policy.Namespace,
+		Name:       policy.Name,
+		UID:        string(policy.UID),
+	}
+}
+
+// auditIdentity returns the identity recorded in the audit log for a policy with a target cluster, which
+// is the kubeconfig Secret since the user in it can't be determined without another request. An empty
+// string means that the default identity of the audit log is used.
+func auditIdentity(namespace string, targetCluster *policyv1.TargetCluster) string {
+	if targetCluster == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("kubeconfig Secret %s/%s", namespace, targetCluster.KubeconfigSecretRef.Name)
+}
+
+// recordEnforced sends a notification and writes an audit entry for a change that the ConfigurationPolicy
+// made on the cluster. The diff function is only called when the audit log is enabled.
+func (r *ConfigurationPolicyReconciler) recordEnforced(
+	ctx context.Context,
+	plc *policyv1.ConfigurationPolicy,
+	action string,
+	obj enforcedObject,
+	diff func() string,
+	message string,
+) {
+	r.Notifier.Enforced(notifications.EnforcedData{
+		Policy:   configPolicyReference(plc),
+		Severity: string(plc.Spec.Severity),
+		Action:   action,
+		Object:   obj.notificationReference(),
+		Message:  message,
+	})
+
+	// The events are only emitted on the default target cluster
+	if plc.Spec.TargetCluster == nil {
+		r.ObjectEvents.Enforced("ConfigurationPolicy", plc.Namespace, plc.Name, action, obj)
+	}
+
+	if !r.AuditLog.Enabled() {
+		return
+	}
+
+	entry := audit.Entry{
+		Policy:   audit.Reference(configPolicyReference(plc)),
+		Action:   action,
+		Object:   obj.auditReference(),
+		Identity: auditIdentity(plc.Namespace, plc.Spec.TargetCluster),
+		Message:  message,
+	}
+
+	if diff != nil {
+		entry.Diff = diff()
+	}
+
+	if err := r.AuditLog.Record(ctx, entry); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Failed to write the audit log entry", "action", action,
+			"objKind", obj.kind, "objNamespace", obj.namespace, "objName", obj.name)
+	}
+}
+
+// singleObjectReference identifies the object of the object template. The object is optional and is used for
+// the UID.
+func singleObjectReference(obj singleObject, object *unstructured.Unstructured) enforcedObject {
+	ref := enforcedObject{
+		apiVersion: obj.scopedGVR.GroupVersion().String(),
+		namespace:  obj.namespace,
+		name:       obj.name,
+	}
+
+	if obj.desiredObj != nil {
+		ref.kind = obj.desiredObj.GetKind()
+	} else if obj.existingObj != nil {
+		ref.kind = obj.existingObj.GetKind()
+	}
+
+	if object != nil {
+		ref.uid = string(object.GetUID())
+	}
+
+	return ref
+}
+
+// auditDiff returns the diff for the audit log between the object before and after it was changed. It honors
+// the recordDiff of the object template, so no diff is returned when it's None and the diff is redacted
+// when it's Censored.
+func auditDiff(
+	log logr.Logger, recordDiff policyv1.RecordDiff, before, after *unstructured.Unstructured, fullDiffs bool,
+) func() string {
+	return func() string {
+		switch recordDiff {
+		case policyv1.RecordDiffNone:
+			return ""
+		case policyv1.RecordDiffCensored:
+			return handleDiff(log, recordDiff, before, after, fullDiffs)
+		}
+
+		if before == nil {
+			before = &unstructured.Unstructured{Object: map[string]interface{}{}}
+		}
+
+		if after == nil {
+			after = &unstructured.Unstructured{Object: map[string]interface{}{}}
+		}
+
+		diff, err := generateDiff(before, after, fullDiffs)
+		if err != nil {
+			log.Error(err, "Failed to generate the diff for the audit log")
+		}
+
+		return diff
+	}
+}
+
+// objectTemplateRecordDiff returns the recordDiff of the object template of the object.
+func objectTemplateRecordDiff(obj singleObject) policyv1.RecordDiff {
+	if obj.index < 0 || obj.index >= len(obj.policy.Spec.ObjectTemplates) {
+		return policyv1.RecordDiffNone
+	}
+
+	return obj.policy.Spec.ObjectTemplates[obj.index].RecordDiffWithDefault()
+}
+
+// recordEnforced sends a notification and writes an audit entry for a change that the OperatorPolicy made on
+// the cluster. The before object is nil for a created object, and the after object is nil for a deleted object.
+// The objects must have their GroupVersionKind set.
+func (r *OperatorPolicyReconciler) recordEnforced(
+	ctx context.Context, policy *policyv1beta1.OperatorPolicy, action string, before, after client.Object,
+) {
+	obj := after
+	if obj == nil {
+		obj = before
+	}
+
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	ref := enforcedObject{
+		apiVersion: gvk.GroupVersion().String(),
+		kind:       gvk.Kind,
+		namespace:  obj.GetNamespace(),
+		name:       obj.GetName(),
+		uid:        string(obj.GetUID()),
+	}
+
+	r.Notifier.Enforced(notifications.EnforcedData{
+		Policy:   operatorPolicyReference(policy),
+		Severity: string(policy.Spec.Severity),
+		Action:   action,
+		Object:   ref.notificationReference(),
+	})
+
+	// The events are only emitted on the default target cluster
+	if policy.Spec.TargetCluster == nil {
+		r.ObjectEvents.Enforced("OperatorPolicy", policy.Namespace, policy.Name, action, ref)
+	}
+
+	if !r.AuditLog.Enabled() {
+		return
+	}
+
+	entry := audit.Entry{
+		Policy:   audit.Reference(operatorPolicyReference(policy)),
+		Action:   action,
+		Object:   ref.auditReference(),
+		Identity: auditIdentity(policy.Namespace, policy.Spec.TargetCluster),
+	}
+
+	// The deleted objects, such as ClusterServiceVersions and CustomResourceDefinitions, are managed by OLM
+	// rather than defined in the policy and can be very large, so their diff is omitted
+	if after != nil {
+		entry.Diff = operatorPolicyAuditDiff(ctrl.LoggerFrom(ctx), before, after)
+	}
+
+	if err := r.AuditLog.Record(ctx, entry); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Failed to write the audit log entry", "action", action,
+			"objKind", gvk.Kind, "objNamespace", ref.namespace, "objName", ref.name)
+	}
+}
+
+// operatorPolicyAuditDiff returns the diff of the object before and after the change for the audit log. The
+// before object is nil for a created object.
+func operatorPolicyAuditDiff(log logr.Logger, before, after client.Object) string {
+	afterObj, err := auditObject(after)
+	if err != nil {
+		log.Error(err, "Failed to generate the diff for the audit log")
+
+		return ""
+	}
+
+	var beforeObj *unstructured.Unstructured
+
+	if before != nil {
+		beforeObj, err = auditObject(before)
+		if err != nil {
+			log.Error(err, "Failed to generate the diff for the audit log")
+
+			return ""
+		}
+	}
+
+	return auditDiff(log, policyv1.RecordDiffLog, beforeObj, afterObj, false)()
+}
+
+// auditObject converts the object to the unstructured form used in the diffs of the audit log, without the
+// fields that aren't relevant to the change.
+func auditObject(obj client.Object) (*unstructured.Unstructured, error) {
+	var converted *unstructured.Unstructured
+
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		converted = u.DeepCopy()
+	} else {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert the %s to unstructured: %w",
+				obj.GetObjectKind().GroupVersionKind().Kind, err)
+		}
+
+		converted = &unstructured.Unstructured{Object: content}
+	}
+
+	removeFieldsForComparison(converted)
+
+	return converted, nil
+}
@@ -0,0 +1,82 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	operatorv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+	policyv1beta1 "open-cluster-management.io/config-policy-controller/api/v1beta1"
+	"open-cluster-management.io/config-policy-controller/pkg/audit"
+)
+
+func TestAuditDiff(t *testing.T) {
+	t.Parallel()
+
+	before := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "cm", "namespace": "default"},
+		"data":       map[string]interface{}{"key": "old"},
+	}}
+	after := before.DeepCopy()
+	assert.NoError(t, unstructured.SetNestedField(after.Object, "new", "data", "key"))
+
+	diff := auditDiff(logr.Discard(), policyv1.RecordDiffLog, before, after, false)()
+	assert.Contains(t, diff, "-  key: old\n+  key: new")
+
+	diff = auditDiff(logr.Discard(), policyv1.RecordDiffInStatus, nil, after, false)()
+	assert.Contains(t, diff, "+  key: new")
+
+	diff = auditDiff(logr.Discard(), policyv1.RecordDiffCensored, before, after, false)()
+	assert.Contains(t, diff, "# The difference is redacted because it contains sensitive data.")
+	assert.NotContains(t, diff, "new")
+
+	assert.Empty(t, auditDiff(logr.Discard(), policyv1.RecordDiffNone, before, after, false)())
+}
+
+func TestOperatorPolicyRecordEnforcedDiff(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	r := &OperatorPolicyReconciler{AuditLog: audit.NewLogger(&audit.WriterSink{Writer: buf}, "")}
+
+	policy := &policyv1beta1.OperatorPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-policy", Namespace: "default"},
+	}
+
+	before := &operatorv1alpha1.Subscription{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "operators.coreos.com/v1alpha1", Kind: "Subscription"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-operator", Namespace: "operators"},
+		Spec:       &operatorv1alpha1.SubscriptionSpec{Channel: "stable-1.0"},
+	}
+	after := before.DeepCopy()
+	after.Spec.Channel = "stable-1.1"
+
+	r.recordEnforced(t.Context(), policy, "created", nil, before)
+	r.recordEnforced(t.Context(), policy, "updated", before, after)
+	r.recordEnforced(t.Context(), policy, "deleted", after, nil)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Len(t, lines, 3)
+
+	entries := make([]audit.Entry, len(lines))
+	for i, line := range lines {
+		assert.NoError(t, json.Unmarshal([]byte(line), &entries[i]))
+		assert.Equal(t, "my-operator", entries[i].Object.Name)
+	}
+
+	assert.Contains(t, entries[0].Diff, "+  channel: stable-1.0")
+	assert.Contains(t, entries[1].Diff, "-  channel: stable-1.0\n+  channel: stable-1.1")
+	// The diff of the deleted objects is omitted
+	assert.Empty(t, entries[2].Diff)
+}
@@ -242,7 +242,10 @@ func (r *ConfigurationPolicyReconciler) getHubClient() *kubernetes.Clientset {
 
 func (r *ConfigurationPolicyReconciler) resolveHubTemplates(
 	ctx context.Context, policy *policyv1.ConfigurationPolicy,
-) error {
+) (err error) {
+	ctx, span := startConfigPolicySpan(ctx, "resolveHubTemplates", policy)
+	defer func() { endSpan(span, err) }()
+
 	if disableAnnotation, ok := policy.GetAnnotations()["policy.open-cluster-management.io/disable-templates"]; ok {
 		disableTemplates, _ := strconv.ParseBool(disableAnnotation) // on error, templates will not be disabled
 
@@ -56,6 +56,57 @@ var (
 		},
 		[]string{"config_policy_name", "namespace", "object"},
 	)
+	policyEvalDurationHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "policy_evaluation_duration_seconds",
+			Help: "The distribution of the seconds taken to evaluate a policy.",
+			// From 5ms to about 41 seconds
+			Buckets: prometheus.ExponentialBuckets(0.005, 2, 14),
+		},
+		[]string{"kind"},
+	)
+	compareObjDurationHistogram = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name: "compare_objects_duration_seconds",
+			Help: "The distribution of the seconds taken to compare a policy object to the object on the cluster.",
+			// From 0.5ms to about 4 seconds
+			Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14),
+		},
+	)
+	apiRequestsCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "policy_api_requests_total",
+			Help: "The number of Kubernetes API requests made while processing policies. The policy labels are " +
+				"empty when the request can't be attributed to a policy, such as for watches shared by policies.",
+		},
+		[]string{
+			"kind",             // The kind of the policy
+			"policy",           // The name of the policy
+			"policy_namespace", // The namespace where the policy is defined
+			"verb",             // The Kubernetes API verb, such as get, list, watch or patch
+			"group",            // The API group of the requested resource
+			"version",          // The API version of the requested resource
+			"resource",         // The requested resource, including any subresource
+		},
+	)
+	objectMetricsDroppedCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "policy_related_object_compliance_dropped_total",
+			Help: "The number of times a policy_related_object_compliance series was not reported because " +
+				"the maximum number of series was reached.",
+		},
+	)
+	targetClusterReachableGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "target_cluster_reachable",
+			Help: "Whether the target cluster referenced in the kubeconfig Secret of a policy is reachable. " +
+				"1 == reachable. 0 == unreachable.",
+		},
+		[]string{
+			"secret_namespace", // The namespace of the kubeconfig Secret
+			"secret_name",      // The name of the kubeconfig Secret
+		},
+	)
 	policyUserErrorsCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "policy_user_errors_total",
@@ -88,6 +139,11 @@ func init() {
 		policyEvalCounter,
 		compareObjSecondsCounter,
 		compareObjEvalCounter,
+		policyEvalDurationHistogram,
+		compareObjDurationHistogram,
+		apiRequestsCounter,
+		objectMetricsDroppedCounter,
+		targetClusterReachableGauge,
 	)
 	// Error metrics may already be registered by template sync
 	alreadyReg := &prometheus.AlreadyRegisteredError{}
@@ -119,6 +175,11 @@ func removeOperatorPolicyMetrics(request ctrl.Request) {
 		"policy":           request.Name,
 		"policy_namespace": request.Namespace,
 	})
+	_ = apiRequestsCounter.DeletePartialMatch(prometheus.Labels{
+		"kind":             "OperatorPolicy",
+		"policy":           request.Name,
+		"policy_namespace": request.Namespace,
+	})
 }
 
 func removeConfigPolicyMetrics(request ctrl.Request) {
@@ -134,4 +195,9 @@ func removeConfigPolicyMetrics(request ctrl.Request) {
 	_ = compareObjSecondsCounter.DeletePartialMatch(prometheus.Labels{"config_policy_name": request.Name})
 	_ = policyUserErrorsCounter.DeletePartialMatch(prometheus.Labels{"template": request.Name})
 	_ = policySystemErrorsCounter.DeletePartialMatch(prometheus.Labels{"template": request.Name})
+	_ = apiRequestsCounter.DeletePartialMatch(prometheus.Labels{
+		"kind":             "ConfigurationPolicy",
+		"policy":           request.Name,
+		"policy_namespace": request.Namespace,
+	})
 }
@@ -0,0 +1,122 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+// reasonObjectViolation is the reason of the events on objects that violate a policy.
+const reasonObjectViolation = "PolicyViolation"
+
+// ObjectEventRecorder emits the opt-in events on the objects that policies create, update, or find in
+// violation, so that they are visible to the owners of the objects. Identical events on an object are only
+// emitted once per interval, and all events are subject to an overall rate limit.
+type ObjectEventRecorder struct {
+	recorder record.EventRecorder
+	limiter  *rate.Limiter
+	interval time.Duration
+	// emitted has the last time each event was emitted, keyed by the object and the event.
+	emitted   map[string]time.Time
+	lastSweep time.Time
+	now       func() time.Time
+	lock      sync.Mutex
+}
+
+// NewObjectEventRecorder returns an ObjectEventRecorder that emits at most qps events per second with the
+// burst, and that doesn't repeat an identical event on an object within the interval. The recorder must emit
+// the events on the default target cluster.
+func NewObjectEventRecorder(
+	recorder record.EventRecorder, qps float64, burst int, interval time.Duration,
+) *ObjectEventRecorder {
+	return &ObjectEventRecorder{
+		recorder: recorder,
+		limiter:  rate.NewLimiter(rate.Limit(qps), burst),
+		interval: interval,
+		emitted:  map[string]time.Time{},
+		now:      time.Now,
+	}
+}
+
+// Enforced emits a Normal event on the object that the policy created, updated, recreated, or approved. The
+// event isn't emitted for deleted objects. It is a no-op if the recorder is not enabled.
+func (r *ObjectEventRecorder) Enforced(policyKind, policyNamespace, policyName, action string, obj enforcedObject) {
+	if r == nil || action == "deleted" || action == "" {
+		return
+	}
+
+	reason := strings.ToUpper(action[:1]) + action[1:] + "ByPolicy"
+	msg := fmt.Sprintf("The object was %s by %s %s/%s", action, policyKind, policyNamespace, policyName)
+
+	r.event(obj, corev1.EventTypeNormal, reason, msg)
+}
+
+// Violation emits a Warning event on the object that doesn't comply with the policy. It is a no-op if the
+// recorder is not enabled.
+func (r *ObjectEventRecorder) Violation(
+	policyKind, policyNamespace, policyName string, obj enforcedObject, details string,
+) {
+	if r == nil {
+		return
+	}
+
+	msg := fmt.Sprintf("The object violates %s %s/%s", policyKind, policyNamespace, policyName)
+	if details != "" {
+		msg += ": " + details
+	}
+
+	r.event(obj, corev1.EventTypeWarning, reasonObjectViolation, msg)
+}
+
+func (r *ObjectEventRecorder) event(obj enforcedObject, eventType, reason, msg string) {
+	key := strings.Join(
+		[]string{obj.apiVersion, obj.kind, obj.namespace, obj.name, obj.uid, eventType, reason, msg}, "\x00",
+	)
+
+	r.lock.Lock()
+
+	now := r.now()
+
+	if last, ok := r.emitted[key]; ok && now.Sub(last) < r.interval {
+		r.lock.Unlock()
+
+		return
+	}
+
+	if !r.limiter.AllowN(now, 1) {
+		r.lock.Unlock()
+
+		return
+	}
+
+	r.emitted[key] = now
+
+	// Forget the events that can be emitted again so that the map doesn't keep growing
+	if now.Sub(r.lastSweep) >= r.interval {
+		for k, last := range r.emitted {
+			if now.Sub(last) >= r.interval {
+				delete(r.emitted, k)
+			}
+		}
+
+		r.lastSweep = now
+	}
+
+	r.lock.Unlock()
+
+	r.recorder.Event(&corev1.ObjectReference{
+		APIVersion: obj.apiVersion,
+		Kind:       obj.kind,
+		Namespace:  obj.namespace,
+		Name:       obj.name,
+		UID:        types.UID(obj.uid),
+	}, eventType, reason, msg)
+}
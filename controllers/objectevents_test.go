@@ -0,0 +1,57 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestObjectEventRecorder(t *testing.T) {
+	t.Parallel()
+
+	fakeRecorder := record.NewFakeRecorder(10)
+	recorder := NewObjectEventRecorder(fakeRecorder, 1, 3, time.Minute)
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	recorder.now = func() time.Time { return now }
+
+	obj := enforcedObject{apiVersion: "v1", kind: "ConfigMap", namespace: "default", name: "cm", uid: "uid"}
+
+	recorder.Enforced("ConfigurationPolicy", "policies", "policy", "updated", obj)
+	// Identical events within the interval are deduplicated
+	recorder.Enforced("ConfigurationPolicy", "policies", "policy", "updated", obj)
+	// No events are emitted for deleted objects
+	recorder.Enforced("ConfigurationPolicy", "policies", "policy", "deleted", obj)
+	recorder.Violation("ConfigurationPolicy", "policies", "policy", obj, "found but not as specified")
+	recorder.Enforced("OperatorPolicy", "policies", "policy", "created", obj)
+	// The burst is exhausted
+	recorder.Enforced("OperatorPolicy", "policies", "policy", "approved", obj)
+
+	now = now.Add(time.Minute)
+
+	// The identical event can be emitted again after the interval
+	recorder.Enforced("ConfigurationPolicy", "policies", "policy", "updated", obj)
+
+	close(fakeRecorder.Events)
+
+	emitted := []string{}
+	for event := range fakeRecorder.Events {
+		emitted = append(emitted, event)
+	}
+
+	assert.Equal(t, []string{
+		"Normal UpdatedByPolicy The object was updated by ConfigurationPolicy policies/policy",
+		"Warning PolicyViolation The object violates ConfigurationPolicy policies/policy: found but not as specified",
+		"Normal CreatedByPolicy The object was created by OperatorPolicy policies/policy",
+		"Normal UpdatedByPolicy The object was updated by ConfigurationPolicy policies/policy",
+	}, emitted)
+
+	var disabled *ObjectEventRecorder
+
+	disabled.Enforced("ConfigurationPolicy", "policies", "policy", "updated", obj)
+	disabled.Violation("ConfigurationPolicy", "policies", "policy", obj, "")
+}
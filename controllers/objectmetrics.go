@@ -0,0 +1,200 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+)
+
+// objectMetricPolicyLabels are always included in the policy_related_object_compliance metric so that each
+// series belongs to a single policy.
+var objectMetricPolicyLabels = []string{
+	"kind",             // The kind of the policy
+	"policy",           // The name of the policy
+	"policy_namespace", // The namespace where the policy is defined
+}
+
+// ObjectMetricLabels are the optional labels that can be included in the policy_related_object_compliance
+// metric.
+var ObjectMetricLabels = []string{
+	"object_group",     // The API group of the related object
+	"object_version",   // The API version of the related object
+	"object_kind",      // The kind of the related object
+	"object_namespace", // The namespace of the related object
+	"object_name",      // The name of the related object
+	"reason",           // The reason for the compliance of the related object
+}
+
+type policyIdentity struct {
+	kind string
+	types.NamespacedName
+}
+
+// ObjectComplianceMetrics reports the compliance of the related objects of policies in the opt-in
+// policy_related_object_compliance metric. When labels are excluded, the related objects that only differ
+// in those labels share a series, which reports the worst compliance of those objects.
+type ObjectComplianceMetrics struct {
+	gauge     *prometheus.GaugeVec
+	labels    []string
+	maxSeries int
+	// series has the series of each policy, keyed by the label values joined by a null character.
+	series      map[policyIdentity]map[string]float64
+	seriesCount int
+	lock        sync.Mutex
+}
+
+// NewObjectComplianceMetrics registers the policy_related_object_compliance metric with the policy labels
+// and only the allowed labels, which must be from ObjectMetricLabels. At most maxSeries series are reported,
+// and additional series are counted in the policy_related_object_compliance_dropped_total metric.
+func NewObjectComplianceMetrics(
+	registerer prometheus.Registerer, allowedLabels []string, maxSeries int,
+) (*ObjectComplianceMetrics, error) {
+	labels := slices.Clone(objectMetricPolicyLabels)
+
+	// Keep the order of ObjectMetricLabels so that the series are consistent regardless of the input order
+	for _, label := range ObjectMetricLabels {
+		if slices.Contains(allowedLabels, label) {
+			labels = append(labels, label)
+		}
+	}
+
+	for _, label := range allowedLabels {
+		if !slices.Contains(ObjectMetricLabels, label) {
+			return nil, fmt.Errorf(
+				"the object metric label %s is invalid, valid labels are: %s",
+				label, strings.Join(ObjectMetricLabels, ", "),
+			)
+		}
+	}
+
+	if maxSeries < 1 {
+		return nil, fmt.Errorf("the maximum number of object metric series must be positive, got %d", maxSeries)
+	}
+
+	gauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "policy_related_object_compliance",
+			Help: "The compliance of the related objects of a policy. 0 == Compliant. 1 == NonCompliant. " +
+				"-1 == Unknown. When related objects share a series, the value is the worst compliance of them.",
+		},
+		labels,
+	)
+
+	if err := registerer.Register(gauge); err != nil {
+		return nil, err
+	}
+
+	return &ObjectComplianceMetrics{
+		gauge:     gauge,
+		labels:    labels,
+		maxSeries: maxSeries,
+		series:    map[policyIdentity]map[string]float64{},
+	}, nil
+}
+
+// Update replaces the series of the policy with the compliance of its related objects. It is a no-op if
+// the metrics are not enabled.
+func (m *ObjectComplianceMetrics) Update(
+	kind, namespace, name string, relatedObjects []policyv1.RelatedObject,
+) {
+	if m == nil {
+		return
+	}
+
+	policy := policyIdentity{kind, types.NamespacedName{Namespace: namespace, Name: name}}
+	desired := map[string]float64{}
+
+	for _, related := range relatedObjects {
+		gv, _ := schema.ParseGroupVersion(related.Object.APIVersion)
+
+		allValues := map[string]string{
+			"kind":             kind,
+			"policy":           name,
+			"policy_namespace": namespace,
+			"object_group":     gv.Group,
+			"object_version":   gv.Version,
+			"object_kind":      related.Object.Kind,
+			"object_namespace": related.Object.Metadata.Namespace,
+			"object_name":      related.Object.Metadata.Name,
+			"reason":           related.Reason,
+		}
+
+		values := make([]string, len(m.labels))
+		for i, label := range m.labels {
+			values[i] = allValues[label]
+		}
+
+		key := strings.Join(values, "\x00")
+		value := getStatusValue(policyv1.ComplianceState(related.Compliant))
+
+		if existing, ok := desired[key]; !ok || worseCompliance(value, existing) {
+			desired[key] = value
+		}
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	current := m.series[policy]
+
+	for key := range current {
+		if _, ok := desired[key]; !ok {
+			m.gauge.DeleteLabelValues(strings.Split(key, "\x00")...)
+			m.seriesCount--
+		}
+	}
+
+	reported := make(map[string]float64, len(desired))
+
+	for key, value := range desired {
+		if _, ok := current[key]; !ok {
+			if m.seriesCount >= m.maxSeries {
+				objectMetricsDroppedCounter.Inc()
+
+				continue
+			}
+
+			m.seriesCount++
+		}
+
+		m.gauge.WithLabelValues(strings.Split(key, "\x00")...).Set(value)
+		reported[key] = value
+	}
+
+	if len(reported) == 0 {
+		delete(m.series, policy)
+	} else {
+		m.series[policy] = reported
+	}
+}
+
+// Remove deletes the series of the policy. It is a no-op if the metrics are not enabled.
+func (m *ObjectComplianceMetrics) Remove(kind, namespace, name string) {
+	m.Update(kind, namespace, name, nil)
+}
+
+// worseCompliance returns true if the compliance value a is worse than b, where NonCompliant is worse than
+// Unknown, which is worse than Compliant.
+func worseCompliance(a, b float64) bool {
+	rank := func(value float64) int {
+		switch value {
+		case 1:
+			return 2
+		case -1:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	return rank(a) > rank(b)
+}
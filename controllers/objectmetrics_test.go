@@ -0,0 +1,110 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+)
+
+func relatedConfigMap(namespace, name, compliant, reason string) policyv1.RelatedObject {
+	return policyv1.RelatedObject{
+		Object: policyv1.ObjectResource{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+			Metadata:   policyv1.ObjectMetadata{Name: name, Namespace: namespace},
+		},
+		Compliant: compliant,
+		Reason:    reason,
+	}
+}
+
+func TestObjectComplianceMetrics(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+
+	metrics, err := NewObjectComplianceMetrics(registry, ObjectMetricLabels, 3)
+	assert.NoError(t, err)
+
+	metrics.Update("ConfigurationPolicy", "policies", "policy-1", []policyv1.RelatedObject{
+		relatedConfigMap("default", "cm-1", "Compliant", "Resource found as expected"),
+		relatedConfigMap("default", "cm-2", "NonCompliant", "Resource not found but should exist"),
+	})
+
+	assert.Equal(t, 2, testutil.CollectAndCount(registry))
+	assert.InDelta(t, 0, testutil.ToFloat64(metrics.gauge.WithLabelValues(
+		"ConfigurationPolicy", "policy-1", "policies", "", "v1", "ConfigMap", "default", "cm-1",
+		"Resource found as expected",
+	)), 0)
+	assert.InDelta(t, 1, testutil.ToFloat64(metrics.gauge.WithLabelValues(
+		"ConfigurationPolicy", "policy-1", "policies", "", "v1", "ConfigMap", "default", "cm-2",
+		"Resource not found but should exist",
+	)), 0)
+
+	// The maximum number of series is reached, so only one of these is reported
+	metrics.Update("OperatorPolicy", "policies", "policy-2", []policyv1.RelatedObject{
+		relatedConfigMap("default", "cm-3", "Compliant", "Resource found as expected"),
+		relatedConfigMap("default", "cm-4", "Compliant", "Resource found as expected"),
+	})
+	assert.Equal(t, 3, testutil.CollectAndCount(registry))
+
+	// Removing a policy frees up its series
+	metrics.Remove("ConfigurationPolicy", "policies", "policy-1")
+	assert.Equal(t, 1, testutil.CollectAndCount(registry))
+
+	metrics.Update("OperatorPolicy", "policies", "policy-2", []policyv1.RelatedObject{
+		relatedConfigMap("default", "cm-3", "Compliant", "Resource found as expected"),
+		relatedConfigMap("default", "cm-4", "Compliant", "Resource found as expected"),
+	})
+	assert.Equal(t, 2, testutil.CollectAndCount(registry))
+}
+
+func TestObjectComplianceMetricsLabelAllowlist(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+
+	metrics, err := NewObjectComplianceMetrics(registry, []string{"object_kind", "reason"}, 10)
+	assert.NoError(t, err)
+
+	// The objects only differ by name, so they share a series with the worst compliance
+	metrics.Update("ConfigurationPolicy", "policies", "policy", []policyv1.RelatedObject{
+		relatedConfigMap("default", "cm-1", "Compliant", "Resource found as expected"),
+		relatedConfigMap("default", "cm-2", "", "Resource found as expected"),
+		relatedConfigMap("other", "cm-3", "NonCompliant", "Resource found as expected"),
+		relatedConfigMap("other", "cm-4", "NonCompliant", "Resource not found but should exist"),
+	})
+
+	assert.Equal(t, 2, testutil.CollectAndCount(registry))
+	assert.InDelta(t, 1, testutil.ToFloat64(metrics.gauge.WithLabelValues(
+		"ConfigurationPolicy", "policy", "policies", "ConfigMap", "Resource found as expected",
+	)), 0)
+	assert.InDelta(t, 1, testutil.ToFloat64(metrics.gauge.WithLabelValues(
+		"ConfigurationPolicy", "policy", "policies", "ConfigMap", "Resource not found but should exist",
+	)), 0)
+
+	_, err = NewObjectComplianceMetrics(prometheus.NewRegistry(), []string{"object_uid"}, 10)
+	assert.ErrorContains(t, err, "the object metric label object_uid is invalid")
+
+	_, err = NewObjectComplianceMetrics(prometheus.NewRegistry(), ObjectMetricLabels, 0)
+	assert.ErrorContains(t, err, "the maximum number of object metric series must be positive")
+}
+
+func TestNilObjectComplianceMetrics(t *testing.T) {
+	t.Parallel()
+
+	var metrics *ObjectComplianceMetrics
+
+	assert.NotPanics(t, func() {
+		metrics.Update("ConfigurationPolicy", "policies", "policy", []policyv1.RelatedObject{
+			relatedConfigMap("default", "cm-1", "Compliant", "Resource found as expected"),
+		})
+		metrics.Remove("ConfigurationPolicy", "policies", "policy")
+	})
+}
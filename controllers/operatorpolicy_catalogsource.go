@@ -0,0 +1,379 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	operatorv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	templates "github.com/stolostron/go-template-utils/v7/pkg/templates"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+	policyv1beta1 "open-cluster-management.io/config-policy-controller/api/v1beta1"
+)
+
+// buildCatalogSource bootstraps the CatalogSource defined in the operator policy with the apiversion
+// and kind in preparation for resource creation. It returns nil when the policy doesn't specify a
+// CatalogSource. The namespace is used when the CatalogSource doesn't specify one.
+func buildCatalogSource(
+	policy *policyv1beta1.OperatorPolicy, namespace string, tmplResolver *templates.TemplateResolver,
+) (*unstructured.Unstructured, error) {
+	if policy.Spec.CatalogSource == nil {
+		return nil, nil
+	}
+
+	rawCatalog := policy.Spec.CatalogSource.Raw
+
+	if tmplResolver != nil && templates.HasTemplate(rawCatalog, "", false) {
+		watcher := opPolIdentifier(policy.Namespace, policy.Name)
+
+		resolvedTmpl, err := tmplResolver.ResolveTemplate(rawCatalog, nil, &templates.ResolveOptions{Watcher: &watcher})
+		if err != nil {
+			return nil, fmt.Errorf("could not build catalog source: %w", err)
+		}
+
+		rawCatalog = resolvedTmpl.ResolvedJSON
+	}
+
+	catalog := make(map[string]interface{})
+
+	if err := json.Unmarshal(rawCatalog, &catalog); err != nil {
+		return nil, fmt.Errorf("the policy spec.catalogSource is invalid: %w", err)
+	}
+
+	name, ok := catalog["name"].(string)
+	if !ok || name == "" {
+		return nil, errors.New("name is required in spec.catalogSource")
+	}
+
+	if validationErrs := validation.IsDNS1123Subdomain(name); len(validationErrs) != 0 {
+		return nil, fmt.Errorf(
+			"the name '%v' used for the catalog source is invalid: %s", name, strings.Join(validationErrs, ", "),
+		)
+	}
+
+	if specifiedNS, ok := catalog["namespace"].(string); ok && specifiedNS != "" {
+		if validationErrs := validation.IsDNS1123Label(specifiedNS); len(validationErrs) != 0 {
+			return nil, fmt.Errorf(
+				"the namespace '%v' used for the catalog source is not a valid namespace identifier", specifiedNS,
+			)
+		}
+
+		namespace = specifiedNS
+	}
+
+	if namespace == "" {
+		return nil, errors.New("namespace is required in spec.catalogSource")
+	}
+
+	// These fields are not actually in the CatalogSource spec
+	delete(catalog, "name")
+	delete(catalog, "namespace")
+
+	if _, ok := catalog["sourceType"]; !ok {
+		catalog["sourceType"] = string(operatorv1alpha1.SourceTypeGrpc)
+	}
+
+	// The UpdateStrategy type panics when it is unmarshaled without a registryPoll
+	if updateStrategy, ok := catalog["updateStrategy"]; ok {
+		strategy, ok := updateStrategy.(map[string]interface{})
+		if !ok || strategy["registryPoll"] == nil {
+			return nil, errors.New("registryPoll is required in spec.catalogSource.updateStrategy")
+		}
+	}
+
+	catalogSpec, err := json.Marshal(catalog)
+	if err != nil {
+		return nil, fmt.Errorf("the policy spec.catalogSource is invalid: %w", err)
+	}
+
+	// Use a decoder to find fields that were erroneously set by the user.
+	dec := json.NewDecoder(bytes.NewReader(catalogSpec))
+	dec.DisallowUnknownFields()
+
+	spec := new(operatorv1alpha1.CatalogSourceSpec)
+
+	if err := dec.Decode(spec); err != nil {
+		return nil, fmt.Errorf("the policy spec.catalogSource is invalid: %w", err)
+	}
+
+	if spec.SourceType == operatorv1alpha1.SourceTypeGrpc && spec.Image == "" && spec.Address == "" {
+		return nil, errors.New("image or address is required in spec.catalogSource")
+	}
+
+	if spec.UpdateStrategy != nil && spec.UpdateStrategy.RegistryPoll != nil {
+		if _, err := time.ParseDuration(spec.UpdateStrategy.RegistryPoll.RawInterval); err != nil {
+			return nil, fmt.Errorf("the spec.catalogSource.updateStrategy.registryPoll.interval is invalid: %w", err)
+		}
+	}
+
+	// The desired object is built from the policy rather than the Go type so that only the fields in
+	// the policy are compared with the CatalogSource on the cluster.
+	catalogSrc := &unstructured.Unstructured{Object: map[string]interface{}{"spec": catalog}}
+	catalogSrc.SetGroupVersionKind(catalogSrcGVK)
+	catalogSrc.SetName(name)
+	catalogSrc.SetNamespace(namespace)
+
+	return catalogSrc, nil
+}
+
+// applyCatalogSourceDefaults points the Subscription at the CatalogSource managed by the policy. The
+// Subscription defaults can't be determined from the PackageManifest before the CatalogSource is created,
+// so the channel and namespace of the Subscription are required.
+func applyCatalogSourceDefaults(sub *operatorv1alpha1.Subscription, catalogSrc *unstructured.Unstructured) error {
+	if sub.Spec.CatalogSource == "" && sub.Spec.CatalogSourceNamespace == "" {
+		sub.Spec.CatalogSource = catalogSrc.GetName()
+		sub.Spec.CatalogSourceNamespace = catalogSrc.GetNamespace()
+	}
+
+	if sub.Spec.CatalogSource != catalogSrc.GetName() || sub.Spec.CatalogSourceNamespace != catalogSrc.GetNamespace() {
+		return errors.New("the source and sourceNamespace in spec.subscription must match spec.catalogSource")
+	}
+
+	if sub.Spec.Channel == "" {
+		return errors.New("channel is required in spec.subscription when spec.catalogSource is set")
+	}
+
+	if sub.Namespace == "" {
+		return errors.New("namespace is required in spec.subscription when spec.catalogSource is set")
+	}
+
+	return nil
+}
+
+// handleManagedCatalogSource enforces the CatalogSource specified in the policy, like the OperatorGroup,
+// and then checks its health when it is wanted.
+func (r *OperatorPolicyReconciler) handleManagedCatalogSource(
+	ctx context.Context,
+	policy *policyv1beta1.OperatorPolicy,
+	desiredCatalogSrc *unstructured.Unstructured,
+	desiredSubName string,
+	desiredSubNamespace string,
+) ([]metav1.Condition, bool, error) {
+	target := r.target(policy)
+	watcher := opPolIdentifier(policy.Namespace, policy.Name)
+
+	foundCatalogSrc, err := target.DynamicWatcher.Get(watcher, catalogSrcGVK,
+		desiredCatalogSrc.GetNamespace(), desiredCatalogSrc.GetName())
+	if err != nil {
+		return nil, false, fmt.Errorf("error getting CatalogSource: %w", err)
+	}
+
+	if policy.Spec.ComplianceType.IsMustNotHave() {
+		return r.mustnothaveManagedCatalogSource(
+			ctx, policy, desiredCatalogSrc, foundCatalogSrc, desiredSubName, desiredSubNamespace,
+		)
+	}
+
+	return r.musthaveManagedCatalogSource(ctx, policy, desiredCatalogSrc, foundCatalogSrc)
+}
+
+func (r *OperatorPolicyReconciler) musthaveManagedCatalogSource(
+	ctx context.Context,
+	policy *policyv1beta1.OperatorPolicy,
+	desiredCatalogSrc *unstructured.Unstructured,
+	foundCatalogSrc *unstructured.Unstructured,
+) ([]metav1.Condition, bool, error) {
+	opLog := ctrl.LoggerFrom(ctx)
+	target := r.target(policy)
+
+	if foundCatalogSrc == nil {
+		// Missing CatalogSource: report NonCompliance
+		changed := updateStatus(policy, catalogSrcCond(missingWantedCond("CatalogSource")),
+			missingWantedObj(desiredCatalogSrc))
+
+		if policy.Spec.RemediationAction.IsInform() {
+			return nil, changed, nil
+		}
+
+		earlyConds := []metav1.Condition{}
+
+		if changed {
+			earlyConds = append(earlyConds, calculateComplianceCondition(policy))
+		}
+
+		err := r.createWithNamespace(ctx, target.Client, desiredCatalogSrc)
+		if err != nil {
+			return nil, changed, fmt.Errorf("error creating the CatalogSource: %w", err)
+		}
+
+		desiredCatalogSrc.SetGroupVersionKind(catalogSrcGVK) // Create stripped this information
+		r.recordEnforced(ctx, policy, "created", nil, desiredCatalogSrc)
+
+		// The health of the new CatalogSource is checked when its status is updated
+		updateStatus(policy, catalogSrcCond(createdCond("CatalogSource")), createdObj(desiredCatalogSrc))
+
+		return earlyConds, true, nil
+	}
+
+	var earlyConds []metav1.Condition
+
+	changed := false
+
+	// The merge modifies the found CatalogSource, so keep a copy for the diff of the audit log
+	existingCatalogSrc := foundCatalogSrc.DeepCopy()
+
+	updateNeeded, skipUpdate, err := r.mergeObjects(
+		ctx, target.Client, desiredCatalogSrc.Object, foundCatalogSrc, policyv1.MustHave,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("error checking if the CatalogSource needs an update: %w", err)
+	}
+
+	if updateNeeded {
+		if policy.Spec.RemediationAction.IsEnforce() && skipUpdate {
+			changed := updateStatus(policy, catalogSrcCond(mismatchCondUnfixable("CatalogSource")),
+				mismatchedObj(foundCatalogSrc))
+
+			return nil, changed, nil
+		}
+
+		// The names match, but the specs don't: report NonCompliance
+		changed = updateStatus(policy, catalogSrcCond(mismatchCond("CatalogSource")), mismatchedObj(foundCatalogSrc))
+
+		if policy.Spec.RemediationAction.IsInform() {
+			return nil, changed, nil
+		}
+
+		if changed {
+			earlyConds = append(earlyConds, calculateComplianceCondition(policy))
+		}
+
+		opLog.Info("Updating CatalogSource to match desired state", "catalogSourceName", foundCatalogSrc.GetName(),
+			"catalogSourceNamespace", foundCatalogSrc.GetNamespace())
+
+		err = target.Client.Update(ctx, foundCatalogSrc)
+		if err != nil {
+			return earlyConds, changed, fmt.Errorf("error updating the CatalogSource: %w", err)
+		}
+
+		foundCatalogSrc.SetGroupVersionKind(catalogSrcGVK) // Update stripped this information
+		r.recordEnforced(ctx, policy, "updated", existingCatalogSrc, foundCatalogSrc)
+
+		updateStatus(policy, catalogSrcCond(updatedCond("CatalogSource")), updatedObj(foundCatalogSrc))
+		earlyConds = append(earlyConds, calculateComplianceCondition(policy))
+		changed = true
+	}
+
+	catalogSrc := new(operatorv1alpha1.CatalogSource)
+
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(foundCatalogSrc.Object, catalogSrc)
+	if err != nil {
+		return earlyConds, changed, fmt.Errorf("error converting the retrieved CatalogSource to the Go type: %w", err)
+	}
+
+	healthChanged, err := r.musthaveCatalogSource(policy, catalogSrc, catalogSrc.Name, catalogSrc.Namespace)
+
+	return earlyConds, changed || healthChanged, err
+}
+
+func (r *OperatorPolicyReconciler) mustnothaveManagedCatalogSource(
+	ctx context.Context,
+	policy *policyv1beta1.OperatorPolicy,
+	desiredCatalogSrc *unstructured.Unstructured,
+	foundCatalogSrc *unstructured.Unstructured,
+	desiredSubName string,
+	desiredSubNamespace string,
+) ([]metav1.Condition, bool, error) {
+	opLog := ctrl.LoggerFrom(ctx)
+	target := r.target(policy)
+
+	if foundCatalogSrc == nil {
+		changed := updateStatus(policy, catalogSrcCond(missingNotWantedCond("CatalogSource")),
+			missingNotWantedObj(desiredCatalogSrc))
+
+		return nil, changed, nil
+	}
+
+	keep := policy.Spec.RemovalBehavior.ApplyDefaults().CatalogSources.IsKeep()
+
+	if !keep {
+		used, err := r.catalogSourceUsedByOthers(policy, foundCatalogSrc, desiredSubName, desiredSubNamespace)
+		if err != nil {
+			return nil, false, err
+		}
+
+		keep = used
+	}
+
+	if keep || len(foundCatalogSrc.GetOwnerReferences()) != 0 {
+		// The CatalogSource is used or managed by something else, so it is kept
+		changed := updateStatus(policy, catalogSrcCond(keptCond("CatalogSource")), leftoverObj(foundCatalogSrc))
+
+		return nil, changed, nil
+	}
+
+	// The found CatalogSource matches what is *not* wanted by the policy. Report NonCompliance.
+	changed := updateStatus(policy, catalogSrcCond(foundNotWantedCond("CatalogSource")),
+		foundNotWantedObj(foundCatalogSrc))
+
+	if policy.Spec.RemediationAction.IsInform() {
+		return nil, changed, nil
+	}
+
+	earlyConds := []metav1.Condition{}
+
+	if changed {
+		earlyConds = append(earlyConds, calculateComplianceCondition(policy))
+	}
+
+	opLog.Info("Deleting CatalogSource", "catalogSourceName", foundCatalogSrc.GetName(),
+		"catalogSourceNamespace", foundCatalogSrc.GetNamespace())
+
+	err := target.Client.Delete(ctx, foundCatalogSrc)
+	if err != nil {
+		return earlyConds, changed, fmt.Errorf("error deleting the CatalogSource: %w", err)
+	}
+
+	foundCatalogSrc.SetGroupVersionKind(catalogSrcGVK)
+	r.recordEnforced(ctx, policy, "deleted", foundCatalogSrc, nil)
+
+	updateStatus(policy, catalogSrcCond(deletedCond("CatalogSource")), deletedObj(foundCatalogSrc))
+
+	return earlyConds, true, nil
+}
+
+// catalogSourceUsedByOthers returns whether a Subscription other than the one for this policy uses the
+// CatalogSource. Subscriptions in any namespace can use CatalogSources in the global catalog namespace, so all
+// Subscriptions are checked.
+func (r *OperatorPolicyReconciler) catalogSourceUsedByOthers(
+	policy *policyv1beta1.OperatorPolicy,
+	catalogSrc *unstructured.Unstructured,
+	desiredSubName string,
+	desiredSubNamespace string,
+) (bool, error) {
+	target := r.target(policy)
+	watcher := opPolIdentifier(policy.Namespace, policy.Name)
+
+	foundSubscriptions, err := target.DynamicWatcher.List(watcher, subscriptionGVK, "", labels.Everything())
+	if err != nil {
+		return false, fmt.Errorf("error listing Subscriptions: %w", err)
+	}
+
+	for _, sub := range foundSubscriptions {
+		if sub.GetName() == desiredSubName && sub.GetNamespace() == desiredSubNamespace {
+			continue
+		}
+
+		source, _, _ := unstructured.NestedString(sub.Object, "spec", "source")
+		sourceNamespace, _, _ := unstructured.NestedString(sub.Object, "spec", "sourceNamespace")
+
+		if source == catalogSrc.GetName() && sourceNamespace == catalogSrc.GetNamespace() {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,132 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"testing"
+
+	operatorv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	policyv1beta1 "open-cluster-management.io/config-policy-controller/api/v1beta1"
+)
+
+func TestBuildCatalogSource(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		catalogSource string
+		expected      string
+	}{
+		"valid": {
+			catalogSource: `{"name": "my-catalog", "image": "quay.io/my/catalog:latest",
+				"updateStrategy": {"registryPoll": {"interval": "30m"}}}`,
+		},
+		"invalid JSON": {
+			catalogSource: `{"name": `,
+			expected:      "the policy spec.catalogSource is invalid",
+		},
+		"missing name": {
+			catalogSource: `{"image": "quay.io/my/catalog:latest"}`,
+			expected:      "name is required in spec.catalogSource",
+		},
+		"invalid name": {
+			catalogSource: `{"name": "My_Catalog", "image": "quay.io/my/catalog:latest"}`,
+			expected:      "the name 'My_Catalog' used for the catalog source is invalid",
+		},
+		"unknown field": {
+			catalogSource: `{"name": "my-catalog", "image": "quay.io/my/catalog:latest", "foo": "bar"}`,
+			expected:      `the policy spec.catalogSource is invalid: json: unknown field "foo"`,
+		},
+		"missing image": {
+			catalogSource: `{"name": "my-catalog"}`,
+			expected:      "image or address is required in spec.catalogSource",
+		},
+		"missing registryPoll": {
+			catalogSource: `{"name": "my-catalog", "image": "quay.io/my/catalog:latest", "updateStrategy": {}}`,
+			expected:      "registryPoll is required in spec.catalogSource.updateStrategy",
+		},
+		"invalid interval": {
+			catalogSource: `{"name": "my-catalog", "image": "quay.io/my/catalog:latest",
+				"updateStrategy": {"registryPoll": {"interval": "often"}}}`,
+			expected: "the spec.catalogSource.updateStrategy.registryPoll.interval is invalid",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			policy := &policyv1beta1.OperatorPolicy{
+				Spec: policyv1beta1.OperatorPolicySpec{
+					CatalogSource: &runtime.RawExtension{Raw: []byte(test.catalogSource)},
+				},
+			}
+
+			catalogSrc, err := buildCatalogSource(policy, "operators", nil)
+			if test.expected != "" {
+				assert.ErrorContains(t, err, test.expected)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, catalogSrcGVK, catalogSrc.GroupVersionKind())
+			assert.Equal(t, "my-catalog", catalogSrc.GetName())
+			assert.Equal(t, "operators", catalogSrc.GetNamespace())
+
+			sourceType, _, _ := unstructured.NestedString(catalogSrc.Object, "spec", "sourceType")
+			assert.Equal(t, "grpc", sourceType)
+		})
+	}
+}
+
+func TestBuildCatalogSourceNotSpecified(t *testing.T) {
+	t.Parallel()
+
+	catalogSrc, err := buildCatalogSource(&policyv1beta1.OperatorPolicy{}, "operators", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, catalogSrc)
+}
+
+func TestApplyCatalogSourceDefaults(t *testing.T) {
+	t.Parallel()
+
+	catalogSrc := &unstructured.Unstructured{}
+	catalogSrc.SetName("my-catalog")
+	catalogSrc.SetNamespace("operators")
+
+	sub := &operatorv1alpha1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-operator", Namespace: "operators"},
+		Spec:       &operatorv1alpha1.SubscriptionSpec{Package: "my-operator", Channel: "stable"},
+	}
+
+	assert.NoError(t, applyCatalogSourceDefaults(sub, catalogSrc))
+	assert.Equal(t, "my-catalog", sub.Spec.CatalogSource)
+	assert.Equal(t, "operators", sub.Spec.CatalogSourceNamespace)
+
+	sub.Spec.CatalogSource = "other-catalog"
+	assert.EqualError(t, applyCatalogSourceDefaults(sub, catalogSrc),
+		"the source and sourceNamespace in spec.subscription must match spec.catalogSource")
+
+	sub.Spec.CatalogSource = "my-catalog"
+	sub.Spec.Channel = ""
+	assert.EqualError(t, applyCatalogSourceDefaults(sub, catalogSrc),
+		"channel is required in spec.subscription when spec.catalogSource is set")
+}
+
+func TestCatalogSrcCond(t *testing.T) {
+	t.Parallel()
+
+	cond := catalogSrcCond(createdCond("CatalogSource"))
+	assert.Equal(t, catalogSrcConditionType, cond.Type)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, "CatalogSourceCreated", cond.Reason)
+
+	cond = catalogSrcCond(missingWantedCond("CatalogSource"))
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Equal(t, "CatalogSourceMissing", cond.Reason)
+}
@@ -0,0 +1,760 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	templates "github.com/stolostron/go-template-utils/v7/pkg/templates"
+	depclient "github.com/stolostron/kubernetes-dependency-watches/client"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+	policyv1beta1 "open-cluster-management.io/config-policy-controller/api/v1beta1"
+)
+
+const (
+	// These labels are added by OLM v1 to the objects it installs for a ClusterExtension
+	clusterExtOwnerKindLabel = "olm.operatorframework.io/owner-kind"
+	clusterExtOwnerNameLabel = "olm.operatorframework.io/owner-name"
+)
+
+// handleClusterExtensionResources is the equivalent of handleResources for a policy that manages an OLM v1
+// ClusterExtension instead of an OLM v0 Subscription. It has the same return values.
+func (r *OperatorPolicyReconciler) handleClusterExtensionResources(
+	ctx context.Context, policy *policyv1beta1.OperatorPolicy,
+) (earlyComplianceEvents []metav1.Condition, condChanged bool, err error) {
+	opLog := ctrl.LoggerFrom(ctx)
+
+	desiredExt, condChanged, err := r.buildClusterExtensionResources(ctx, policy)
+	if err != nil || desiredExt == nil {
+		if err != nil {
+			opLog.Error(err, "Error building the desired ClusterExtension")
+		}
+
+		return nil, condChanged, err
+	}
+
+	ext, earlyComplianceEvents, changed, err := r.handleClusterExtension(ctx, policy, desiredExt)
+	condChanged = condChanged || changed
+
+	if err != nil {
+		opLog.Error(err, "Error handling ClusterExtension")
+
+		return earlyComplianceEvents, condChanged, err
+	}
+
+	changed, err = r.handleClusterExtensionDeployments(ctx, policy, ext)
+	condChanged = condChanged || changed
+
+	if err != nil {
+		opLog.Error(err, "Error handling Deployments")
+
+		return earlyComplianceEvents, condChanged, err
+	}
+
+	changed, err = r.handleClusterCatalogs(ctx, policy, desiredExt)
+	condChanged = condChanged || changed
+
+	if err != nil {
+		opLog.Error(err, "Error handling ClusterCatalogs")
+
+		return earlyComplianceEvents, condChanged, err
+	}
+
+	changed = updateClusterExtensionDeprecationStatus(policy, ext)
+
+	return earlyComplianceEvents, condChanged || changed, nil
+}
+
+// buildClusterExtensionResources builds the desired ClusterExtension and checks if the policy's spec is
+// valid. It returns the built ClusterExtension, or nil if the policy is invalid, whether the status has
+// changed, and an error if an API call failed.
+func (r *OperatorPolicyReconciler) buildClusterExtensionResources(
+	ctx context.Context, policy *policyv1beta1.OperatorPolicy,
+) (*unstructured.Unstructured, bool, error) {
+	tmplResolver, err := r.templateResolver(ctx, policy)
+	if err != nil {
+		return nil, updateStatus(policy, validationCond([]error{err})), nil
+	}
+
+	if tmplResolver != nil {
+		err = resolveVersionsTemplates(policy, tmplResolver)
+		if err != nil {
+			newError := fmt.Errorf("unable to create template resolver: %w", err)
+
+			return nil, updateStatus(policy, validationCond([]error{newError})), nil
+		}
+	}
+
+	canonicalizeVersions(policy)
+
+	if _, err := versionRangeConstraints(policy); err != nil {
+		return nil, updateStatus(policy, validationCond([]error{err})), nil
+	}
+
+	ext, err := buildClusterExtension(policy, r.DefaultNamespace, tmplResolver)
+	if err != nil {
+		return nil, updateStatus(policy, validationCond([]error{err})), nil
+	}
+
+	extNamespace, _, _ := unstructured.NestedString(ext.Object, "spec", "namespace")
+	watcher := opPolIdentifier(policy.Namespace, policy.Name)
+
+	gotNamespace, err := r.target(policy).DynamicWatcher.Get(watcher, namespaceGVK, "", extNamespace)
+	if err != nil {
+		return nil, false, fmt.Errorf("error getting operator namespace: %w", err)
+	}
+
+	if gotNamespace == nil && policy.Spec.ComplianceType.IsMustHave() {
+		newError := fmt.Errorf("the operator namespace ('%v') does not exist", extNamespace)
+
+		return ext, updateStatus(policy, validationCond([]error{newError})), nil
+	}
+
+	return ext, updateStatus(policy, validationCond([]error{})), nil
+}
+
+// buildClusterExtension bootstraps the ClusterExtension defined in the operator policy with the apiversion
+// and kind in preparation for resource creation. The namespace is used when the ClusterExtension doesn't
+// specify one. If an error is returned, it will include details on why the policy spec is invalid.
+func buildClusterExtension(
+	policy *policyv1beta1.OperatorPolicy, namespace string, tmplResolver *templates.TemplateResolver,
+) (*unstructured.Unstructured, error) {
+	if len(policy.Spec.Subscription.Raw) != 0 {
+		return nil, errors.New("only one of spec.subscription and spec.clusterExtension can be set")
+	}
+
+	if err := validateClusterExtensionPolicy(policy); err != nil {
+		return nil, err
+	}
+
+	rawExt := policy.Spec.ClusterExtension.Raw
+
+	if tmplResolver != nil && templates.HasTemplate(rawExt, "", false) {
+		watcher := opPolIdentifier(policy.Namespace, policy.Name)
+
+		resolvedTmpl, err := tmplResolver.ResolveTemplate(rawExt, nil, &templates.ResolveOptions{Watcher: &watcher})
+		if err != nil {
+			return nil, fmt.Errorf("could not build cluster extension: %w", err)
+		}
+
+		rawExt = resolvedTmpl.ResolvedJSON
+	}
+
+	spec := make(map[string]interface{})
+
+	if err := json.Unmarshal(rawExt, &spec); err != nil {
+		return nil, fmt.Errorf("the policy spec.clusterExtension is invalid: %w", err)
+	}
+
+	name, ok := spec["name"].(string)
+	if !ok || name == "" {
+		return nil, errors.New("name is required in spec.clusterExtension")
+	}
+
+	if validationErrs := validation.IsDNS1123Label(name); len(validationErrs) != 0 {
+		return nil, fmt.Errorf(
+			"the name '%v' used for the cluster extension is invalid: %s", name, strings.Join(validationErrs, ", "),
+		)
+	}
+
+	// This field is not actually in the ClusterExtension spec
+	delete(spec, "name")
+
+	if specifiedNS, ok := spec["namespace"].(string); ok && specifiedNS != "" {
+		namespace = specifiedNS
+	}
+
+	if namespace == "" {
+		return nil, errors.New("namespace is required in spec.clusterExtension")
+	}
+
+	if validationErrs := validation.IsDNS1123Label(namespace); len(validationErrs) != 0 {
+		return nil, fmt.Errorf(
+			"the namespace '%v' used for the cluster extension is not a valid namespace identifier", namespace,
+		)
+	}
+
+	spec["namespace"] = namespace
+
+	if saName, _, _ := unstructured.NestedString(spec, "serviceAccount", "name"); saName == "" {
+		return nil, errors.New("serviceAccount.name is required in spec.clusterExtension")
+	}
+
+	sourceType, found, err := unstructured.NestedString(spec, "source", "sourceType")
+	if err != nil {
+		return nil, fmt.Errorf("the policy spec.clusterExtension is invalid: %w", err)
+	}
+
+	if !found {
+		sourceType = "Catalog"
+
+		if err := unstructured.SetNestedField(spec, sourceType, "source", "sourceType"); err != nil {
+			return nil, fmt.Errorf("the policy spec.clusterExtension is invalid: %w", err)
+		}
+	}
+
+	if sourceType != "Catalog" {
+		return nil, errors.New("only the Catalog sourceType is supported in spec.clusterExtension.source")
+	}
+
+	if pkgName, _, _ := unstructured.NestedString(spec, "source", "catalog", "packageName"); pkgName == "" {
+		return nil, errors.New("source.catalog.packageName is required in spec.clusterExtension")
+	}
+
+	if _, found, _ := unstructured.NestedFieldNoCopy(spec, "source", "catalog", "version"); found {
+		return nil, errors.New("source.catalog.version is prohibited in spec.clusterExtension")
+	}
+
+	for _, version := range policy.Spec.Versions {
+		if _, err := semver.NewVersion(bundleVersionFromName(version)); err != nil {
+			return nil, fmt.Errorf(
+				"the version of the bundle '%v' in spec.versions can't be determined for the cluster extension",
+				version,
+			)
+		}
+	}
+
+	// The desired object is built from the policy rather than a Go type so that only the fields in
+	// the policy are compared with the ClusterExtension on the cluster.
+	ext := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	ext.SetGroupVersionKind(clusterExtensionGVK)
+	ext.SetName(name)
+
+	return ext, nil
+}
+
+// validateClusterExtensionPolicy returns an error listing the policy fields that only apply to OLM v0
+// operators, when they are set.
+func validateClusterExtensionPolicy(policy *policyv1beta1.OperatorPolicy) error {
+	unsupported := []string{}
+
+	if policy.Spec.OperatorGroup != nil {
+		unsupported = append(unsupported, "spec.operatorGroup")
+	}
+
+	if policy.Spec.CatalogSource != nil {
+		unsupported = append(unsupported, "spec.catalogSource")
+	}
+
+	if len(policy.Spec.Operands) != 0 {
+		unsupported = append(unsupported, "spec.operands")
+	}
+
+	if len(policy.Spec.UpgradeApprovalWindows) != 0 {
+		unsupported = append(unsupported, "spec.upgradeApprovalWindows")
+	}
+
+	if policy.Spec.UpgradeSoakTime != "" {
+		unsupported = append(unsupported, "spec.upgradeSoakTime")
+	}
+
+	if len(policy.Spec.AllowedDependencies) != 0 {
+		unsupported = append(unsupported, "spec.allowedDependencies")
+	}
+
+	if policy.Spec.UpgradeFailurePolicy == "Rollback" {
+		unsupported = append(unsupported, "spec.upgradeFailurePolicy")
+	}
+
+	config := policy.Spec.ComplianceConfig
+
+	if config.PodsUnhealthy != "" {
+		unsupported = append(unsupported, "spec.complianceConfig.podsUnhealthy")
+	}
+
+	if config.CSVRequirementsNotMet != "" {
+		unsupported = append(unsupported, "spec.complianceConfig.csvRequirementsNotMet")
+	}
+
+	if config.OwnedAPIsUnavailable != "" {
+		unsupported = append(unsupported, "spec.complianceConfig.ownedAPIsUnavailable")
+	}
+
+	if len(unsupported) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%s can't be used with spec.clusterExtension", strings.Join(unsupported, ", "))
+}
+
+// bundleVersionFromName returns the version in a bundle name like my-operator.v1.2.3, which is the
+// format used in spec.versions.
+func bundleVersionFromName(bundleName string) string {
+	if idx := strings.LastIndex(bundleName, ".v"); idx != -1 {
+		return bundleName[idx+2:]
+	}
+
+	return bundleName
+}
+
+// clusterExtensionVersionConstraint returns the version constraint for the ClusterExtension that allows the
+// versions in spec.versionRange and spec.versions. It is empty when all versions are allowed.
+func clusterExtensionVersionConstraint(policy *policyv1beta1.OperatorPolicy) string {
+	constraints := make([]string, 0, len(policy.Spec.Versions)+1)
+
+	if policy.Spec.VersionRange != "" {
+		constraints = append(constraints, policy.Spec.VersionRange)
+	}
+
+	for _, version := range policy.Spec.Versions {
+		constraints = append(constraints, bundleVersionFromName(version))
+	}
+
+	return strings.Join(constraints, " || ")
+}
+
+// desiredClusterExtensionVersion returns the version for the spec.source.catalog.version field of the
+// ClusterExtension. When upgrades are not approved, the version is pinned to the installed bundle.
+func desiredClusterExtensionVersion(policy *policyv1beta1.OperatorPolicy, foundExt *unstructured.Unstructured) string {
+	if policy.Spec.UpgradeApproval == "None" && foundExt != nil {
+		installedVersion, _, _ := unstructured.NestedString(
+			foundExt.Object, "status", "install", "bundle", "version",
+		)
+		if installedVersion != "" {
+			return installedVersion
+		}
+	}
+
+	return clusterExtensionVersionConstraint(policy)
+}
+
+// handleClusterExtension gets the ClusterExtension on the cluster and compares it with the desired one,
+// enforcing it if necessary. It returns the found or created ClusterExtension, compliance conditions that
+// should be emitted as events, whether the status changed, and an error if an API call failed.
+func (r *OperatorPolicyReconciler) handleClusterExtension(
+	ctx context.Context,
+	policy *policyv1beta1.OperatorPolicy,
+	desiredExt *unstructured.Unstructured,
+) (*unstructured.Unstructured, []metav1.Condition, bool, error) {
+	ctx, span := startOperatorPolicySpan(ctx, "handleClusterExtension", policy)
+	defer span.End()
+
+	watcher := opPolIdentifier(policy.Namespace, policy.Name)
+
+	foundExt, err := r.target(policy).DynamicWatcher.Get(watcher, clusterExtensionGVK, "", desiredExt.GetName())
+	if err != nil {
+		if !errors.Is(err, depclient.ErrNoVersionedResource) {
+			return nil, nil, false, fmt.Errorf("error getting the ClusterExtension: %w", err)
+		}
+
+		if policy.Spec.ComplianceType.IsMustHave() {
+			newError := errors.New("the ClusterExtension API is not available on the cluster")
+
+			return nil, nil, updateStatus(policy, validationCond([]error{newError})), nil
+		}
+
+		// Without the API, there can't be a ClusterExtension to remove
+		foundExt = nil
+	}
+
+	if policy.Spec.ComplianceType.IsMustNotHave() {
+		return r.mustnothaveClusterExtension(ctx, policy, desiredExt, foundExt)
+	}
+
+	return r.musthaveClusterExtension(ctx, policy, desiredExt, foundExt)
+}
+
+func (r *OperatorPolicyReconciler) musthaveClusterExtension(
+	ctx context.Context,
+	policy *policyv1beta1.OperatorPolicy,
+	desiredExt *unstructured.Unstructured,
+	foundExt *unstructured.Unstructured,
+) (*unstructured.Unstructured, []metav1.Condition, bool, error) {
+	opLog := ctrl.LoggerFrom(ctx)
+	target := r.target(policy)
+
+	// The version is only set when enforcing, so an inform policy doesn't compare it
+	desiredVersion := ""
+
+	if policy.Spec.RemediationAction.IsEnforce() {
+		desiredVersion = desiredClusterExtensionVersion(policy, foundExt)
+
+		if desiredVersion != "" {
+			err := unstructured.SetNestedField(
+				desiredExt.Object, desiredVersion, "spec", "source", "catalog", "version",
+			)
+			if err != nil {
+				return nil, nil, false, fmt.Errorf("error setting the ClusterExtension version: %w", err)
+			}
+		}
+	}
+
+	if foundExt == nil {
+		// Missing ClusterExtension: report NonCompliance
+		changed := updateStatus(policy, missingWantedCond("ClusterExtension"), missingWantedObj(desiredExt))
+
+		if policy.Spec.RemediationAction.IsInform() {
+			return nil, nil, changed, nil
+		}
+
+		earlyConds := []metav1.Condition{}
+
+		if changed {
+			earlyConds = append(earlyConds, calculateComplianceCondition(policy))
+		}
+
+		opLog.Info("Creating ClusterExtension", "clusterExtensionName", desiredExt.GetName())
+
+		err := target.Client.Create(ctx, desiredExt)
+		if err != nil {
+			return nil, earlyConds, changed, fmt.Errorf("error creating the ClusterExtension: %w", err)
+		}
+
+		desiredExt.SetGroupVersionKind(clusterExtensionGVK) // Create stripped this information
+		r.recordEnforced(ctx, policy, "created", nil, desiredExt)
+
+		// The installation is checked when the status of the new ClusterExtension is updated
+		updateStatus(policy, createdCond("ClusterExtension"), createdObj(desiredExt))
+
+		return desiredExt, earlyConds, true, nil
+	}
+
+	var earlyConds []metav1.Condition
+
+	changed := false
+
+	// The merge modifies the found ClusterExtension, so keep a copy for the diff of the audit log
+	existingExt := foundExt.DeepCopy()
+
+	updateNeeded, skipUpdate, err := r.mergeObjects(
+		ctx, target.Client, desiredExt.Object, foundExt, policyv1.MustHave,
+	)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("error checking if the ClusterExtension needs an update: %w", err)
+	}
+
+	// Merging doesn't remove fields, so a version pinned before upgrades were approved is removed here
+	if policy.Spec.RemediationAction.IsEnforce() && desiredVersion == "" {
+		if _, found, _ := unstructured.NestedString(foundExt.Object, "spec", "source", "catalog", "version"); found {
+			unstructured.RemoveNestedField(foundExt.Object, "spec", "source", "catalog", "version")
+
+			updateNeeded = true
+		}
+	}
+
+	if updateNeeded {
+		if policy.Spec.RemediationAction.IsEnforce() && skipUpdate {
+			changed := updateStatus(policy, mismatchCondUnfixable("ClusterExtension"), mismatchedObj(foundExt))
+
+			return foundExt, nil, changed, nil
+		}
+
+		// The names match, but the specs don't: report NonCompliance
+		changed = updateStatus(policy, mismatchCond("ClusterExtension"), mismatchedObj(foundExt))
+
+		if policy.Spec.RemediationAction.IsInform() {
+			return foundExt, nil, changed, nil
+		}
+
+		if changed {
+			earlyConds = append(earlyConds, calculateComplianceCondition(policy))
+		}
+
+		opLog.Info("Updating ClusterExtension to match desired state", "clusterExtensionName", foundExt.GetName())
+
+		err = target.Client.Update(ctx, foundExt)
+		if err != nil {
+			return foundExt, earlyConds, changed, fmt.Errorf("error updating the ClusterExtension: %w", err)
+		}
+
+		foundExt.SetGroupVersionKind(clusterExtensionGVK) // Update stripped this information
+		r.recordEnforced(ctx, policy, "updated", existingExt, foundExt)
+
+		updateStatus(policy, updatedCond("ClusterExtension"), updatedObj(foundExt))
+		earlyConds = append(earlyConds, calculateComplianceCondition(policy))
+		changed = true
+	}
+
+	installCond, installObj := clusterExtensionInstallStatus(policy, foundExt)
+
+	return foundExt, earlyConds, updateStatus(policy, installCond, installObj) || changed, nil
+}
+
+// clusterExtensionInstallStatus returns the condition and related object for the installation of the
+// ClusterExtension, which is compliant when a bundle allowed by the policy is installed.
+func clusterExtensionInstallStatus(
+	policy *policyv1beta1.OperatorPolicy, ext *unstructured.Unstructured,
+) (metav1.Condition, policyv1.RelatedObject) {
+	conditions := clusterExtensionConditions(ext)
+	bundleName, _, _ := unstructured.NestedString(ext.Object, "status", "install", "bundle", "name")
+	bundleVersion, _, _ := unstructured.NestedString(ext.Object, "status", "install", "bundle", "version")
+
+	installed := meta.FindStatusCondition(conditions, "Installed")
+
+	if bundleName == "" || installed == nil || installed.Status != metav1.ConditionTrue {
+		details := ""
+
+		// The Progressing condition has the most recent reason that the installation hasn't succeeded
+		if progressing := meta.FindStatusCondition(conditions, "Progressing"); progressing != nil {
+			details = progressing.Message
+		}
+
+		if details == "" && installed != nil {
+			details = installed.Message
+		}
+
+		return clusterExtNotInstalledCond(details), nonCompObj(ext, "The ClusterExtension is not installed")
+	}
+
+	if !csvAllowed(policy, nil, bundleName, bundleVersion) {
+		return clusterExtDisallowedCond(bundleName),
+			nonCompObj(ext, "The installed bundle "+bundleName+" is not allowed by the policy")
+	}
+
+	return clusterExtInstalledCond(bundleName), matchedObj(ext)
+}
+
+// clusterExtensionConditions returns the conditions in the status of the ClusterExtension, skipping any
+// that can't be parsed.
+func clusterExtensionConditions(ext *unstructured.Unstructured) []metav1.Condition {
+	rawConditions, _, _ := unstructured.NestedSlice(ext.Object, "status", "conditions")
+	conditions := make([]metav1.Condition, 0, len(rawConditions))
+
+	for _, rawCondition := range rawConditions {
+		conditionMap, ok := rawCondition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		condition := metav1.Condition{}
+
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(conditionMap, &condition)
+		if err != nil {
+			continue
+		}
+
+		conditions = append(conditions, condition)
+	}
+
+	return conditions
+}
+
+func (r *OperatorPolicyReconciler) mustnothaveClusterExtension(
+	ctx context.Context,
+	policy *policyv1beta1.OperatorPolicy,
+	desiredExt *unstructured.Unstructured,
+	foundExt *unstructured.Unstructured,
+) (*unstructured.Unstructured, []metav1.Condition, bool, error) {
+	if foundExt == nil {
+		// Missing ClusterExtension: report Compliance
+		changed := updateStatus(policy, missingNotWantedCond("ClusterExtension"), missingNotWantedObj(desiredExt))
+
+		return nil, nil, changed, nil
+	}
+
+	if policy.Spec.RemovalBehavior.ApplyDefaults().ClusterExtensions.IsKeep() {
+		changed := updateStatus(policy, keptCond("ClusterExtension"), leftoverObj(foundExt))
+
+		return foundExt, nil, changed, nil
+	}
+
+	// ClusterExtension found, not wanted: report NonCompliance.
+	changed := updateStatus(policy, foundNotWantedCond("ClusterExtension"), foundNotWantedObj(foundExt))
+
+	if policy.Spec.RemediationAction.IsInform() {
+		return foundExt, nil, changed, nil
+	}
+
+	if foundExt.GetDeletionTimestamp() != nil {
+		// No "early" condition because that would cause the status to flap
+		return foundExt, nil, updateStatus(policy, deletingCond("ClusterExtension"), deletingObj(foundExt)), nil
+	}
+
+	earlyConds := []metav1.Condition{}
+
+	if changed {
+		earlyConds = append(earlyConds, calculateComplianceCondition(policy))
+	}
+
+	opLog := ctrl.LoggerFrom(ctx)
+	opLog.Info("Deleting ClusterExtension", "clusterExtensionName", foundExt.GetName())
+
+	err := r.target(policy).Client.Delete(ctx, foundExt)
+	if err != nil {
+		return foundExt, earlyConds, changed, fmt.Errorf("error deleting the ClusterExtension: %w", err)
+	}
+
+	foundExt.SetGroupVersionKind(clusterExtensionGVK)
+	r.recordEnforced(ctx, policy, "deleted", foundExt, nil)
+
+	updateStatus(policy, deletedCond("ClusterExtension"), deletedObj(foundExt))
+
+	return foundExt, earlyConds, true, nil
+}
+
+// handleClusterExtensionDeployments checks the availability of the Deployments installed for the
+// ClusterExtension, which OLM v1 labels with the ClusterExtension that owns them.
+func (r *OperatorPolicyReconciler) handleClusterExtensionDeployments(
+	ctx context.Context,
+	policy *policyv1beta1.OperatorPolicy,
+	ext *unstructured.Unstructured,
+) (bool, error) {
+	ctx, span := startOperatorPolicySpan(ctx, "handleClusterExtensionDeployments", policy)
+	defer span.End()
+
+	if policy.Spec.ComplianceType.IsMustNotHave() {
+		return updateStatus(policy, notApplicableCond("Deployment")), nil
+	}
+
+	if ext == nil {
+		return updateStatus(policy, noClusterExtDeploymentsCond, noExistingDeploymentObj), nil
+	}
+
+	opLog := ctrl.LoggerFrom(ctx)
+	watcher := opPolIdentifier(policy.Namespace, policy.Name)
+	extNamespace, _, _ := unstructured.NestedString(ext.Object, "spec", "namespace")
+
+	selector := labels.SelectorFromSet(labels.Set{
+		clusterExtOwnerKindLabel: clusterExtensionGVK.Kind,
+		clusterExtOwnerNameLabel: ext.GetName(),
+	})
+
+	foundDeps, err := r.target(policy).DynamicWatcher.List(watcher, deploymentGVK, extNamespace, selector)
+	if err != nil {
+		return false, fmt.Errorf("error listing the Deployments: %w", err)
+	}
+
+	var relatedObjects []policyv1.RelatedObject
+	var unavailableDeployments []appsv1.Deployment
+
+	complianceConfig := policy.Spec.ComplianceConfig.DeploymentsUnavailable
+
+	for _, foundDep := range foundDeps {
+		var dep appsv1.Deployment
+
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(foundDep.Object, &dep)
+		if err != nil {
+			opLog.Error(err, "Unable to convert unstructured Deployment to typed", "Deployment.Name", dep.Name)
+
+			continue
+		}
+
+		if dep.Status.UnavailableReplicas > 0 {
+			unavailableDeployments = append(unavailableDeployments, dep)
+		}
+
+		relatedObjects = append(relatedObjects, existingDeploymentObj(&dep, complianceConfig))
+	}
+
+	if len(relatedObjects) == 0 {
+		relatedObjects = append(relatedObjects, noExistingDeploymentObj)
+	}
+
+	return updateStatus(policy, buildDeploymentCond(complianceConfig, len(foundDeps) > 0, unavailableDeployments),
+		relatedObjects...), nil
+}
+
+// handleClusterCatalogs checks that the ClusterCatalogs that the ClusterExtension can install from are
+// serving, like the CatalogSource for a Subscription. The catalogs are selected with the
+// spec.source.catalog.selector field of the desired ClusterExtension, and all catalogs are used when it is
+// not set.
+func (r *OperatorPolicyReconciler) handleClusterCatalogs(
+	ctx context.Context,
+	policy *policyv1beta1.OperatorPolicy,
+	desiredExt *unstructured.Unstructured,
+) (bool, error) {
+	_, span := startOperatorPolicySpan(ctx, "handleClusterCatalogs", policy)
+	defer span.End()
+
+	if policy.Spec.ComplianceType.IsMustNotHave() {
+		cond := notApplicableCond("ClusterCatalog")
+		cond.Status = metav1.ConditionFalse // ClusterCatalog condition has the opposite polarity
+
+		return updateStatus(policy, cond), nil
+	}
+
+	selector := labels.Everything()
+
+	rawSelector, found, _ := unstructured.NestedMap(desiredExt.Object, "spec", "source", "catalog", "selector")
+	if found {
+		labelSelector := &metav1.LabelSelector{}
+
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(rawSelector, labelSelector)
+		if err == nil {
+			selector, err = metav1.LabelSelectorAsSelector(labelSelector)
+		}
+
+		if err != nil {
+			newError := fmt.Errorf("the spec.clusterExtension.source.catalog.selector is invalid: %w", err)
+
+			return updateStatus(policy, validationCond([]error{newError})), nil
+		}
+	}
+
+	watcher := opPolIdentifier(policy.Namespace, policy.Name)
+
+	catalogs, err := r.target(policy).DynamicWatcher.List(watcher, clusterCatalogGVK, "", selector)
+	if err != nil {
+		return false, fmt.Errorf("error listing the ClusterCatalogs: %w", err)
+	}
+
+	complianceConfig := policy.Spec.ComplianceConfig.CatalogSourceUnhealthy
+	relatedObjects := make([]policyv1.RelatedObject, 0, len(catalogs))
+	notServing := []string{}
+
+	for i := range catalogs {
+		serving := meta.IsStatusConditionTrue(clusterExtensionConditions(&catalogs[i]), "Serving")
+		if !serving {
+			notServing = append(notServing, catalogs[i].GetName())
+		}
+
+		relatedObjects = append(relatedObjects, clusterCatalogObj(&catalogs[i], serving, complianceConfig))
+	}
+
+	if len(catalogs) == 0 {
+		relatedObjects = append(relatedObjects, noClusterCatalogsObj(complianceConfig))
+	}
+
+	return updateStatus(policy, clusterCatalogsCond(complianceConfig, len(catalogs) > 0, notServing),
+		relatedObjects...), nil
+}
+
+// updateClusterExtensionDeprecationStatus updates the deprecation condition based on the deprecation
+// conditions that OLM v1 sets on the ClusterExtension, in the same order as for a PackageManifest: package,
+// channel, and then bundle. It returns whether the status changed.
+func updateClusterExtensionDeprecationStatus(
+	policy *policyv1beta1.OperatorPolicy, ext *unstructured.Unstructured,
+) bool {
+	if !policy.Spec.ComplianceType.IsMustHave() || ext == nil {
+		return false
+	}
+
+	conditions := clusterExtensionConditions(ext)
+
+	if cond := meta.FindStatusCondition(conditions, "PackageDeprecated"); cond != nil &&
+		cond.Status == metav1.ConditionTrue {
+		packageName, _, _ := unstructured.NestedString(ext.Object, "spec", "source", "catalog", "packageName")
+
+		return updateStatus(policy, deprecationCond(packageName, Package, cond.Message))
+	}
+
+	if cond := meta.FindStatusCondition(conditions, "ChannelDeprecated"); cond != nil &&
+		cond.Status == metav1.ConditionTrue {
+		channels, _, _ := unstructured.NestedStringSlice(ext.Object, "spec", "source", "catalog", "channels")
+
+		return updateStatus(policy, deprecationCond(strings.Join(channels, ", "), Channel, cond.Message))
+	}
+
+	if cond := meta.FindStatusCondition(conditions, "BundleDeprecated"); cond != nil &&
+		cond.Status == metav1.ConditionTrue {
+		bundleName, _, _ := unstructured.NestedString(ext.Object, "status", "install", "bundle", "name")
+
+		return updateStatus(policy, deprecationCond(bundleName, Bundle, cond.Message))
+	}
+
+	return updateStatus(policy, noDeprecationsCond)
+}
@@ -0,0 +1,104 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policyv1beta1 "open-cluster-management.io/config-policy-controller/api/v1beta1"
+)
+
+var _ = Describe("OperatorPolicy with an OLM v1 ClusterExtension", Ordered, func() {
+	const clusterExtension = `{"name": "argocd", "namespace": "argocd", "serviceAccount": {"name": "argocd-installer"},
+		"source": {"catalog": {"packageName": "argocd-operator", "channels": ["alpha"]}}}`
+
+	ctx := context.TODO()
+
+	newPolicy := func(name string) *policyv1beta1.OperatorPolicy {
+		return &policyv1beta1.OperatorPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: policyv1beta1.OperatorPolicySpec{
+				RemediationAction: "enforce",
+				ComplianceType:    "musthave",
+				UpgradeApproval:   "Automatic",
+			},
+		}
+	}
+
+	It("requires exactly one of subscription or clusterExtension", func() {
+		policy := newPolicy("neither")
+
+		err := k8sClient.Create(ctx, policy)
+		Expect(err).To(MatchError(ContainSubstring(
+			"exactly one of subscription or clusterExtension is required",
+		)))
+
+		policy = newPolicy("both")
+		policy.Spec.Subscription = runtime.RawExtension{Raw: []byte(`{"name": "argocd-operator"}`)}
+		policy.Spec.ClusterExtension = &runtime.RawExtension{Raw: []byte(clusterExtension)}
+
+		err = k8sClient.Create(ctx, policy)
+		Expect(err).To(MatchError(ContainSubstring(
+			"exactly one of subscription or clusterExtension is required",
+		)))
+
+		policy = newPolicy("cluster-extension")
+		policy.Spec.ClusterExtension = &runtime.RawExtension{Raw: []byte(clusterExtension)}
+
+		Expect(k8sClient.Create(ctx, policy)).To(Succeed())
+		DeferCleanup(func() { Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, policy))).To(Succeed()) })
+	})
+
+	It("builds a ClusterExtension that the OLM v1 API accepts", func() {
+		policy := newPolicy("build")
+		policy.Spec.ClusterExtension = &runtime.RawExtension{Raw: []byte(clusterExtension)}
+		policy.Spec.Versions = []string{"argocd-operator.v0.6.0"}
+
+		ext, err := buildClusterExtension(policy, "", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(unstructured.SetNestedField(
+			ext.Object, desiredClusterExtensionVersion(policy, nil), "spec", "source", "catalog", "version",
+		)).To(Succeed())
+
+		Expect(k8sClient.Create(ctx, ext)).To(Succeed())
+		DeferCleanup(func() { Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, ext))).To(Succeed()) })
+
+		Expect(unstructured.SetNestedField(ext.Object, map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{
+					"type":               "Installed",
+					"status":             "True",
+					"reason":             "Succeeded",
+					"message":            "Installed bundle argocd-operator.v0.6.0 successfully",
+					"lastTransitionTime": "2024-01-02T03:04:05Z",
+				},
+			},
+			"install": map[string]interface{}{
+				"bundle": map[string]interface{}{"name": "argocd-operator.v0.6.0", "version": "0.6.0"},
+			},
+		}, "status")).To(Succeed())
+
+		Expect(k8sClient.Status().Update(ctx, ext)).To(Succeed())
+
+		found := &unstructured.Unstructured{}
+		found.SetGroupVersionKind(clusterExtensionGVK)
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(ext), found)).To(Succeed())
+
+		cond, relatedObj := clusterExtensionInstallStatus(policy, found)
+		Expect(cond.Status).To(Equal(metav1.ConditionTrue), cond.Message)
+		Expect(relatedObj.Compliant).To(Equal("Compliant"))
+
+		// The OLM v1 API doesn't allow the namespace of the ClusterExtension to change
+		Expect(unstructured.SetNestedField(found.Object, "other", "spec", "namespace")).To(Succeed())
+		Expect(k8sClient.Update(ctx, found)).To(MatchError(ContainSubstring("namespace is immutable")))
+	})
+})
@@ -0,0 +1,311 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+	policyv1beta1 "open-cluster-management.io/config-policy-controller/api/v1beta1"
+)
+
+func TestBuildClusterExtension(t *testing.T) {
+	t.Parallel()
+
+	validExt := `{"name": "argocd", "namespace": "argocd", "serviceAccount": {"name": "argocd-installer"},
+		"source": {"catalog": {"packageName": "argocd-operator", "channels": ["alpha"]}}}`
+
+	tests := map[string]struct {
+		clusterExtension string
+		subscription     string
+		versions         []string
+		operands         []policyv1beta1.Operand
+		expected         string
+	}{
+		"valid": {
+			clusterExtension: validExt,
+			versions:         []string{"argocd-operator.v0.6.0"},
+		},
+		"subscription also set": {
+			clusterExtension: validExt,
+			subscription:     `{"name": "argocd-operator"}`,
+			expected:         "only one of spec.subscription and spec.clusterExtension can be set",
+		},
+		"operands set": {
+			clusterExtension: validExt,
+			operands:         []policyv1beta1.Operand{{}},
+			expected:         "spec.operands can't be used with spec.clusterExtension",
+		},
+		"invalid JSON": {
+			clusterExtension: `{"name": `,
+			expected:         "the policy spec.clusterExtension is invalid",
+		},
+		"missing name": {
+			clusterExtension: `{"namespace": "argocd"}`,
+			expected:         "name is required in spec.clusterExtension",
+		},
+		"missing service account": {
+			clusterExtension: `{"name": "argocd", "source": {"catalog": {"packageName": "argocd-operator"}}}`,
+			expected:         "serviceAccount.name is required in spec.clusterExtension",
+		},
+		"missing package": {
+			clusterExtension: `{"name": "argocd", "serviceAccount": {"name": "argocd-installer"}}`,
+			expected:         "source.catalog.packageName is required in spec.clusterExtension",
+		},
+		"unsupported source type": {
+			clusterExtension: `{"name": "argocd", "serviceAccount": {"name": "argocd-installer"},
+				"source": {"sourceType": "Image", "catalog": {"packageName": "argocd-operator"}}}`,
+			expected: "only the Catalog sourceType is supported in spec.clusterExtension.source",
+		},
+		"version set": {
+			clusterExtension: `{"name": "argocd", "serviceAccount": {"name": "argocd-installer"},
+				"source": {"catalog": {"packageName": "argocd-operator", "version": "0.6.0"}}}`,
+			expected: "source.catalog.version is prohibited in spec.clusterExtension",
+		},
+		"invalid bundle version": {
+			clusterExtension: validExt,
+			versions:         []string{"argocd-operator-latest"},
+			expected:         "the version of the bundle 'argocd-operator-latest' in spec.versions can't be determined",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			policy := &policyv1beta1.OperatorPolicy{
+				Spec: policyv1beta1.OperatorPolicySpec{
+					ClusterExtension: &runtime.RawExtension{Raw: []byte(test.clusterExtension)},
+					Subscription:     runtime.RawExtension{Raw: []byte(test.subscription)},
+					Versions:         test.versions,
+					Operands:         test.operands,
+				},
+			}
+
+			ext, err := buildClusterExtension(policy, "default-ns", nil)
+			if test.expected != "" {
+				assert.ErrorContains(t, err, test.expected)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, clusterExtensionGVK, ext.GroupVersionKind())
+			assert.Equal(t, "argocd", ext.GetName())
+
+			namespace, _, _ := unstructured.NestedString(ext.Object, "spec", "namespace")
+			assert.Equal(t, "argocd", namespace)
+
+			sourceType, _, _ := unstructured.NestedString(ext.Object, "spec", "source", "sourceType")
+			assert.Equal(t, "Catalog", sourceType)
+		})
+	}
+}
+
+func TestBuildClusterExtensionDefaultNamespace(t *testing.T) {
+	t.Parallel()
+
+	policy := &policyv1beta1.OperatorPolicy{
+		Spec: policyv1beta1.OperatorPolicySpec{
+			ClusterExtension: &runtime.RawExtension{Raw: []byte(`{"name": "argocd",
+				"serviceAccount": {"name": "argocd-installer"},
+				"source": {"catalog": {"packageName": "argocd-operator"}}}`)},
+		},
+	}
+
+	ext, err := buildClusterExtension(policy, "default-ns", nil)
+	assert.NoError(t, err)
+
+	namespace, _, _ := unstructured.NestedString(ext.Object, "spec", "namespace")
+	assert.Equal(t, "default-ns", namespace)
+
+	_, err = buildClusterExtension(policy, "", nil)
+	assert.EqualError(t, err, "namespace is required in spec.clusterExtension")
+}
+
+func TestBuildSubscriptionNotSpecified(t *testing.T) {
+	t.Parallel()
+
+	_, err := buildSubscription(&policyv1beta1.OperatorPolicy{}, nil, false)
+	assert.EqualError(t, err, "spec.subscription or spec.clusterExtension is required")
+}
+
+func TestDesiredClusterExtensionVersion(t *testing.T) {
+	t.Parallel()
+
+	installedExt := &unstructured.Unstructured{Object: map[string]any{
+		"status": map[string]any{
+			"install": map[string]any{
+				"bundle": map[string]any{"name": "argocd-operator.v0.6.0", "version": "0.6.0"},
+			},
+		},
+	}}
+
+	tests := map[string]struct {
+		upgradeApproval string
+		versions        []string
+		versionRange    string
+		foundExt        *unstructured.Unstructured
+		expected        string
+	}{
+		"automatic without versions": {
+			upgradeApproval: "Automatic",
+			foundExt:        installedExt,
+		},
+		"automatic with versions and range": {
+			upgradeApproval: "Automatic",
+			versions:        []string{"argocd-operator.v0.7.0", "argocd-operator.v0.8.0"},
+			versionRange:    ">=0.9.0, <1.0.0",
+			foundExt:        installedExt,
+			expected:        ">=0.9.0, <1.0.0 || 0.7.0 || 0.8.0",
+		},
+		"none before installation": {
+			upgradeApproval: "None",
+			versions:        []string{"argocd-operator.v0.7.0"},
+			expected:        "0.7.0",
+		},
+		"none after installation": {
+			upgradeApproval: "None",
+			versions:        []string{"argocd-operator.v0.7.0"},
+			foundExt:        installedExt,
+			expected:        "0.6.0",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			policy := &policyv1beta1.OperatorPolicy{
+				Spec: policyv1beta1.OperatorPolicySpec{
+					UpgradeApproval: test.upgradeApproval,
+					Versions:        test.versions,
+					VersionRange:    test.versionRange,
+				},
+			}
+
+			assert.Equal(t, test.expected, desiredClusterExtensionVersion(policy, test.foundExt))
+		})
+	}
+}
+
+func TestClusterExtensionInstallStatus(t *testing.T) {
+	t.Parallel()
+
+	ext := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "olm.operatorframework.io/v1",
+		"kind":       "ClusterExtension",
+		"metadata":   map[string]any{"name": "argocd"},
+		"status": map[string]any{
+			"conditions": []any{
+				map[string]any{
+					"type": "Progressing", "status": "True", "reason": "Retrying",
+					"message": "error upgrading from currently installed version",
+				},
+				map[string]any{"type": "Installed", "status": "False", "reason": "Failed"},
+			},
+		},
+	}}
+
+	policy := &policyv1beta1.OperatorPolicy{
+		Spec: policyv1beta1.OperatorPolicySpec{Versions: []string{"argocd-operator.v0.7.0"}},
+	}
+
+	cond, relatedObj := clusterExtensionInstallStatus(policy, ext)
+	assert.Equal(t, clusterExtConditionType, cond.Type)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, "ClusterExtensionNotInstalled", cond.Reason)
+	assert.Equal(t,
+		"the ClusterExtension is not installed: error upgrading from currently installed version", cond.Message,
+	)
+	assert.Equal(t, string(policyv1.NonCompliant), relatedObj.Compliant)
+
+	_ = unstructured.SetNestedSlice(ext.Object, []any{
+		map[string]any{"type": "Installed", "status": "True", "reason": "Succeeded"},
+	}, "status", "conditions")
+	_ = unstructured.SetNestedMap(ext.Object, map[string]any{
+		"name": "argocd-operator.v0.6.0", "version": "0.6.0",
+	}, "status", "install", "bundle")
+
+	cond, relatedObj = clusterExtensionInstallStatus(policy, ext)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, "BundleVersionNotApproved", cond.Reason)
+	assert.Equal(t, string(policyv1.NonCompliant), relatedObj.Compliant)
+
+	policy.Spec.VersionRange = ">=0.6.0"
+
+	cond, relatedObj = clusterExtensionInstallStatus(policy, ext)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Equal(t, "the ClusterExtension installed the bundle argocd-operator.v0.6.0", cond.Message)
+	assert.Equal(t, string(policyv1.Compliant), relatedObj.Compliant)
+}
+
+func TestUpdateClusterExtensionDeprecationStatus(t *testing.T) {
+	t.Parallel()
+
+	ext := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{
+			"source": map[string]any{
+				"catalog": map[string]any{"packageName": "argocd-operator", "channels": []any{"alpha"}},
+			},
+		},
+		"status": map[string]any{
+			"conditions": []any{
+				map[string]any{"type": "PackageDeprecated", "status": "False"},
+				map[string]any{
+					"type": "ChannelDeprecated", "status": "True", "message": "Use the stable channel.",
+				},
+			},
+		},
+	}}
+
+	policy := &policyv1beta1.OperatorPolicy{Spec: policyv1beta1.OperatorPolicySpec{ComplianceType: "musthave"}}
+
+	assert.True(t, updateClusterExtensionDeprecationStatus(policy, ext))
+
+	_, cond := policy.Status.GetCondition(deprecationType)
+	assert.Equal(t, "ChannelDeprecated", cond.Reason)
+	assert.Equal(t, "the requested alpha Channel was deprecated. Use the stable channel.", cond.Message)
+
+	unstructured.RemoveNestedField(ext.Object, "status", "conditions")
+
+	assert.True(t, updateClusterExtensionDeprecationStatus(policy, ext))
+
+	_, cond = policy.Status.GetCondition(deprecationType)
+	assert.Equal(t, "Recommended", cond.Reason)
+}
+
+func TestCalculateComplianceConditionClusterExtension(t *testing.T) {
+	t.Parallel()
+
+	policy := &policyv1beta1.OperatorPolicy{
+		Spec: policyv1beta1.OperatorPolicySpec{
+			ComplianceType:   "musthave",
+			ClusterExtension: &runtime.RawExtension{},
+		},
+	}
+
+	updateStatus(policy, validationCond(nil))
+	updateStatus(policy, clusterExtInstalledCond("argocd-operator.v0.6.0"))
+	updateStatus(policy, buildDeploymentCond("NonCompliant", true, nil))
+	updateStatus(policy, clusterCatalogsCond("NonCompliant", true, nil))
+
+	cond := calculateComplianceCondition(policy)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Equal(t,
+		"Compliant; the policy spec is valid, the ClusterExtension installed the bundle argocd-operator.v0.6.0, "+
+			"all operator Deployments have their minimum availability, "+
+			"the ClusterCatalogs for the ClusterExtension are serving",
+		cond.Message,
+	)
+
+	updateStatus(policy, clusterCatalogsCond("NonCompliant", true, []string{"operatorhubio"}))
+
+	cond = calculateComplianceCondition(policy)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+}
@@ -17,6 +17,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
 	operatorv1 "github.com/operator-framework/api/pkg/operators/v1"
 	operatorv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	templates "github.com/stolostron/go-template-utils/v7/pkg/templates"
@@ -47,7 +48,9 @@ import (
 
 	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
 	policyv1beta1 "open-cluster-management.io/config-policy-controller/api/v1beta1"
+	"open-cluster-management.io/config-policy-controller/pkg/audit"
 	common "open-cluster-management.io/config-policy-controller/pkg/common"
+	"open-cluster-management.io/config-policy-controller/pkg/notifications"
 )
 
 const (
@@ -101,6 +104,21 @@ var (
 		Version: "v1alpha1",
 		Kind:    "InstallPlan",
 	}
+	clusterExtensionGVK = schema.GroupVersionKind{
+		Group:   "olm.operatorframework.io",
+		Version: "v1",
+		Kind:    "ClusterExtension",
+	}
+	clusterCatalogGVK = schema.GroupVersionKind{
+		Group:   "olm.operatorframework.io",
+		Version: "v1",
+		Kind:    "ClusterCatalog",
+	}
+	clusterVersionGVK = schema.GroupVersionKind{
+		Group:   "config.openshift.io",
+		Version: "v1",
+		Kind:    "ClusterVersion",
+	}
 	packageManifestGVR = schema.GroupVersionResource{
 		Group:    "packages.operators.coreos.com",
 		Version:  "v1",
@@ -119,8 +137,21 @@ type OperatorPolicyReconciler struct {
 	DefaultNamespace string
 	// MaxHistoryLength controls how many compliance history entries are stored in status.history.
 	// If set to < 1, it will default to 10.
-	MaxHistoryLength  int
-	TargetClient      client.Client
+	MaxHistoryLength int
+	TargetClient     client.Client
+	// TargetClusters provides the clients for policies that set spec.targetCluster. When nil, those
+	// policies are reported as noncompliant.
+	TargetClusters *TargetClusterManager
+	// ObjectMetrics reports the compliance of the related objects when the opt-in metric is enabled
+	ObjectMetrics *ObjectComplianceMetrics
+	// PolicyReporter writes the compliance of the related objects to PolicyReports when enabled
+	PolicyReporter *PolicyReporter
+	// Notifier sends CloudEvents notifications to the configured sinks when enabled
+	Notifier *notifications.Notifier
+	// AuditLog records the changes made by the policies when enabled
+	AuditLog *audit.Logger
+	// ObjectEvents emits events on the affected objects when enabled
+	ObjectEvents      *ObjectEventRecorder
 	HubDynamicWatcher depclient.DynamicWatcher
 	HubClient         *kubernetes.Clientset
 	ClusterName       string
@@ -128,6 +159,11 @@ type OperatorPolicyReconciler struct {
 	// This is a workaround to account for race conditions where the status is updated but the controller-runtime cache
 	// has not updated yet.
 	lastEvaluatedCache sync.Map
+	// evaluations has the evaluation state of the policies for the debug endpoint.
+	evaluations evaluationTracker
+	// targetClients has the OperatorPolicy ObjectIdentifier as the key and the *TargetClusterClients
+	// resolved from its spec.targetCluster field as the value.
+	targetClients sync.Map
 }
 
 // SetupWithManager sets up the controller with the Manager and will reconcile when the dynamic watcher
@@ -225,6 +261,11 @@ var _ reconcile.Reconciler = &OperatorPolicyReconciler{}
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.14.4/pkg/reconcile
 func (r *OperatorPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := startReconcileSpan(ctx, "OperatorPolicy", req)
+	defer span.End()
+
+	ctx = withPolicyRequests(ctx, "OperatorPolicy", req.Namespace, req.Name)
+
 	opLog := ctrl.LoggerFrom(ctx)
 	policy := &policyv1beta1.OperatorPolicy{}
 	watcher := opPolIdentifier(req.Namespace, req.Name)
@@ -244,12 +285,25 @@ func (r *OperatorPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		if k8serrors.IsNotFound(err) {
 			opLog.Info("Operator policy could not be found")
 			removeOperatorPolicyMetrics(req)
+			r.ObjectMetrics.Remove("OperatorPolicy", req.Namespace, req.Name)
+
+			err = r.PolicyReporter.Remove(ctx, "OperatorPolicy", req.Namespace, req.Name)
+			if err != nil {
+				opLog.Error(err, "Error removing the policy from the policy reports. Ignoring the failure.")
+			}
 
 			err = r.DynamicWatcher.RemoveWatcher(watcher)
 			if err != nil {
 				opLog.Error(err, "Error updating dependency watcher. Ignoring the failure.")
 			}
 
+			if r.TargetClusters != nil {
+				r.TargetClusters.Release(watcher)
+			}
+
+			r.targetClients.Delete(watcher)
+			r.evaluations.remove(req.NamespacedName)
+
 			return reconcile.Result{}, nil
 		}
 
@@ -279,27 +333,44 @@ func (r *OperatorPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	}
 
 	originalStatus := *policy.Status.DeepCopy()
+	errs := make([]error, 0)
 
-	// Start query batch for caching and watching related objects
-	err = r.DynamicWatcher.StartQueryBatch(watcher)
-	if err != nil {
-		opLog.Error(err, "Could not start query batch for the watcher")
+	var conditionsToEmit []metav1.Condition
+	var statusChanged bool
+	var evaluatedAt time.Time
 
-		return reconcile.Result{}, err
-	}
+	targetErr := r.resolveTargetCluster(ctx, policy)
+	if targetErr != nil {
+		opLog.Error(targetErr, "Failed to get the clients for the target cluster")
 
-	defer func() {
-		err := r.DynamicWatcher.EndQueryBatch(watcher)
+		statusChanged = updateStatus(policy, targetClusterUnavailableCond(targetErr))
+	} else {
+		dynamicWatcher := r.target(policy).DynamicWatcher
+
+		// Start query batch for caching and watching related objects
+		err = dynamicWatcher.StartQueryBatch(watcher)
 		if err != nil {
-			opLog.Error(err, "Could not end query batch for the watcher")
+			opLog.Error(err, "Could not start query batch for the watcher")
+
+			return reconcile.Result{}, err
 		}
-	}()
 
-	errs := make([]error, 0)
+		defer func() {
+			err := dynamicWatcher.EndQueryBatch(watcher)
+			if err != nil {
+				opLog.Error(err, "Could not end query batch for the watcher")
+			}
+		}()
 
-	conditionsToEmit, statusChanged, err := r.handleResources(ctx, policy)
-	if err != nil {
-		errs = append(errs, err)
+		before := time.Now()
+
+		conditionsToEmit, statusChanged, err = r.handleResources(ctx, policy)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		evaluatedAt = time.Now().UTC()
+		policyEvalDurationHistogram.WithLabelValues("OperatorPolicy").Observe(evaluatedAt.Sub(before).Seconds())
 	}
 
 	if statusChanged {
@@ -311,6 +382,13 @@ func (r *OperatorPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	for _, cond := range conditionsToEmit {
 		if err := r.emitComplianceEvent(ctx, policy, cond); err != nil {
 			errs = append(errs, err)
+		} else {
+			r.Notifier.ComplianceChanged(notifications.ComplianceChangedData{
+				Policy:     operatorPolicyReference(policy),
+				Severity:   string(policy.Spec.Severity),
+				Compliance: cond.Reason,
+				Message:    cond.Message,
+			})
 		}
 
 		var latestEvent policyv1.HistoryEvent
@@ -350,12 +428,32 @@ func (r *OperatorPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	result := reconcile.Result{}
 	finalErr := utilerrors.NewAggregate(errs)
 
-	if len(errs) == 0 {
+	if targetErr != nil {
+		result.RequeueAfter = targetClusterRetryInterval
+	} else if len(errs) == 0 {
 		// Schedule a requeue for the intervention.
 		// Note: this requeue will be superseded if the Subscription's status is flapping.
 		if policy.Status.SubscriptionInterventionWaiting() {
 			result.RequeueAfter = time.Until(policy.Status.SubscriptionInterventionTime.Add(time.Second))
 		}
+
+		// Schedule a requeue for when the pending upgrade can be approved
+		if pending := policy.Status.PendingUpgrade; pending != nil && pending.NextApprovalTime != nil {
+			untilApproval := time.Until(pending.NextApprovalTime.Add(time.Second))
+
+			if untilApproval > 0 && (result.RequeueAfter == 0 || untilApproval < result.RequeueAfter) {
+				result.RequeueAfter = untilApproval
+			}
+		}
+
+		// Schedule a requeue for when the policy stops waiting for the operands to be deleted
+		if policy.Status.OperandDeletionWaiting() {
+			untilDeadline := time.Until(policy.Status.OperandDeletionDeadline.Add(time.Second))
+
+			if result.RequeueAfter == 0 || untilDeadline < result.RequeueAfter {
+				result.RequeueAfter = untilDeadline
+			}
+		}
 	}
 
 	policyStatusGauge.WithLabelValues(
@@ -363,13 +461,62 @@ func (r *OperatorPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	).Set(
 		getStatusValue(policy.Status.ComplianceState),
 	)
+	r.ObjectMetrics.Update("OperatorPolicy", policy.Namespace, policy.Name, policy.Status.RelatedObjects)
+
+	if reportErr := r.PolicyReporter.Update(ctx, policy); reportErr != nil {
+		opLog.Error(reportErr, "Error updating the policy reports")
+	}
 
 	opLog.Info("Reconciling complete", "finalErr", finalErr,
 		"statusChanged", statusChanged, "eventCount", len(conditionsToEmit))
 
+	r.evaluations.reconciled(req.NamespacedName, evaluatedAt, result, finalErr)
+
 	return result, finalErr
 }
 
+// target returns the clients to use when evaluating and enforcing the policy. This is the cluster in the
+// policy's spec.targetCluster field if set, and otherwise the default target cluster of the controller.
+func (r *OperatorPolicyReconciler) target(policy *policyv1beta1.OperatorPolicy) *TargetClusterClients {
+	if clients, ok := r.targetClients.Load(opPolIdentifier(policy.Namespace, policy.Name)); ok {
+		return clients.(*TargetClusterClients)
+	}
+
+	return &TargetClusterClients{
+		Client:         r.TargetClient,
+		DynamicClient:  r.DynamicClient,
+		DynamicWatcher: r.DynamicWatcher,
+	}
+}
+
+// resolveTargetCluster determines the clients returned by target for the policy. When the policy
+// switches to another cluster, its watches on the previous cluster are stopped.
+func (r *OperatorPolicyReconciler) resolveTargetCluster(
+	ctx context.Context, policy *policyv1beta1.OperatorPolicy,
+) error {
+	watcher := opPolIdentifier(policy.Namespace, policy.Name)
+
+	clients, err := resolveTargetCluster(ctx, r.TargetClusters, watcher, policy.Namespace, policy.Spec.TargetCluster)
+	if err != nil {
+		return err
+	}
+
+	if clients == nil {
+		r.targetClients.Delete(watcher)
+
+		return nil
+	}
+
+	if _, loaded := r.targetClients.Swap(watcher, clients); !loaded {
+		// The policy was previously evaluated on the default cluster
+		if err := r.DynamicWatcher.RemoveWatcher(watcher); err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, "Failed to remove the watches on the default cluster. Will ignore.")
+		}
+	}
+
+	return nil
+}
+
 // handleResources determines the current desired state based on the policy, and
 // determines status details for the policy based on the current state of
 // resources in the cluster. If the policy is enforced, it will make updates
@@ -382,6 +529,9 @@ func (r *OperatorPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 func (r *OperatorPolicyReconciler) handleResources(ctx context.Context, policy *policyv1beta1.OperatorPolicy) (
 	earlyComplianceEvents []metav1.Condition, condChanged bool, err error,
 ) {
+	ctx, span := startOperatorPolicySpan(ctx, "handleResources", policy)
+	defer func() { endSpan(span, err) }()
+
 	opLog := ctrl.LoggerFrom(ctx)
 
 	earlyComplianceEvents = make([]metav1.Condition, 0)
@@ -396,7 +546,13 @@ func (r *OperatorPolicyReconciler) handleResources(ctx context.Context, policy *
 		return earlyComplianceEvents, condChanged || changed, err
 	}
 
-	desiredSub, desiredOG, changed, err := r.buildResources(ctx, policy)
+	if policy.Spec.ClusterExtension != nil {
+		earlyConds, changed, err := r.handleClusterExtensionResources(ctx, policy)
+
+		return append(earlyComplianceEvents, earlyConds...), condChanged || changed, err
+	}
+
+	desiredSub, desiredOG, desiredCatalogSrc, changed, err := r.buildResources(ctx, policy)
 	condChanged = condChanged || changed
 
 	if err != nil {
@@ -410,6 +566,18 @@ func (r *OperatorPolicyReconciler) handleResources(ctx context.Context, policy *
 		desiredSubName = desiredSub.Name
 	}
 
+	// The operands are deleted first so that the operator is still running to handle their finalizers.
+	if policy.Spec.ComplianceType.IsMustNotHave() {
+		changed, err = r.removeOperands(ctx, policy, desiredSub)
+		condChanged = condChanged || changed
+
+		if err != nil {
+			opLog.Error(err, "Error removing operands")
+
+			return earlyComplianceEvents, condChanged, err
+		}
+	}
+
 	ogCorrect, earlyConds, changed, err := r.handleOpGroup(ctx, policy, desiredOG, desiredSubName)
 	earlyComplianceEvents = append(earlyComplianceEvents, earlyConds...)
 	condChanged = condChanged || changed
@@ -473,7 +641,17 @@ func (r *OperatorPolicyReconciler) handleResources(ctx context.Context, policy *
 		return earlyComplianceEvents, condChanged, err
 	}
 
-	changed, err = r.handleCatalogSource(policy, subscription)
+	changed, err = r.handleWorkloadHealth(ctx, policy, csv)
+	condChanged = condChanged || changed
+
+	if err != nil {
+		opLog.Error(err, "Error handling the operator workload health")
+
+		return earlyComplianceEvents, condChanged, err
+	}
+
+	earlyConds, changed, err = r.handleCatalogSource(ctx, policy, subscription, desiredSub, desiredCatalogSrc)
+	earlyComplianceEvents = append(earlyComplianceEvents, earlyConds...)
 	condChanged = condChanged || changed
 
 	if err != nil {
@@ -482,6 +660,15 @@ func (r *OperatorPolicyReconciler) handleResources(ctx context.Context, policy *
 		return earlyComplianceEvents, condChanged, err
 	}
 
+	changed, err = r.handleOperands(ctx, policy, subscription, csv)
+	condChanged = condChanged || changed
+
+	if err != nil {
+		opLog.Error(err, "Error handling operands")
+
+		return earlyComplianceEvents, condChanged, err
+	}
+
 	return earlyComplianceEvents, condChanged, nil
 }
 
@@ -494,46 +681,80 @@ func (r *OperatorPolicyReconciler) handleResources(ctx context.Context, policy *
 //
 // The built objects can be used to find relevant objects for a 'mustnothave' policy.
 func (r *OperatorPolicyReconciler) buildResources(ctx context.Context, policy *policyv1beta1.OperatorPolicy) (
-	*operatorv1alpha1.Subscription, *operatorv1.OperatorGroup, bool, error,
+	*operatorv1alpha1.Subscription, *operatorv1.OperatorGroup, *unstructured.Unstructured, bool, error,
 ) {
-	opLog := ctrl.LoggerFrom(ctx)
-	disableTemplates := false
+	target := r.target(policy)
 
-	if disableAnnotation, ok := policy.GetAnnotations()["policy.open-cluster-management.io/disable-templates"]; ok {
-		disableTemplates, _ = strconv.ParseBool(disableAnnotation) // on error, templates will not be disabled
+	tmplResolver, err := r.templateResolver(ctx, policy)
+	if err != nil {
+		return nil, nil, nil, updateStatus(policy, validationCond([]error{err})), nil
 	}
 
-	var tmplResolver *templates.TemplateResolver
-
-	if !disableTemplates {
-		var err error
-
-		tmplResolver, err = templates.NewResolverWithDynamicWatcher(
-			r.DynamicWatcher, templates.Config{SkipBatchManagement: true},
-		)
+	if tmplResolver != nil {
+		err = resolveVersionsTemplates(policy, tmplResolver)
 		if err != nil {
 			newError := fmt.Errorf("unable to create template resolver: %w", err)
 
-			return nil, nil, updateStatus(policy, validationCond([]error{newError})), nil
+			return nil, nil, nil, updateStatus(policy, validationCond([]error{newError})), nil
 		}
 
-		err = resolveVersionsTemplates(policy, tmplResolver)
+		err = resolveOperandsTemplates(policy, tmplResolver)
 		if err != nil {
-			newError := fmt.Errorf("unable to create template resolver: %w", err)
-
-			return nil, nil, updateStatus(policy, validationCond([]error{newError})), nil
+			return nil, nil, nil, updateStatus(policy, validationCond([]error{err})), nil
 		}
-	} else {
-		opLog.V(1).Info("Templates disabled by annotation")
 	}
 
 	canonicalizeVersions(policy)
 
+	if _, err := versionRangeConstraints(policy); err != nil {
+		return nil, nil, nil, updateStatus(policy, validationCond([]error{err})), nil
+	}
+
+	if err := validateUpgradeApproval(policy); err != nil {
+		return nil, nil, nil, updateStatus(policy, validationCond([]error{err})), nil
+	}
+
+	if err := validateAllowedDependencies(policy); err != nil {
+		return nil, nil, nil, updateStatus(policy, validationCond([]error{err})), nil
+	}
+
+	if _, err := buildOperands(policy); err != nil {
+		return nil, nil, nil, updateStatus(policy, validationCond([]error{err})), nil
+	}
+
+	if _, err := operandDeletionTimeout(policy); err != nil {
+		return nil, nil, nil, updateStatus(policy, validationCond([]error{err})), nil
+	}
+
 	var returnedErr error
 
-	sub, subErr := buildSubscription(policy, tmplResolver)
+	var catalogSrc *unstructured.Unstructured
+
+	// Whether the cluster is OpenShift only matters when OLM could approve the upgrades itself
+	onOpenShift := false
+
+	if policy.Spec.RemediationAction.IsEnforce() && policy.Spec.UpgradeApproval == "Automatic" {
+		clusterVersion, err := r.clusterVersion(policy)
+		if err != nil {
+			return nil, nil, nil, false, err
+		}
+
+		onOpenShift = clusterVersion != nil
+	}
+
+	sub, subErr := buildSubscription(policy, tmplResolver, onOpenShift)
 	if subErr == nil {
-		err := r.applySubscriptionDefaults(ctx, policy, sub)
+		catalogSrc, err = buildCatalogSource(policy, sub.Namespace, tmplResolver)
+		if err == nil && catalogSrc != nil {
+			err = applyCatalogSourceDefaults(sub, catalogSrc)
+		}
+
+		if err != nil {
+			// CatalogSource spec invalid - mark status, don't requeue
+			return sub, nil, nil, updateStatus(policy, validationCond([]error{err})), nil
+		}
+
+		err = r.applySubscriptionDefaults(ctx, policy, sub)
 		if err != nil {
 			// If it's a PackageManifest API error, then that means it should be returned for the Reconcile method
 			// to requeue the request. This is to workaround the PackageManifest API not supporting watches.
@@ -541,7 +762,7 @@ func (r *OperatorPolicyReconciler) buildResources(ctx context.Context, policy *p
 				returnedErr = err
 			}
 
-			return nil, nil, updateStatus(policy, validationCond([]error{err})), returnedErr
+			return nil, nil, nil, updateStatus(policy, validationCond([]error{err})), returnedErr
 		}
 
 		if sub != nil && sub.Namespace == "" {
@@ -550,12 +771,12 @@ func (r *OperatorPolicyReconciler) buildResources(ctx context.Context, policy *p
 			} else {
 				newError := errors.New("namespace is required in spec.subscription")
 
-				return sub, nil, updateStatus(policy, validationCond([]error{newError})), nil
+				return sub, nil, nil, updateStatus(policy, validationCond([]error{newError})), nil
 			}
 		}
 	} else {
 		// Invalid subscription spec - mark status and return without an API error
-		return sub, nil, updateStatus(policy, validationCond([]error{subErr})), nil
+		return sub, nil, nil, updateStatus(policy, validationCond([]error{subErr})), nil
 	}
 
 	opGroupNS := r.DefaultNamespace
@@ -566,20 +787,20 @@ func (r *OperatorPolicyReconciler) buildResources(ctx context.Context, policy *p
 	opGroup, ogErr := buildOperatorGroup(policy, opGroupNS, tmplResolver)
 	if ogErr != nil {
 		// OperatorGroup spec invalid - mark status, don't requeue
-		return sub, nil, updateStatus(policy, validationCond([]error{ogErr})), nil
+		return sub, nil, nil, updateStatus(policy, validationCond([]error{ogErr})), nil
 	}
 
 	watcher := opPolIdentifier(policy.Namespace, policy.Name)
 
-	gotNamespace, err := r.DynamicWatcher.Get(watcher, namespaceGVK, "", opGroupNS)
+	gotNamespace, err := target.DynamicWatcher.Get(watcher, namespaceGVK, "", opGroupNS)
 	if err != nil {
-		return sub, opGroup, false, fmt.Errorf("error getting operator namespace: %w", err)
+		return sub, opGroup, nil, false, fmt.Errorf("error getting operator namespace: %w", err)
 	}
 
 	if gotNamespace == nil && policy.Spec.ComplianceType.IsMustHave() {
 		newError := fmt.Errorf("the operator namespace ('%v') does not exist", opGroupNS)
 
-		return sub, opGroup, updateStatus(policy, validationCond([]error{newError})), nil
+		return sub, opGroup, nil, updateStatus(policy, validationCond([]error{newError})), nil
 	}
 
 	changed, overlapErr, apiErr := r.checkSubOverlap(ctx, policy, sub)
@@ -591,12 +812,39 @@ func (r *OperatorPolicyReconciler) buildResources(ctx context.Context, policy *p
 		// When an overlap is detected, the generated subscription and operatorgroup
 		// will be considered to be invalid to prevent creations/updates.
 		// sub and opgroup should be nil to prevent creation/update.
-		return nil, nil, updateStatus(policy, validationCond([]error{overlapErr})), returnedErr
+		return nil, nil, nil, updateStatus(policy, validationCond([]error{overlapErr})), returnedErr
 	}
 
 	changed = updateStatus(policy, validationCond([]error{})) || changed
 
-	return sub, opGroup, changed, returnedErr
+	return sub, opGroup, catalogSrc, changed, returnedErr
+}
+
+// templateResolver returns the template resolver for the policy's target cluster, or nil when templates are
+// disabled by the policy's annotation.
+func (r *OperatorPolicyReconciler) templateResolver(
+	ctx context.Context, policy *policyv1beta1.OperatorPolicy,
+) (*templates.TemplateResolver, error) {
+	disableTemplates := false
+
+	if disableAnnotation, ok := policy.GetAnnotations()["policy.open-cluster-management.io/disable-templates"]; ok {
+		disableTemplates, _ = strconv.ParseBool(disableAnnotation) // on error, templates will not be disabled
+	}
+
+	if disableTemplates {
+		ctrl.LoggerFrom(ctx).V(1).Info("Templates disabled by annotation")
+
+		return nil, nil
+	}
+
+	tmplResolver, err := templates.NewResolverWithDynamicWatcher(
+		r.target(policy).DynamicWatcher, templates.Config{SkipBatchManagement: true},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create template resolver: %w", err)
+	}
+
+	return tmplResolver, nil
 }
 
 func (r *OperatorPolicyReconciler) checkSubOverlap(
@@ -680,6 +928,7 @@ func (r *OperatorPolicyReconciler) applySubscriptionDefaults(
 	ctx context.Context, policy *policyv1beta1.OperatorPolicy, subscription *operatorv1alpha1.Subscription,
 ) error {
 	opLog := ctrl.LoggerFrom(ctx)
+	target := r.target(policy)
 	subSpec := subscription.Spec
 
 	defaultsNeeded := subSpec.Channel == "" || subSpec.CatalogSource == "" ||
@@ -693,7 +942,7 @@ func (r *OperatorPolicyReconciler) applySubscriptionDefaults(
 
 	// PackageManifests come from an API server and not a Kubernetes resource, so the DynamicWatcher can't be used since
 	// it utilizes watches. The namespace doesn't have any meaning but is required.
-	packageManifest, err := r.DynamicClient.Resource(packageManifestGVR).Namespace("default").Get(
+	packageManifest, err := target.DynamicClient.Resource(packageManifestGVR).Namespace("default").Get(
 		ctx, subSpec.Package, metav1.GetOptions{},
 	)
 	if err != nil {
@@ -793,6 +1042,8 @@ func (r *OperatorPolicyReconciler) applySubscriptionDefaults(
 func (r *OperatorPolicyReconciler) usingExistingSubIfFound(
 	policy *policyv1beta1.OperatorPolicy, subscription *operatorv1alpha1.Subscription,
 ) bool {
+	target := r.target(policy)
+
 	if subscription.Namespace == "" {
 		// check for an already-known subscription to "adopt"
 		subs := policy.Status.RelatedObjsOfKind("Subscription")
@@ -808,7 +1059,7 @@ func (r *OperatorPolicyReconciler) usingExistingSubIfFound(
 	if subscription.Namespace != "" {
 		watcher := opPolIdentifier(policy.Namespace, policy.Name)
 
-		gotSub, err := r.DynamicWatcher.Get(watcher, subscriptionGVK, subscription.Namespace, subscription.Name)
+		gotSub, err := target.DynamicWatcher.Get(watcher, subscriptionGVK, subscription.Namespace, subscription.Name)
 		if err != nil || gotSub == nil {
 			return false
 		}
@@ -836,7 +1087,7 @@ func (r *OperatorPolicyReconciler) usingExistingSubIfFound(
 	return false
 }
 
-// resolveVersionsTemplates will resolve all templates in spec.versions.
+// resolveVersionsTemplates will resolve all templates in spec.versions and spec.versionRange.
 func resolveVersionsTemplates(
 	policy *policyv1beta1.OperatorPolicy, tmplResolver *templates.TemplateResolver,
 ) error {
@@ -844,6 +1095,25 @@ func resolveVersionsTemplates(
 		return nil
 	}
 
+	watcher := opPolIdentifier(policy.Namespace, policy.Name)
+
+	if templates.HasTemplate([]byte(policy.Spec.VersionRange), "", false) {
+		rangeJSON, err := json.Marshal(policy.Spec.VersionRange)
+		if err != nil {
+			return fmt.Errorf("failed to marshal the spec.versionRange field to JSON: %w", err)
+		}
+
+		resolvedTmpl, err := tmplResolver.ResolveTemplate(rangeJSON, nil, &templates.ResolveOptions{Watcher: &watcher})
+		if err != nil {
+			return fmt.Errorf("could not resolve the version range template: %w", err)
+		}
+
+		err = json.Unmarshal(resolvedTmpl.ResolvedJSON, &policy.Spec.VersionRange)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal the spec.versionRange field after template resolution: %w", err)
+		}
+	}
+
 	var hasTemplate bool
 
 	for _, version := range policy.Spec.Versions {
@@ -863,8 +1133,6 @@ func resolveVersionsTemplates(
 		return fmt.Errorf("failed to marshal the spec.versions field to JSON: %w", err)
 	}
 
-	watcher := opPolIdentifier(policy.Namespace, policy.Name)
-
 	resolvedTmpl, err := tmplResolver.ResolveTemplate(versionsJSON, nil, &templates.ResolveOptions{Watcher: &watcher})
 	if err != nil {
 		return fmt.Errorf("could not resolve the version template: %w", err)
@@ -897,18 +1165,90 @@ func canonicalizeVersions(policy *policyv1beta1.OperatorPolicy) {
 	}
 
 	policy.Spec.Versions = nonEmptyVersions
+	policy.Spec.VersionRange = strings.TrimSpace(policy.Spec.VersionRange)
+}
+
+// versionRangeConstraints parses spec.versionRange. It returns nil without an error when the range is unset.
+func versionRangeConstraints(policy *policyv1beta1.OperatorPolicy) (*semver.Constraints, error) {
+	if policy.Spec.VersionRange == "" {
+		return nil, nil
+	}
+
+	constraints, err := parseVersionConstraint(policy.Spec.VersionRange)
+	if err != nil {
+		return nil, fmt.Errorf("the spec.versionRange %q is invalid: %w", policy.Spec.VersionRange, err)
+	}
+
+	return constraints, nil
+}
+
+// parseVersionConstraint parses a semantic version constraint that is compared against operator bundle versions.
+func parseVersionConstraint(expr string) (*semver.Constraints, error) {
+	constraints, err := semver.NewConstraint(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Operator bundles commonly have pre-release suffixes for their builds, such as 4.16.3-rhodf
+	constraints.IncludePrerelease = true
+
+	return constraints, nil
+}
+
+// csvAllowed returns whether the ClusterServiceVersion with the name and bundle version is allowed by the
+// spec.versions and spec.versionRange fields of the policy. The starting CSV of the Subscription is always allowed,
+// unless the version is blocked because it failed to upgrade. The version may be empty when it is not known, in which
+// case only the name is checked.
+func csvAllowed(
+	policy *policyv1beta1.OperatorPolicy, sub *operatorv1alpha1.Subscription, csvName string, version string,
+) bool {
+	if slices.Contains(policy.Status.BlockedVersions, csvName) {
+		return false
+	}
+
+	if len(policy.Spec.Versions) == 0 && policy.Spec.VersionRange == "" {
+		return true
+	}
+
+	if sub != nil && sub.Spec != nil && sub.Spec.StartingCSV != "" && sub.Spec.StartingCSV == csvName {
+		return true
+	}
+
+	if slices.Contains(policy.Spec.Versions, csvName) {
+		return true
+	}
+
+	if version == "" {
+		return false
+	}
+
+	constraints, err := versionRangeConstraints(policy)
+	if err != nil || constraints == nil {
+		return false
+	}
+
+	parsedVersion, err := semver.NewVersion(version)
+	if err != nil {
+		return false
+	}
+
+	return constraints.Check(parsedVersion)
 }
 
 // buildSubscription bootstraps the subscription spec defined in the operator policy
 // with the apiversion and kind in preparation for resource creation.
 // If an error is returned, it will include details on why the policy spec if invalid and
-// why the desired subscription can't be determined.
+// why the desired subscription can't be determined. The onOpenShift argument is whether the
+// target cluster is OpenShift.
 func buildSubscription(
-	policy *policyv1beta1.OperatorPolicy, tmplResolver *templates.TemplateResolver,
+	policy *policyv1beta1.OperatorPolicy, tmplResolver *templates.TemplateResolver, onOpenShift bool,
 ) (*operatorv1alpha1.Subscription, error) {
 	subscription := new(operatorv1alpha1.Subscription)
 
 	rawSub := policy.Spec.Subscription.Raw
+	if len(rawSub) == 0 {
+		return nil, errors.New("spec.subscription or spec.clusterExtension is required")
+	}
 
 	if tmplResolver != nil && templates.HasTemplate(rawSub, "", false) {
 		watcher := opPolIdentifier(policy.Namespace, policy.Name)
@@ -975,14 +1315,28 @@ func buildSubscription(
 		return nil, errors.New("installPlanApproval is prohibited in spec.subscription")
 	}
 
-	// Usually set InstallPlanApproval to manual so that upgrades can be controlled
+	// Usually set InstallPlanApproval to manual so that upgrades can be controlled. The approval windows,
+	// soak time, and allowed dependencies are enforced by the controller approving the InstallPlans, so
+	// they also require it. On OpenShift, the controller also checks the olm.maxOpenShiftVersion of the
+	// bundles before approving an upgrade.
 	spec.InstallPlanApproval = operatorv1alpha1.ApprovalManual
-	if policy.Spec.RemediationAction.IsEnforce() &&
+	if !onOpenShift &&
+		policy.Spec.RemediationAction.IsEnforce() &&
 		policy.Spec.UpgradeApproval == "Automatic" &&
-		len(policy.Spec.Versions) == 0 {
+		len(policy.Spec.Versions) == 0 &&
+		policy.Spec.VersionRange == "" &&
+		len(policy.Spec.UpgradeApprovalWindows) == 0 &&
+		policy.Spec.UpgradeSoakTime == "" &&
+		len(policy.Spec.AllowedDependencies) == 0 &&
+		len(policy.Status.BlockedVersions) == 0 {
 		spec.InstallPlanApproval = operatorv1alpha1.ApprovalAutomatic
 	}
 
+	// When a failed upgrade is rolled back, the Subscription is recreated to install the previous version
+	if policy.Status.Rollback != nil {
+		spec.StartingCSV = policy.Status.Rollback.Version
+	}
+
 	return subscription, nil
 }
 
@@ -1068,6 +1422,11 @@ func (r *OperatorPolicyReconciler) handleOpGroup(
 	desiredOpGroup *operatorv1.OperatorGroup,
 	desiredSubName string,
 ) (bool, []metav1.Condition, bool, error) {
+	ctx, span := startOperatorPolicySpan(ctx, "handleOpGroup", policy)
+	defer span.End()
+
+	target := r.target(policy)
+
 	watcher := opPolIdentifier(policy.Namespace, policy.Name)
 
 	if desiredOpGroup == nil || desiredOpGroup.Namespace == "" {
@@ -1075,7 +1434,7 @@ func (r *OperatorPolicyReconciler) handleOpGroup(
 		return false, nil, updateStatus(policy, invalidCausingUnknownCond("OperatorGroup")), nil
 	}
 
-	foundOpGroups, err := r.DynamicWatcher.List(
+	foundOpGroups, err := target.DynamicWatcher.List(
 		watcher, operatorGroupGVK, desiredOpGroup.Namespace, labels.Everything())
 	if err != nil {
 		return false, nil, false, fmt.Errorf("error listing OperatorGroups: %w", err)
@@ -1098,6 +1457,7 @@ func (r *OperatorPolicyReconciler) musthaveOpGroup(
 	foundOpGroups []unstructured.Unstructured,
 ) (bool, []metav1.Condition, bool, error) {
 	opLog := ctrl.LoggerFrom(ctx)
+	target := r.target(policy)
 
 	opLog.V(2).Info("Entered musthaveOpGroup", "foundOpGroupsLen", len(foundOpGroups))
 
@@ -1116,12 +1476,13 @@ func (r *OperatorPolicyReconciler) musthaveOpGroup(
 			earlyConds = append(earlyConds, calculateComplianceCondition(policy))
 		}
 
-		err := r.createWithNamespace(ctx, desiredOpGroup)
+		err := r.createWithNamespace(ctx, target.Client, desiredOpGroup)
 		if err != nil {
 			return false, nil, changed, fmt.Errorf("error creating the OperatorGroup: %w", err)
 		}
 
 		desiredOpGroup.SetGroupVersionKind(operatorGroupGVK) // Create stripped this information
+		r.recordEnforced(ctx, policy, "created", nil, desiredOpGroup)
 
 		// Now the OperatorGroup should match, so report Compliance
 		updateStatus(policy, createdCond("OperatorGroup"), createdObj(desiredOpGroup))
@@ -1152,7 +1513,10 @@ func (r *OperatorPolicyReconciler) musthaveOpGroup(
 			return false, nil, updateStatus(policy, mismatchCond("OperatorGroup"), missing, badExisting), nil
 		}
 
-		updateNeeded, skipUpdate, err := r.mergeOpGroups(ctx, desiredOpGroup, &opGroup)
+		// The merge modifies the found OperatorGroup, so keep a copy for the diff of the audit log
+		existingOpGroup := opGroup.DeepCopy()
+
+		updateNeeded, skipUpdate, err := r.mergeOpGroups(ctx, target.Client, desiredOpGroup, &opGroup)
 		if err != nil {
 			return false, nil, false, fmt.Errorf("error checking if the OperatorGroup needs an update: %w", err)
 		}
@@ -1193,12 +1557,13 @@ func (r *OperatorPolicyReconciler) musthaveOpGroup(
 
 		opLog.Info("Updating OperatorGroup to match desired state", "opGroupName", opGroup.GetName())
 
-		err = r.TargetClient.Update(ctx, &opGroup)
+		err = target.Client.Update(ctx, &opGroup)
 		if err != nil {
 			return false, nil, changed, fmt.Errorf("error updating the OperatorGroup: %w", err)
 		}
 
 		opGroup.SetGroupVersionKind(operatorGroupGVK) // Update stripped this information
+		r.recordEnforced(ctx, policy, "updated", existingOpGroup, &opGroup)
 
 		updateStatus(policy, updatedCond("OperatorGroup"), updatedObj(&opGroup))
 
@@ -1211,13 +1576,15 @@ func (r *OperatorPolicyReconciler) musthaveOpGroup(
 }
 
 // createWithNamespace will create the input object and the object's namespace if needed.
-func (r *OperatorPolicyReconciler) createWithNamespace(ctx context.Context, object client.Object) error {
+func (r *OperatorPolicyReconciler) createWithNamespace(
+	ctx context.Context, targetClient client.Client, object client.Object,
+) error {
 	opLog := ctrl.LoggerFrom(ctx)
 
 	opLog.Info("Creating resource", "resourceGVK", object.GetObjectKind().GroupVersionKind(),
 		"resourceName", object.GetName(), "resourceNamespace", object.GetNamespace())
 
-	err := r.TargetClient.Create(ctx, object)
+	err := targetClient.Create(ctx, object)
 	if err == nil {
 		return nil
 	}
@@ -1235,13 +1602,13 @@ func (r *OperatorPolicyReconciler) createWithNamespace(ctx context.Context, obje
 		},
 	}
 
-	err = r.TargetClient.Create(ctx, &ns)
+	err = targetClient.Create(ctx, &ns)
 	if err != nil && !k8serrors.IsAlreadyExists(err) {
 		return err
 	}
 
 	// Try creating the object again now that the namespace was created.
-	return r.TargetClient.Create(ctx, object)
+	return targetClient.Create(ctx, object)
 }
 
 // isNamespaceNotFound detects if the input error from r.Create failed due to the specified namespace not existing.
@@ -1267,6 +1634,8 @@ func (r *OperatorPolicyReconciler) mustnothaveOpGroup(
 	allFoundOpGroups []unstructured.Unstructured,
 	desiredSubName string,
 ) ([]metav1.Condition, bool, error) {
+	target := r.target(policy)
+
 	if len(allFoundOpGroups) == 0 {
 		// Missing OperatorGroup: report Compliance
 		changed := updateStatus(policy, missingNotWantedCond("OperatorGroup"), missingNotWantedObj(desiredOpGroup))
@@ -1290,7 +1659,7 @@ func (r *OperatorPolicyReconciler) mustnothaveOpGroup(
 		// since deleting the OperatorGroup before that could cause problems
 		watcher := opPolIdentifier(policy.Namespace, policy.Name)
 
-		foundSubscriptions, err := r.DynamicWatcher.List(
+		foundSubscriptions, err := target.DynamicWatcher.List(
 			watcher, subscriptionGVK, desiredOpGroup.Namespace, labels.Everything())
 		if err != nil {
 			return nil, false, fmt.Errorf("error listing Subscriptions: %w", err)
@@ -1353,12 +1722,13 @@ func (r *OperatorPolicyReconciler) mustnothaveOpGroup(
 	opLog := ctrl.LoggerFrom(ctx)
 	opLog.Info("Deleting OperatorGroup", "opGroupName", desiredOpGroup.Name)
 
-	err := r.TargetClient.Delete(ctx, desiredOpGroup)
+	err := target.Client.Delete(ctx, desiredOpGroup)
 	if err != nil {
 		return earlyConds, changed, fmt.Errorf("error deleting the OperatorGroup: %w", err)
 	}
 
 	desiredOpGroup.SetGroupVersionKind(operatorGroupGVK) // Delete stripped this information
+	r.recordEnforced(ctx, policy, "deleted", desiredOpGroup, nil)
 
 	updateStatus(policy, deletedCond("OperatorGroup"), deletedObj(desiredOpGroup))
 
@@ -1371,6 +1741,11 @@ func (r *OperatorPolicyReconciler) handleSubscription(
 	desiredSub *operatorv1alpha1.Subscription,
 	ogCorrect bool,
 ) (*operatorv1alpha1.Subscription, []metav1.Condition, bool, error) {
+	ctx, span := startOperatorPolicySpan(ctx, "handleSubscription", policy)
+	defer span.End()
+
+	target := r.target(policy)
+
 	watcher := opPolIdentifier(policy.Namespace, policy.Name)
 
 	if desiredSub == nil {
@@ -1378,7 +1753,7 @@ func (r *OperatorPolicyReconciler) handleSubscription(
 		return nil, nil, updateStatus(policy, invalidCausingUnknownCond("Subscription")), nil
 	}
 
-	foundSub, err := r.DynamicWatcher.Get(watcher, subscriptionGVK, desiredSub.Namespace, desiredSub.Name)
+	foundSub, err := target.DynamicWatcher.Get(watcher, subscriptionGVK, desiredSub.Namespace, desiredSub.Name)
 	if err != nil {
 		return nil, nil, false, fmt.Errorf("error getting the Subscription: %w", err)
 	}
@@ -1397,6 +1772,8 @@ func (r *OperatorPolicyReconciler) musthaveSubscription(
 	foundSub *unstructured.Unstructured,
 	ogCorrect bool,
 ) (*operatorv1alpha1.Subscription, []metav1.Condition, bool, error) {
+	target := r.target(policy)
+
 	if foundSub == nil {
 		policy.Status.SubscriptionInterventionTime = nil
 
@@ -1414,20 +1791,31 @@ func (r *OperatorPolicyReconciler) musthaveSubscription(
 			earlyConds = append(earlyConds, calculateComplianceCondition(policy))
 		}
 
-		err := r.createWithNamespace(ctx, desiredSub)
+		err := r.createWithNamespace(ctx, target.Client, desiredSub)
 		if err != nil {
 			return nil, nil, changed, fmt.Errorf("error creating the Subscription: %w", err)
 		}
 
 		desiredSub.SetGroupVersionKind(subscriptionGVK) // Create stripped this information
+		r.recordEnforced(ctx, policy, "created", nil, desiredSub)
+
 		// Now it should match, so report Compliance
 		updateStatus(policy, createdCond("Subscription"), createdObj(desiredSub))
 
 		return desiredSub, earlyConds, true, nil
 	}
 
+	// The merge modifies the found Subscription, so keep a copy for the diff of the audit log. It is converted
+	// like the merged Subscription so that the diff only has the changes.
+	existingSub := new(operatorv1alpha1.Subscription)
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(foundSub.Object, existingSub); err != nil {
+		return nil, nil, false, fmt.Errorf("error converting the retrieved Subscription to the go type: %w", err)
+	}
+
 	// Subscription found; check if specs match
-	updateNeeded, skipUpdate, err := r.mergeSubscriptions(ctx, desiredSub, foundSub, policy.Spec.RemediationAction)
+	updateNeeded, skipUpdate, err := r.mergeSubscriptions(
+		ctx, target.Client, desiredSub, foundSub, policy.Spec.RemediationAction,
+	)
 	if err != nil {
 		return nil, nil, false, fmt.Errorf("error checking if the Subscription needs an update: %w", err)
 	}
@@ -1506,12 +1894,13 @@ func (r *OperatorPolicyReconciler) musthaveSubscription(
 	opLog.Info("Updating Subscription to match the desired state", "subName", foundSub.GetName(),
 		"subNamespace", foundSub.GetNamespace())
 
-	err = r.TargetClient.Update(ctx, mergedSub)
+	err = target.Client.Update(ctx, mergedSub)
 	if err != nil {
 		return mergedSub, nil, changed, fmt.Errorf("error updating the Subscription: %w", err)
 	}
 
 	mergedSub.SetGroupVersionKind(subscriptionGVK) // Update stripped this information
+	r.recordEnforced(ctx, policy, "updated", existingSub, mergedSub)
 
 	updateStatus(policy, updatedCond("Subscription"), updatedObj(mergedSub))
 
@@ -1530,13 +1919,14 @@ func (r *OperatorPolicyReconciler) updateDeprecationStatus(ctx context.Context,
 	csv *operatorv1alpha1.ClusterServiceVersion,
 ) error {
 	opLog := ctrl.LoggerFrom(ctx)
+	target := r.target(policy)
 
 	// Handle the case where 'sub' is null in other functions
 	if !policy.Spec.ComplianceType.IsMustHave() || desiredSub == nil {
 		return nil
 	}
 
-	packageManifest, err := r.DynamicClient.Resource(packageManifestGVR).Namespace("default").Get(
+	packageManifest, err := target.DynamicClient.Resource(packageManifestGVR).Namespace("default").Get(
 		ctx, desiredSub.Spec.Package, metav1.GetOptions{},
 	)
 	if err != nil {
@@ -1637,12 +2027,7 @@ func (r *OperatorPolicyReconciler) updateDeprecationStatus(ctx context.Context,
 		}
 	}
 
-	updateStatus(policy, metav1.Condition{
-		Type:    deprecationType,
-		Status:  metav1.ConditionTrue,
-		Reason:  "Recommended",
-		Message: "The requested package, channel, and bundle are all at the recommended versions",
-	})
+	updateStatus(policy, noDeprecationsCond)
 
 	return nil
 }
@@ -1653,6 +2038,7 @@ func (r *OperatorPolicyReconciler) considerResolutionFailed(
 	mergedSub *operatorv1alpha1.Subscription,
 ) (*operatorv1alpha1.Subscription, []metav1.Condition, bool, error) {
 	opLog := ctrl.LoggerFrom(ctx)
+	target := r.target(policy)
 	subResFailed := mergedSub.Status.GetCondition(operatorv1alpha1.SubscriptionResolutionFailed)
 
 	// Handle non-ConstraintsNotSatisfiable reasons separately
@@ -1739,7 +2125,9 @@ func (r *OperatorPolicyReconciler) considerResolutionFailed(
 
 	watcher := opPolIdentifier(policy.Namespace, policy.Name)
 
-	existingCSV, err := r.DynamicWatcher.Get(watcher, clusterServiceVersionGVK, mergedSub.Namespace, unrefCSVMatches[1])
+	existingCSV, err := target.DynamicWatcher.Get(
+		watcher, clusterServiceVersionGVK, mergedSub.Namespace, unrefCSVMatches[1],
+	)
 	if err != nil {
 		return mergedSub, nil, changed, fmt.Errorf("error getting the existing CSV in the subscription status: %w", err)
 	}
@@ -1771,7 +2159,7 @@ func (r *OperatorPolicyReconciler) considerResolutionFailed(
 
 	mergedSub.Status.CurrentCSV = existingCSV.GetName()
 
-	if err := r.TargetClient.Status().Update(ctx, mergedSub); err != nil {
+	if err := target.Client.Status().Update(ctx, mergedSub); err != nil {
 		return mergedSub, nil, changed,
 			fmt.Errorf("error updating the Subscription status to point to the CSV: %w", err)
 	}
@@ -1789,6 +2177,8 @@ func (r *OperatorPolicyReconciler) mustnothaveSubscription(
 	desiredSub *operatorv1alpha1.Subscription,
 	foundUnstructSub *unstructured.Unstructured,
 ) (*operatorv1alpha1.Subscription, []metav1.Condition, bool, error) {
+	target := r.target(policy)
+
 	policy.Status.SubscriptionInterventionTime = nil
 
 	if foundUnstructSub == nil {
@@ -1816,6 +2206,11 @@ func (r *OperatorPolicyReconciler) mustnothaveSubscription(
 		return foundSub, nil, changed, nil
 	}
 
+	if policy.Status.OperandDeletionWaiting() {
+		// The operator is kept running until its operands are deleted
+		return foundSub, nil, changed, nil
+	}
+
 	if foundSub.GetDeletionTimestamp() != nil {
 		// No "early" condition because that would cause the status to flap
 		return foundSub, nil, updateStatus(policy, deletingCond("Subscription"), deletingObj(foundSub)), nil
@@ -1831,11 +2226,13 @@ func (r *OperatorPolicyReconciler) mustnothaveSubscription(
 	opLog.Info("Deleting Subscription", "subName", foundUnstructSub.GetName(),
 		"subNamespace", foundUnstructSub.GetNamespace())
 
-	err := r.TargetClient.Delete(ctx, foundUnstructSub)
+	err := target.Client.Delete(ctx, foundUnstructSub)
 	if err != nil {
 		return foundSub, earlyConds, changed, fmt.Errorf("error deleting the Subscription: %w", err)
 	}
 
+	r.recordEnforced(ctx, policy, "deleted", foundUnstructSub, nil)
+
 	updateStatus(policy, deletedCond("Subscription"), deletedObj(desiredSub))
 
 	return foundSub, earlyConds, true, nil
@@ -1869,7 +2266,15 @@ func messageIncludesSubscription(subscription *operatorv1alpha1.Subscription, me
 func (r *OperatorPolicyReconciler) handleInstallPlan(
 	ctx context.Context, policy *policyv1beta1.OperatorPolicy, sub *operatorv1alpha1.Subscription,
 ) (bool, error) {
+	ctx, span := startOperatorPolicySpan(ctx, "handleInstallPlan", policy)
+	defer span.End()
+
 	opLog := ctrl.LoggerFrom(ctx)
+	target := r.target(policy)
+
+	// The pending upgrade is set again if the latest InstallPlan still requires approval
+	previousPending := policy.Status.PendingUpgrade
+	policy.Status.PendingUpgrade = nil
 
 	if sub == nil {
 		// Note: existing related objects will not be removed by this status update
@@ -1888,7 +2293,7 @@ func (r *OperatorPolicyReconciler) handleInstallPlan(
 	// existing CSV, `status.currentCSV` will get included in the new InstallPlan. A new Subscription will also trigger
 	// a new InstallPlan. This code will always pick the latest InstallPlan so as to avoid installing older and
 	// potentially vulnerable operators.
-	installPlans, err := r.DynamicWatcher.List(watcher, installPlanGVK, sub.Namespace, labels.Everything())
+	installPlans, err := target.DynamicWatcher.List(watcher, installPlanGVK, sub.Namespace, labels.Everything())
 	if err != nil {
 		return false, fmt.Errorf("error listing InstallPlans: %w", err)
 	}
@@ -1969,7 +2374,7 @@ func (r *OperatorPolicyReconciler) handleInstallPlan(
 	}
 
 	if policy.Spec.ComplianceType.IsMustHave() {
-		changed, err := r.musthaveInstallPlan(ctx, policy, sub, latestInstallPlan)
+		changed, err := r.musthaveInstallPlan(ctx, policy, sub, latestInstallPlan, previousPending)
 
 		return changed, err
 	}
@@ -1982,8 +2387,10 @@ func (r *OperatorPolicyReconciler) musthaveInstallPlan(
 	policy *policyv1beta1.OperatorPolicy,
 	sub *operatorv1alpha1.Subscription,
 	latestInstallPlanUnstruct *unstructured.Unstructured,
+	previousPending *policyv1beta1.PendingUpgrade,
 ) (bool, error) {
 	opLog := ctrl.LoggerFrom(ctx)
+	target := r.target(policy)
 	complianceConfig := policy.Spec.ComplianceConfig.UpgradesAvailable
 	latestInstallPlan := operatorv1alpha1.InstallPlan{}
 
@@ -2065,6 +2472,10 @@ func (r *OperatorPolicyReconciler) musthaveInstallPlan(
 	initialInstall := sub.Status.InstalledCSV == ""
 	autoUpgrade := policy.Spec.UpgradeApproval == "Automatic"
 
+	if !initialInstall {
+		policy.Status.PendingUpgrade = pendingUpgrade(previousPending, &latestInstallPlan, sub.Status.CurrentCSV)
+	}
+
 	// Only report this status when not approving an InstallPlan, because otherwise it could easily
 	// oscillate between this and another condition.
 	if policy.Spec.RemediationAction.IsInform() || (!initialInstall && !autoUpgrade) {
@@ -2088,17 +2499,65 @@ func (r *OperatorPolicyReconciler) musthaveInstallPlan(
 		), nil
 	}
 
+	disallowed := disallowedDependencies(ctx, policy, &latestInstallPlan, sub.Status.CurrentCSV)
+	if len(disallowed) != 0 {
+		return updateStatus(
+			policy,
+			installPlanDisallowedDepsCond(ipCSVs, disallowed),
+			disallowedDepsInstallPlanObj(&latestInstallPlan, string(phase), disallowed),
+		), nil
+	}
+
+	// Like the upgradeApproval setting, the olm.maxOpenShiftVersion check and the approval windows and soak
+	// time don't apply to the initial installation
+	if !initialInstall {
+		clusterVersion, err := r.openShiftVersion(policy)
+		if err != nil {
+			return false, err
+		}
+
+		if incompatible := incompatibleBundles(&latestInstallPlan, clusterVersion); len(incompatible) != 0 {
+			return updateStatus(
+				policy,
+				installPlanBlockedCond(complianceConfig, ipCSVs, incompatible),
+				existingInstallPlanObj(&latestInstallPlan, string(phase), complianceConfig),
+			), nil
+		}
+
+		now := time.Now()
+
+		nextApproval, err := nextUpgradeApproval(policy, policy.Status.PendingUpgrade.FirstSeen.Time, now)
+		if err != nil {
+			return updateStatus(policy, validationCond([]error{err})), nil
+		}
+
+		if nextApproval.After(now) {
+			nextApprovalTime := metav1.NewTime(nextApproval.Truncate(time.Second))
+			policy.Status.PendingUpgrade.NextApprovalTime = &nextApprovalTime
+
+			return updateStatus(
+				policy,
+				installPlanDeferredCond(complianceConfig, ipCSVs, nextApprovalTime.Time),
+				existingInstallPlanObj(&latestInstallPlan, string(phase), complianceConfig),
+			), nil
+		}
+	}
+
 	opLog.Info("Approving InstallPlan", "InstallPlanName", latestInstallPlan.Name,
 		"InstallPlanNamespace", latestInstallPlan.Namespace)
 
+	unapprovedInstallPlan := latestInstallPlanUnstruct.DeepCopy()
+
 	if err := unstructured.SetNestedField(latestInstallPlanUnstruct.Object, true, "spec", "approved"); err != nil {
 		return false, fmt.Errorf("error approving InstallPlan: %w", err)
 	}
 
-	if err := r.TargetClient.Update(ctx, latestInstallPlanUnstruct); err != nil {
+	if err := target.Client.Update(ctx, latestInstallPlanUnstruct); err != nil {
 		return false, fmt.Errorf("error updating approved InstallPlan: %w", err)
 	}
 
+	r.recordEnforced(ctx, policy, "approved", unapprovedInstallPlan, latestInstallPlanUnstruct)
+
 	return updateStatus(
 		policy,
 		installPlanApprovedCond(sub.Status.CurrentCSV),
@@ -2112,11 +2571,14 @@ func (r *OperatorPolicyReconciler) getRemainingCSVApprovals(
 	currentSub *operatorv1alpha1.Subscription,
 	installPlan *operatorv1alpha1.InstallPlan,
 ) ([]string, error) {
+	target := r.target(currentPolicy)
+
 	csvNames := installPlan.Spec.ClusterServiceVersionNames
 
 	requiredCSVs := sets.New(csvNames...)
 	// First try the current OperatorPolicy without checking others.
-	approvedCSVs := getApprovedCSVs(ctx, currentPolicy, currentSub, installPlan)
+	bundleVersion := r.bundleVersionLookup(ctx, currentPolicy, currentSub, installPlan)
+	approvedCSVs := getApprovedCSVs(ctx, currentPolicy, currentSub, installPlan, bundleVersion)
 
 	if approvedCSVs.IsSuperset(requiredCSVs) {
 		return nil, nil
@@ -2133,7 +2595,7 @@ func (r *OperatorPolicyReconciler) getRemainingCSVApprovals(
 
 	// List the subscriptions in the namespace managed by OperatorPolicy. This is done to avoid resolving all templates
 	// for every OperatorPolicy to see if it manages a subscription in the namespace.
-	subs, err := r.DynamicWatcher.List(watcher, subscriptionGVK, currentSub.Namespace, managedBySelector)
+	subs, err := target.DynamicWatcher.List(watcher, subscriptionGVK, currentSub.Namespace, managedBySelector)
 	if err != nil {
 		return nil, err
 	}
@@ -2176,7 +2638,7 @@ func (r *OperatorPolicyReconciler) getRemainingCSVApprovals(
 			continue
 		}
 
-		approvedCSVs = approvedCSVs.Union(getApprovedCSVs(ctx, &policy, &subTyped, installPlan))
+		approvedCSVs = approvedCSVs.Union(getApprovedCSVs(ctx, &policy, &subTyped, installPlan, bundleVersion))
 	}
 
 	unapprovedCSVs := requiredCSVs.Difference(approvedCSVs).UnsortedList()
@@ -2193,6 +2655,7 @@ func getApprovedCSVs(
 	policy *policyv1beta1.OperatorPolicy,
 	sub *operatorv1alpha1.Subscription,
 	installPlan *operatorv1alpha1.InstallPlan,
+	bundleVersion func(csvName string) string,
 ) sets.Set[string] {
 	// Only enforce policies can approve InstallPlans
 	if !policy.Spec.RemediationAction.IsEnforce() {
@@ -2214,33 +2677,12 @@ func getApprovedCSVs(
 		return nil
 	}
 
-	approvedSubCSV := false
-
-	if len(policy.Spec.Versions) == 0 {
-		approvedSubCSV = true
-	} else {
-		allowedCSVs := make([]policyv1.NonEmptyString, 0, len(policy.Spec.Versions)+1)
-
-		for _, version := range policy.Spec.Versions {
-			if version != "" {
-				allowedCSVs = append(allowedCSVs, policyv1.NonEmptyString(version))
-			}
-		}
-
-		if sub.Spec != nil && sub.Spec.StartingCSV != "" {
-			allowedCSVs = append(allowedCSVs, policyv1.NonEmptyString(sub.Spec.StartingCSV))
-		}
-
-		for _, allowedCSV := range allowedCSVs {
-			if string(allowedCSV) == subscriptionCSV {
-				approvedSubCSV = true
-
-				break
-			}
-		}
+	version := ""
+	if policy.Spec.VersionRange != "" && bundleVersion != nil {
+		version = bundleVersion(subscriptionCSV)
 	}
 
-	if !approvedSubCSV {
+	if !csvAllowed(policy, sub, subscriptionCSV, version) {
 		return nil
 	}
 
@@ -2339,6 +2781,252 @@ func getBundleDependencies(
 	return approvedCSVs
 }
 
+// bundleVersionLookup returns a function that returns the bundle version of a ClusterServiceVersion in the
+// InstallPlan. The version is read from the olm.package property of the bundle lookups in the InstallPlan status,
+// and otherwise from the channel entries of the PackageManifest of the Subscription, which is only retrieved when
+// needed. An empty string is returned when the version can't be determined.
+func (r *OperatorPolicyReconciler) bundleVersionLookup(
+	ctx context.Context,
+	policy *policyv1beta1.OperatorPolicy,
+	sub *operatorv1alpha1.Subscription,
+	installPlan *operatorv1alpha1.InstallPlan,
+) func(csvName string) string {
+	versions := installPlanBundleVersions(installPlan)
+	packageManifestChecked := false
+
+	return func(csvName string) string {
+		if version, ok := versions[csvName]; ok || packageManifestChecked {
+			return version
+		}
+
+		packageManifestChecked = true
+
+		if sub == nil || sub.Spec == nil || sub.Spec.Package == "" {
+			return ""
+		}
+
+		packageManifest, err := r.target(policy).DynamicClient.Resource(packageManifestGVR).Namespace("default").Get(
+			ctx, sub.Spec.Package, metav1.GetOptions{},
+		)
+		if err != nil {
+			ctrl.LoggerFrom(ctx).V(1).Info(
+				"Failed to get the PackageManifest to determine the bundle versions",
+				"name", sub.Spec.Package, "error", err.Error(),
+			)
+
+			return ""
+		}
+
+		for csv, version := range packageManifestBundleVersions(packageManifest) {
+			if _, ok := versions[csv]; !ok {
+				versions[csv] = version
+			}
+		}
+
+		return versions[csvName]
+	}
+}
+
+// installPlanBundleVersions returns the bundle versions of the ClusterServiceVersions in the InstallPlan from the
+// olm.package property of its bundle lookups.
+func installPlanBundleVersions(installPlan *operatorv1alpha1.InstallPlan) map[string]string {
+	versions := map[string]string{}
+
+	for csvName, pkg := range installPlanBundlePackages(installPlan) {
+		if pkg.Version != "" {
+			versions[csvName] = pkg.Version
+		}
+	}
+
+	return versions
+}
+
+// bundlePackage is the value of the olm.package property of a bundle.
+type bundlePackage struct {
+	PackageName string `json:"packageName"`
+	Version     string `json:"version"`
+}
+
+// installPlanBundlePackages returns the olm.package property of the bundles in the InstallPlan, by
+// ClusterServiceVersion name, from the properties of its bundle lookups.
+func installPlanBundlePackages(installPlan *operatorv1alpha1.InstallPlan) map[string]bundlePackage {
+	packages := map[string]bundlePackage{}
+
+	for csvName, value := range installPlanBundleProperties(installPlan, "olm.package") {
+		pkg := bundlePackage{}
+
+		if err := json.Unmarshal(value, &pkg); err == nil {
+			packages[csvName] = pkg
+		}
+	}
+
+	return packages
+}
+
+// installPlanBundleProperties returns the raw value of the property with the given type of the bundles in the
+// InstallPlan, by ClusterServiceVersion name, from the properties of its bundle lookups. Bundle lookups with
+// properties that can't be parsed are skipped.
+func installPlanBundleProperties(
+	installPlan *operatorv1alpha1.InstallPlan, propType string,
+) map[string]json.RawMessage {
+	values := map[string]json.RawMessage{}
+
+	if installPlan == nil {
+		return values
+	}
+
+	for _, bundle := range installPlan.Status.BundleLookups {
+		if bundle.Properties == "" || bundle.Identifier == "" {
+			continue
+		}
+
+		props := struct {
+			Properties []struct {
+				Type  string          `json:"type"`
+				Value json.RawMessage `json:"value"`
+			} `json:"properties"`
+		}{}
+
+		if err := json.Unmarshal([]byte(bundle.Properties), &props); err != nil {
+			continue
+		}
+
+		for _, prop := range props.Properties {
+			if prop.Type == propType {
+				values[bundle.Identifier] = prop.Value
+			}
+		}
+	}
+
+	return values
+}
+
+// disallowedDependencies returns the dependencies of the target ClusterServiceVersion in the InstallPlan that are
+// not allowed by spec.allowedDependencies, formatted like 'package (csv name)'. Nothing is returned when
+// spec.allowedDependencies is empty.
+func disallowedDependencies(
+	ctx context.Context,
+	policy *policyv1beta1.OperatorPolicy,
+	installPlan *operatorv1alpha1.InstallPlan,
+	targetCSV string,
+) []string {
+	if len(policy.Spec.AllowedDependencies) == 0 {
+		return nil
+	}
+
+	dependencyCSVs := getBundleDependencies(ctx, installPlan, targetCSV)
+	dependencyCSVs.Delete(targetCSV)
+
+	packages := installPlanBundlePackages(installPlan)
+	disallowed := []string{}
+
+	for _, csvName := range sets.List(dependencyCSVs) {
+		pkg := packages[csvName]
+
+		if dependencyAllowed(policy, pkg) {
+			continue
+		}
+
+		if pkg.PackageName == "" {
+			disallowed = append(disallowed, csvName)
+		} else {
+			disallowed = append(disallowed, pkg.PackageName+" ("+csvName+")")
+		}
+	}
+
+	return disallowed
+}
+
+// dependencyAllowed returns whether the package and version of the dependency are in spec.allowedDependencies.
+func dependencyAllowed(policy *policyv1beta1.OperatorPolicy, pkg bundlePackage) bool {
+	for _, allowed := range policy.Spec.AllowedDependencies {
+		if allowed.Package != pkg.PackageName {
+			continue
+		}
+
+		if allowed.VersionRange == "" {
+			return true
+		}
+
+		constraints, err := allowedDependencyConstraints(allowed)
+		if err != nil {
+			return false
+		}
+
+		version, err := semver.NewVersion(pkg.Version)
+		if err != nil {
+			return false
+		}
+
+		if constraints.Check(version) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowedDependencyConstraints parses the version range of the spec.allowedDependencies entry.
+func allowedDependencyConstraints(allowed policyv1beta1.AllowedDependency) (*semver.Constraints, error) {
+	constraints, err := parseVersionConstraint(allowed.VersionRange)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"the versionRange %q of the allowed dependency %s is invalid: %w",
+			allowed.VersionRange, allowed.Package, err,
+		)
+	}
+
+	return constraints, nil
+}
+
+// validateAllowedDependencies returns an error if a version range in spec.allowedDependencies is invalid.
+func validateAllowedDependencies(policy *policyv1beta1.OperatorPolicy) error {
+	for _, allowed := range policy.Spec.AllowedDependencies {
+		if allowed.VersionRange == "" {
+			continue
+		}
+
+		if _, err := allowedDependencyConstraints(allowed); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// packageManifestBundleVersions returns the bundle versions of the ClusterServiceVersions in the channel entries of
+// the PackageManifest.
+func packageManifestBundleVersions(packageManifest *unstructured.Unstructured) map[string]string {
+	versions := map[string]string{}
+
+	channels, _, _ := unstructured.NestedSlice(packageManifest.Object, "status", "channels")
+
+	for _, channel := range channels {
+		chanObj, ok := channel.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		entries, _, _ := unstructured.NestedSlice(chanObj, "entries")
+
+		for _, entry := range entries {
+			entryObj, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			name, _, _ := unstructured.NestedString(entryObj, "name")
+			version, _, _ := unstructured.NestedString(entryObj, "version")
+
+			if name != "" && version != "" {
+				versions[name] = version
+			}
+		}
+	}
+
+	return versions
+}
+
 // getDependencyCSVs recursively converts the package dependencies to their CSV identifiers
 func getDependencyCSVs(
 	startingCSV string, packageToCSV map[string]string, packageDependencies map[string]sets.Set[string],
@@ -2387,6 +3075,11 @@ func (r *OperatorPolicyReconciler) handleCSV(
 	policy *policyv1beta1.OperatorPolicy,
 	sub *operatorv1alpha1.Subscription,
 ) (*operatorv1alpha1.ClusterServiceVersion, []metav1.Condition, bool, error) {
+	ctx, span := startOperatorPolicySpan(ctx, "handleCSV", policy)
+	defer span.End()
+
+	target := r.target(policy)
+
 	// case where subscription is nil
 	if sub == nil {
 		// need to report lack of existing CSV
@@ -2396,7 +3089,7 @@ func (r *OperatorPolicyReconciler) handleCSV(
 	watcher := opPolIdentifier(policy.Namespace, policy.Name)
 	selector := subLabelSelector(sub)
 
-	csvList, err := r.DynamicWatcher.List(watcher, clusterServiceVersionGVK, sub.Namespace, selector)
+	csvList, err := target.DynamicWatcher.List(watcher, clusterServiceVersionGVK, sub.Namespace, selector)
 	if err != nil {
 		return nil, nil, false, fmt.Errorf("error listing CSVs: %w", err)
 	}
@@ -2439,35 +3132,29 @@ func (r *OperatorPolicyReconciler) handleCSV(
 	}
 
 	// Check if the CSV is an approved version
-	if len(policy.Spec.Versions) != 0 {
-		allowedVersions := make([]policyv1.NonEmptyString, 0, len(policy.Spec.Versions)+1)
-
-		for _, version := range policy.Spec.Versions {
-			if version != "" {
-				allowedVersions = append(allowedVersions, policyv1.NonEmptyString(version))
-			}
-		}
+	csvVersion := ""
+	if foundCSV.Spec.Version.Major != 0 || foundCSV.Spec.Version.Minor != 0 || foundCSV.Spec.Version.Patch != 0 {
+		csvVersion = foundCSV.Spec.Version.String()
+	}
 
-		if sub.Spec.StartingCSV != "" {
-			allowedVersions = append(allowedVersions, policyv1.NonEmptyString(sub.Spec.StartingCSV))
-		}
+	allowed := csvAllowed(policy, sub, foundCSV.Name, csvVersion)
 
-		allowed := false
+	// A failed version is blocked when its rollback starts, so this is handled before reporting the CSV as
+	// disallowed in order to finish a rollback that was interrupted.
+	rolledBack, earlyConds, changed, err := r.handleUpgradeFailure(ctx, policy, sub, foundCSV, relatedCSVs, allowed)
+	if rolledBack || err != nil {
+		return nil, earlyConds, changed, err
+	}
 
-		for _, allowedVersion := range allowedVersions {
-			if string(allowedVersion) == foundCSV.Name {
-				allowed = true
+	if !allowed {
+		changed = updateStatus(policy, disallowedCSVCond(foundCSV), disallowedCSVObj(foundCSV)) || changed
 
-				break
-			}
-		}
-
-		if !allowed {
-			return foundCSV, nil, updateStatus(policy, disallowedCSVCond(foundCSV), disallowedCSVObj(foundCSV)), nil
-		}
+		return foundCSV, earlyConds, changed, nil
 	}
 
-	return foundCSV, nil, updateStatus(policy, allowedCSVCond(foundCSV), relatedCSVs...), nil
+	changed = updateStatus(policy, allowedCSVCond(foundCSV), relatedCSVs...) || changed
+
+	return foundCSV, earlyConds, changed, nil
 }
 
 func (r *OperatorPolicyReconciler) mustnothaveCSV(
@@ -2477,6 +3164,7 @@ func (r *OperatorPolicyReconciler) mustnothaveCSV(
 	namespace string,
 ) ([]metav1.Condition, bool, error) {
 	opLog := ctrl.LoggerFrom(ctx)
+	target := r.target(policy)
 
 	if len(csvList) == 0 {
 		changed := updateStatus(policy, missingNotWantedCond("ClusterServiceVersion"),
@@ -2508,6 +3196,11 @@ func (r *OperatorPolicyReconciler) mustnothaveCSV(
 		return nil, changed, nil
 	}
 
+	if policy.Status.OperandDeletionWaiting() {
+		// The operator is kept running until its operands are deleted
+		return nil, changed, nil
+	}
+
 	earlyConds := []metav1.Condition{}
 
 	if changed {
@@ -2528,7 +3221,7 @@ func (r *OperatorPolicyReconciler) mustnothaveCSV(
 			// and does not necessarily get notified events when the object is fully removed.
 			watcher := opPolIdentifier(policy.Namespace, policy.Name)
 
-			_, err := r.DynamicWatcher.Get(watcher, clusterServiceVersionGVK,
+			_, err := target.DynamicWatcher.Get(watcher, clusterServiceVersionGVK,
 				csvList[i].GetNamespace(), csvList[i].GetName())
 			if err != nil {
 				return earlyConds, changed, fmt.Errorf("error watching the deleting CSV: %w", err)
@@ -2540,7 +3233,7 @@ func (r *OperatorPolicyReconciler) mustnothaveCSV(
 		opLog.Info("Deleting ClusterServiceVersion", "csvName", csvList[i].GetName(),
 			"csvNamespace", csvList[i].GetNamespace())
 
-		err := r.TargetClient.Delete(ctx, &csvList[i])
+		err := target.Client.Delete(ctx, &csvList[i])
 		if err != nil {
 			changed := updateStatus(policy, foundNotWantedCond("ClusterServiceVersion", csvNames...), relatedCSVs...)
 
@@ -2554,6 +3247,7 @@ func (r *OperatorPolicyReconciler) mustnothaveCSV(
 
 		csvList[i].SetGroupVersionKind(clusterServiceVersionGVK)
 		relatedCSVs[i] = deletedObj(&csvList[i])
+		r.recordEnforced(ctx, policy, "deleted", &csvList[i], nil)
 	}
 
 	if anyAlreadyDeleting {
@@ -2573,6 +3267,11 @@ func (r *OperatorPolicyReconciler) handleDeployment(
 	policy *policyv1beta1.OperatorPolicy,
 	csv *operatorv1alpha1.ClusterServiceVersion,
 ) (bool, error) {
+	ctx, span := startOperatorPolicySpan(ctx, "handleDeployment", policy)
+	defer span.End()
+
+	target := r.target(policy)
+
 	// case where csv is nil
 	if csv == nil {
 		// need to report lack of existing Deployments
@@ -2594,7 +3293,7 @@ func (r *OperatorPolicyReconciler) handleDeployment(
 	depNum := 0
 
 	for _, dep := range csv.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
-		foundDep, err := r.DynamicWatcher.Get(watcher, deploymentGVK, csv.Namespace, dep.Name)
+		foundDep, err := target.DynamicWatcher.Get(watcher, deploymentGVK, csv.Namespace, dep.Name)
 		if err != nil {
 			return false, fmt.Errorf("error getting the Deployment: %w", err)
 		}
@@ -2644,6 +3343,11 @@ func (r *OperatorPolicyReconciler) handleCRDs(
 	policy *policyv1beta1.OperatorPolicy,
 	sub *operatorv1alpha1.Subscription,
 ) ([]metav1.Condition, bool, error) {
+	ctx, span := startOperatorPolicySpan(ctx, "handleCRDs", policy)
+	defer span.End()
+
+	target := r.target(policy)
+
 	if sub == nil {
 		return nil, updateStatus(policy, noCRDCond, noExistingCRDObj), nil
 	}
@@ -2652,7 +3356,7 @@ func (r *OperatorPolicyReconciler) handleCRDs(
 	watcher := opPolIdentifier(policy.Namespace, policy.Name)
 	selector := subLabelSelector(sub)
 
-	crdList, err := r.DynamicWatcher.List(watcher, customResourceDefinitionGVK, sub.Namespace, selector)
+	crdList, err := target.DynamicWatcher.List(watcher, customResourceDefinitionGVK, sub.Namespace, selector)
 	if err != nil {
 		return nil, false, fmt.Errorf("error listing CRDs: %w", err)
 	}
@@ -2690,6 +3394,11 @@ func (r *OperatorPolicyReconciler) handleCRDs(
 		return nil, changed, nil
 	}
 
+	if policy.Status.OperandDeletionWaiting() {
+		// The CRDs are kept until the operands are deleted
+		return nil, changed, nil
+	}
+
 	earlyConds := []metav1.Condition{}
 
 	if changed {
@@ -2708,7 +3417,9 @@ func (r *OperatorPolicyReconciler) handleCRDs(
 
 			// Add a watch specifically for this CRD: the existing watch uses a label selector,
 			// and does not necessarily get notified events when the object is fully removed.
-			_, err := r.DynamicWatcher.Get(watcher, customResourceDefinitionGVK, sub.Namespace, crdList[i].GetName())
+			_, err := target.DynamicWatcher.Get(
+				watcher, customResourceDefinitionGVK, sub.Namespace, crdList[i].GetName(),
+			)
 			if err != nil {
 				return earlyConds, changed, fmt.Errorf("error watching the deleting CRD: %w", err)
 			}
@@ -2718,7 +3429,7 @@ func (r *OperatorPolicyReconciler) handleCRDs(
 
 		opLog.Info("Deleting CustomResourceDefinition", "crdName", crdList[i].GetName())
 
-		err := r.TargetClient.Delete(ctx, &crdList[i])
+		err := target.Client.Delete(ctx, &crdList[i])
 		if err != nil {
 			changed := updateStatus(policy, foundNotWantedCond("CustomResourceDefinition"), relatedCRDs...)
 
@@ -2732,6 +3443,7 @@ func (r *OperatorPolicyReconciler) handleCRDs(
 
 		crdList[i].SetGroupVersionKind(customResourceDefinitionGVK)
 		relatedCRDs[i] = deletedObj(&crdList[i])
+		r.recordEnforced(ctx, policy, "deleted", &crdList[i], nil)
 	}
 
 	if anyAlreadyDeleting {
@@ -2747,15 +3459,29 @@ func (r *OperatorPolicyReconciler) handleCRDs(
 }
 
 func (r *OperatorPolicyReconciler) handleCatalogSource(
+	ctx context.Context,
 	policy *policyv1beta1.OperatorPolicy,
 	subscription *operatorv1alpha1.Subscription,
-) (bool, error) {
+	desiredSub *operatorv1alpha1.Subscription,
+	desiredCatalogSrc *unstructured.Unstructured,
+) ([]metav1.Condition, bool, error) {
+	ctx, span := startOperatorPolicySpan(ctx, "handleCatalogSource", policy)
+	defer span.End()
+
+	target := r.target(policy)
+
 	watcher := opPolIdentifier(policy.Namespace, policy.Name)
 
+	// The CatalogSource specified in the policy is handled even without a Subscription, so it can be
+	// created before the Subscription resolves and deleted after the Subscription is removed.
+	if desiredCatalogSrc != nil && desiredSub != nil {
+		return r.handleManagedCatalogSource(ctx, policy, desiredCatalogSrc, desiredSub.Name, desiredSub.Namespace)
+	}
+
 	if subscription == nil {
 		// Note: existing related objects will not be removed by this status update
 		if policy.Spec.ComplianceType.IsMustHave() {
-			return updateStatus(policy, invalidCausingUnknownCond("CatalogSource")), nil
+			return nil, updateStatus(policy, invalidCausingUnknownCond("CatalogSource")), nil
 		}
 
 		// CatalogSource may be available
@@ -2763,17 +3489,17 @@ func (r *OperatorPolicyReconciler) handleCatalogSource(
 		cond := notApplicableCond("CatalogSource")
 		cond.Status = metav1.ConditionFalse
 
-		return updateStatus(policy, cond), nil
+		return nil, updateStatus(policy, cond), nil
 	}
 
 	catalogName := subscription.Spec.CatalogSource
 	catalogNS := subscription.Spec.CatalogSourceNamespace
 
 	// Check if CatalogSource exists
-	foundCatalogSrc, err := r.DynamicWatcher.Get(watcher, catalogSrcGVK,
+	foundCatalogSrc, err := target.DynamicWatcher.Get(watcher, catalogSrcGVK,
 		catalogNS, catalogName)
 	if err != nil {
-		return false, fmt.Errorf("error getting CatalogSource: %w", err)
+		return nil, false, fmt.Errorf("error getting CatalogSource: %w", err)
 	}
 
 	var catalogSrc *operatorv1alpha1.CatalogSource
@@ -2785,15 +3511,19 @@ func (r *OperatorPolicyReconciler) handleCatalogSource(
 		err := runtime.DefaultUnstructuredConverter.
 			FromUnstructured(foundCatalogSrc.Object, catalogSrc)
 		if err != nil {
-			return false, fmt.Errorf("error converting the retrieved CatalogSource to the Go type: %w", err)
+			return nil, false, fmt.Errorf("error converting the retrieved CatalogSource to the Go type: %w", err)
 		}
 	}
 
 	if policy.Spec.ComplianceType.IsMustNotHave() {
-		return r.mustnothaveCatalogSource(policy, catalogSrc, catalogName, catalogNS)
+		changed, err := r.mustnothaveCatalogSource(policy, catalogSrc, catalogName, catalogNS)
+
+		return nil, changed, err
 	}
 
-	return r.musthaveCatalogSource(policy, catalogSrc, catalogName, catalogNS)
+	changed, err := r.musthaveCatalogSource(policy, catalogSrc, catalogName, catalogNS)
+
+	return nil, changed, err
 }
 
 func (r *OperatorPolicyReconciler) mustnothaveCatalogSource(
@@ -2861,6 +3591,7 @@ func opPolIdentifier(namespace, name string) depclient.ObjectIdentifier {
 
 func (r *OperatorPolicyReconciler) mergeOpGroups(
 	ctx context.Context,
+	targetClient client.Client,
 	desired *operatorv1.OperatorGroup,
 	existing *unstructured.Unstructured,
 ) (updateNeeded, updateIsForbidden bool, err error) {
@@ -2914,13 +3645,14 @@ func (r *OperatorPolicyReconciler) mergeOpGroups(
 		}
 	}
 
-	updateNeeded, forbidden, err := r.mergeObjects(ctx, desiredUnstruct, existing)
+	updateNeeded, forbidden, err := r.mergeObjects(ctx, targetClient, desiredUnstruct, existing, policyv1.MustHave)
 
 	return updateNeeded || forceUpdate, forbidden, err
 }
 
 func (r *OperatorPolicyReconciler) mergeSubscriptions(
 	ctx context.Context,
+	targetClient client.Client,
 	desired *operatorv1alpha1.Subscription,
 	existing *unstructured.Unstructured,
 	action policyv1.RemediationAction,
@@ -2959,18 +3691,20 @@ func (r *OperatorPolicyReconciler) mergeSubscriptions(
 		unstructured.RemoveNestedField(desiredUnstruct, "spec", "installPlanApproval")
 	}
 
-	updateNeeded, forbidden, err := r.mergeObjects(ctx, desiredUnstruct, existing)
+	updateNeeded, forbidden, err := r.mergeObjects(ctx, targetClient, desiredUnstruct, existing, policyv1.MustHave)
 
 	return updateNeeded || forceUpdate, forbidden, err
 }
 
 // mergeObjects takes fields from the desired object and sets/merges them on the
-// existing object. It checks and returns whether an update is really necessary
-// with a server-side dry-run.
+// existing object based on the compliance type. It checks and returns whether an
+// update is really necessary with a server-side dry-run.
 func (r *OperatorPolicyReconciler) mergeObjects(
 	ctx context.Context,
+	targetClient client.Client,
 	desired map[string]any,
 	existing *unstructured.Unstructured,
+	compType policyv1.ComplianceType,
 ) (updateNeeded, updateIsForbidden bool, err error) {
 	log := ctrl.LoggerFrom(ctx, "objName", existing.GetName(), "objNamespace", existing.GetNamespace())
 	desiredObj := &unstructured.Unstructured{Object: desired}
@@ -2980,13 +3714,13 @@ func (r *OperatorPolicyReconciler) mergeObjects(
 	removeFieldsForComparison(existingObjectCopy)
 
 	//nolint:dogsled
-	_, errMsg, updateNeeded, _, _ := handleKeys(log, desiredObj, existing, existingObjectCopy, policyv1.MustHave, "")
+	_, errMsg, updateNeeded, _, _ := handleKeys(log, desiredObj, existing, existingObjectCopy, compType, "")
 	if errMsg != "" {
 		return updateNeeded, false, errors.New(errMsg)
 	}
 
 	if updateNeeded {
-		err := r.TargetClient.Update(ctx, existing, client.DryRunAll)
+		err := targetClient.Update(ctx, existing, client.DryRunAll)
 		if err != nil {
 			if k8serrors.IsForbidden(err) {
 				// This indicates the update would make a change, but the change is not allowed,
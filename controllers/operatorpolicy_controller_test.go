@@ -10,6 +10,7 @@ import (
 	operatorv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	"github.com/stretchr/testify/assert"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -49,7 +50,7 @@ func TestBuildResources_SubscriptionErrorUpdatesStatus(t *testing.T) {
 		},
 	}
 
-	_, _, changed, returnedErr := r.buildResources(t.Context(), policy)
+	_, _, _, changed, returnedErr := r.buildResources(t.Context(), policy)
 	assert.True(t, changed, "expected status to be updated")
 	assert.NoError(t, returnedErr)
 
@@ -90,7 +91,7 @@ func TestBuildResources_subInstallPlanApprovalErrorUpdatesStatus(t *testing.T) {
 		},
 	}
 
-	_, _, changed, returnedErr := r.buildResources(t.Context(), policy)
+	_, _, _, changed, returnedErr := r.buildResources(t.Context(), policy)
 	assert.True(t, changed, "expected status to be updated")
 	assert.NoError(t, returnedErr)
 
@@ -100,6 +101,47 @@ func TestBuildResources_subInstallPlanApprovalErrorUpdatesStatus(t *testing.T) {
 	assert.Equal(t, "installPlanApproval is prohibited in spec.subscription", cond.Message)
 }
 
+func TestBuildResources_InvalidVersionRangeUpdatesStatus(t *testing.T) {
+	t.Parallel()
+
+	r := &OperatorPolicyReconciler{}
+
+	policy := &policyv1beta1.OperatorPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-policy",
+			Namespace: "default",
+			Annotations: map[string]string{
+				"policy.open-cluster-management.io/disable-templates": "true",
+			},
+		},
+		Spec: policyv1beta1.OperatorPolicySpec{
+			Severity:          "low",
+			RemediationAction: "inform",
+			ComplianceType:    "musthave",
+			Subscription: runtime.RawExtension{
+				Raw: []byte(`{
+					"namespace": "default",
+					"source": "my-catalog",
+					"sourceNamespace": "my-ns",
+					"name": "my-operator",
+					"channel": "stable"
+				}`),
+			},
+			UpgradeApproval: "None",
+			VersionRange:    " >=one ",
+		},
+	}
+
+	_, _, _, changed, returnedErr := r.buildResources(t.Context(), policy)
+	assert.True(t, changed, "expected status to be updated")
+	assert.NoError(t, returnedErr)
+
+	_, cond := policy.Status.GetCondition(validPolicyConditionType)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, "InvalidPolicySpec", cond.Reason)
+	assert.Contains(t, cond.Message, `the spec.versionRange ">=one" is invalid`)
+}
+
 func TestBuildResources_SubDefaultsPkgManifestNotFoundUpdatesStatusAndReturnsErr(t *testing.T) {
 	// To test line 542
 	t.Parallel()
@@ -134,7 +176,7 @@ func TestBuildResources_SubDefaultsPkgManifestNotFoundUpdatesStatusAndReturnsErr
 		},
 	}
 
-	sub, opGroup, changed, returnedErr := r.buildResources(t.Context(), policy)
+	sub, opGroup, _, changed, returnedErr := r.buildResources(t.Context(), policy)
 	assert.True(t, changed, "expected status to be updated")
 	assert.ErrorIs(t, returnedErr, ErrPackageManifest, "expected returned error to wrap ErrPackageManifest")
 
@@ -182,7 +224,7 @@ func TestBuildResources_OperatorGroupErrorUpdatesStatus(t *testing.T) {
 		},
 	}
 
-	_, _, changed, returnedErr := r.buildResources(t.Context(), policy)
+	_, _, _, changed, returnedErr := r.buildResources(t.Context(), policy)
 	assert.True(t, changed, "expected status to be updated")
 	assert.NoError(t, returnedErr)
 
@@ -223,7 +265,7 @@ func TestBuildResources_TemplateResolverCreationErrorUpdatesStatus(t *testing.T)
 		},
 	}
 
-	sub, opGroup, changed, returnedErr := r.buildResources(t.Context(), policy)
+	sub, opGroup, _, changed, returnedErr := r.buildResources(t.Context(), policy)
 	assert.True(t, changed, "expected status to be updated")
 	assert.NoError(t, returnedErr)
 	assert.Nil(t, sub, "expected subscription to be nil on early return")
@@ -268,7 +310,7 @@ func TestBuildSubscription(t *testing.T) {
 	}
 
 	// Check values are correctly bootstrapped to the Subscription
-	ret, err := buildSubscription(testPolicy, nil)
+	ret, err := buildSubscription(testPolicy, nil, false)
 	assert.NoError(t, err)
 	assert.Equal(t, ret.GroupVersionKind(), desiredGVK)
 	assert.Equal(t, "my-operator", ret.ObjectMeta.Name)
@@ -276,6 +318,114 @@ func TestBuildSubscription(t *testing.T) {
 	assert.Equal(t, operatorv1alpha1.ApprovalManual, ret.Spec.InstallPlanApproval)
 }
 
+func TestBuildSubscriptionRollback(t *testing.T) {
+	t.Parallel()
+
+	policy := &policyv1beta1.OperatorPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-policy", Namespace: "default"},
+		Spec: policyv1beta1.OperatorPolicySpec{
+			RemediationAction: "enforce",
+			ComplianceType:    "musthave",
+			Subscription: runtime.RawExtension{
+				Raw: []byte(`{
+					"namespace": "default",
+					"source": "my-catalog",
+					"sourceNamespace": "my-ns",
+					"name": "my-operator",
+					"channel": "stable"
+				}`),
+			},
+			UpgradeApproval:      "Automatic",
+			UpgradeFailurePolicy: "Rollback",
+		},
+	}
+
+	ret, err := buildSubscription(policy, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, operatorv1alpha1.ApprovalAutomatic, ret.Spec.InstallPlanApproval)
+	assert.Empty(t, ret.Spec.StartingCSV)
+
+	// While rolling back, the previous version is the starting CSV and upgrades require approval
+	policy.Status.BlockedVersions = []string{"my-operator.v1.1.0"}
+	policy.Status.Rollback = &policyv1beta1.UpgradeRollback{
+		FailedVersion: "my-operator.v1.1.0",
+		Version:       "my-operator.v1.0.0",
+	}
+
+	ret, err = buildSubscription(policy, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, operatorv1alpha1.ApprovalManual, ret.Spec.InstallPlanApproval)
+	assert.Equal(t, "my-operator.v1.0.0", ret.Spec.StartingCSV)
+}
+
+func TestBuildSubscriptionControlledUpgrades(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]func(spec *policyv1beta1.OperatorPolicySpec){
+		"approval windows": func(spec *policyv1beta1.OperatorPolicySpec) {
+			spec.UpgradeApprovalWindows = []policyv1beta1.UpgradeApprovalWindow{{Start: "02:00", Duration: "4h"}}
+		},
+		"soak time": func(spec *policyv1beta1.OperatorPolicySpec) {
+			spec.UpgradeSoakTime = "72h"
+		},
+		"allowed dependencies": func(spec *policyv1beta1.OperatorPolicySpec) {
+			spec.AllowedDependencies = []policyv1beta1.AllowedDependency{{Package: "my-dependency"}}
+		},
+	}
+
+	for name, setField := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			policy := &policyv1beta1.OperatorPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-policy", Namespace: "default"},
+				Spec: policyv1beta1.OperatorPolicySpec{
+					RemediationAction: "enforce",
+					ComplianceType:    "musthave",
+					Subscription: runtime.RawExtension{
+						Raw: []byte(`{"namespace": "default", "source": "my-catalog", ` +
+							`"sourceNamespace": "my-ns", "name": "my-operator", "channel": "stable"}`),
+					},
+					UpgradeApproval: "Automatic",
+				},
+			}
+
+			ret, err := buildSubscription(policy, nil, false)
+			assert.NoError(t, err)
+			assert.Equal(t, operatorv1alpha1.ApprovalAutomatic, ret.Spec.InstallPlanApproval)
+
+			// Without Manual approval, OLM would approve the upgrades before the controller could hold them
+			setField(&policy.Spec)
+
+			ret, err = buildSubscription(policy, nil, false)
+			assert.NoError(t, err)
+			assert.Equal(t, operatorv1alpha1.ApprovalManual, ret.Spec.InstallPlanApproval)
+		})
+	}
+}
+
+func TestBuildSubscriptionOpenShift(t *testing.T) {
+	t.Parallel()
+
+	policy := &policyv1beta1.OperatorPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-policy", Namespace: "default"},
+		Spec: policyv1beta1.OperatorPolicySpec{
+			RemediationAction: "enforce",
+			ComplianceType:    "musthave",
+			Subscription: runtime.RawExtension{
+				Raw: []byte(`{"namespace": "default", "source": "my-catalog", ` +
+					`"sourceNamespace": "my-ns", "name": "my-operator", "channel": "stable"}`),
+			},
+			UpgradeApproval: "Automatic",
+		},
+	}
+
+	// The controller approves the upgrades on OpenShift so that it can check the olm.maxOpenShiftVersion
+	ret, err := buildSubscription(policy, nil, true)
+	assert.NoError(t, err)
+	assert.Equal(t, operatorv1alpha1.ApprovalManual, ret.Spec.InstallPlanApproval)
+}
+
 func TestBuildSubscriptionInvalidNames(t *testing.T) {
 	t.Parallel()
 
@@ -326,7 +476,7 @@ func TestBuildSubscriptionInvalidNames(t *testing.T) {
 				}
 
 				// Check values are correctly bootstrapped to the Subscription
-				_, err := buildSubscription(testPolicy, nil)
+				_, err := buildSubscription(testPolicy, nil, false)
 				assert.Equal(t, test.expected, err.Error())
 			},
 		)
@@ -509,7 +659,7 @@ func TestGetApprovedCSVs(t *testing.T) {
 		},
 	}
 
-	csvs := getApprovedCSVs(t.Context(), policy, subscription, odfIP)
+	csvs := getApprovedCSVs(t.Context(), policy, subscription, odfIP, nil)
 
 	expectedCSVs := sets.Set[string]{}
 	expectedCSVs.Insert(odfIP.Spec.ClusterServiceVersionNames...)
@@ -530,7 +680,7 @@ func TestGetApprovedCSVs(t *testing.T) {
 		},
 	}
 
-	csvs = getApprovedCSVs(t.Context(), policy, subscription, odfIP)
+	csvs = getApprovedCSVs(t.Context(), policy, subscription, odfIP, nil)
 
 	if !csvs.Equal(expectedCSVs) {
 		t.Fatalf(
@@ -548,7 +698,7 @@ func TestGetApprovedCSVs(t *testing.T) {
 		},
 	}
 
-	csvs = getApprovedCSVs(t.Context(), policy, subscription, odfIP)
+	csvs = getApprovedCSVs(t.Context(), policy, subscription, odfIP, nil)
 	if len(csvs) != 0 {
 		t.Fatalf("Expected no CSVs to be approved, but got: %s", strings.Join(csvs.UnsortedList(), ", "))
 	}
@@ -586,7 +736,7 @@ func TestGetApprovedCSVsWithPackageNameLookup(t *testing.T) {
 		},
 	}
 
-	csvs := getApprovedCSVs(t.Context(), policy, subscription, installPlan)
+	csvs := getApprovedCSVs(t.Context(), policy, subscription, installPlan, nil)
 
 	expectedCSVs := sets.Set[string]{}
 	expectedCSVs.Insert("mtc-operator.v1.8.9")
@@ -601,6 +751,260 @@ func TestGetApprovedCSVsWithPackageNameLookup(t *testing.T) {
 	}
 }
 
+func TestGetApprovedCSVsWithVersionRange(t *testing.T) {
+	odfIPRaw, err := os.ReadFile("../test/resources/unit/odf-installplan.yaml")
+	if err != nil {
+		t.Fatalf("Encountered an error when reading the odf-installplan.yaml: %v", err)
+	}
+
+	odfIP := &operatorv1alpha1.InstallPlan{}
+
+	err = yaml.Unmarshal(odfIPRaw, odfIP)
+	if err != nil {
+		t.Fatalf("Encountered an error when umarshaling the odf-installplan.yaml: %v", err)
+	}
+
+	subscription := &operatorv1alpha1.Subscription{
+		Spec: &operatorv1alpha1.SubscriptionSpec{},
+		Status: operatorv1alpha1.SubscriptionStatus{
+			InstalledCSV: "odf-operator.v4.16.0-rhodf",
+			CurrentCSV:   "odf-operator.v4.16.3-rhodf",
+		},
+	}
+
+	bundleVersions := installPlanBundleVersions(odfIP)
+	assert.Equal(t, "4.16.3-rhodf", bundleVersions["odf-operator.v4.16.3-rhodf"])
+
+	bundleVersion := func(csvName string) string { return bundleVersions[csvName] }
+
+	expectedCSVs := sets.New(odfIP.Spec.ClusterServiceVersionNames...)
+
+	tests := map[string]struct {
+		versionRange string
+		approved     bool
+	}{
+		"minor range":         {">=4.16.0 <4.17.0", true},
+		"tilde range":         {"~4.16", true},
+		"max version":         {"<=4.16.2", false},
+		"range of old minors": {">=4.14.0 <4.16.0", false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			policy := &policyv1beta1.OperatorPolicy{
+				Spec: policyv1beta1.OperatorPolicySpec{
+					UpgradeApproval:   "Automatic",
+					RemediationAction: "enforce",
+					VersionRange:      test.versionRange,
+				},
+			}
+
+			csvs := getApprovedCSVs(t.Context(), policy, subscription, odfIP, bundleVersion)
+
+			if test.approved {
+				assert.True(t, csvs.Equal(expectedCSVs), "Expected all CSVs to be approved, got %v", csvs)
+			} else {
+				assert.Empty(t, csvs)
+			}
+		})
+	}
+}
+
+func TestDisallowedDependencies(t *testing.T) {
+	odfIPRaw, err := os.ReadFile("../test/resources/unit/odf-installplan.yaml")
+	if err != nil {
+		t.Fatalf("Encountered an error when reading the odf-installplan.yaml: %v", err)
+	}
+
+	odfIP := &operatorv1alpha1.InstallPlan{}
+
+	err = yaml.Unmarshal(odfIPRaw, odfIP)
+	if err != nil {
+		t.Fatalf("Encountered an error when umarshaling the odf-installplan.yaml: %v", err)
+	}
+
+	allDependencies := []policyv1beta1.AllowedDependency{
+		{Package: "mcg-operator"},
+		{Package: "ocs-client-operator"},
+		{Package: "ocs-operator"},
+		{Package: "odf-csi-addons-operator"},
+		{Package: "odf-prometheus-operator"},
+		{Package: "recipe"},
+		{Package: "rook-ceph-operator"},
+	}
+
+	tests := map[string]struct {
+		allowed  []policyv1beta1.AllowedDependency
+		expected []string
+	}{
+		"no allowlist": {nil, nil},
+		"all allowed":  {allDependencies, []string{}},
+		"missing packages": {
+			allDependencies[2:],
+			[]string{
+				"mcg-operator (mcg-operator.v4.16.3-rhodf)",
+				"ocs-client-operator (ocs-client-operator.v4.16.3-rhodf)",
+			},
+		},
+		"version out of range": {
+			append([]policyv1beta1.AllowedDependency{{Package: "recipe", VersionRange: "<4.16.0"}},
+				allDependencies[:5]...),
+			[]string{"recipe (recipe.v4.16.3-rhodf)", "rook-ceph-operator (rook-ceph-operator.v4.16.3-rhodf)"},
+		},
+		"version in range": {
+			append([]policyv1beta1.AllowedDependency{{Package: "rook-ceph-operator", VersionRange: "~4.16"}},
+				allDependencies[:6]...),
+			[]string{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			policy := &policyv1beta1.OperatorPolicy{
+				Spec: policyv1beta1.OperatorPolicySpec{AllowedDependencies: test.allowed},
+			}
+
+			disallowed := disallowedDependencies(t.Context(), policy, odfIP, "odf-operator.v4.16.3-rhodf")
+			assert.Equal(t, test.expected, disallowed)
+		})
+	}
+}
+
+func TestValidateAllowedDependencies(t *testing.T) {
+	t.Parallel()
+
+	policy := &policyv1beta1.OperatorPolicy{
+		Spec: policyv1beta1.OperatorPolicySpec{
+			AllowedDependencies: []policyv1beta1.AllowedDependency{
+				{Package: "mcg-operator"},
+				{Package: "recipe", VersionRange: "~4.16"},
+			},
+		},
+	}
+
+	assert.NoError(t, validateAllowedDependencies(policy))
+
+	policy.Spec.AllowedDependencies[1].VersionRange = "four"
+
+	assert.ErrorContains(t, validateAllowedDependencies(policy),
+		`the versionRange "four" of the allowed dependency recipe is invalid`)
+}
+
+func TestInstallPlanDisallowedDepsCond(t *testing.T) {
+	t.Parallel()
+
+	cond := installPlanDisallowedDepsCond([]string{"odf-operator.v4.16.3-rhodf"}, []string{"recipe (recipe.v4.16.3)"})
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, "DependenciesNotAllowed", cond.Reason)
+	assert.True(t, strings.HasSuffix(cond.Message,
+		"but it can't be approved because the dependencies [recipe (recipe.v4.16.3)] are not allowed by "+
+			"spec.allowedDependencies"), cond.Message)
+
+	ip := &operatorv1alpha1.InstallPlan{}
+	ip.SetName("install-abcde")
+	ip.SetNamespace("openshift-storage")
+
+	relObj := disallowedDepsInstallPlanObj(ip, "RequiresApproval", []string{"recipe (recipe.v4.16.3)"})
+	assert.Equal(t, "NonCompliant", relObj.Compliant)
+	assert.True(t, strings.HasSuffix(relObj.Reason, "but the dependencies [recipe (recipe.v4.16.3)] are not allowed"),
+		relObj.Reason)
+}
+
+func TestCSVAllowed(t *testing.T) {
+	t.Parallel()
+
+	sub := &operatorv1alpha1.Subscription{
+		Spec: &operatorv1alpha1.SubscriptionSpec{StartingCSV: "example.v1.10.0"},
+	}
+
+	tests := map[string]struct {
+		versions     []string
+		versionRange string
+		csvName      string
+		version      string
+		expected     bool
+	}{
+		"no restrictions":           {nil, "", "example.v2.0.0", "2.0.0", true},
+		"exact name":                {[]string{"example.v1.13.1"}, "", "example.v1.13.1", "1.13.1", true},
+		"starting CSV":              {[]string{"example.v1.13.1"}, "", "example.v1.10.0", "1.10.0", true},
+		"name not in versions":      {[]string{"example.v1.13.1"}, "", "example.v1.13.2", "1.13.2", false},
+		"in range":                  {nil, ">=1.12.0 <1.14.0", "example.v1.13.2", "1.13.2", true},
+		"above range":               {nil, ">=1.12.0 <1.14.0", "example.v1.14.0", "1.14.0", false},
+		"below max version":         {nil, "<=1.14.2", "example.v1.14.2", "1.14.2", true},
+		"in range with pre-release": {nil, "~2.3", "example.v2.3.4-build", "2.3.4-build", true},
+		"unknown version":           {nil, "~2.3", "example.v2.3.4", "", false},
+		"invalid version":           {nil, "~2.3", "example.v2.3.4", "latest", false},
+		"name in versions out of range": {
+			[]string{"example.v3.0.0"}, "~2.3", "example.v3.0.0", "3.0.0", true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			policy := &policyv1beta1.OperatorPolicy{
+				Spec: policyv1beta1.OperatorPolicySpec{
+					Versions:     test.versions,
+					VersionRange: test.versionRange,
+				},
+			}
+
+			assert.Equal(t, test.expected, csvAllowed(policy, sub, test.csvName, test.version))
+		})
+	}
+}
+
+func TestCSVAllowedBlocked(t *testing.T) {
+	t.Parallel()
+
+	sub := &operatorv1alpha1.Subscription{
+		Spec: &operatorv1alpha1.SubscriptionSpec{StartingCSV: "example.v1.10.0"},
+	}
+	policy := &policyv1beta1.OperatorPolicy{
+		Status: policyv1beta1.OperatorPolicyStatus{BlockedVersions: []string{"example.v1.10.0"}},
+	}
+
+	// A blocked version is not allowed, even as the starting CSV
+	assert.False(t, csvAllowed(policy, sub, "example.v1.10.0", "1.10.0"))
+	assert.True(t, csvAllowed(policy, sub, "example.v1.11.0", "1.11.0"))
+}
+
+func TestPackageManifestBundleVersions(t *testing.T) {
+	t.Parallel()
+
+	packageManifest := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"channels": []interface{}{
+				map[string]interface{}{
+					"name": "stable",
+					"entries": []interface{}{
+						map[string]interface{}{"name": "example.v1.2.0", "version": "1.2.0"},
+						map[string]interface{}{"name": "example.v1.1.0", "version": "1.1.0"},
+					},
+				},
+				map[string]interface{}{
+					"name": "candidate",
+					"entries": []interface{}{
+						map[string]interface{}{"name": "example.v1.3.0-rc1", "version": "1.3.0-rc1"},
+						map[string]interface{}{"name": "example.v0.1.0"},
+					},
+				},
+			},
+		},
+	}}
+
+	assert.Equal(t, map[string]string{
+		"example.v1.2.0":     "1.2.0",
+		"example.v1.1.0":     "1.1.0",
+		"example.v1.3.0-rc1": "1.3.0-rc1",
+	}, packageManifestBundleVersions(packageManifest))
+}
+
 func TestGetDependencyCSVs(t *testing.T) {
 	happyPackageToCSV := map[string]string{
 		"starting-operator":          "starting-operator.v7.8.9",
@@ -0,0 +1,267 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	operatorv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	policyv1beta1 "open-cluster-management.io/config-policy-controller/api/v1beta1"
+)
+
+// defaultPodRestartThreshold is the number of container restarts at which an operator pod is considered
+// unhealthy when spec.complianceConfig.podRestartThreshold is not set.
+const defaultPodRestartThreshold int32 = 5
+
+var (
+	podGVK = schema.GroupVersionKind{
+		Group:   "",
+		Version: "v1",
+		Kind:    "Pod",
+	}
+	apiServiceGVK = schema.GroupVersionKind{
+		Group:   "apiregistration.k8s.io",
+		Version: "v1",
+		Kind:    "APIService",
+	}
+)
+
+// handleWorkloadHealth runs the opt-in health checks of spec.complianceConfig on the workloads of the
+// operator: the pods of its Deployments, the requirements of its ClusterServiceVersion, and the webhooks
+// and APIServices that the ClusterServiceVersion owns. The condition of a check that is not enabled, or
+// that doesn't apply because the policy is mustnothave, is removed from the status.
+func (r *OperatorPolicyReconciler) handleWorkloadHealth(
+	ctx context.Context,
+	policy *policyv1beta1.OperatorPolicy,
+	csv *operatorv1alpha1.ClusterServiceVersion,
+) (bool, error) {
+	ctx, span := startOperatorPolicySpan(ctx, "handleWorkloadHealth", policy)
+	defer span.End()
+
+	config := policy.Spec.ComplianceConfig
+	mustHave := policy.Spec.ComplianceType.IsMustHave()
+	changed := false
+
+	if config.PodsUnhealthy == "" || !mustHave {
+		changed = removeStatusCondition(policy, podsConditionType) || changed
+	} else {
+		podsChanged, err := r.handlePods(ctx, policy, csv)
+		changed = podsChanged || changed
+
+		if err != nil {
+			return changed, err
+		}
+	}
+
+	switch {
+	case config.CSVRequirementsNotMet == "" || !mustHave:
+		changed = removeStatusCondition(policy, csvReqConditionType) || changed
+	case csv == nil:
+		changed = updateStatus(policy, noCSVHealthCond(csvReqConditionType, "requirements")) || changed
+	default:
+		changed = updateStatus(policy, csvRequirementsCond(unmetCSVRequirements(csv))) || changed
+	}
+
+	if config.OwnedAPIsUnavailable == "" || !mustHave {
+		return removeStatusCondition(policy, ownedAPIsConditionType) || changed, nil
+	}
+
+	if csv == nil {
+		return updateStatus(policy, noCSVHealthCond(ownedAPIsConditionType, "webhooks or APIServices")) || changed, nil
+	}
+
+	owned := len(csv.Spec.WebhookDefinitions) != 0 || len(csv.Spec.APIServiceDefinitions.Owned) != 0
+
+	unavailable, err := r.unavailableOwnedAPIs(policy, csv)
+	if err != nil {
+		return changed, err
+	}
+
+	return updateStatus(policy, ownedAPIsCond(owned, unavailable)) || changed, nil
+}
+
+// handlePods checks the pods of the Deployments in the ClusterServiceVersion and sets the PodsHealthy
+// condition.
+func (r *OperatorPolicyReconciler) handlePods(
+	ctx context.Context,
+	policy *policyv1beta1.OperatorPolicy,
+	csv *operatorv1alpha1.ClusterServiceVersion,
+) (bool, error) {
+	if csv == nil {
+		return updateStatus(policy, noCSVHealthCond(podsConditionType, "pods")), nil
+	}
+
+	opLog := ctrl.LoggerFrom(ctx)
+	watcher := opPolIdentifier(policy.Namespace, policy.Name)
+	pods := []corev1.Pod{}
+
+	for _, dep := range csv.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		if dep.Spec.Selector == nil {
+			continue
+		}
+
+		selector, err := metav1.LabelSelectorAsSelector(dep.Spec.Selector)
+		if err != nil {
+			opLog.Error(err, "Unable to parse the Deployment selector", "Deployment.Name", dep.Name)
+
+			continue
+		}
+
+		foundPods, err := r.target(policy).DynamicWatcher.List(watcher, podGVK, csv.Namespace, selector)
+		if err != nil {
+			return false, fmt.Errorf("error listing the pods of the Deployment: %w", err)
+		}
+
+		for i := range foundPods {
+			var pod corev1.Pod
+
+			err := runtime.DefaultUnstructuredConverter.FromUnstructured(foundPods[i].Object, &pod)
+			if err != nil {
+				opLog.Error(err, "Unable to convert unstructured Pod to typed", "Pod.Name", foundPods[i].GetName())
+
+				continue
+			}
+
+			pods = append(pods, pod)
+		}
+	}
+
+	threshold := policy.Spec.ComplianceConfig.PodRestartThreshold
+	if threshold <= 0 {
+		threshold = defaultPodRestartThreshold
+	}
+
+	return updateStatus(policy, podsHealthyCond(len(pods) != 0, unhealthyPods(pods, threshold))), nil
+}
+
+// unhealthyPods returns the sorted names of the pods with a container in CrashLoopBackOff or with a
+// container that restarted at least `threshold` times, along with why the pod is unhealthy.
+func unhealthyPods(pods []corev1.Pod, threshold int32) []string {
+	unhealthy := []string{}
+
+	for _, pod := range pods {
+		statuses := slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses)
+		crashLooping := false
+		restarts := int32(0)
+
+		for _, status := range statuses {
+			if status.State.Waiting != nil && status.State.Waiting.Reason == "CrashLoopBackOff" {
+				crashLooping = true
+			}
+
+			restarts = max(restarts, status.RestartCount)
+		}
+
+		switch {
+		case crashLooping:
+			unhealthy = append(unhealthy, pod.Name+" (CrashLoopBackOff)")
+		case restarts >= threshold:
+			unhealthy = append(unhealthy, fmt.Sprintf("%s (%d restarts)", pod.Name, restarts))
+		}
+	}
+
+	slices.Sort(unhealthy)
+
+	return unhealthy
+}
+
+// unmetCSVRequirements returns the entries of the ClusterServiceVersion status.requirementStatus that are
+// not present, like `ServiceAccount my-operator (NotPresent)`.
+func unmetCSVRequirements(csv *operatorv1alpha1.ClusterServiceVersion) []string {
+	unmet := []string{}
+
+	for _, req := range csv.Status.RequirementStatus {
+		if req.Status == operatorv1alpha1.RequirementStatusReasonPresent {
+			continue
+		}
+
+		unmet = append(unmet, fmt.Sprintf("%s %s (%s)", req.Kind, req.Name, req.Status))
+	}
+
+	slices.Sort(unmet)
+
+	return unmet
+}
+
+// unavailableOwnedAPIs returns the webhooks and APIServices owned by the ClusterServiceVersion that are
+// unavailable. An APIService is unavailable when its Available condition is not True, and a webhook is
+// unavailable when the Deployment serving it has no available replicas.
+func (r *OperatorPolicyReconciler) unavailableOwnedAPIs(
+	policy *policyv1beta1.OperatorPolicy,
+	csv *operatorv1alpha1.ClusterServiceVersion,
+) ([]string, error) {
+	watcher := opPolIdentifier(policy.Namespace, policy.Name)
+	target := r.target(policy)
+	unavailable := []string{}
+
+	for _, desc := range csv.Spec.APIServiceDefinitions.Owned {
+		name := desc.Version + "." + desc.Group
+
+		apiService, err := target.DynamicWatcher.Get(watcher, apiServiceGVK, "", name)
+		if err != nil {
+			return nil, fmt.Errorf("error getting the APIService: %w", err)
+		}
+
+		if !apiServiceAvailable(apiService) {
+			unavailable = append(unavailable, "APIService "+name)
+		}
+	}
+
+	for _, webhook := range csv.Spec.WebhookDefinitions {
+		dep, err := target.DynamicWatcher.Get(watcher, deploymentGVK, csv.Namespace, webhook.DeploymentName)
+		if err != nil {
+			return nil, fmt.Errorf("error getting the Deployment of the webhook: %w", err)
+		}
+
+		if !webhookDeploymentAvailable(dep) {
+			unavailable = append(unavailable, "webhook "+webhook.GenerateName)
+		}
+	}
+
+	slices.Sort(unavailable)
+
+	return unavailable, nil
+}
+
+// apiServiceAvailable returns whether the APIService exists and has a True Available condition.
+func apiServiceAvailable(apiService *unstructured.Unstructured) bool {
+	if apiService == nil {
+		return false
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(apiService.Object, "status", "conditions")
+
+	for _, condition := range conditions {
+		condMap, ok := condition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if condMap["type"] == "Available" {
+			return condMap["status"] == string(metav1.ConditionTrue)
+		}
+	}
+
+	return false
+}
+
+// webhookDeploymentAvailable returns whether the Deployment serving a webhook exists and has an available
+// replica.
+func webhookDeploymentAvailable(dep *unstructured.Unstructured) bool {
+	if dep == nil {
+		return false
+	}
+
+	available, _, _ := unstructured.NestedInt64(dep.Object, "status", "availableReplicas")
+
+	return available > 0
+}
@@ -0,0 +1,213 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"testing"
+
+	operatorv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	policyv1beta1 "open-cluster-management.io/config-policy-controller/api/v1beta1"
+)
+
+func TestUnhealthyPods(t *testing.T) {
+	t.Parallel()
+
+	pods := []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "operator-healthy"},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{RestartCount: 1}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "operator-restarting"},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{RestartCount: 2}, {RestartCount: 7}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "operator-crashing"},
+			Status: corev1.PodStatus{
+				InitContainerStatuses: []corev1.ContainerStatus{{
+					RestartCount: 1,
+					State: corev1.ContainerState{
+						Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+					},
+				}},
+			},
+		},
+	}
+
+	assert.Equal(t,
+		[]string{"operator-crashing (CrashLoopBackOff)", "operator-restarting (7 restarts)"},
+		unhealthyPods(pods, defaultPodRestartThreshold),
+	)
+	assert.Equal(t, []string{"operator-crashing (CrashLoopBackOff)"}, unhealthyPods(pods, 10))
+}
+
+func TestUnmetCSVRequirements(t *testing.T) {
+	t.Parallel()
+
+	csv := &operatorv1alpha1.ClusterServiceVersion{
+		Status: operatorv1alpha1.ClusterServiceVersionStatus{
+			RequirementStatus: []operatorv1alpha1.RequirementStatus{
+				{Kind: "ServiceAccount", Name: "my-operator", Status: operatorv1alpha1.RequirementStatusReasonPresent},
+				{
+					Kind: "CustomResourceDefinition", Name: "widgets.example.com",
+					Status: operatorv1alpha1.RequirementStatusReasonNotPresent,
+				},
+				{
+					Kind: "ClusterRole", Name: "my-operator",
+					Status: operatorv1alpha1.RequirementStatusReasonPresentNotSatisfied,
+				},
+			},
+		},
+	}
+
+	assert.Equal(t,
+		[]string{
+			"ClusterRole my-operator (PresentNotSatisfied)",
+			"CustomResourceDefinition widgets.example.com (NotPresent)",
+		},
+		unmetCSVRequirements(csv),
+	)
+}
+
+func TestOwnedAPIAvailability(t *testing.T) {
+	t.Parallel()
+
+	apiService := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Available", "status": "False", "reason": "MissingEndpoints"},
+			},
+		},
+	}}
+
+	assert.False(t, apiServiceAvailable(nil))
+	assert.False(t, apiServiceAvailable(apiService))
+
+	_ = unstructured.SetNestedSlice(apiService.Object, []interface{}{
+		map[string]interface{}{"type": "Available", "status": "True", "reason": "Passed"},
+	}, "status", "conditions")
+
+	assert.True(t, apiServiceAvailable(apiService))
+
+	dep := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{"replicas": int64(1), "unavailableReplicas": int64(1)},
+	}}
+
+	assert.False(t, webhookDeploymentAvailable(nil))
+	assert.False(t, webhookDeploymentAvailable(dep))
+
+	_ = unstructured.SetNestedField(dep.Object, int64(1), "status", "availableReplicas")
+
+	assert.True(t, webhookDeploymentAvailable(dep))
+}
+
+func TestHandleWorkloadHealth(t *testing.T) {
+	t.Parallel()
+
+	r := &OperatorPolicyReconciler{}
+
+	policy := &policyv1beta1.OperatorPolicy{
+		Spec: policyv1beta1.OperatorPolicySpec{
+			ComplianceType: "musthave",
+			ComplianceConfig: policyv1beta1.ComplianceConfig{
+				PodsUnhealthy:         "Compliant",
+				CSVRequirementsNotMet: "NonCompliant",
+				OwnedAPIsUnavailable:  "NonCompliant",
+			},
+		},
+	}
+
+	csv := &operatorv1alpha1.ClusterServiceVersion{
+		Status: operatorv1alpha1.ClusterServiceVersionStatus{
+			RequirementStatus: []operatorv1alpha1.RequirementStatus{{
+				Kind: "ServiceAccount", Name: "my-operator", Status: operatorv1alpha1.RequirementStatusReasonNotPresent,
+			}},
+		},
+	}
+
+	changed, err := r.handleWorkloadHealth(t.Context(), policy, csv)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+
+	_, cond := policy.Status.GetCondition(podsConditionType)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Equal(t, "no existing operator pods", cond.Message)
+
+	_, cond = policy.Status.GetCondition(csvReqConditionType)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, "RequirementsNotMet", cond.Reason)
+	assert.Equal(t,
+		"the ClusterServiceVersion requirements ServiceAccount my-operator (NotPresent) are not met", cond.Message,
+	)
+
+	_, cond = policy.Status.GetCondition(ownedAPIsConditionType)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Equal(t, "the ClusterServiceVersion does not own any webhooks or APIServices", cond.Message)
+
+	assert.Equal(t,
+		[]string{csvReqConditionType, ownedAPIsConditionType}, workloadHealthConditionTypes(policy),
+	)
+
+	policy.Spec.ComplianceType = "mustnothave"
+
+	changed, err = r.handleWorkloadHealth(t.Context(), policy, csv)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+
+	for _, condType := range []string{podsConditionType, csvReqConditionType, ownedAPIsConditionType} {
+		idx, _ := policy.Status.GetCondition(condType)
+		assert.Equal(t, -1, idx, "expected the %s condition to be removed", condType)
+	}
+
+	assert.Empty(t, workloadHealthConditionTypes(policy))
+}
+
+func TestCalculateComplianceConditionWorkloadHealth(t *testing.T) {
+	t.Parallel()
+
+	policy := &policyv1beta1.OperatorPolicy{
+		Spec: policyv1beta1.OperatorPolicySpec{
+			ComplianceType: "musthave",
+			ComplianceConfig: policyv1beta1.ComplianceConfig{
+				PodsUnhealthy:        "Compliant",
+				OwnedAPIsUnavailable: "NonCompliant",
+			},
+		},
+	}
+
+	updateStatus(policy, validationCond(nil))
+	updateStatus(policy, opGroupPreexistingCond)
+	updateStatus(policy, createdCond("Subscription"))
+	updateStatus(policy, noInstallPlansCond)
+	updateStatus(policy, allowedCSVCond(&operatorv1alpha1.ClusterServiceVersion{
+		TypeMeta:   metav1.TypeMeta{Kind: "ClusterServiceVersion"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-operator.v1.0.0"},
+		Status:     operatorv1alpha1.ClusterServiceVersionStatus{Phase: operatorv1alpha1.CSVPhaseSucceeded},
+	}))
+	updateStatus(policy, crdFoundCond)
+	updateStatus(policy, buildDeploymentCond("NonCompliant", true, nil))
+	updateStatus(policy, catalogSrcCond(createdCond("CatalogSource")))
+	updateStatus(policy, podsHealthyCond(true, []string{"operator-abcde (CrashLoopBackOff)"}))
+	updateStatus(policy, ownedAPIsCond(true, nil))
+
+	cond := calculateComplianceCondition(policy)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status, cond.Message)
+	assert.Contains(t, cond.Message, "the webhooks and APIServices owned by the ClusterServiceVersion are available")
+	assert.NotContains(t, cond.Message, "operator-abcde")
+
+	updateStatus(policy, ownedAPIsCond(true, []string{"APIService v1beta1.metrics.example.com"}))
+
+	cond = calculateComplianceCondition(policy)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Contains(t, cond.Message,
+		"the APIService v1beta1.metrics.example.com owned by the ClusterServiceVersion are unavailable")
+}
@@ -0,0 +1,443 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	operatorv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	templates "github.com/stolostron/go-template-utils/v7/pkg/templates"
+	depclient "github.com/stolostron/kubernetes-dependency-watches/client"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+	policyv1beta1 "open-cluster-management.io/config-policy-controller/api/v1beta1"
+)
+
+// olmRelatedKinds are the kinds of the related objects that OperatorPolicy reports for the operator itself. Operands
+// can't be of these kinds so that their related objects can be told apart.
+var olmRelatedKinds = map[string]bool{
+	operatorGroupGVK.Kind:            true,
+	subscriptionGVK.Kind:             true,
+	installPlanGVK.Kind:              true,
+	clusterServiceVersionGVK.Kind:    true,
+	customResourceDefinitionGVK.Kind: true,
+	deploymentGVK.Kind:               true,
+	catalogSrcGVK.Kind:               true,
+}
+
+// resolveOperandsTemplates resolves the templates in the spec.operands object definitions of the policy in place.
+func resolveOperandsTemplates(
+	policy *policyv1beta1.OperatorPolicy, tmplResolver *templates.TemplateResolver,
+) error {
+	if tmplResolver == nil {
+		return nil
+	}
+
+	watcher := opPolIdentifier(policy.Namespace, policy.Name)
+
+	for i, operand := range policy.Spec.Operands {
+		if !templates.HasTemplate(operand.ObjectDefinition.Raw, "", false) {
+			continue
+		}
+
+		resolvedTmpl, err := tmplResolver.ResolveTemplate(
+			operand.ObjectDefinition.Raw, nil, &templates.ResolveOptions{Watcher: &watcher},
+		)
+		if err != nil {
+			return fmt.Errorf("could not resolve the spec.operands[%d] template: %w", i, err)
+		}
+
+		policy.Spec.Operands[i].ObjectDefinition.Raw = resolvedTmpl.ResolvedJSON
+	}
+
+	return nil
+}
+
+// buildOperands parses the spec.operands object definitions of the policy. If an error is returned, it includes
+// details on why the operand is invalid.
+func buildOperands(policy *policyv1beta1.OperatorPolicy) ([]*unstructured.Unstructured, error) {
+	operands := make([]*unstructured.Unstructured, 0, len(policy.Spec.Operands))
+
+	for i, operand := range policy.Spec.Operands {
+		obj := &unstructured.Unstructured{}
+
+		if err := json.Unmarshal(operand.ObjectDefinition.Raw, &obj.Object); err != nil {
+			return nil, fmt.Errorf("the spec.operands[%d].objectDefinition is invalid: %w", i, err)
+		}
+
+		if obj.GetAPIVersion() == "" || obj.GetKind() == "" {
+			return nil, fmt.Errorf("apiVersion and kind are required in spec.operands[%d].objectDefinition", i)
+		}
+
+		if obj.GetName() == "" {
+			return nil, fmt.Errorf("metadata.name is required in spec.operands[%d].objectDefinition", i)
+		}
+
+		if olmRelatedKinds[obj.GetKind()] {
+			return nil, fmt.Errorf(
+				"the spec.operands[%d].objectDefinition kind %s is managed by the operator installation",
+				i, obj.GetKind(),
+			)
+		}
+
+		operands = append(operands, obj)
+	}
+
+	return operands, nil
+}
+
+// operandCRDName returns the name of the CustomResourceDefinition owned by the ClusterServiceVersion for the operand,
+// or an empty string if the ClusterServiceVersion doesn't own it.
+func operandCRDName(csv *operatorv1alpha1.ClusterServiceVersion, operand *unstructured.Unstructured) string {
+	gvk := operand.GroupVersionKind()
+
+	for _, owned := range csv.Spec.CustomResourceDefinitions.Owned {
+		if owned.Kind == gvk.Kind && strings.HasSuffix(owned.Name, "."+gvk.Group) {
+			return owned.Name
+		}
+	}
+
+	return ""
+}
+
+// crdEstablished returns whether the CustomResourceDefinition has the Established condition set to True.
+func crdEstablished(crd *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(crd.Object, "status", "conditions")
+
+	for _, condition := range conditions {
+		condMap, ok := condition.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		if condMap["type"] == "Established" && condMap["status"] == "True" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// handleOperands creates or updates the operands of the policy once the operator is installed, and reports them in
+// the OperandsCompliant condition and related objects. It returns whether the status changed.
+func (r *OperatorPolicyReconciler) handleOperands(
+	ctx context.Context,
+	policy *policyv1beta1.OperatorPolicy,
+	sub *operatorv1alpha1.Subscription,
+	csv *operatorv1alpha1.ClusterServiceVersion,
+) (changed bool, err error) {
+	ctx, span := startOperatorPolicySpan(ctx, "handleOperands", policy)
+	defer func() { endSpan(span, err) }()
+
+	if policy.Spec.ComplianceType.IsMustNotHave() {
+		// The operands are handled by removeOperands before the operator is removed
+		return false, nil
+	}
+
+	if policy.Status.OperandDeletionDeadline != nil {
+		policy.Status.OperandDeletionDeadline = nil
+		changed = true
+	}
+
+	if len(policy.Spec.Operands) == 0 || !policy.Spec.ComplianceType.IsMustHave() {
+		return updateOperandsStatus(policy, nil) || changed, nil
+	}
+
+	if sub == nil || csv == nil || csv.Status.Phase != operatorv1alpha1.CSVPhaseSucceeded {
+		cond := operandsPendingCond("the ClusterServiceVersion has succeeded")
+
+		return updateOperandsStatus(policy, &cond) || changed, nil
+	}
+
+	operands, err := buildOperands(policy)
+	if err != nil {
+		// The operands are validated when building the resources, so this is not expected
+		return updateOperandsStatus(policy, nil) || changed, err
+	}
+
+	opLog := ctrl.LoggerFrom(ctx)
+	target := r.target(policy)
+	watcher := opPolIdentifier(policy.Namespace, policy.Name)
+
+	relatedObjects := make([]policyv1.RelatedObject, 0, len(operands))
+	missing := make([]string, 0)
+	mismatched := make([]string, 0)
+
+	for i, operand := range operands {
+		if crdName := operandCRDName(csv, operand); crdName != "" {
+			crd, err := target.DynamicWatcher.Get(watcher, customResourceDefinitionGVK, "", crdName)
+			if err != nil {
+				return false, fmt.Errorf("error getting the CustomResourceDefinition %s: %w", crdName, err)
+			}
+
+			if crd == nil || !crdEstablished(crd) {
+				cond := operandsPendingCond("the CustomResourceDefinition " + crdName + " is established")
+
+				return updateOperandsStatus(policy, &cond) || changed, nil
+			}
+		}
+
+		gvk := operand.GroupVersionKind()
+
+		scopedGVR, err := target.DynamicWatcher.GVKToGVR(gvk)
+		if err != nil {
+			if errors.Is(err, depclient.ErrNoVersionedResource) {
+				cond := operandsPendingCond("the " + gvk.Kind + " API is available")
+
+				return updateOperandsStatus(policy, &cond) || changed, nil
+			}
+
+			return false, fmt.Errorf("error getting the resource mapping for %s: %w", gvk, err)
+		}
+
+		if !scopedGVR.Namespaced {
+			operand.SetNamespace("")
+		} else if operand.GetNamespace() == "" {
+			operand.SetNamespace(sub.Namespace)
+		}
+
+		compType := policy.Spec.Operands[i].ComplianceType
+		if compType == "" {
+			compType = policyv1.MustHave
+		}
+
+		foundOperand, err := target.DynamicWatcher.Get(watcher, gvk, operand.GetNamespace(), operand.GetName())
+		if err != nil {
+			return false, fmt.Errorf("error getting the %s operand: %w", gvk.Kind, err)
+		}
+
+		if foundOperand == nil {
+			if policy.Spec.RemediationAction.IsInform() {
+				missing = append(missing, operandIdentifier(operand))
+				relatedObjects = append(relatedObjects, missingWantedObj(operand))
+
+				continue
+			}
+
+			opLog.Info("Creating the operand", "kind", gvk.Kind, "name", operand.GetName(),
+				"namespace", operand.GetNamespace())
+
+			err := target.Client.Create(ctx, operand)
+			if err != nil {
+				return false, fmt.Errorf("error creating the %s operand: %w", gvk.Kind, err)
+			}
+
+			operand.SetGroupVersionKind(gvk) // Create stripped this information
+			r.recordEnforced(ctx, policy, "created", nil, operand)
+
+			relatedObjects = append(relatedObjects, createdObj(operand))
+
+			continue
+		}
+
+		existing := foundOperand.DeepCopy()
+
+		updateNeeded, updateIsForbidden, err := r.mergeObjects(
+			ctx, target.Client, operand.Object, existing, compType,
+		)
+		if err != nil {
+			return false, fmt.Errorf("error checking if the %s operand needs an update: %w", gvk.Kind, err)
+		}
+
+		if !updateNeeded {
+			relatedObjects = append(relatedObjects, matchedObj(foundOperand))
+
+			continue
+		}
+
+		if policy.Spec.RemediationAction.IsInform() || updateIsForbidden {
+			mismatched = append(mismatched, operandIdentifier(operand))
+			relatedObjects = append(relatedObjects, mismatchedObj(foundOperand))
+
+			continue
+		}
+
+		opLog.Info("Updating the operand to match the desired state", "kind", gvk.Kind,
+			"name", existing.GetName(), "namespace", existing.GetNamespace())
+
+		err = target.Client.Update(ctx, existing)
+		if err != nil {
+			return false, fmt.Errorf("error updating the %s operand: %w", gvk.Kind, err)
+		}
+
+		existing.SetGroupVersionKind(gvk) // Update stripped this information
+		r.recordEnforced(ctx, policy, "updated", foundOperand, existing)
+
+		relatedObjects = append(relatedObjects, updatedObj(existing))
+	}
+
+	cond := operandsCond(missing, mismatched)
+
+	return updateOperandsStatus(policy, &cond, relatedObjects...) || changed, nil
+}
+
+// operandIdentifier returns a description of the operand like "Kind namespace/name" for the condition messages.
+func operandIdentifier(operand *unstructured.Unstructured) string {
+	if operand.GetNamespace() == "" {
+		return operand.GetKind() + " " + operand.GetName()
+	}
+
+	return operand.GetKind() + " " + operand.GetNamespace() + "/" + operand.GetName()
+}
+
+// operandDeletionTimeout parses the spec.removalBehavior.operandDeletionTimeout of the policy.
+func operandDeletionTimeout(policy *policyv1beta1.OperatorPolicy) (time.Duration, error) {
+	rawTimeout := policy.Spec.RemovalBehavior.ApplyDefaults().OperandDeletionTimeout
+
+	timeout, err := time.ParseDuration(rawTimeout)
+	if err != nil || timeout < 0 {
+		return 0, errors.New(
+			"spec.removalBehavior.operandDeletionTimeout must be a non-negative duration: " + rawTimeout,
+		)
+	}
+
+	return timeout, nil
+}
+
+// crdStorageGVK returns the group, storage version, and kind of the custom resources of the
+// CustomResourceDefinition. It returns false if the CustomResourceDefinition has no storage version.
+func crdStorageGVK(crd *unstructured.Unstructured) (schema.GroupVersionKind, bool) {
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+
+	for _, version := range versions {
+		versionMap, ok := version.(map[string]any)
+		if !ok || versionMap["storage"] != true {
+			continue
+		}
+
+		name, _ := versionMap["name"].(string)
+		if name == "" || kind == "" {
+			return schema.GroupVersionKind{}, false
+		}
+
+		return schema.GroupVersionKind{Group: group, Version: name, Kind: kind}, true
+	}
+
+	return schema.GroupVersionKind{}, false
+}
+
+// removeOperands deletes all of the custom resources of the CustomResourceDefinitions of the operator when the
+// policy is `mustnothave` with the `operands: DeleteAll` removal behavior. Until they are gone or the
+// operandDeletionTimeout elapses, the status OperandDeletionDeadline is set so that the Subscription,
+// ClusterServiceVersion, and CustomResourceDefinitions are not removed, which keeps the operator running to handle
+// the finalizers of the operands. It returns whether the status changed.
+func (r *OperatorPolicyReconciler) removeOperands(
+	ctx context.Context,
+	policy *policyv1beta1.OperatorPolicy,
+	sub *operatorv1alpha1.Subscription,
+) (changed bool, err error) {
+	ctx, span := startOperatorPolicySpan(ctx, "removeOperands", policy)
+	defer func() { endSpan(span, err) }()
+
+	if sub == nil || !policy.Spec.RemovalBehavior.ApplyDefaults().Operands.IsDeleteAll() {
+		if policy.Status.OperandDeletionDeadline != nil {
+			policy.Status.OperandDeletionDeadline = nil
+			changed = true
+		}
+
+		return updateOperandsStatus(policy, nil) || changed, nil
+	}
+
+	opLog := ctrl.LoggerFrom(ctx)
+	target := r.target(policy)
+	watcher := opPolIdentifier(policy.Namespace, policy.Name)
+
+	crdList, err := target.DynamicWatcher.List(
+		watcher, customResourceDefinitionGVK, sub.Namespace, subLabelSelector(sub),
+	)
+	if err != nil {
+		return false, fmt.Errorf("error listing CRDs: %w", err)
+	}
+
+	foundOperands := make([]unstructured.Unstructured, 0)
+
+	for i := range crdList {
+		gvk, ok := crdStorageGVK(&crdList[i])
+		if !ok || !crdEstablished(&crdList[i]) {
+			continue
+		}
+
+		operands, err := target.DynamicWatcher.List(watcher, gvk, "", labels.Everything())
+		if err != nil {
+			return false, fmt.Errorf("error listing the %s operands: %w", gvk.Kind, err)
+		}
+
+		foundOperands = append(foundOperands, operands...)
+	}
+
+	if len(foundOperands) == 0 {
+		if policy.Status.OperandDeletionDeadline != nil {
+			policy.Status.OperandDeletionDeadline = nil
+			changed = true
+		}
+
+		return updateOperandsStatus(policy, &noOperandsCond) || changed, nil
+	}
+
+	relatedObjects := make([]policyv1.RelatedObject, 0, len(foundOperands))
+	identifiers := make([]string, 0, len(foundOperands))
+
+	for i := range foundOperands {
+		relatedObjects = append(relatedObjects, foundNotWantedObj(&foundOperands[i]))
+		identifiers = append(identifiers, operandIdentifier(&foundOperands[i]))
+	}
+
+	if policy.Spec.RemediationAction.IsInform() {
+		cond := operandsFoundCond(identifiers)
+
+		return updateOperandsStatus(policy, &cond, relatedObjects...), nil
+	}
+
+	timeout, err := operandDeletionTimeout(policy)
+	if err != nil {
+		// The timeout is validated when building the resources, so this is not expected
+		return false, err
+	}
+
+	if policy.Status.OperandDeletionDeadline == nil {
+		deadline := metav1.NewTime(time.Now().Add(timeout))
+		policy.Status.OperandDeletionDeadline = &deadline
+		changed = true
+	}
+
+	for i := range foundOperands {
+		operand := &foundOperands[i]
+
+		if operand.GetDeletionTimestamp() == nil {
+			gvk := operand.GroupVersionKind()
+
+			opLog.Info("Deleting the operand", "kind", gvk.Kind, "name", operand.GetName(),
+				"namespace", operand.GetNamespace())
+
+			err := target.Client.Delete(ctx, operand)
+			if err != nil && !k8serrors.IsNotFound(err) {
+				return changed, fmt.Errorf("error deleting the %s operand: %w", gvk.Kind, err)
+			}
+
+			operand.SetGroupVersionKind(gvk) // Delete stripped this information
+			r.recordEnforced(ctx, policy, "deleted", operand, nil)
+		}
+
+		relatedObjects[i] = deletingObj(operand)
+	}
+
+	cond := operandsDeletingCond(identifiers)
+	if !policy.Status.OperandDeletionWaiting() {
+		cond = operandsDeletionTimedOutCond(identifiers, timeout)
+	}
+
+	return updateOperandsStatus(policy, &cond, relatedObjects...) || changed, nil
+}
@@ -0,0 +1,314 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	operatorv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+	policyv1beta1 "open-cluster-management.io/config-policy-controller/api/v1beta1"
+)
+
+func TestBuildOperands(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		objectDefinition string
+		expected         string
+	}{
+		"valid": {
+			objectDefinition: `{"apiVersion": "kafka.strimzi.io/v1beta2", "kind": "Kafka", "metadata": {"name": "my"}}`,
+		},
+		"invalid JSON": {
+			objectDefinition: `{"apiVersion": `,
+			expected:         "the spec.operands[0].objectDefinition is invalid",
+		},
+		"missing kind": {
+			objectDefinition: `{"apiVersion": "kafka.strimzi.io/v1beta2", "metadata": {"name": "my"}}`,
+			expected:         "apiVersion and kind are required in spec.operands[0].objectDefinition",
+		},
+		"missing name": {
+			objectDefinition: `{"apiVersion": "kafka.strimzi.io/v1beta2", "kind": "Kafka", "metadata": {}}`,
+			expected:         "metadata.name is required in spec.operands[0].objectDefinition",
+		},
+		"operator installation kind": {
+			objectDefinition: `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "my"}}`,
+			expected: "the spec.operands[0].objectDefinition kind Deployment is managed by the operator " +
+				"installation",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			policy := &policyv1beta1.OperatorPolicy{
+				Spec: policyv1beta1.OperatorPolicySpec{
+					Operands: []policyv1beta1.Operand{{
+						ObjectDefinition: runtime.RawExtension{Raw: []byte(test.objectDefinition)},
+					}},
+				},
+			}
+
+			operands, err := buildOperands(policy)
+			if test.expected == "" {
+				assert.NoError(t, err)
+				assert.Len(t, operands, 1)
+				assert.Equal(t, "Kafka", operands[0].GetKind())
+			} else {
+				assert.ErrorContains(t, err, test.expected)
+			}
+		})
+	}
+}
+
+func TestOperandCRDName(t *testing.T) {
+	t.Parallel()
+
+	csv := &operatorv1alpha1.ClusterServiceVersion{
+		Spec: operatorv1alpha1.ClusterServiceVersionSpec{
+			CustomResourceDefinitions: operatorv1alpha1.CustomResourceDefinitions{
+				Owned: []operatorv1alpha1.CRDDescription{
+					{Name: "kafkas.kafka.strimzi.io", Version: "v1beta2", Kind: "Kafka"},
+					{Name: "kafkatopics.kafka.strimzi.io", Version: "v1beta2", Kind: "KafkaTopic"},
+				},
+			},
+		},
+	}
+
+	operand := &unstructured.Unstructured{}
+	operand.SetAPIVersion("kafka.strimzi.io/v1beta2")
+	operand.SetKind("KafkaTopic")
+
+	assert.Equal(t, "kafkatopics.kafka.strimzi.io", operandCRDName(csv, operand))
+
+	operand.SetAPIVersion("v1")
+	operand.SetKind("ConfigMap")
+
+	assert.Empty(t, operandCRDName(csv, operand))
+}
+
+func TestCRDEstablished(t *testing.T) {
+	t.Parallel()
+
+	crd := &unstructured.Unstructured{Object: map[string]any{
+		"status": map[string]any{
+			"conditions": []any{
+				map[string]any{"type": "NamesAccepted", "status": "True"},
+				map[string]any{"type": "Established", "status": "False"},
+			},
+		},
+	}}
+
+	assert.False(t, crdEstablished(crd))
+
+	_ = unstructured.SetNestedSlice(crd.Object, []any{
+		map[string]any{"type": "NamesAccepted", "status": "True"},
+		map[string]any{"type": "Established", "status": "True"},
+	}, "status", "conditions")
+
+	assert.True(t, crdEstablished(crd))
+}
+
+func TestUpdateOperandsStatus(t *testing.T) {
+	t.Parallel()
+
+	policy := &policyv1beta1.OperatorPolicy{
+		Spec: policyv1beta1.OperatorPolicySpec{
+			ComplianceType: "musthave",
+			Operands:       []policyv1beta1.Operand{{}},
+		},
+		Status: policyv1beta1.OperatorPolicyStatus{
+			RelatedObjects: []policyv1.RelatedObject{{
+				Object: policyv1.ObjectResource{
+					Kind:     "Subscription",
+					Metadata: policyv1.ObjectMetadata{Name: "sub"},
+				},
+				Compliant: "Compliant",
+			}},
+		},
+	}
+
+	created := true
+	kafka := policyv1.RelatedObject{
+		Object: policyv1.ObjectResource{
+			Kind:       "Kafka",
+			APIVersion: "kafka.strimzi.io/v1beta2",
+			Metadata:   policyv1.ObjectMetadata{Name: "my", Namespace: "kafka"},
+		},
+		Compliant:  "Compliant",
+		Reason:     reasonWantFoundCreated,
+		Properties: &policyv1.ObjectProperties{UID: "123", CreatedByPolicy: &created},
+	}
+
+	cond := operandsCond(nil, nil)
+	assert.True(t, updateOperandsStatus(policy, &cond, kafka))
+	assert.Len(t, policy.Status.RelatedObjects, 2)
+	assert.Equal(t, "Kafka", policy.Status.RelatedObjects[0].Object.Kind)
+	assert.Equal(t, "Subscription", policy.Status.RelatedObjects[1].Object.Kind)
+
+	idx, foundCond := policy.Status.GetCondition(operandsConditionType)
+	assert.NotEqual(t, -1, idx)
+	assert.Equal(t, metav1.ConditionTrue, foundCond.Status)
+
+	// The same operand is now found as expected, and should keep that it was created by the policy
+	matched := policyv1.RelatedObject{
+		Object:     kafka.Object,
+		Compliant:  "Compliant",
+		Reason:     reasonWantFoundExists,
+		Properties: &policyv1.ObjectProperties{UID: "123"},
+	}
+
+	assert.True(t, updateOperandsStatus(policy, &cond, matched))
+	assert.Equal(t, &created, policy.Status.RelatedObjects[0].Properties.CreatedByPolicy)
+	assert.False(t, updateOperandsStatus(policy, &cond, matched))
+
+	// Removing the condition also removes the operand related objects
+	assert.True(t, updateOperandsStatus(policy, nil))
+	assert.Len(t, policy.Status.RelatedObjects, 1)
+	assert.Equal(t, "Subscription", policy.Status.RelatedObjects[0].Object.Kind)
+
+	idx, _ = policy.Status.GetCondition(operandsConditionType)
+	assert.Equal(t, -1, idx)
+}
+
+func TestOperandsCond(t *testing.T) {
+	t.Parallel()
+
+	cond := operandsCond(nil, nil)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Equal(t, "OperandsMatch", cond.Reason)
+
+	cond = operandsCond([]string{"Kafka kafka/my"}, []string{"KafkaTopic kafka/topic"})
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, "OperandsNotCompliant", cond.Reason)
+	assert.Equal(t,
+		"the operands Kafka kafka/my are missing and the operands KafkaTopic kafka/topic do not match the policy",
+		cond.Message,
+	)
+
+	cond = operandsPendingCond("the ClusterServiceVersion has succeeded")
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, "OperandsPending", cond.Reason)
+	assert.Equal(t, "the operands will be handled once the ClusterServiceVersion has succeeded", cond.Message)
+}
+
+func TestOperandDeletionTimeout(t *testing.T) {
+	t.Parallel()
+
+	policy := &policyv1beta1.OperatorPolicy{}
+
+	timeout, err := operandDeletionTimeout(policy)
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, timeout)
+
+	policy.Spec.RemovalBehavior.OperandDeletionTimeout = "1h"
+
+	timeout, err = operandDeletionTimeout(policy)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, timeout)
+
+	policy.Spec.RemovalBehavior.OperandDeletionTimeout = "soon"
+
+	_, err = operandDeletionTimeout(policy)
+	assert.EqualError(t, err, "spec.removalBehavior.operandDeletionTimeout must be a non-negative duration: soon")
+}
+
+func TestCRDStorageGVK(t *testing.T) {
+	t.Parallel()
+
+	crd := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{
+			"group": "kafka.strimzi.io",
+			"names": map[string]any{"kind": "KafkaTopic"},
+			"versions": []any{
+				map[string]any{"name": "v1alpha1", "served": true, "storage": false},
+				map[string]any{"name": "v1beta2", "served": true, "storage": true},
+			},
+		},
+	}}
+
+	gvk, ok := crdStorageGVK(crd)
+	assert.True(t, ok)
+	assert.Equal(t, schema.GroupVersionKind{Group: "kafka.strimzi.io", Version: "v1beta2", Kind: "KafkaTopic"}, gvk)
+
+	unstructured.RemoveNestedField(crd.Object, "spec", "versions")
+
+	_, ok = crdStorageGVK(crd)
+	assert.False(t, ok)
+}
+
+func TestOperandsConditionApplies(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		complianceType policyv1.ComplianceType
+		operands       []policyv1beta1.Operand
+		removal        policyv1beta1.RemovalAction
+		expected       bool
+	}{
+		"musthave without operands": {
+			complianceType: "musthave",
+		},
+		"musthave with operands": {
+			complianceType: "musthave",
+			operands:       []policyv1beta1.Operand{{}},
+			expected:       true,
+		},
+		"mustnothave keeping the operands": {
+			complianceType: "mustnothave",
+			operands:       []policyv1beta1.Operand{{}},
+		},
+		"mustnothave deleting the operands": {
+			complianceType: "mustnothave",
+			removal:        "DeleteAll",
+			expected:       true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			policy := &policyv1beta1.OperatorPolicy{
+				Spec: policyv1beta1.OperatorPolicySpec{
+					ComplianceType:  test.complianceType,
+					Operands:        test.operands,
+					RemovalBehavior: policyv1beta1.RemovalBehavior{Operands: test.removal},
+				},
+			}
+
+			assert.Equal(t, test.expected, operandsConditionApplies(policy))
+		})
+	}
+}
+
+func TestOperandsRemovalConds(t *testing.T) {
+	t.Parallel()
+
+	identifiers := []string{"KafkaTopic kafka/topic", "Kafka kafka/my"}
+
+	cond := operandsFoundCond(identifiers)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, "the operands KafkaTopic kafka/topic, Kafka kafka/my are present", cond.Message)
+
+	cond = operandsDeletingCond(identifiers)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, "OperandsDeleting", cond.Reason)
+
+	cond = operandsDeletionTimedOutCond(identifiers, 10*time.Minute)
+	assert.Equal(t, "OperandsDeletionTimedOut", cond.Reason)
+	assert.Equal(t,
+		"the operands KafkaTopic kafka/topic, Kafka kafka/my were not deleted within 10m0s, so the operator is "+
+			"being removed anyway",
+		cond.Message,
+	)
+}
@@ -6,6 +6,7 @@ import (
 	"slices"
 	"sort"
 	"strings"
+	"time"
 
 	operatorv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
@@ -137,6 +138,107 @@ func updateStatus(
 	return condChanged || relObjsChanged || genUpdated
 }
 
+// updateOperandsStatus sets the OperandsCompliant condition and replaces the related objects of the operands,
+// which are the related objects that are not of a kind managed by the operator installation. When the condition is
+// nil, the condition is removed. Like updateStatus, the compliance is recalculated and the `CreatedByPolicy` property
+// on relatedObjects is preserved.
+//
+// returns true if the status should be updated and a new compliance event should be emitted.
+func updateOperandsStatus(
+	policy *policyv1beta1.OperatorPolicy,
+	updatedCondition *metav1.Condition,
+	updatedRelatedObjs ...policyv1.RelatedObject,
+) (changed bool) {
+	condChanged := false
+
+	condIdx, existingCondition := policy.Status.GetCondition(operandsConditionType)
+
+	switch {
+	case updatedCondition == nil:
+		if condIdx != -1 {
+			condChanged = true
+
+			policy.Status.Conditions = slices.Delete(policy.Status.Conditions, condIdx, condIdx+1)
+		}
+	case condIdx == -1:
+		condChanged = true
+
+		if updatedCondition.LastTransitionTime.IsZero() {
+			updatedCondition.LastTransitionTime = metav1.Now()
+		}
+
+		policy.Status.Conditions = append(policy.Status.Conditions, *updatedCondition)
+	case conditionChanged(*updatedCondition, existingCondition):
+		condChanged = true
+
+		if updatedCondition.LastTransitionTime.IsZero() {
+			updatedCondition.LastTransitionTime = metav1.Now()
+		}
+
+		policy.Status.Conditions[condIdx] = *updatedCondition
+	}
+
+	updateComplianceCondition(policy)
+
+	newRelObjs := make([]policyv1.RelatedObject, 0, len(policy.Status.RelatedObjects))
+	prevOperandObjs := make([]policyv1.RelatedObject, 0)
+
+	for _, relObj := range policy.Status.RelatedObjects {
+		if olmRelatedKinds[relObj.Object.Kind] {
+			newRelObjs = append(newRelObjs, relObj)
+		} else {
+			prevOperandObjs = append(prevOperandObjs, relObj)
+		}
+	}
+
+	relObjsChanged := len(prevOperandObjs) != len(updatedRelatedObjs)
+
+	for i, updatedObj := range updatedRelatedObjs {
+		prevIdx := slices.IndexFunc(prevOperandObjs, func(prevObj policyv1.RelatedObject) bool {
+			return prevObj.Object.Kind == updatedObj.Object.Kind &&
+				prevObj.Object.APIVersion == updatedObj.Object.APIVersion &&
+				prevObj.Object.Metadata.Namespace == updatedObj.Object.Metadata.Namespace &&
+				prevObj.Object.Metadata.Name == updatedObj.Object.Metadata.Name
+		})
+		if prevIdx == -1 {
+			relObjsChanged = true
+
+			continue
+		}
+
+		prevObj := prevOperandObjs[prevIdx]
+
+		if updatedObj.Properties != nil && prevObj.Properties != nil {
+			if updatedObj.Properties.UID != prevObj.Properties.UID {
+				relObjsChanged = true
+			} else if prevObj.Properties.CreatedByPolicy != nil {
+				updatedRelatedObjs[i].Properties.CreatedByPolicy = prevObj.Properties.CreatedByPolicy
+			}
+		}
+
+		if prevObj.Compliant != updatedObj.Compliant || prevObj.Reason != updatedObj.Reason {
+			relObjsChanged = true
+		}
+	}
+
+	if relObjsChanged {
+		newRelObjs = append(newRelObjs, updatedRelatedObjs...)
+
+		// sort the related objects by kind and name
+		sort.SliceStable(newRelObjs, func(i, j int) bool {
+			if newRelObjs[i].Object.Kind != newRelObjs[j].Object.Kind {
+				return newRelObjs[i].Object.Kind < newRelObjs[j].Object.Kind
+			}
+
+			return newRelObjs[i].Object.Metadata.Name < newRelObjs[j].Object.Metadata.Name
+		})
+
+		policy.Status.RelatedObjects = newRelObjs
+	}
+
+	return condChanged || relObjsChanged
+}
+
 // Update ComplianceCondition and Sort conditions
 func updateComplianceCondition(policy *policyv1beta1.OperatorPolicy) {
 	updatedComplianceCondition := calculateComplianceCondition(policy)
@@ -215,82 +317,55 @@ func calculateComplianceCondition(policy *policyv1beta1.OperatorPolicy) metav1.C
 		foundNonCompliant = true
 	}
 
-	idx, cond = policy.Status.GetCondition(opGroupConditionType)
-	if idx != -1 {
-		messages = append(messages, cond.Message)
-
-		if cond.Status != metav1.ConditionTrue {
-			foundNonCompliant = true
-		}
-	} else {
-		foundNonCompliant = true
-	}
-
-	idx, cond = policy.Status.GetCondition(subConditionType)
-	if idx != -1 {
-		messages = append(messages, cond.Message)
-
-		if cond.Status != metav1.ConditionTrue {
-			foundNonCompliant = true
-		}
-	} else {
-		foundNonCompliant = true
-	}
-
-	idx, cond = policy.Status.GetCondition(installPlanConditionType)
-	if idx != -1 {
-		messages = append(messages, cond.Message)
-
-		if cond.Status != metav1.ConditionTrue {
-			foundNonCompliant = true
-		}
-	} else {
-		foundNonCompliant = true
-	}
-
-	idx, cond = policy.Status.GetCondition(csvConditionType)
-	if idx != -1 {
-		messages = append(messages, cond.Message)
+	for _, condType := range operatorConditionTypes(policy) {
+		idx, cond = policy.Status.GetCondition(condType)
+		if idx != -1 {
+			messages = append(messages, cond.Message)
 
-		if cond.Status != metav1.ConditionTrue {
+			if cond.Status != metav1.ConditionTrue {
+				foundNonCompliant = true
+			}
+		} else {
 			foundNonCompliant = true
 		}
-	} else {
-		foundNonCompliant = true
 	}
 
-	idx, cond = policy.Status.GetCondition(crdConditionType)
+	idx, cond = policy.Status.GetCondition(catalogSrcConditionType)
 	if idx != -1 {
 		messages = append(messages, cond.Message)
 
-		if cond.Status != metav1.ConditionTrue {
+		// Note: the CatalogSource condition has a different polarity
+		if cond.Status != metav1.ConditionFalse {
 			foundNonCompliant = true
 		}
 	} else {
 		foundNonCompliant = true
 	}
 
-	idx, cond = policy.Status.GetCondition(deploymentConditionType)
-	if idx != -1 {
-		messages = append(messages, cond.Message)
+	if operandsConditionApplies(policy) {
+		idx, cond = policy.Status.GetCondition(operandsConditionType)
+		if idx != -1 {
+			messages = append(messages, cond.Message)
 
-		if cond.Status != metav1.ConditionTrue {
+			if cond.Status != metav1.ConditionTrue {
+				foundNonCompliant = true
+			}
+		} else {
 			foundNonCompliant = true
 		}
-	} else {
-		foundNonCompliant = true
 	}
 
-	idx, cond = policy.Status.GetCondition(catalogSrcConditionType)
-	if idx != -1 {
-		messages = append(messages, cond.Message)
+	for _, condType := range workloadHealthConditionTypes(policy) {
+		idx, cond = policy.Status.GetCondition(condType)
+		if idx != -1 {
+			messages = append(messages, cond.Message)
 
-		// Note: the CatalogSource condition has a different polarity
-		if cond.Status != metav1.ConditionFalse {
+			if cond.Status != metav1.ConditionTrue {
+				foundNonCompliant = true
+			}
+		} else {
 			foundNonCompliant = true
 		}
-	} else {
-		foundNonCompliant = true
 	}
 
 	if !policy.Spec.ComplianceType.IsMustNotHave() &&
@@ -345,6 +420,81 @@ func calculateComplianceCondition(policy *policyv1beta1.OperatorPolicy) metav1.C
 	}
 }
 
+// operatorConditionTypes returns the condition types, in order, for the resources that install the operator,
+// which depend on whether the policy uses an OLM v0 Subscription or an OLM v1 ClusterExtension. The
+// CatalogSource condition is handled separately since it has the opposite polarity.
+func operatorConditionTypes(policy *policyv1beta1.OperatorPolicy) []string {
+	if policy.Spec.ClusterExtension != nil {
+		return []string{clusterExtConditionType, deploymentConditionType}
+	}
+
+	return []string{
+		opGroupConditionType,
+		subConditionType,
+		installPlanConditionType,
+		csvConditionType,
+		crdConditionType,
+		deploymentConditionType,
+	}
+}
+
+// operandsConditionApplies returns whether the OperandsCompliant condition is part of the compliance of the policy,
+// which is when the policy has operands to apply or when it deletes the operands before removing the operator.
+func operandsConditionApplies(policy *policyv1beta1.OperatorPolicy) bool {
+	if policy.Spec.ClusterExtension != nil {
+		return false
+	}
+
+	if policy.Spec.ComplianceType.IsMustNotHave() {
+		return policy.Spec.RemovalBehavior.ApplyDefaults().Operands.IsDeleteAll()
+	}
+
+	return len(policy.Spec.Operands) != 0 && policy.Spec.ComplianceType.IsMustHave()
+}
+
+// workloadHealthConditionTypes returns the types of the opt-in workload health conditions that are part of the
+// compliance of the policy, which are the ones with a NonCompliant action in spec.complianceConfig. Like the
+// NoDeprecations condition, the conditions of the checks with a Compliant action are only informational.
+func workloadHealthConditionTypes(policy *policyv1beta1.OperatorPolicy) []string {
+	if !policy.Spec.ComplianceType.IsMustHave() || policy.Spec.ClusterExtension != nil {
+		return nil
+	}
+
+	config := policy.Spec.ComplianceConfig
+	condTypes := []string{}
+
+	if config.PodsUnhealthy == "NonCompliant" {
+		condTypes = append(condTypes, podsConditionType)
+	}
+
+	if config.CSVRequirementsNotMet == "NonCompliant" {
+		condTypes = append(condTypes, csvReqConditionType)
+	}
+
+	if config.OwnedAPIsUnavailable == "NonCompliant" {
+		condTypes = append(condTypes, ownedAPIsConditionType)
+	}
+
+	return condTypes
+}
+
+// removeStatusCondition removes the condition of the given type, for conditions that only apply to some
+// policies. If the condition was removed, the compliance is recalculated.
+//
+// returns true if the status should be updated and a new compliance event should be emitted.
+func removeStatusCondition(policy *policyv1beta1.OperatorPolicy, conditionType string) bool {
+	condIdx, _ := policy.Status.GetCondition(conditionType)
+	if condIdx == -1 {
+		return false
+	}
+
+	policy.Status.Conditions = slices.Delete(policy.Status.Conditions, condIdx, condIdx+1)
+
+	updateComplianceCondition(policy)
+
+	return true
+}
+
 // emitComplianceEvent creates a compliance event on the parent policy (if there is
 // one) based on the given compliance condition. It returns an error if creating the
 // event fails.
@@ -412,6 +562,11 @@ const (
 	catalogSrcConditionType  = "CatalogSourcesUnhealthy"
 	installPlanConditionType = "InstallPlanCompliant"
 	deprecationType          = "NoDeprecations"
+	operandsConditionType    = "OperandsCompliant"
+	clusterExtConditionType  = "ClusterExtensionCompliant"
+	podsConditionType        = "PodsHealthy"
+	csvReqConditionType      = "CSVRequirementsMet"
+	ownedAPIsConditionType   = "OwnedAPIsAvailable"
 )
 
 func condType(kind string) string {
@@ -428,8 +583,10 @@ func condType(kind string) string {
 		return crdConditionType
 	case "Deployment":
 		return deploymentConditionType
-	case "CatalogSource":
+	case "CatalogSource", "ClusterCatalog":
 		return catalogSrcConditionType
+	case "ClusterExtension":
+		return clusterExtConditionType
 	default:
 		panic("Unknown condition type for kind " + kind)
 	}
@@ -624,6 +781,15 @@ func deprecationCond(depSchemaName string, depSchema Schema, message string) met
 	}
 }
 
+// noDeprecationsCond is a Compliant condition with Reason 'Recommended', for when the package, channel,
+// and bundle are not deprecated.
+var noDeprecationsCond = metav1.Condition{
+	Type:    deprecationType,
+	Status:  metav1.ConditionTrue,
+	Reason:  "Recommended",
+	Message: "The requested package, channel, and bundle are all at the recommended versions",
+}
+
 // validationCond returns a condition based on the errors passed in...
 // If no errors are passed, it will be Compliant, with Reason 'PolicyValidated'.
 // If errors are passed in, it is NonCompliant, with Reason 'InvalidPolicySpec',
@@ -652,6 +818,17 @@ func validationCond(validationErrors []error) metav1.Condition {
 	}
 }
 
+// targetClusterUnavailableCond is a NonCompliant condition with Reason 'TargetClusterUnavailable'
+// and the error as the message.
+func targetClusterUnavailableCond(err error) metav1.Condition {
+	return metav1.Condition{
+		Type:    validPolicyConditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  "TargetClusterUnavailable",
+		Message: err.Error(),
+	}
+}
+
 // subConstraintsNotSatisfiableCond is a NonCompliant condition with Reason 'ConstraintsNotSatisfiable',
 // and Message 'constraints not satisfiable: refer to the Subscription for more details'.
 var subConstraintsNotSatisfiableCond = metav1.Condition{
@@ -780,6 +957,51 @@ func installPlanUpgradeCond(
 	return cond
 }
 
+// installPlanDeferredCond is a condition with Reason 'InstallPlanApprovalDeferred' and a message like
+// 'an InstallPlan to update to [____] is available for approval but the approval is deferred until ____'.
+// The status of the condition depends on the upgradesAvailable compliance configuration.
+func installPlanDeferredCond(
+	complianceConfig policyv1beta1.ComplianceConfigAction,
+	csvsInInstallPlan []string,
+	nextApproval time.Time,
+) metav1.Condition {
+	cond := installPlanUpgradeCond(complianceConfig, csvsInInstallPlan, nil)
+	cond.Reason = "InstallPlanApprovalDeferred"
+	cond.Message += " but the approval is deferred until " + nextApproval.UTC().Format(time.RFC3339) +
+		" by the upgrade approval windows and soak time"
+
+	return cond
+}
+
+// installPlanBlockedCond is a condition with Reason 'UpgradeBlocked' and a message like
+// 'an InstallPlan to update to [____] is available for approval but it can't be approved because ____'.
+// The status of the condition depends on the upgradesAvailable compliance configuration.
+func installPlanBlockedCond(
+	complianceConfig policyv1beta1.ComplianceConfigAction,
+	csvsInInstallPlan []string,
+	failedConstraints []string,
+) metav1.Condition {
+	cond := installPlanUpgradeCond(complianceConfig, csvsInInstallPlan, nil)
+	cond.Reason = "UpgradeBlocked"
+	cond.Message += " but it can't be approved because " + strings.Join(failedConstraints, ", ")
+
+	return cond
+}
+
+// installPlanDisallowedDepsCond is a NonCompliant condition with Reason 'DependenciesNotAllowed' and a message
+// like 'an InstallPlan to update to [____] is available for approval but it can't be approved because the
+// dependencies [____] are not allowed by spec.allowedDependencies'.
+func installPlanDisallowedDepsCond(csvsInInstallPlan []string, dependencies []string) metav1.Condition {
+	cond := installPlanUpgradeCond("NonCompliant", csvsInInstallPlan, nil)
+	cond.Reason = "DependenciesNotAllowed"
+	cond.Message += fmt.Sprintf(
+		" but it can't be approved because the dependencies [%s] are not allowed by spec.allowedDependencies",
+		strings.Join(dependencies, ", "),
+	)
+
+	return cond
+}
+
 // installPlanApprovedCond is a Compliant condition with Reason 'InstallPlanApproved'
 // and a message like 'the InstallPlan for _____ was approved'
 func installPlanApprovedCond(version string) metav1.Condition {
@@ -818,6 +1040,42 @@ func disallowedCSVCond(csv *operatorv1alpha1.ClusterServiceVersion) metav1.Condi
 	}
 }
 
+// upgradeFailedCond is a NonCompliant condition with Reason 'UpgradeFailed' and a message like
+// 'the upgrade to ClusterServiceVersion (____) failed and will be rolled back to (____)'
+func upgradeFailedCond(failedVersion string, version string) metav1.Condition {
+	return metav1.Condition{
+		Type:   csvConditionType,
+		Status: metav1.ConditionFalse,
+		Reason: "UpgradeFailed",
+		Message: "the upgrade to ClusterServiceVersion (" + failedVersion + ") failed and will be rolled back to (" +
+			version + ")",
+	}
+}
+
+// rollingBackCond is a NonCompliant condition with Reason 'RollingBack' and a message like
+// 'the Subscription was recreated to roll back from ClusterServiceVersion (____) to (____)'
+func rollingBackCond(failedVersion string, version string) metav1.Condition {
+	return metav1.Condition{
+		Type:   csvConditionType,
+		Status: metav1.ConditionFalse,
+		Reason: "RollingBack",
+		Message: "the Subscription was recreated to roll back from ClusterServiceVersion (" + failedVersion +
+			") to (" + version + ")",
+	}
+}
+
+// rollbackSucceededCond is a Compliant condition with Reason 'RollbackSucceeded' and a message like
+// 'ClusterServiceVersion (____) was rolled back to (____) and the failed version is blocked'
+func rollbackSucceededCond(failedVersion string, version string) metav1.Condition {
+	return metav1.Condition{
+		Type:   csvConditionType,
+		Status: metav1.ConditionTrue,
+		Reason: "RollbackSucceeded",
+		Message: "ClusterServiceVersion (" + failedVersion + ") was rolled back to (" + version +
+			") and the failed version is blocked",
+	}
+}
+
 // noCSVCond is a NonCompliant condition with Reason 'RelevantCSVNotFound'
 var noCSVCond = metav1.Condition{
 	Type:    csvConditionType,
@@ -897,6 +1155,106 @@ var noDeploymentsCond = metav1.Condition{
 	Message: "there are no relevant deployments because the ClusterServiceVersion is missing",
 }
 
+// noCSVHealthCond is a Compliant condition for one of the workload health checks, with Reason
+// 'NoClusterServiceVersion', and a message saying that there is nothing to check because the CSV is missing.
+func noCSVHealthCond(conditionType string, resources string) metav1.Condition {
+	return metav1.Condition{
+		Type:    conditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  "NoClusterServiceVersion",
+		Message: "there are no relevant " + resources + " because the ClusterServiceVersion is missing",
+	}
+}
+
+// podsHealthyCond is a Compliant condition with Reason 'PodsHealthy' when none of the operator pods are
+// unhealthy, and otherwise a NonCompliant condition with Reason 'PodsUnhealthy' listing the unhealthy pods.
+// Whether it affects the policy compliance is determined by spec.complianceConfig.podsUnhealthy.
+func podsHealthyCond(podsExist bool, unhealthyPods []string) metav1.Condition {
+	if len(unhealthyPods) != 0 {
+		return metav1.Condition{
+			Type:    podsConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  "PodsUnhealthy",
+			Message: "the operator pods " + strings.Join(unhealthyPods, ", ") + " are unhealthy",
+		}
+	}
+
+	message := "all operator pods are healthy"
+	if !podsExist {
+		message = "no existing operator pods"
+	}
+
+	return metav1.Condition{
+		Type:    podsConditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  "PodsHealthy",
+		Message: message,
+	}
+}
+
+// csvRequirementsCond is a Compliant condition with Reason 'RequirementsMet' when all of the
+// ClusterServiceVersion requirements are present, and otherwise a NonCompliant condition with Reason
+// 'RequirementsNotMet' listing the unmet requirements. Whether it affects the policy compliance is
+// determined by spec.complianceConfig.csvRequirementsNotMet.
+func csvRequirementsCond(unmetRequirements []string) metav1.Condition {
+	if len(unmetRequirements) != 0 {
+		return metav1.Condition{
+			Type:   csvReqConditionType,
+			Status: metav1.ConditionFalse,
+			Reason: "RequirementsNotMet",
+			Message: "the ClusterServiceVersion requirements " + strings.Join(unmetRequirements, ", ") +
+				" are not met",
+		}
+	}
+
+	return metav1.Condition{
+		Type:    csvReqConditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  "RequirementsMet",
+		Message: "the ClusterServiceVersion requirements are met",
+	}
+}
+
+// ownedAPIsCond is a Compliant condition with Reason 'OwnedAPIsAvailable' when all of the webhooks and
+// APIServices owned by the ClusterServiceVersion are available, and otherwise a NonCompliant condition with
+// Reason 'OwnedAPIsUnavailable' listing the unavailable ones. Whether it affects the policy compliance is
+// determined by spec.complianceConfig.ownedAPIsUnavailable.
+func ownedAPIsCond(ownsAPIs bool, unavailableAPIs []string) metav1.Condition {
+	if len(unavailableAPIs) != 0 {
+		return metav1.Condition{
+			Type:   ownedAPIsConditionType,
+			Status: metav1.ConditionFalse,
+			Reason: "OwnedAPIsUnavailable",
+			Message: "the " + strings.Join(unavailableAPIs, ", ") +
+				" owned by the ClusterServiceVersion are unavailable",
+		}
+	}
+
+	message := "the webhooks and APIServices owned by the ClusterServiceVersion are available"
+	if !ownsAPIs {
+		message = "the ClusterServiceVersion does not own any webhooks or APIServices"
+	}
+
+	return metav1.Condition{
+		Type:    ownedAPIsConditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  "OwnedAPIsAvailable",
+		Message: message,
+	}
+}
+
+// catalogSrcCond flips the status of a condition returned by one of the generic condition functions, such as
+// createdCond, for the CatalogSource, since the CatalogSourcesUnhealthy condition has the opposite polarity.
+func catalogSrcCond(cond metav1.Condition) metav1.Condition {
+	if cond.Status == metav1.ConditionTrue {
+		cond.Status = metav1.ConditionFalse
+	} else {
+		cond.Status = metav1.ConditionTrue
+	}
+
+	return cond
+}
+
 // catalogSourceFindCond is a conditionally compliant condition with reason
 // based on the `isUnhealthy` and `isMissing` parameters. The `complianceConfig`
 // parameter determines whether an unhealthy CatalogSource should lead to
@@ -936,6 +1294,176 @@ func catalogSourceFindCond(
 	}
 }
 
+// clusterExtInstalledCond is a Compliant condition with Reason 'ClusterExtensionInstalled', and a message
+// saying which bundle the ClusterExtension installed.
+func clusterExtInstalledCond(bundleName string) metav1.Condition {
+	return metav1.Condition{
+		Type:    clusterExtConditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  "ClusterExtensionInstalled",
+		Message: "the ClusterExtension installed the bundle " + bundleName,
+	}
+}
+
+// clusterExtNotInstalledCond is a NonCompliant condition with Reason 'ClusterExtensionNotInstalled', and a
+// message including the details reported by the ClusterExtension, if any.
+func clusterExtNotInstalledCond(details string) metav1.Condition {
+	message := "the ClusterExtension is not installed"
+	if details != "" {
+		message += ": " + details
+	}
+
+	return metav1.Condition{
+		Type:    clusterExtConditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  "ClusterExtensionNotInstalled",
+		Message: message,
+	}
+}
+
+// clusterExtDisallowedCond is a NonCompliant condition with Reason 'BundleVersionNotApproved', and a
+// message saying that the installed bundle is not allowed by the policy.
+func clusterExtDisallowedCond(bundleName string) metav1.Condition {
+	return metav1.Condition{
+		Type:   clusterExtConditionType,
+		Status: metav1.ConditionFalse,
+		Reason: "BundleVersionNotApproved",
+		Message: "the ClusterExtension installed the bundle " + bundleName + ", which is not allowed by the " +
+			"spec.versions or spec.versionRange policy fields",
+	}
+}
+
+// noClusterExtDeploymentsCond is a Compliant condition with Reason 'NoRelevantDeployments',
+// and a message saying that the ClusterExtension is missing.
+var noClusterExtDeploymentsCond = metav1.Condition{
+	Type:    deploymentConditionType,
+	Status:  metav1.ConditionTrue,
+	Reason:  "NoRelevantDeployments",
+	Message: "there are no relevant deployments because the ClusterExtension is missing",
+}
+
+// clusterCatalogsCond is a conditionally compliant condition for the ClusterCatalogs used by a
+// ClusterExtension, which are unhealthy when none are found or when any are not serving. Like the
+// CatalogSource condition, it has the opposite polarity. The `complianceConfig` parameter determines
+// whether unhealthy ClusterCatalogs should lead to NonCompliance when status is updated.
+func clusterCatalogsCond(
+	complianceConfig policyv1beta1.ComplianceConfigAction,
+	found bool,
+	notServing []string,
+) metav1.Condition {
+	status := metav1.ConditionFalse
+	reason := "ClusterCatalogsServing"
+	message := "the ClusterCatalogs for the ClusterExtension are serving"
+
+	if !found {
+		status = metav1.ConditionTrue
+		reason = "ClusterCatalogsNotFound"
+		message = "no ClusterCatalogs were found for the ClusterExtension"
+	} else if len(notServing) != 0 {
+		status = metav1.ConditionTrue
+		reason = "ClusterCatalogsNotServing"
+		message = fmt.Sprintf("the ClusterCatalogs %s are not serving", strings.Join(notServing, ", "))
+	}
+
+	// Only override if condition evaluated to NonCompliant
+	if status == metav1.ConditionTrue && complianceConfig == "Compliant" {
+		status = metav1.ConditionFalse
+	}
+
+	return metav1.Condition{
+		Type:    catalogSrcConditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+}
+
+// operandsPendingCond is a NonCompliant condition with Reason 'OperandsPending', and a message saying what the
+// operands are waiting for before they are handled.
+func operandsPendingCond(waitingFor string) metav1.Condition {
+	return metav1.Condition{
+		Type:    operandsConditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  "OperandsPending",
+		Message: "the operands will be handled once " + waitingFor,
+	}
+}
+
+// operandsCond creates a Condition for the operands. If any are missing or do not match, the condition will be
+// NonCompliant, and the message will list them.
+func operandsCond(missing []string, mismatched []string) metav1.Condition {
+	if len(missing) == 0 && len(mismatched) == 0 {
+		return metav1.Condition{
+			Type:    operandsConditionType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "OperandsMatch",
+			Message: "the operands match what is required by the policy",
+		}
+	}
+
+	problems := make([]string, 0, 2)
+
+	if len(missing) != 0 {
+		problems = append(problems, "the operands "+strings.Join(missing, ", ")+" are missing")
+	}
+
+	if len(mismatched) != 0 {
+		problems = append(problems, "the operands "+strings.Join(mismatched, ", ")+" do not match the policy")
+	}
+
+	return metav1.Condition{
+		Type:    operandsConditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  "OperandsNotCompliant",
+		Message: strings.Join(problems, " and "),
+	}
+}
+
+// noOperandsCond is a Compliant condition for when no operands of the operator are found and the policy deletes them
+var noOperandsCond = metav1.Condition{
+	Type:    operandsConditionType,
+	Status:  metav1.ConditionTrue,
+	Reason:  "NoOperandsFound",
+	Message: "no operands of the operator were found",
+}
+
+// operandsFoundCond is a NonCompliant condition with Reason 'OperandsPresent', listing the operands that would be
+// deleted before the operator is removed.
+func operandsFoundCond(identifiers []string) metav1.Condition {
+	return metav1.Condition{
+		Type:    operandsConditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  "OperandsPresent",
+		Message: "the operands " + strings.Join(identifiers, ", ") + " are present",
+	}
+}
+
+// operandsDeletingCond is a NonCompliant condition with Reason 'OperandsDeleting', listing the operands that are
+// being deleted before the operator is removed.
+func operandsDeletingCond(identifiers []string) metav1.Condition {
+	return metav1.Condition{
+		Type:   operandsConditionType,
+		Status: metav1.ConditionFalse,
+		Reason: "OperandsDeleting",
+		Message: "the operands " + strings.Join(identifiers, ", ") +
+			" are being deleted and the operator will be removed once they are gone",
+	}
+}
+
+// operandsDeletionTimedOutCond is a NonCompliant condition with Reason 'OperandsDeletionTimedOut', listing the
+// operands that were not deleted within the timeout, after which the operator is removed anyway.
+func operandsDeletionTimedOutCond(identifiers []string, timeout time.Duration) metav1.Condition {
+	return metav1.Condition{
+		Type:   operandsConditionType,
+		Status: metav1.ConditionFalse,
+		Reason: "OperandsDeletionTimedOut",
+		Message: fmt.Sprintf(
+			"the operands %s were not deleted within %s, so the operator is being removed anyway",
+			strings.Join(identifiers, ", "), timeout,
+		),
+	}
+}
+
 // catalogSourceUnknownCond is a NonCompliant condition
 var catalogSourceUnknownCond = metav1.Condition{
 	Type:    "CatalogSourcesUnknownState",
@@ -1204,6 +1732,16 @@ func existingInstallPlanObj(
 	return relObj
 }
 
+// disallowedDepsInstallPlanObj returns a NonCompliant RelatedObject for an InstallPlan that can't be approved
+// because it would install dependencies that are not allowed by the policy.
+func disallowedDepsInstallPlanObj(ip client.Object, phase string, dependencies []string) policyv1.RelatedObject {
+	relObj := existingInstallPlanObj(ip, phase, "NonCompliant")
+	relObj.Compliant = string(policyv1.NonCompliant)
+	relObj.Reason += " but the dependencies [" + strings.Join(dependencies, ", ") + "] are not allowed"
+
+	return relObj
+}
+
 // missingCSVObj returns a NonCompliant RelatedObject for the ClusterServiceVersion,
 // with Reason 'Resource not found but should exist'
 func missingCSVObj(name string, namespace string) policyv1.RelatedObject {
@@ -1389,6 +1927,47 @@ func catalogSourceObj(
 	}
 }
 
+// clusterCatalogObj returns a conditionally compliant RelatedObject for a ClusterCatalog based on whether it
+// is serving. The `complianceConfig` parameter determines whether a ClusterCatalog that is not serving should
+// lead to NonCompliance when status is updated.
+func clusterCatalogObj(
+	catalog client.Object, serving bool, complianceConfig policyv1beta1.ComplianceConfigAction,
+) policyv1.RelatedObject {
+	if serving {
+		return matchedObj(catalog)
+	}
+
+	relatedObj := nonCompObj(catalog, reasonWantFoundExists+" but is not serving")
+
+	if complianceConfig == "Compliant" {
+		relatedObj.Compliant = string(policyv1.Compliant)
+	}
+
+	return relatedObj
+}
+
+// noClusterCatalogsObj returns a conditionally compliant RelatedObject for ClusterCatalogs, with Reason
+// 'No ClusterCatalogs found for the ClusterExtension'. The `complianceConfig` parameter determines whether
+// the lack of ClusterCatalogs should lead to NonCompliance when status is updated.
+func noClusterCatalogsObj(complianceConfig policyv1beta1.ComplianceConfigAction) policyv1.RelatedObject {
+	compliance := string(policyv1.NonCompliant)
+	if complianceConfig == "Compliant" {
+		compliance = string(policyv1.Compliant)
+	}
+
+	return policyv1.RelatedObject{
+		Object: policyv1.ObjectResource{
+			Kind:       clusterCatalogGVK.Kind,
+			APIVersion: clusterCatalogGVK.GroupVersion().String(),
+			Metadata: policyv1.ObjectMetadata{
+				Name: "-",
+			},
+		},
+		Compliant: compliance,
+		Reason:    "No ClusterCatalogs found for the ClusterExtension",
+	}
+}
+
 // catalogSrcUnknownObj returns a NonCompliant RelatedObject with
 // reason = 'Resource found but current state is unknown'
 func catalogSrcUnknownObj(catalogName string, catalogNS string) policyv1.RelatedObject {
@@ -0,0 +1,427 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	// Embed the time zone database so that the time zones of the upgrade approval windows can be
+	// loaded on clusters where the image doesn't have one.
+	_ "time/tzdata"
+
+	"github.com/Masterminds/semver/v3"
+	operatorv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	depclient "github.com/stolostron/kubernetes-dependency-watches/client"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+	policyv1beta1 "open-cluster-management.io/config-policy-controller/api/v1beta1"
+)
+
+const (
+	// maxUpgradeWindowDuration is the longest an upgrade approval window can stay open.
+	maxUpgradeWindowDuration = 24 * time.Hour
+	// maxOpenShiftVersionProperty is the bundle property with the latest OpenShift minor version that the
+	// bundle supports. OpenShift doesn't allow the cluster to upgrade past it while the bundle is installed.
+	maxOpenShiftVersionProperty = "olm.maxOpenShiftVersion"
+)
+
+var weekdays = map[policyv1beta1.Weekday]time.Weekday{
+	"Sunday":    time.Sunday,
+	"Monday":    time.Monday,
+	"Tuesday":   time.Tuesday,
+	"Wednesday": time.Wednesday,
+	"Thursday":  time.Thursday,
+	"Friday":    time.Friday,
+	"Saturday":  time.Saturday,
+}
+
+// upgradeWindow is a parsed spec.upgradeApprovalWindows entry.
+type upgradeWindow struct {
+	// days are the days of the week when the window opens. When empty, the window opens every day.
+	days     map[time.Weekday]bool
+	hour     int
+	minute   int
+	duration time.Duration
+	location *time.Location
+}
+
+// nextOpening returns the earliest time that is not before t when the window is open.
+func (w upgradeWindow) nextOpening(t time.Time) time.Time {
+	local := t.In(w.location)
+
+	var next time.Time
+
+	// Start a day earlier in case the window opened the day before and is still open. Since windows are at most a
+	// day long, checking the following week always finds an opening.
+	for day := -1; day <= 7; day++ {
+		start := time.Date(local.Year(), local.Month(), local.Day()+day, w.hour, w.minute, 0, 0, w.location)
+
+		if len(w.days) != 0 && !w.days[start.Weekday()] {
+			continue
+		}
+
+		if !t.Before(start.Add(w.duration)) {
+			continue
+		}
+
+		opening := start
+		if opening.Before(t) {
+			opening = t
+		}
+
+		if next.IsZero() || opening.Before(next) {
+			next = opening
+		}
+	}
+
+	return next
+}
+
+// parseUpgradeWindows parses and validates the spec.upgradeApprovalWindows of the policy.
+func parseUpgradeWindows(policy *policyv1beta1.OperatorPolicy) ([]upgradeWindow, error) {
+	windows := make([]upgradeWindow, 0, len(policy.Spec.UpgradeApprovalWindows))
+
+	for i, window := range policy.Spec.UpgradeApprovalWindows {
+		parsed := upgradeWindow{days: map[time.Weekday]bool{}}
+
+		for _, day := range window.Days {
+			weekday, ok := weekdays[day]
+			if !ok {
+				return nil, fmt.Errorf("spec.upgradeApprovalWindows[%d].days has an invalid day: %s", i, day)
+			}
+
+			parsed.days[weekday] = true
+		}
+
+		start, err := time.Parse("15:04", window.Start)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"spec.upgradeApprovalWindows[%d].start must be in the HH:MM format: %s", i, window.Start,
+			)
+		}
+
+		parsed.hour = start.Hour()
+		parsed.minute = start.Minute()
+
+		parsed.duration, err = time.ParseDuration(window.Duration)
+		if err != nil || parsed.duration <= 0 || parsed.duration > maxUpgradeWindowDuration {
+			return nil, fmt.Errorf(
+				"spec.upgradeApprovalWindows[%d].duration must be a positive duration of at most 24h: %s",
+				i, window.Duration,
+			)
+		}
+
+		parsed.location = time.UTC
+
+		if window.TimeZone != "" {
+			parsed.location, err = time.LoadLocation(window.TimeZone)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"spec.upgradeApprovalWindows[%d].timeZone is not a valid time zone: %s", i, window.TimeZone,
+				)
+			}
+		}
+
+		windows = append(windows, parsed)
+	}
+
+	return windows, nil
+}
+
+// parseUpgradeSoakTime parses and validates the spec.upgradeSoakTime of the policy.
+func parseUpgradeSoakTime(policy *policyv1beta1.OperatorPolicy) (time.Duration, error) {
+	if policy.Spec.UpgradeSoakTime == "" {
+		return 0, nil
+	}
+
+	soakTime, err := time.ParseDuration(policy.Spec.UpgradeSoakTime)
+	if err != nil || soakTime < 0 {
+		return 0, errors.New("spec.upgradeSoakTime must be a non-negative duration: " + policy.Spec.UpgradeSoakTime)
+	}
+
+	return soakTime, nil
+}
+
+// validateUpgradeApproval returns an error if the upgrade approval windows or soak time of the policy are invalid.
+func validateUpgradeApproval(policy *policyv1beta1.OperatorPolicy) error {
+	if _, err := parseUpgradeWindows(policy); err != nil {
+		return err
+	}
+
+	_, err := parseUpgradeSoakTime(policy)
+
+	return err
+}
+
+// nextUpgradeApproval returns the earliest time that is not before now when an upgrade first available at firstSeen
+// can be approved, based on the upgrade approval windows and soak time of the policy.
+func nextUpgradeApproval(policy *policyv1beta1.OperatorPolicy, firstSeen time.Time, now time.Time) (time.Time, error) {
+	windows, err := parseUpgradeWindows(policy)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	soakTime, err := parseUpgradeSoakTime(policy)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	earliest := now
+	if soakEnd := firstSeen.Add(soakTime); soakEnd.After(earliest) {
+		earliest = soakEnd
+	}
+
+	if len(windows) == 0 {
+		return earliest, nil
+	}
+
+	var next time.Time
+
+	for _, window := range windows {
+		opening := window.nextOpening(earliest)
+
+		if next.IsZero() || opening.Before(next) {
+			next = opening
+		}
+	}
+
+	return next, nil
+}
+
+// pendingUpgrade returns the upgrade pending in the InstallPlan. The time the upgrade was first seen is kept from the
+// previous pending upgrade if it's for the same version, since OLM can recreate InstallPlans.
+func pendingUpgrade(
+	previous *policyv1beta1.PendingUpgrade, installPlan *operatorv1alpha1.InstallPlan, version string,
+) *policyv1beta1.PendingUpgrade {
+	pending := &policyv1beta1.PendingUpgrade{
+		Version:   version,
+		FirstSeen: installPlan.CreationTimestamp,
+	}
+
+	if pending.FirstSeen.IsZero() {
+		pending.FirstSeen = metav1.Now().Rfc3339Copy()
+	}
+
+	if previous != nil && previous.Version == version && previous.FirstSeen.Before(&pending.FirstSeen) {
+		pending.FirstSeen = previous.FirstSeen
+	}
+
+	return pending
+}
+
+// openShiftVersion returns the version of OpenShift that the target cluster is on or upgrading to, or an empty
+// string when the cluster is not OpenShift.
+func (r *OperatorPolicyReconciler) openShiftVersion(policy *policyv1beta1.OperatorPolicy) (string, error) {
+	clusterVersion, err := r.clusterVersion(policy)
+	if err != nil || clusterVersion == nil {
+		return "", err
+	}
+
+	version, _, _ := unstructured.NestedString(clusterVersion.Object, "status", "desired", "version")
+
+	return version, nil
+}
+
+// clusterVersion returns the OpenShift ClusterVersion of the target cluster, or nil when the cluster isn't
+// OpenShift.
+func (r *OperatorPolicyReconciler) clusterVersion(
+	policy *policyv1beta1.OperatorPolicy,
+) (*unstructured.Unstructured, error) {
+	watcher := opPolIdentifier(policy.Namespace, policy.Name)
+
+	clusterVersion, err := r.target(policy).DynamicWatcher.Get(watcher, clusterVersionGVK, "", "version")
+	if err != nil {
+		if errors.Is(err, depclient.ErrNoVersionedResource) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("error getting the ClusterVersion: %w", err)
+	}
+
+	return clusterVersion, nil
+}
+
+// bundleMaxOpenShiftVersions returns the olm.maxOpenShiftVersion property of the bundles in the InstallPlan, by
+// ClusterServiceVersion name, from the properties of its bundle lookups.
+func bundleMaxOpenShiftVersions(installPlan *operatorv1alpha1.InstallPlan) map[string]string {
+	maxVersions := map[string]string{}
+
+	for csvName, value := range installPlanBundleProperties(installPlan, maxOpenShiftVersionProperty) {
+		// The value is usually a string, but a number like 4.10 is also accepted by OLM
+		var maxVersion string
+		if err := json.Unmarshal(value, &maxVersion); err != nil {
+			maxVersion = string(value)
+		}
+
+		maxVersions[csvName] = strings.TrimSpace(maxVersion)
+	}
+
+	return maxVersions
+}
+
+// incompatibleBundles returns a description of each olm.maxOpenShiftVersion constraint of the bundles in the
+// InstallPlan that is not compatible with the cluster version. Like OLM, a bundle is considered incompatible when
+// its maximum version is before the next minor version of the cluster, since installing it would block the cluster
+// from upgrading. Nothing is returned when the cluster version is unknown.
+func incompatibleBundles(installPlan *operatorv1alpha1.InstallPlan, clusterVersion string) []string {
+	if clusterVersion == "" {
+		return nil
+	}
+
+	current, err := semver.NewVersion(clusterVersion)
+	if err != nil {
+		return nil
+	}
+
+	maxVersions := bundleMaxOpenShiftVersions(installPlan)
+	incompatible := []string{}
+
+	for _, csvName := range installPlan.Spec.ClusterServiceVersionNames {
+		maxVersion, ok := maxVersions[csvName]
+		if !ok {
+			continue
+		}
+
+		parsedMax, err := semver.NewVersion(maxVersion)
+		if err != nil {
+			incompatible = append(incompatible, fmt.Sprintf(
+				"the bundle %s has an invalid %s property (%s)", csvName, maxOpenShiftVersionProperty, maxVersion,
+			))
+
+			continue
+		}
+
+		if parsedMax.Major() > current.Major() ||
+			(parsedMax.Major() == current.Major() && parsedMax.Minor() > current.Minor()) {
+			continue
+		}
+
+		incompatible = append(incompatible, fmt.Sprintf(
+			"the bundle %s has the %s property %s, which blocks upgrading the cluster from version %s",
+			csvName, maxOpenShiftVersionProperty, maxVersion, clusterVersion,
+		))
+	}
+
+	return incompatible
+}
+
+// clearRollbackStatus removes the status fields used to roll back failed upgrades, and returns whether any were set.
+func clearRollbackStatus(policy *policyv1beta1.OperatorPolicy) bool {
+	changed := policy.Status.LastSucceededVersion != "" || len(policy.Status.BlockedVersions) != 0 ||
+		policy.Status.Rollback != nil
+
+	policy.Status.LastSucceededVersion = ""
+	policy.Status.BlockedVersions = nil
+	policy.Status.Rollback = nil
+
+	return changed
+}
+
+// handleUpgradeFailure tracks the last ClusterServiceVersion that succeeded and, when the policy has the
+// `upgradeFailurePolicy: Rollback` setting and is enforced, rolls back an upgrade whose ClusterServiceVersion failed.
+// The rollback deletes the Subscription and the failed ClusterServiceVersion and blocks the failed version, so that
+// the Subscription is recreated with the previous version as its starting CSV. A version that is not allowed by the
+// policy is neither tracked nor rolled back, but a rollback that was started for it is finished. It returns whether a
+// rollback was done.
+func (r *OperatorPolicyReconciler) handleUpgradeFailure(
+	ctx context.Context,
+	policy *policyv1beta1.OperatorPolicy,
+	sub *operatorv1alpha1.Subscription,
+	csv *operatorv1alpha1.ClusterServiceVersion,
+	relatedCSVs []policyv1.RelatedObject,
+	allowed bool,
+) (bool, []metav1.Condition, bool, error) {
+	if policy.Spec.UpgradeFailurePolicy != "Rollback" {
+		return false, nil, clearRollbackStatus(policy), nil
+	}
+
+	earlyConds := []metav1.Condition{}
+	changed := false
+
+	if csv.Status.Phase == operatorv1alpha1.CSVPhaseSucceeded {
+		if !allowed {
+			return false, nil, false, nil
+		}
+
+		if rollback := policy.Status.Rollback; rollback != nil && rollback.Version == csv.Name {
+			policy.Status.Rollback = nil
+
+			updateStatus(policy, rollbackSucceededCond(rollback.FailedVersion, rollback.Version), relatedCSVs...)
+			earlyConds = append(earlyConds, calculateComplianceCondition(policy))
+			changed = true
+		}
+
+		if policy.Status.LastSucceededVersion != csv.Name {
+			policy.Status.LastSucceededVersion = csv.Name
+			changed = true
+		}
+
+		return false, earlyConds, changed, nil
+	}
+
+	if policy.Status.Rollback == nil {
+		previous := policy.Status.LastSucceededVersion
+
+		if !allowed || csv.Status.Phase != operatorv1alpha1.CSVPhaseFailed || previous == "" ||
+			previous == csv.Name || policy.Spec.RemediationAction.IsInform() {
+			return false, nil, false, nil
+		}
+
+		if !slices.Contains(policy.Status.BlockedVersions, csv.Name) {
+			policy.Status.BlockedVersions = append(policy.Status.BlockedVersions, csv.Name)
+		}
+
+		policy.Status.Rollback = &policyv1beta1.UpgradeRollback{FailedVersion: csv.Name, Version: previous}
+
+		updateStatus(policy, upgradeFailedCond(csv.Name, previous), relatedCSVs...)
+		earlyConds = append(earlyConds, calculateComplianceCondition(policy))
+		changed = true
+	}
+
+	// The failed ClusterServiceVersion is deleted again if a previous attempt didn't finish
+	rollback := policy.Status.Rollback
+	if csv.Name != rollback.FailedVersion || policy.Spec.RemediationAction.IsInform() {
+		return false, earlyConds, changed, nil
+	}
+
+	opLog := ctrl.LoggerFrom(ctx)
+	target := r.target(policy)
+
+	opLog.Info("Rolling back the failed upgrade", "failedVersion", rollback.FailedVersion,
+		"version", rollback.Version)
+
+	opLog.Info("Deleting Subscription", "subName", sub.Name, "subNamespace", sub.Namespace)
+
+	err := target.Client.Delete(ctx, sub)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return false, earlyConds, changed, fmt.Errorf("error deleting the Subscription for the rollback: %w", err)
+	}
+
+	sub.SetGroupVersionKind(subscriptionGVK)
+	r.recordEnforced(ctx, policy, "deleted", sub, nil)
+
+	opLog.Info("Deleting ClusterServiceVersion", "csvName", csv.Name, "csvNamespace", csv.Namespace)
+
+	err = target.Client.Delete(ctx, csv)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return false, earlyConds, changed, fmt.Errorf(
+			"error deleting the ClusterServiceVersion for the rollback: %w", err,
+		)
+	}
+
+	csv.SetGroupVersionKind(clusterServiceVersionGVK)
+	r.recordEnforced(ctx, policy, "deleted", csv, nil)
+
+	updateStatus(policy, rollingBackCond(rollback.FailedVersion, rollback.Version), deletedObj(csv))
+
+	return true, earlyConds, true, nil
+}
@@ -0,0 +1,478 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	operatorv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	depclient "github.com/stolostron/kubernetes-dependency-watches/client"
+	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	policyv1beta1 "open-cluster-management.io/config-policy-controller/api/v1beta1"
+)
+
+func TestNextUpgradeApproval(t *testing.T) {
+	t.Parallel()
+
+	// A Wednesday
+	now := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		windows   []policyv1beta1.UpgradeApprovalWindow
+		soakTime  string
+		firstSeen time.Time
+		expected  time.Time
+	}{
+		"no restrictions": {
+			firstSeen: now.Add(-time.Minute),
+			expected:  now,
+		},
+		"soak time elapsed": {
+			soakTime:  "72h",
+			firstSeen: now.Add(-73 * time.Hour),
+			expected:  now,
+		},
+		"soak time remaining": {
+			soakTime:  "72h",
+			firstSeen: now.Add(-70 * time.Hour),
+			expected:  now.Add(2 * time.Hour),
+		},
+		"inside a daily window": {
+			windows:   []policyv1beta1.UpgradeApprovalWindow{{Start: "11:00", Duration: "2h"}},
+			firstSeen: now,
+			expected:  now,
+		},
+		"before a daily window": {
+			windows:   []policyv1beta1.UpgradeApprovalWindow{{Start: "22:30", Duration: "2h"}},
+			firstSeen: now,
+			expected:  time.Date(2024, 5, 15, 22, 30, 0, 0, time.UTC),
+		},
+		"inside a window that opened the day before": {
+			windows:   []policyv1beta1.UpgradeApprovalWindow{{Start: "22:00", Duration: "15h"}},
+			firstSeen: now,
+			expected:  now,
+		},
+		"after a daily window": {
+			windows:   []policyv1beta1.UpgradeApprovalWindow{{Start: "02:00", Duration: "4h"}},
+			firstSeen: now,
+			expected:  time.Date(2024, 5, 16, 2, 0, 0, 0, time.UTC),
+		},
+		"weekend window": {
+			windows: []policyv1beta1.UpgradeApprovalWindow{
+				{Days: []policyv1beta1.Weekday{"Saturday", "Sunday"}, Start: "01:00", Duration: "3h"},
+			},
+			firstSeen: now,
+			expected:  time.Date(2024, 5, 18, 1, 0, 0, 0, time.UTC),
+		},
+		"earliest of several windows": {
+			windows: []policyv1beta1.UpgradeApprovalWindow{
+				{Days: []policyv1beta1.Weekday{"Friday"}, Start: "01:00", Duration: "3h"},
+				{Days: []policyv1beta1.Weekday{"Thursday"}, Start: "20:00", Duration: "1h"},
+			},
+			firstSeen: now,
+			expected:  time.Date(2024, 5, 16, 20, 0, 0, 0, time.UTC),
+		},
+		"window in a time zone": {
+			windows: []policyv1beta1.UpgradeApprovalWindow{
+				{Start: "22:00", Duration: "1h", TimeZone: "America/New_York"},
+			},
+			firstSeen: now,
+			expected:  time.Date(2024, 5, 16, 2, 0, 0, 0, time.UTC),
+		},
+		"window after the soak time": {
+			windows:   []policyv1beta1.UpgradeApprovalWindow{{Start: "11:00", Duration: "2h"}},
+			soakTime:  "24h",
+			firstSeen: now.Add(-time.Hour),
+			expected:  time.Date(2024, 5, 16, 11, 0, 0, 0, time.UTC),
+		},
+		"soak time ending inside a window": {
+			windows:   []policyv1beta1.UpgradeApprovalWindow{{Start: "11:00", Duration: "3h"}},
+			soakTime:  "24h",
+			firstSeen: now.Add(-23 * time.Hour),
+			expected:  now.Add(time.Hour),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			policy := &policyv1beta1.OperatorPolicy{
+				Spec: policyv1beta1.OperatorPolicySpec{
+					UpgradeApprovalWindows: test.windows,
+					UpgradeSoakTime:        test.soakTime,
+				},
+			}
+
+			next, err := nextUpgradeApproval(policy, test.firstSeen, now)
+			assert.NoError(t, err)
+			assert.True(t, test.expected.Equal(next), "expected %s, got %s", test.expected, next)
+		})
+	}
+}
+
+func TestValidateUpgradeApproval(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		window   policyv1beta1.UpgradeApprovalWindow
+		soakTime string
+		expected string
+	}{
+		"valid": {
+			window:   policyv1beta1.UpgradeApprovalWindow{Start: "23:30", Duration: "24h", TimeZone: "Europe/Paris"},
+			soakTime: "168h",
+		},
+		"invalid day": {
+			window:   policyv1beta1.UpgradeApprovalWindow{Days: []policyv1beta1.Weekday{"Caturday"}, Start: "01:00"},
+			expected: "spec.upgradeApprovalWindows[0].days has an invalid day: Caturday",
+		},
+		"invalid start": {
+			window:   policyv1beta1.UpgradeApprovalWindow{Start: "25:00", Duration: "1h"},
+			expected: "spec.upgradeApprovalWindows[0].start must be in the HH:MM format: 25:00",
+		},
+		"window too long": {
+			window:   policyv1beta1.UpgradeApprovalWindow{Start: "01:00", Duration: "25h"},
+			expected: "spec.upgradeApprovalWindows[0].duration must be a positive duration of at most 24h: 25h",
+		},
+		"invalid time zone": {
+			window:   policyv1beta1.UpgradeApprovalWindow{Start: "01:00", Duration: "1h", TimeZone: "Mars/Olympus"},
+			expected: "spec.upgradeApprovalWindows[0].timeZone is not a valid time zone: Mars/Olympus",
+		},
+		"invalid soak time": {
+			window:   policyv1beta1.UpgradeApprovalWindow{Start: "01:00", Duration: "1h"},
+			soakTime: "3 days",
+			expected: "spec.upgradeSoakTime must be a non-negative duration: 3 days",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			policy := &policyv1beta1.OperatorPolicy{
+				Spec: policyv1beta1.OperatorPolicySpec{
+					UpgradeApprovalWindows: []policyv1beta1.UpgradeApprovalWindow{test.window},
+					UpgradeSoakTime:        test.soakTime,
+				},
+			}
+
+			err := validateUpgradeApproval(policy)
+			if test.expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.expected)
+			}
+		})
+	}
+}
+
+func TestPendingUpgrade(t *testing.T) {
+	t.Parallel()
+
+	created := metav1.NewTime(time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC))
+	earlier := metav1.NewTime(created.Add(-time.Hour))
+
+	installPlan := &operatorv1alpha1.InstallPlan{
+		ObjectMeta: metav1.ObjectMeta{CreationTimestamp: created},
+	}
+
+	pending := pendingUpgrade(nil, installPlan, "example.v1.2.0")
+	assert.Equal(t, "example.v1.2.0", pending.Version)
+	assert.Equal(t, created, pending.FirstSeen)
+
+	// The upgrade was seen earlier in another InstallPlan
+	previous := &policyv1beta1.PendingUpgrade{Version: "example.v1.2.0", FirstSeen: earlier}
+	assert.Equal(t, earlier, pendingUpgrade(previous, installPlan, "example.v1.2.0").FirstSeen)
+
+	// A different upgrade is pending
+	previous = &policyv1beta1.PendingUpgrade{Version: "example.v1.1.0", FirstSeen: earlier}
+	assert.Equal(t, created, pendingUpgrade(previous, installPlan, "example.v1.2.0").FirstSeen)
+}
+
+func TestIncompatibleBundles(t *testing.T) {
+	t.Parallel()
+
+	installPlan := &operatorv1alpha1.InstallPlan{
+		Spec: operatorv1alpha1.InstallPlanSpec{
+			ClusterServiceVersionNames: []string{"example.v1.2.0", "dependency.v0.3.0", "other.v2.0.0"},
+		},
+		Status: operatorv1alpha1.InstallPlanStatus{
+			BundleLookups: []operatorv1alpha1.BundleLookup{
+				{
+					Identifier: "example.v1.2.0",
+					Properties: `{"properties":[{"type":"olm.package","value":{"packageName":"example",` +
+						`"version":"1.2.0"}},{"type":"olm.maxOpenShiftVersion","value":"4.15"}]}`,
+				},
+				{
+					Identifier: "dependency.v0.3.0",
+					Properties: `{"properties":[{"type":"olm.maxOpenShiftVersion","value":4.10}]}`,
+				},
+				{
+					Identifier: "other.v2.0.0",
+					Properties: `{"properties":[{"type":"olm.package","value":{"packageName":"other",` +
+						`"version":"2.0.0"}}]}`,
+				},
+			},
+		},
+	}
+
+	assert.Equal(t,
+		map[string]string{"example.v1.2.0": "4.15", "dependency.v0.3.0": "4.10"},
+		bundleMaxOpenShiftVersions(installPlan),
+	)
+
+	tests := map[string]struct {
+		clusterVersion string
+		expected       []string
+	}{
+		"not OpenShift": {},
+		"compatible": {
+			clusterVersion: "4.9.12",
+			expected:       []string{},
+		},
+		"blocks the next minor upgrade": {
+			clusterVersion: "4.10.3",
+			expected: []string{
+				"the bundle dependency.v0.3.0 has the olm.maxOpenShiftVersion property 4.10, which blocks " +
+					"upgrading the cluster from version 4.10.3",
+			},
+		},
+		"past both versions": {
+			clusterVersion: "4.16.0-0.nightly-2024-05-01-000000",
+			expected: []string{
+				"the bundle example.v1.2.0 has the olm.maxOpenShiftVersion property 4.15, which blocks " +
+					"upgrading the cluster from version 4.16.0-0.nightly-2024-05-01-000000",
+				"the bundle dependency.v0.3.0 has the olm.maxOpenShiftVersion property 4.10, which blocks " +
+					"upgrading the cluster from version 4.16.0-0.nightly-2024-05-01-000000",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, incompatibleBundles(installPlan, test.clusterVersion))
+		})
+	}
+}
+
+func TestInstallPlanBlockedCond(t *testing.T) {
+	t.Parallel()
+
+	constraints := []string{
+		"the bundle example.v1.2.0 has the olm.maxOpenShiftVersion property 4.15, which blocks upgrading the " +
+			"cluster from version 4.15.2",
+	}
+
+	cond := installPlanBlockedCond("NonCompliant", []string{"example.v1.2.0"}, constraints)
+	assert.Equal(t, installPlanConditionType, cond.Type)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, "UpgradeBlocked", cond.Reason)
+	assert.Equal(t,
+		"an InstallPlan to update to [example.v1.2.0] is available for approval but it can't be approved because "+
+			constraints[0],
+		cond.Message,
+	)
+
+	cond = installPlanBlockedCond("Compliant", []string{"example.v1.2.0"}, constraints)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+}
+
+func TestHandleUpgradeFailure(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	assert.NoError(t, operatorv1alpha1.AddToScheme(scheme))
+
+	sub := &operatorv1alpha1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "operators"},
+	}
+	succeededCSV := &operatorv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "example.v1.0.0", Namespace: "operators"},
+		Status:     operatorv1alpha1.ClusterServiceVersionStatus{Phase: operatorv1alpha1.CSVPhaseSucceeded},
+	}
+	failedCSV := &operatorv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "example.v1.1.0", Namespace: "operators"},
+		Status:     operatorv1alpha1.ClusterServiceVersionStatus{Phase: operatorv1alpha1.CSVPhaseFailed},
+	}
+
+	targetClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sub, failedCSV).Build()
+	r := &OperatorPolicyReconciler{TargetClient: targetClient}
+
+	policy := &policyv1beta1.OperatorPolicy{
+		Spec: policyv1beta1.OperatorPolicySpec{
+			RemediationAction:    "enforce",
+			ComplianceType:       "musthave",
+			UpgradeFailurePolicy: "Rollback",
+		},
+	}
+
+	// The succeeded version is tracked
+	rolledBack, _, changed, err := r.handleUpgradeFailure(t.Context(), policy, sub, succeededCSV, nil, true)
+	assert.NoError(t, err)
+	assert.False(t, rolledBack)
+	assert.True(t, changed)
+	assert.Equal(t, "example.v1.0.0", policy.Status.LastSucceededVersion)
+
+	// The failed upgrade is rolled back
+	rolledBack, earlyConds, changed, err := r.handleUpgradeFailure(t.Context(), policy, sub, failedCSV, nil, true)
+	assert.NoError(t, err)
+	assert.True(t, rolledBack)
+	assert.True(t, changed)
+	assert.Len(t, earlyConds, 1)
+	assert.Equal(t, []string{"example.v1.1.0"}, policy.Status.BlockedVersions)
+	assert.Equal(t,
+		&policyv1beta1.UpgradeRollback{FailedVersion: "example.v1.1.0", Version: "example.v1.0.0"},
+		policy.Status.Rollback,
+	)
+
+	_, cond := policy.Status.GetCondition(csvConditionType)
+	assert.Equal(t, "RollingBack", cond.Reason)
+
+	err = targetClient.Get(t.Context(), client.ObjectKeyFromObject(sub), &operatorv1alpha1.Subscription{})
+	assert.True(t, k8serrors.IsNotFound(err))
+
+	err = targetClient.Get(
+		t.Context(), client.ObjectKeyFromObject(failedCSV), &operatorv1alpha1.ClusterServiceVersion{},
+	)
+	assert.True(t, k8serrors.IsNotFound(err))
+
+	// The rollback finishes when the previous version succeeds, and the failed version stays blocked
+	rolledBack, earlyConds, changed, err = r.handleUpgradeFailure(t.Context(), policy, sub, succeededCSV, nil, true)
+	assert.NoError(t, err)
+	assert.False(t, rolledBack)
+	assert.True(t, changed)
+	assert.Len(t, earlyConds, 1)
+	assert.Nil(t, policy.Status.Rollback)
+	assert.Equal(t, []string{"example.v1.1.0"}, policy.Status.BlockedVersions)
+
+	_, cond = policy.Status.GetCondition(csvConditionType)
+	assert.Equal(t, "RollbackSucceeded", cond.Reason)
+
+	// Without the Rollback setting, the status is cleared
+	policy.Spec.UpgradeFailurePolicy = "None"
+
+	_, _, changed, err = r.handleUpgradeFailure(t.Context(), policy, sub, failedCSV, nil, true)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Empty(t, policy.Status.LastSucceededVersion)
+	assert.Empty(t, policy.Status.BlockedVersions)
+}
+
+func TestHandleUpgradeFailureInform(t *testing.T) {
+	t.Parallel()
+
+	r := &OperatorPolicyReconciler{}
+	policy := &policyv1beta1.OperatorPolicy{
+		Spec: policyv1beta1.OperatorPolicySpec{
+			RemediationAction:    "inform",
+			ComplianceType:       "musthave",
+			UpgradeFailurePolicy: "Rollback",
+		},
+		Status: policyv1beta1.OperatorPolicyStatus{LastSucceededVersion: "example.v1.0.0"},
+	}
+	failedCSV := &operatorv1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "example.v1.1.0", Namespace: "operators"},
+		Status:     operatorv1alpha1.ClusterServiceVersionStatus{Phase: operatorv1alpha1.CSVPhaseFailed},
+	}
+
+	rolledBack, earlyConds, changed, err := r.handleUpgradeFailure(t.Context(), policy, nil, failedCSV, nil, true)
+	assert.NoError(t, err)
+	assert.False(t, rolledBack)
+	assert.False(t, changed)
+	assert.Empty(t, earlyConds)
+	assert.Nil(t, policy.Status.Rollback)
+	assert.Empty(t, policy.Status.BlockedVersions)
+}
+
+// csvWatcher only implements the method of the DynamicWatcher used by handleCSV.
+type csvWatcher struct {
+	depclient.DynamicWatcher
+	csvs []unstructured.Unstructured
+}
+
+func (w *csvWatcher) List(
+	_ depclient.ObjectIdentifier, _ schema.GroupVersionKind, _ string, _ labels.Selector,
+) ([]unstructured.Unstructured, error) {
+	return w.csvs, nil
+}
+
+func TestHandleCSVInterruptedRollback(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	assert.NoError(t, operatorv1alpha1.AddToScheme(scheme))
+
+	sub := &operatorv1alpha1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "operators"},
+		Spec:       &operatorv1alpha1.SubscriptionSpec{Package: "example", StartingCSV: "example.v1.0.0"},
+		Status:     operatorv1alpha1.SubscriptionStatus{InstalledCSV: "example.v1.1.0"},
+	}
+	failedCSV := &operatorv1alpha1.ClusterServiceVersion{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "operators.coreos.com/v1alpha1", Kind: "ClusterServiceVersion"},
+		ObjectMeta: metav1.ObjectMeta{Name: "example.v1.1.0", Namespace: "operators"},
+		Status:     operatorv1alpha1.ClusterServiceVersionStatus{Phase: operatorv1alpha1.CSVPhaseFailed},
+	}
+
+	unstructuredCSV, err := runtime.DefaultUnstructuredConverter.ToUnstructured(failedCSV)
+	assert.NoError(t, err)
+
+	targetClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sub, failedCSV).Build()
+	r := &OperatorPolicyReconciler{
+		TargetClient:   targetClient,
+		DynamicWatcher: &csvWatcher{csvs: []unstructured.Unstructured{{Object: unstructuredCSV}}},
+	}
+
+	// The rollback was started, but the failed ClusterServiceVersion wasn't deleted
+	policy := &policyv1beta1.OperatorPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "policies"},
+		Spec: policyv1beta1.OperatorPolicySpec{
+			RemediationAction:    "enforce",
+			ComplianceType:       "musthave",
+			UpgradeFailurePolicy: "Rollback",
+		},
+		Status: policyv1beta1.OperatorPolicyStatus{
+			LastSucceededVersion: "example.v1.0.0",
+			BlockedVersions:      []string{"example.v1.1.0"},
+			Rollback: &policyv1beta1.UpgradeRollback{
+				FailedVersion: "example.v1.1.0",
+				Version:       "example.v1.0.0",
+			},
+		},
+	}
+
+	csv, _, changed, err := r.handleCSV(t.Context(), policy, sub)
+	assert.NoError(t, err)
+	assert.Nil(t, csv)
+	assert.True(t, changed)
+
+	_, cond := policy.Status.GetCondition(csvConditionType)
+	assert.Equal(t, "RollingBack", cond.Reason)
+
+	err = targetClient.Get(
+		t.Context(), client.ObjectKeyFromObject(failedCSV), &operatorv1alpha1.ClusterServiceVersion{},
+	)
+	assert.True(t, k8serrors.IsNotFound(err))
+
+	// Without a rollback in progress, the blocked version is reported as not allowed
+	policy.Status.Rollback = nil
+
+	csv, _, _, err = r.handleCSV(t.Context(), policy, sub)
+	assert.NoError(t, err)
+	assert.NotNil(t, csv)
+
+	_, cond = policy.Status.GetCondition(csvConditionType)
+	assert.Equal(t, "UnapprovedVersion", cond.Reason)
+}
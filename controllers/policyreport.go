@@ -0,0 +1,526 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+	policyv1beta1 "open-cluster-management.io/config-policy-controller/api/v1beta1"
+)
+
+const (
+	// PolicyReportPerNamespace writes a PolicyReport in the namespace of each related object, and a
+	// ClusterPolicyReport for cluster-scoped related objects. The reports are shared by all policies.
+	PolicyReportPerNamespace = "namespace"
+	// PolicyReportPerPolicy writes a PolicyReport for each policy in the namespace of the policy.
+	PolicyReportPerPolicy = "policy"
+
+	// policyReportSource is the source of the results written by the controller, which is used to distinguish
+	// them from the results of other tools in a shared report.
+	policyReportSource = "config-policy-controller"
+	// policyReportName is the name of the shared reports in the PolicyReportPerNamespace mode.
+	policyReportName           = "config-policy-controller"
+	policyReportManagedByLabel = "app.kubernetes.io/managed-by"
+)
+
+var (
+	policyReportGVK = schema.GroupVersionKind{
+		Group: "wgpolicyk8s.io", Version: "v1alpha2", Kind: "PolicyReport",
+	}
+	clusterPolicyReportGVK = schema.GroupVersionKind{
+		Group: "wgpolicyk8s.io", Version: "v1alpha2", Kind: "ClusterPolicyReport",
+	}
+	// policyReportSeverities are the severities supported by the PolicyReport API.
+	policyReportSeverities = []string{"critical", "high", "medium", "low", "info"}
+)
+
+// policyReportID identifies a report. A ClusterPolicyReport has an empty namespace.
+type policyReportID types.NamespacedName
+
+// PolicyReporter writes the compliance of the related objects of policies to the PolicyReport and
+// ClusterPolicyReport resources of the Kubernetes Policy WG. Each related object is a result in the report.
+type PolicyReporter struct {
+	mode string
+	// policyClient writes the reports of the PolicyReportPerPolicy mode, which are on the cluster of the
+	// policies so that they can be owned by them.
+	policyClient client.Client
+	// targetClient writes the reports of the PolicyReportPerNamespace mode, which are on the cluster of the
+	// related objects.
+	targetClient client.Client
+	// reports has the reports that each policy has results in, in the PolicyReportPerNamespace mode. A policy
+	// without an entry has not been reported since the controller started.
+	reports map[policyIdentity]map[policyReportID]bool
+	// lock only protects the reports map, so that the API requests are not made while it is held
+	lock sync.Mutex
+	// policyLocks serializes the syncs of a policy, and reportLocks serializes the writes to a report, so
+	// that the policies of both controllers are only serialized when they write to the same report.
+	policyLocks keyedMutex[policyIdentity]
+	reportLocks keyedMutex[policyReportID]
+}
+
+// keyedMutex is a mutex for each key, which is removed when it is not in use.
+type keyedMutex[K comparable] struct {
+	lock  sync.Mutex
+	locks map[K]*keyMutex
+}
+
+type keyMutex struct {
+	sync.Mutex
+	// users is the number of goroutines that hold or wait for the mutex
+	users int
+}
+
+func (k *keyedMutex[K]) Lock(key K) {
+	k.lock.Lock()
+
+	if k.locks == nil {
+		k.locks = map[K]*keyMutex{}
+	}
+
+	mutex, ok := k.locks[key]
+	if !ok {
+		mutex = &keyMutex{}
+		k.locks[key] = mutex
+	}
+
+	mutex.users++
+
+	k.lock.Unlock()
+
+	mutex.Lock()
+}
+
+func (k *keyedMutex[K]) Unlock(key K) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	mutex := k.locks[key]
+	mutex.users--
+
+	if mutex.users == 0 {
+		delete(k.locks, key)
+	}
+
+	mutex.Unlock()
+}
+
+// NewPolicyReporter returns a PolicyReporter in the PolicyReportPerNamespace or PolicyReportPerPolicy mode.
+// An error is returned if the PolicyReport CRDs are not installed on the cluster the reports are written to.
+func NewPolicyReporter(mode string, policyClient, targetClient client.Client) (*PolicyReporter, error) {
+	reporter := &PolicyReporter{
+		mode:         mode,
+		policyClient: policyClient,
+		targetClient: targetClient,
+		reports:      map[policyIdentity]map[policyReportID]bool{},
+	}
+
+	var gvks []schema.GroupVersionKind
+
+	switch mode {
+	case PolicyReportPerNamespace:
+		gvks = []schema.GroupVersionKind{policyReportGVK, clusterPolicyReportGVK}
+	case PolicyReportPerPolicy:
+		gvks = []schema.GroupVersionKind{policyReportGVK}
+	default:
+		return nil, fmt.Errorf(
+			"the policy report mode %s is invalid, valid modes are: %s, %s",
+			mode, PolicyReportPerNamespace, PolicyReportPerPolicy,
+		)
+	}
+
+	for _, gvk := range gvks {
+		_, err := reporter.client().RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, fmt.Errorf("the %s API is not available: %w", gvk.Kind, err)
+		}
+	}
+
+	return reporter, nil
+}
+
+func (r *PolicyReporter) client() client.Client {
+	if r.mode == PolicyReportPerPolicy {
+		return r.policyClient
+	}
+
+	return r.targetClient
+}
+
+// Update replaces the results of the ConfigurationPolicy or OperatorPolicy in the reports with its related
+// objects. It is a no-op if the reports are not enabled.
+func (r *PolicyReporter) Update(ctx context.Context, policy client.Object) error {
+	if r == nil {
+		return nil
+	}
+
+	var kind, apiVersion string
+	var severity policyv1.Severity
+	var relatedObjects []policyv1.RelatedObject
+	var remoteTarget bool
+
+	switch plc := policy.(type) {
+	case *policyv1.ConfigurationPolicy:
+		kind = "ConfigurationPolicy"
+		severity = plc.Spec.Severity
+		relatedObjects = plc.Status.RelatedObjects
+		remoteTarget = plc.Spec.TargetCluster != nil
+		apiVersion = policyv1.GroupVersion.String()
+	case *policyv1beta1.OperatorPolicy:
+		kind = "OperatorPolicy"
+		severity = plc.Spec.Severity
+		relatedObjects = plc.Status.RelatedObjects
+		remoteTarget = plc.Spec.TargetCluster != nil
+		apiVersion = policyv1beta1.GroupVersion.String()
+	default:
+		return fmt.Errorf("the policy type %T is not supported in policy reports", policy)
+	}
+
+	// The related objects of a policy with a target cluster are not on the cluster of the shared reports
+	if r.mode == PolicyReportPerNamespace && remoteTarget {
+		relatedObjects = nil
+	}
+
+	id := policyIdentity{kind, types.NamespacedName{Namespace: policy.GetNamespace(), Name: policy.GetName()}}
+
+	var owner *metav1.OwnerReference
+
+	// Shared reports are not owned by a policy
+	if r.mode == PolicyReportPerPolicy {
+		owner = &metav1.OwnerReference{
+			APIVersion: apiVersion,
+			Kind:       kind,
+			Name:       policy.GetName(),
+			UID:        policy.GetUID(),
+		}
+	}
+
+	return r.sync(ctx, id, owner, policyReportResults(id, severity, relatedObjects))
+}
+
+// Remove deletes the results of the policy from the reports. It is a no-op if the reports are not enabled.
+func (r *PolicyReporter) Remove(ctx context.Context, kind, namespace, name string) error {
+	if r == nil {
+		return nil
+	}
+
+	return r.sync(ctx, policyIdentity{kind, types.NamespacedName{Namespace: namespace, Name: name}}, nil, nil)
+}
+
+// sync writes the results of the policy to the reports they belong in, and removes the results of the policy
+// from the other reports. A nil results slice means that the policy was deleted.
+func (r *PolicyReporter) sync(
+	ctx context.Context, id policyIdentity, owner *metav1.OwnerReference, results []interface{},
+) error {
+	desired := map[policyReportID][]interface{}{}
+
+	if r.mode == PolicyReportPerPolicy {
+		desired[policyReportID{Namespace: id.Namespace, Name: perPolicyReportName(id)}] = results
+	} else {
+		for _, result := range results {
+			resources, _, _ := unstructured.NestedSlice(result.(map[string]interface{}), "resources")
+			namespace, _, _ := unstructured.NestedString(resources[0].(map[string]interface{}), "namespace")
+			report := policyReportID{Namespace: namespace, Name: policyReportName}
+
+			desired[report] = append(desired[report], result)
+		}
+	}
+
+	r.policyLocks.Lock(id)
+	defer r.policyLocks.Unlock(id)
+
+	if r.mode == PolicyReportPerNamespace {
+		r.lock.Lock()
+		known, ok := r.reports[id]
+		r.lock.Unlock()
+
+		if !ok {
+			var err error
+
+			known, err = r.discoverReports(ctx, id)
+			if err != nil {
+				return err
+			}
+		}
+
+		for report := range known {
+			if _, ok := desired[report]; !ok {
+				desired[report] = nil
+			}
+		}
+	}
+
+	var errs []error
+
+	// The reports with errors are kept so that they are synced again on the next update
+	reports := map[policyReportID]bool{}
+
+	for report, reportResults := range desired {
+		r.reportLocks.Lock(report)
+		err := r.syncReport(ctx, report, id, owner, reportResults)
+		r.reportLocks.Unlock(report)
+
+		if err != nil {
+			errs = append(errs, err)
+			reports[report] = true
+		} else if len(reportResults) != 0 {
+			reports[report] = true
+		}
+	}
+
+	if r.mode == PolicyReportPerNamespace {
+		r.lock.Lock()
+
+		if results == nil && len(reports) == 0 {
+			delete(r.reports, id)
+		} else {
+			r.reports[id] = reports
+		}
+
+		r.lock.Unlock()
+	}
+
+	if len(errs) != 0 {
+		return fmt.Errorf(
+			"failed to update the policy reports of %s %s: %w", id.kind, id.String(), errors.Join(errs...),
+		)
+	}
+
+	return nil
+}
+
+// discoverReports finds the shared reports with results of the policy, which are from before the controller
+// started.
+func (r *PolicyReporter) discoverReports(ctx context.Context, id policyIdentity) (map[policyReportID]bool, error) {
+	reports := map[policyReportID]bool{}
+
+	for _, gvk := range []schema.GroupVersionKind{policyReportGVK, clusterPolicyReportGVK} {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+
+		err := r.targetClient.List(ctx, list, client.MatchingLabels{policyReportManagedByLabel: policyReportSource})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the %s resources: %w", gvk.Kind, err)
+		}
+
+		for _, report := range list.Items {
+			results, _, _ := unstructured.NestedSlice(report.Object, "results")
+
+			if slices.ContainsFunc(results, func(result interface{}) bool { return isPolicyResult(result, id) }) {
+				reports[policyReportID{Namespace: report.GetNamespace(), Name: report.GetName()}] = true
+			}
+		}
+	}
+
+	return reports, nil
+}
+
+// syncReport replaces the results of the policy in the report. The report is deleted when it has no
+// results left.
+func (r *PolicyReporter) syncReport(
+	ctx context.Context, report policyReportID, id policyIdentity, owner *metav1.OwnerReference,
+	results []interface{},
+) error {
+	reportClient := r.client()
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj := &unstructured.Unstructured{}
+
+		if report.Namespace == "" {
+			obj.SetGroupVersionKind(clusterPolicyReportGVK)
+		} else {
+			obj.SetGroupVersionKind(policyReportGVK)
+		}
+
+		err := reportClient.Get(ctx, client.ObjectKey(report), obj)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+
+		exists := err == nil
+
+		existingResults, _, _ := unstructured.NestedSlice(obj.Object, "results")
+		newResults := slices.DeleteFunc(
+			slices.Clone(existingResults), func(result interface{}) bool { return isPolicyResult(result, id) },
+		)
+		newResults = append(newResults, results...)
+		slices.SortStableFunc(newResults, comparePolicyResults)
+
+		if len(newResults) == 0 {
+			if !exists || obj.GetLabels()[policyReportManagedByLabel] != policyReportSource {
+				return nil
+			}
+
+			return client.IgnoreNotFound(reportClient.Delete(ctx, obj))
+		}
+
+		if exists && equality.Semantic.DeepEqual(existingResults, newResults) {
+			return nil
+		}
+
+		if err := unstructured.SetNestedSlice(obj.Object, newResults, "results"); err != nil {
+			return err
+		}
+
+		if err := unstructured.SetNestedMap(obj.Object, policyReportSummary(newResults), "summary"); err != nil {
+			return err
+		}
+
+		if !exists {
+			obj.SetNamespace(report.Namespace)
+			obj.SetName(report.Name)
+			obj.SetLabels(map[string]string{policyReportManagedByLabel: policyReportSource})
+
+			if owner != nil {
+				obj.SetOwnerReferences([]metav1.OwnerReference{*owner})
+			}
+
+			return reportClient.Create(ctx, obj)
+		}
+
+		return reportClient.Update(ctx, obj)
+	})
+}
+
+// perPolicyReportName is the name of the report of the policy in the PolicyReportPerPolicy mode.
+func perPolicyReportName(id policyIdentity) string {
+	return strings.ToLower(id.kind) + "-" + id.Name
+}
+
+// policyReportResults converts the related objects of the policy to report results.
+func policyReportResults(
+	id policyIdentity, severity policyv1.Severity, relatedObjects []policyv1.RelatedObject,
+) []interface{} {
+	results := make([]interface{}, 0, len(relatedObjects))
+
+	for _, related := range relatedObjects {
+		resource := map[string]interface{}{
+			"apiVersion": related.Object.APIVersion,
+			"kind":       related.Object.Kind,
+			"name":       related.Object.Metadata.Name,
+		}
+
+		if related.Object.Metadata.Namespace != "" {
+			resource["namespace"] = related.Object.Metadata.Namespace
+		}
+
+		if related.Properties != nil && related.Properties.UID != "" {
+			resource["uid"] = related.Properties.UID
+		}
+
+		result := map[string]interface{}{
+			"source":    policyReportSource,
+			"category":  id.kind,
+			"policy":    id.Name,
+			"result":    policyReportResult(related.Compliant),
+			"message":   related.Reason,
+			"resources": []interface{}{resource},
+			"properties": map[string]interface{}{
+				"policyNamespace": id.Namespace,
+			},
+		}
+
+		if sev := strings.ToLower(string(severity)); slices.Contains(policyReportSeverities, sev) {
+			result["severity"] = sev
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// policyReportResult converts the compliance of a related object to a report result.
+func policyReportResult(compliant string) string {
+	switch policyv1.ComplianceState(compliant) {
+	case policyv1.Compliant:
+		return "pass"
+	case policyv1.NonCompliant:
+		return "fail"
+	case policyv1.UnknownCompliancy:
+		return "warn"
+	default:
+		return "skip"
+	}
+}
+
+// policyReportSummary counts the results of the report by their result.
+func policyReportSummary(results []interface{}) map[string]interface{} {
+	summary := map[string]interface{}{
+		"pass": int64(0), "fail": int64(0), "warn": int64(0), "error": int64(0), "skip": int64(0),
+	}
+
+	for _, result := range results {
+		value, _, _ := unstructured.NestedString(result.(map[string]interface{}), "result")
+		if count, ok := summary[value].(int64); ok {
+			summary[value] = count + 1
+		}
+	}
+
+	return summary
+}
+
+// isPolicyResult returns true if the report result was written by the controller for the policy.
+func isPolicyResult(result interface{}, id policyIdentity) bool {
+	fields, ok := result.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	source, _, _ := unstructured.NestedString(fields, "source")
+	category, _, _ := unstructured.NestedString(fields, "category")
+	name, _, _ := unstructured.NestedString(fields, "policy")
+	namespace, _, _ := unstructured.NestedString(fields, "properties", "policyNamespace")
+
+	return source == policyReportSource && category == id.kind && name == id.Name && namespace == id.Namespace
+}
+
+// comparePolicyResults orders the report results by their policy and then their resource, so that the
+// results are consistent regardless of the order the policies are updated in.
+func comparePolicyResults(a, b interface{}) int {
+	return strings.Compare(policyResultSortKey(a), policyResultSortKey(b))
+}
+
+func policyResultSortKey(result interface{}) string {
+	fields, ok := result.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	keyFields := [][]string{
+		{"source"}, {"category"}, {"properties", "policyNamespace"}, {"policy"},
+	}
+
+	key := make([]string, 0, len(keyFields)+4)
+
+	for _, path := range keyFields {
+		value, _, _ := unstructured.NestedString(fields, path...)
+		key = append(key, value)
+	}
+
+	resources, _, _ := unstructured.NestedSlice(fields, "resources")
+	if len(resources) != 0 {
+		if resource, ok := resources[0].(map[string]interface{}); ok {
+			for _, field := range []string{"apiVersion", "kind", "namespace", "name"} {
+				value, _, _ := unstructured.NestedString(resource, field)
+				key = append(key, value)
+			}
+		}
+	}
+
+	return strings.Join(key, "\x00")
+}
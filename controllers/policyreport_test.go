@@ -0,0 +1,293 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+	policyv1beta1 "open-cluster-management.io/config-policy-controller/api/v1beta1"
+)
+
+func policyReportClient() client.Client {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{policyReportGVK.GroupVersion()})
+	mapper.Add(policyReportGVK, meta.RESTScopeNamespace)
+	mapper.Add(clusterPolicyReportGVK, meta.RESTScopeRoot)
+
+	return fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithRESTMapper(mapper).Build()
+}
+
+func getPolicyReport(t *testing.T, c client.Client, namespace, name string) *unstructured.Unstructured {
+	t.Helper()
+
+	report := &unstructured.Unstructured{}
+
+	if namespace == "" {
+		report.SetGroupVersionKind(clusterPolicyReportGVK)
+	} else {
+		report.SetGroupVersionKind(policyReportGVK)
+	}
+
+	err := c.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: name}, report)
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+
+	assert.NoError(t, err)
+
+	return report
+}
+
+func reportResults(t *testing.T, report *unstructured.Unstructured) []map[string]interface{} {
+	t.Helper()
+
+	results, _, err := unstructured.NestedSlice(report.Object, "results")
+	assert.NoError(t, err)
+
+	summarized := make([]map[string]interface{}, 0, len(results))
+
+	for _, result := range results {
+		fields := result.(map[string]interface{})
+		resources := fields["resources"].([]interface{})
+
+		summarized = append(summarized, map[string]interface{}{
+			"policy":   fields["policy"],
+			"category": fields["category"],
+			"result":   fields["result"],
+			"severity": fields["severity"],
+			"message":  fields["message"],
+			"resource": resources[0].(map[string]interface{})["name"],
+		})
+	}
+
+	return summarized
+}
+
+func TestPolicyReporterPerNamespace(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	c := policyReportClient()
+
+	reporter, err := NewPolicyReporter(PolicyReportPerNamespace, nil, c)
+	assert.NoError(t, err)
+
+	configPolicy := &policyv1.ConfigurationPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "config-policy", Namespace: "policies"},
+		Spec:       policyv1.ConfigurationPolicySpec{Severity: "High"},
+		Status: policyv1.ConfigurationPolicyStatus{
+			RelatedObjects: []policyv1.RelatedObject{
+				relatedConfigMap("default", "cm-2", "NonCompliant", "Resource not found but should exist"),
+				relatedConfigMap("default", "cm-1", "Compliant", "Resource found as expected"),
+				relatedConfigMap("other", "cm-3", "Compliant", "Resource found as expected"),
+				{
+					Object: policyv1.ObjectResource{
+						APIVersion: "v1",
+						Kind:       "Namespace",
+						Metadata:   policyv1.ObjectMetadata{Name: "default"},
+					},
+					Compliant: "Compliant",
+					Reason:    "Resource found as expected",
+				},
+			},
+		},
+	}
+
+	operatorPolicy := &policyv1beta1.OperatorPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "operator-policy", Namespace: "policies"},
+		Spec:       policyv1beta1.OperatorPolicySpec{Severity: "unsupported"},
+		Status: policyv1beta1.OperatorPolicyStatus{
+			RelatedObjects: []policyv1.RelatedObject{
+				relatedConfigMap("default", "cm-0", "", "Resource is pending"),
+			},
+		},
+	}
+
+	assert.NoError(t, reporter.Update(ctx, configPolicy))
+	assert.NoError(t, reporter.Update(ctx, operatorPolicy))
+
+	defaultReport := getPolicyReport(t, c, "default", policyReportName)
+	assert.NotNil(t, defaultReport)
+	assert.Equal(t, []map[string]interface{}{
+		{
+			"policy": "config-policy", "category": "ConfigurationPolicy", "result": "pass", "severity": "high",
+			"message": "Resource found as expected", "resource": "cm-1",
+		},
+		{
+			"policy": "config-policy", "category": "ConfigurationPolicy", "result": "fail", "severity": "high",
+			"message": "Resource not found but should exist", "resource": "cm-2",
+		},
+		{
+			"policy": "operator-policy", "category": "OperatorPolicy", "result": "warn", "severity": nil,
+			"message": "Resource is pending", "resource": "cm-0",
+		},
+	}, reportResults(t, defaultReport))
+
+	summary, _, _ := unstructured.NestedMap(defaultReport.Object, "summary")
+	assert.Equal(t, map[string]interface{}{
+		"pass": int64(1), "fail": int64(1), "warn": int64(1), "error": int64(0), "skip": int64(0),
+	}, summary)
+
+	assert.Len(t, reportResults(t, getPolicyReport(t, c, "other", policyReportName)), 1)
+	assert.Len(t, reportResults(t, getPolicyReport(t, c, "", policyReportName)), 1)
+
+	// Moving the related object out of a namespace deletes the report of that namespace
+	configPolicy.Status.RelatedObjects = configPolicy.Status.RelatedObjects[:2]
+	assert.NoError(t, reporter.Update(ctx, configPolicy))
+	assert.Nil(t, getPolicyReport(t, c, "other", policyReportName))
+	assert.Nil(t, getPolicyReport(t, c, "", policyReportName))
+
+	// A new reporter discovers the reports from before it started
+	reporter, err = NewPolicyReporter(PolicyReportPerNamespace, nil, c)
+	assert.NoError(t, err)
+
+	assert.NoError(t, reporter.Remove(ctx, "ConfigurationPolicy", "policies", "config-policy"))
+	assert.Len(t, reportResults(t, getPolicyReport(t, c, "default", policyReportName)), 1)
+
+	assert.NoError(t, reporter.Remove(ctx, "OperatorPolicy", "policies", "operator-policy"))
+	assert.Nil(t, getPolicyReport(t, c, "default", policyReportName))
+	assert.Empty(t, reporter.reports)
+}
+
+func TestPolicyReporterConcurrentUpdates(t *testing.T) {
+	t.Parallel()
+
+	c := policyReportClient()
+
+	reporter, err := NewPolicyReporter(PolicyReportPerNamespace, nil, c)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	// The policies write to the same report concurrently, so none of the results can be lost
+	for i := range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			policy := &policyv1.ConfigurationPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("policy-%d", i), Namespace: "policies"},
+				Status: policyv1.ConfigurationPolicyStatus{
+					RelatedObjects: []policyv1.RelatedObject{
+						relatedConfigMap("default", fmt.Sprintf("cm-%d", i), "Compliant", "Resource found as expected"),
+					},
+				},
+			}
+
+			assert.NoError(t, reporter.Update(t.Context(), policy))
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Len(t, reportResults(t, getPolicyReport(t, c, "default", policyReportName)), 10)
+	assert.Empty(t, reporter.policyLocks.locks)
+	assert.Empty(t, reporter.reportLocks.locks)
+}
+
+func TestKeyedMutex(t *testing.T) {
+	t.Parallel()
+
+	var mutex keyedMutex[string]
+
+	mutex.Lock("a")
+
+	// A different key isn't blocked
+	mutex.Lock("b")
+	mutex.Unlock("b")
+
+	locked := make(chan struct{})
+
+	go func() {
+		mutex.Lock("a")
+		mutex.Unlock("a")
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("expected the second lock of the key to wait for the first one")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	mutex.Unlock("a")
+	<-locked
+
+	assert.Empty(t, mutex.locks)
+}
+
+func TestPolicyReporterPerPolicy(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	c := policyReportClient()
+
+	reporter, err := NewPolicyReporter(PolicyReportPerPolicy, c, nil)
+	assert.NoError(t, err)
+
+	policy := &policyv1.ConfigurationPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "config-policy", Namespace: "policies", UID: "policy-uid"},
+		Spec:       policyv1.ConfigurationPolicySpec{Severity: "low"},
+		Status: policyv1.ConfigurationPolicyStatus{
+			RelatedObjects: []policyv1.RelatedObject{
+				relatedConfigMap("default", "cm-1", "Compliant", "Resource found as expected"),
+				relatedConfigMap("other", "cm-2", "Compliant", "Resource found as expected"),
+			},
+		},
+	}
+
+	assert.NoError(t, reporter.Update(ctx, policy))
+
+	report := getPolicyReport(t, c, "policies", "configurationpolicy-config-policy")
+	assert.NotNil(t, report)
+	assert.Len(t, reportResults(t, report), 2)
+	assert.Equal(t, []metav1.OwnerReference{{
+		APIVersion: "policy.open-cluster-management.io/v1",
+		Kind:       "ConfigurationPolicy",
+		Name:       "config-policy",
+		UID:        "policy-uid",
+	}}, report.GetOwnerReferences())
+
+	// An unchanged report is not updated
+	assert.NoError(t, reporter.Update(ctx, policy))
+	assert.Equal(
+		t,
+		report.GetResourceVersion(),
+		getPolicyReport(t, c, "policies", "configurationpolicy-config-policy").GetResourceVersion(),
+	)
+
+	assert.NoError(t, reporter.Remove(ctx, "ConfigurationPolicy", "policies", "config-policy"))
+	assert.Nil(t, getPolicyReport(t, c, "policies", "configurationpolicy-config-policy"))
+}
+
+func TestNewPolicyReporter(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewPolicyReporter("cluster", nil, nil)
+	assert.ErrorContains(t, err, "the policy report mode cluster is invalid")
+
+	c := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()
+
+	_, err = NewPolicyReporter(PolicyReportPerPolicy, c, c)
+	assert.ErrorContains(t, err, "the PolicyReport API is not available")
+
+	var reporter *PolicyReporter
+
+	assert.NoError(t, reporter.Update(context.TODO(), &policyv1.ConfigurationPolicy{}))
+	assert.NoError(t, reporter.Remove(context.TODO(), "ConfigurationPolicy", "policies", "policy"))
+}
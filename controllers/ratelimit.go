@@ -42,3 +42,29 @@ func (l *PerItemRateLimiter[T]) GetLimiter(item T) *rate.Limiter {
 
 	return limiter
 }
+
+// RateLimiterState is a snapshot of the rate limiter of an item, for troubleshooting.
+type RateLimiterState struct {
+	// Tokens is the number of evaluations that are currently allowed.
+	Tokens float64 `json:"tokens"`
+	Burst  int     `json:"burst"`
+	// TokensPerSecond is the rate at which the tokens are replenished.
+	TokensPerSecond float64 `json:"tokensPerSecond"`
+}
+
+// State returns the state of the rate limiter of the item, and false if the item has no rate limiter yet.
+func (l *PerItemRateLimiter[T]) State(item T) (RateLimiterState, bool) {
+	l.lock.RLock()
+	limiter, exists := l.limiters[item]
+	l.lock.RUnlock()
+
+	if !exists {
+		return RateLimiterState{}, false
+	}
+
+	return RateLimiterState{
+		Tokens:          limiter.Tokens(),
+		Burst:           limiter.Burst(),
+		TokensPerSecond: float64(limiter.Limit()),
+	}, true
+}
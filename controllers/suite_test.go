@@ -41,7 +41,11 @@ var _ = BeforeSuite(func() {
 
 	By("bootstrapping test environment")
 	testEnv = &envtest.Environment{
-		CRDDirectoryPaths:     []string{filepath.Join("..", "deploy", "crds")},
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "deploy", "crds"),
+			filepath.Join("..", "test", "crds", "clusterextensions.olm.operatorframework.io.yaml"),
+			filepath.Join("..", "test", "crds", "clustercatalogs.olm.operatorframework.io.yaml"),
+		},
 		ErrorIfCRDPathMissing: true,
 	}
 
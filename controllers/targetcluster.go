@@ -0,0 +1,492 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	depclient "github.com/stolostron/kubernetes-dependency-watches/client"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/config"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+	"open-cluster-management.io/config-policy-controller/pkg/common"
+)
+
+// ErrTargetClusterUnavailable is returned when the target cluster referenced by a policy can't be used.
+var ErrTargetClusterUnavailable = errors.New("the target cluster is unavailable")
+
+const (
+	// targetClusterHealthInterval is how often the connectivity to a target cluster is checked.
+	targetClusterHealthInterval = 30 * time.Second
+	// targetClusterTimeout is the maximum time to wait on a target cluster when checking its
+	// connectivity or starting its dynamic watcher.
+	targetClusterTimeout = 10 * time.Second
+	// targetClusterRetryInterval is when a policy is reevaluated after its target cluster was unavailable.
+	targetClusterRetryInterval = 30 * time.Second
+)
+
+// TargetClusterClients are the clients used to evaluate and enforce policies on a cluster.
+type TargetClusterClients struct {
+	K8sClient      kubernetes.Interface
+	DynamicClient  dynamic.Interface
+	Client         client.Client
+	DynamicWatcher depclient.DynamicWatcher
+	// SelectorReconciler is only set when the TargetClusterManager is configured for namespace selection.
+	SelectorReconciler common.SelectorReconciler
+}
+
+type targetCluster struct {
+	clients         TargetClusterClients
+	kubeconfigHash  [sha256.Size]byte
+	secret          types.NamespacedName
+	cancel          context.CancelFunc
+	lastHealthCheck time.Time
+	healthErr       error
+}
+
+// TargetClusterManager creates and caches the clients for the target clusters referenced in the
+// `spec.targetCluster.kubeconfigSecretRef` field of policies. The clients for a cluster are shared by all
+// of the policies referencing the same Secret key, and they are stopped when no policy references it.
+type TargetClusterManager struct {
+	ctx context.Context
+	// secretReader reads the kubeconfig Secrets. It is not backed by a cache so that the controller does
+	// not need to watch all Secrets.
+	secretReader      client.Reader
+	scheme            *runtime.Scheme
+	watcherReconciler depclient.Reconciler
+	selectorUpdates   chan<- event.GenericEvent
+
+	defaultIncludeTerminating string
+
+	clusters map[string]*targetCluster
+	users    map[depclient.ObjectIdentifier]string
+	lock     sync.Mutex
+}
+
+// NewTargetClusterManager returns a TargetClusterManager whose clients are stopped when the context is
+// canceled. The events from the dynamic watchers of the target clusters are sent to the watcher
+// reconciler. When selectorUpdates is not nil, a NamespaceSelectorReconciler is started for each target
+// cluster and sends its updates to that channel.
+func NewTargetClusterManager(
+	ctx context.Context,
+	secretReader client.Reader,
+	scheme *runtime.Scheme,
+	watcherReconciler depclient.Reconciler,
+	selectorUpdates chan<- event.GenericEvent,
+	defaultIncludeTerminating string,
+) *TargetClusterManager {
+	return &TargetClusterManager{
+		ctx:                       ctx,
+		secretReader:              secretReader,
+		scheme:                    scheme,
+		watcherReconciler:         watcherReconciler,
+		selectorUpdates:           selectorUpdates,
+		defaultIncludeTerminating: defaultIncludeTerminating,
+		clusters:                  map[string]*targetCluster{},
+		users:                     map[depclient.ObjectIdentifier]string{},
+	}
+}
+
+// Get returns the clients for the target cluster in the referenced Secret in the namespace and
+// registers the user, which is the identifier of the policy, as using them. If the user previously used
+// another target cluster, its watches and namespace selection on that cluster are stopped. An error
+// wrapping ErrTargetClusterUnavailable is returned if the Secret is invalid or the cluster is unreachable.
+func (m *TargetClusterManager) Get(
+	ctx context.Context, user depclient.ObjectIdentifier, namespace string, ref policyv1.KubeconfigSecretReference,
+) (*TargetClusterClients, error) {
+	key := ref.Key
+	if key == "" {
+		key = "kubeconfig"
+	}
+
+	secretName := types.NamespacedName{Namespace: namespace, Name: ref.Name}
+	secret := corev1.Secret{}
+
+	if err := m.secretReader.Get(ctx, secretName, &secret); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, fmt.Errorf(
+				"%w: the kubeconfig Secret %s was not found", ErrTargetClusterUnavailable, secretName,
+			)
+		}
+
+		return nil, fmt.Errorf(
+			"%w: failed to get the kubeconfig Secret %s: %w", ErrTargetClusterUnavailable, secretName, err,
+		)
+	}
+
+	kubeconfig, ok := secret.Data[key]
+	if !ok || len(kubeconfig) == 0 {
+		return nil, fmt.Errorf(
+			"%w: the kubeconfig Secret %s does not have the %s key", ErrTargetClusterUnavailable, secretName, key,
+		)
+	}
+
+	clusterKey := secretName.String() + "/" + key
+	kubeconfigHash := sha256.Sum256(kubeconfig)
+
+	m.lock.Lock()
+
+	cluster := m.clusters[clusterKey]
+
+	// The kubeconfig changed, so the clients must be recreated
+	if cluster != nil && cluster.kubeconfigHash != kubeconfigHash {
+		m.stopLocked(clusterKey)
+
+		cluster = nil
+	}
+
+	checkHealth := cluster != nil && time.Since(cluster.lastHealthCheck) >= targetClusterHealthInterval
+
+	m.lock.Unlock()
+
+	// Connecting to the cluster can take a while, so it's done without holding the lock
+	if cluster == nil {
+		newCluster, err := m.newTargetCluster(kubeconfig)
+		if err != nil {
+			targetClusterReachableGauge.WithLabelValues(secretName.Namespace, secretName.Name).Set(0)
+
+			return nil, fmt.Errorf("%w: %w", ErrTargetClusterUnavailable, err)
+		}
+
+		newCluster.kubeconfigHash = kubeconfigHash
+		newCluster.secret = secretName
+
+		m.lock.Lock()
+
+		// Another policy may have connected to the same cluster in the meantime
+		if existing := m.clusters[clusterKey]; existing != nil && existing.kubeconfigHash == kubeconfigHash {
+			newCluster.cancel()
+
+			cluster = existing
+		} else {
+			m.stopLocked(clusterKey)
+
+			m.clusters[clusterKey] = newCluster
+			cluster = newCluster
+		}
+	} else {
+		var healthErr error
+
+		if checkHealth {
+			healthErr = checkTargetClusterHealth(cluster.clients.K8sClient)
+		}
+
+		m.lock.Lock()
+
+		// The clients were stopped in the meantime because the kubeconfig changed
+		if m.clusters[clusterKey] != cluster {
+			m.lock.Unlock()
+
+			return nil, fmt.Errorf("%w: the kubeconfig Secret %s changed", ErrTargetClusterUnavailable, secretName)
+		}
+
+		if checkHealth {
+			cluster.healthErr = healthErr
+			cluster.lastHealthCheck = time.Now()
+		}
+	}
+
+	defer m.lock.Unlock()
+
+	if cluster.healthErr != nil {
+		targetClusterReachableGauge.WithLabelValues(secretName.Namespace, secretName.Name).Set(0)
+
+		return nil, fmt.Errorf("%w: %w", ErrTargetClusterUnavailable, cluster.healthErr)
+	}
+
+	targetClusterReachableGauge.WithLabelValues(secretName.Namespace, secretName.Name).Set(1)
+
+	if prevClusterKey, ok := m.users[user]; ok && prevClusterKey != clusterKey {
+		m.releaseLocked(user)
+	}
+
+	m.users[user] = clusterKey
+
+	return &cluster.clients, nil
+}
+
+// Lookup returns the clients for the target cluster that the user was last registered with in Get. It
+// returns nil if the user is not registered.
+func (m *TargetClusterManager) Lookup(user depclient.ObjectIdentifier) *TargetClusterClients {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	cluster, ok := m.clusters[m.users[user]]
+	if !ok {
+		return nil
+	}
+
+	return &cluster.clients
+}
+
+// Release stops the watches and namespace selection of the user on its target cluster. The clients for
+// the target cluster are stopped if there are no other users.
+func (m *TargetClusterManager) Release(user depclient.ObjectIdentifier) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.releaseLocked(user)
+}
+
+func (m *TargetClusterManager) releaseLocked(user depclient.ObjectIdentifier) {
+	clusterKey, ok := m.users[user]
+	if !ok {
+		return
+	}
+
+	delete(m.users, user)
+
+	cluster, ok := m.clusters[clusterKey]
+	if !ok {
+		return
+	}
+
+	if err := cluster.clients.DynamicWatcher.RemoveWatcher(user); err != nil {
+		ctrl.LoggerFrom(m.ctx).Error(
+			err, "Failed to remove the watches on the target cluster. Will ignore.", "watcher", user,
+		)
+	}
+
+	if cluster.clients.SelectorReconciler != nil {
+		cluster.clients.SelectorReconciler.Stop(user.Namespace, user.Name)
+	}
+
+	for _, otherClusterKey := range m.users {
+		if otherClusterKey == clusterKey {
+			return
+		}
+	}
+
+	m.stopLocked(clusterKey)
+}
+
+// stopLocked stops the clients of the target cluster. The users of the cluster will need to call Get to
+// use the cluster again.
+func (m *TargetClusterManager) stopLocked(clusterKey string) {
+	cluster, ok := m.clusters[clusterKey]
+	if !ok {
+		return
+	}
+
+	cluster.cancel()
+	delete(m.clusters, clusterKey)
+
+	_ = targetClusterReachableGauge.DeletePartialMatch(prometheus.Labels{
+		"secret_namespace": cluster.secret.Namespace,
+		"secret_name":      cluster.secret.Name,
+	})
+}
+
+func (m *TargetClusterManager) newTargetCluster(kubeconfig []byte) (*targetCluster, error) {
+	cfg, err := restConfigFromKubeconfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Wrap(CountAPIRequests)
+
+	k8sClient, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fail fast before starting any watches if the cluster is unreachable
+	if err := checkTargetClusterHealth(k8sClient); err != nil {
+		return nil, err
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	runtimeClient, err := client.New(cfg, client.Options{Scheme: m.scheme})
+	if err != nil {
+		return nil, err
+	}
+
+	clusterCtx, cancel := context.WithCancel(m.ctx)
+
+	watcher, err := depclient.New(
+		cfg,
+		m.watcherReconciler,
+		&depclient.Options{DisableInitialReconcile: true, EnableCache: true},
+	)
+	if err != nil {
+		cancel()
+
+		return nil, err
+	}
+
+	go func() {
+		if err := watcher.Start(clusterCtx); err != nil {
+			ctrl.LoggerFrom(m.ctx).Error(err, "The dynamic watcher for the target cluster stopped", "host", cfg.Host)
+		}
+	}()
+
+	select {
+	case <-watcher.Started():
+	case <-time.After(targetClusterTimeout):
+		cancel()
+
+		return nil, errors.New("timed out waiting for the dynamic watcher to start")
+	}
+
+	cluster := &targetCluster{
+		clients: TargetClusterClients{
+			K8sClient:      k8sClient,
+			DynamicClient:  dynamicClient,
+			Client:         runtimeClient,
+			DynamicWatcher: watcher,
+		},
+		cancel:          cancel,
+		lastHealthCheck: time.Now(),
+	}
+
+	if m.selectorUpdates != nil {
+		selectorReconciler, err := m.startSelectorReconciler(clusterCtx, cfg)
+		if err != nil {
+			cancel()
+
+			return nil, err
+		}
+
+		cluster.clients.SelectorReconciler = selectorReconciler
+	}
+
+	return cluster, nil
+}
+
+// startSelectorReconciler starts a NamespaceSelectorReconciler for the target cluster with its own
+// controller-runtime manager, since it relies on a cache of the namespaces on the target cluster.
+func (m *TargetClusterManager) startSelectorReconciler(
+	ctx context.Context, cfg *rest.Config,
+) (*common.NamespaceSelectorReconciler, error) {
+	mgr, err := manager.New(cfg, manager.Options{
+		Scheme:  m.scheme,
+		Metrics: metricsserver.Options{BindAddress: "0"},
+		// The same controller is started for every target cluster
+		Controller: config.Controller{SkipNameValidation: ptr.To(true)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	selectorReconciler, err := common.NewNamespaceSelectorReconciler(
+		mgr.GetClient(), m.selectorUpdates, m.defaultIncludeTerminating,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := selectorReconciler.SetupWithManager(mgr); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		if err := mgr.Start(ctx); err != nil {
+			ctrl.LoggerFrom(m.ctx).Error(err, "The namespace selector for the target cluster stopped", "host", cfg.Host)
+		}
+	}()
+
+	return &selectorReconciler, nil
+}
+
+func checkTargetClusterHealth(k8sClient kubernetes.Interface) error {
+	restClient := k8sClient.Discovery().RESTClient()
+	if restClient == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), targetClusterTimeout)
+	defer cancel()
+
+	err := restClient.Get().AbsPath("/version").Do(ctx).Error()
+	if err != nil {
+		return fmt.Errorf("the target cluster is unreachable: %w", err)
+	}
+
+	return nil
+}
+
+// restConfigFromKubeconfig builds the REST config from the kubeconfig in a Secret. Since any policy
+// author can reference a Secret, the kubeconfig may only contain inline credentials: exec and
+// auth-provider plugins would run in the controller pod, and file paths would read its filesystem.
+func restConfigFromKubeconfig(kubeconfig []byte) (*rest.Config, error) {
+	rawConfig, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("the kubeconfig is invalid: %w", err)
+	}
+
+	for name, authInfo := range rawConfig.AuthInfos {
+		switch {
+		case authInfo.Exec != nil:
+			return nil, fmt.Errorf("the kubeconfig is invalid: the user %s uses an exec plugin", name)
+		case authInfo.AuthProvider != nil:
+			return nil, fmt.Errorf("the kubeconfig is invalid: the user %s uses an auth-provider", name)
+		case authInfo.TokenFile != "" || authInfo.ClientCertificate != "" || authInfo.ClientKey != "":
+			return nil, fmt.Errorf("the kubeconfig is invalid: the user %s references a file", name)
+		}
+	}
+
+	for name, cluster := range rawConfig.Clusters {
+		if cluster.CertificateAuthority != "" {
+			return nil, fmt.Errorf("the kubeconfig is invalid: the cluster %s references a file", name)
+		}
+	}
+
+	cfg, err := clientcmd.NewDefaultClientConfig(*rawConfig, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("the kubeconfig is invalid: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// resolveTargetCluster determines the clients to use for the policy. It returns nil if the policy should
+// use the default clients.
+func resolveTargetCluster(
+	ctx context.Context,
+	targetClusters *TargetClusterManager,
+	user depclient.ObjectIdentifier,
+	namespace string,
+	targetCluster *policyv1.TargetCluster,
+) (*TargetClusterClients, error) {
+	if targetClusters == nil {
+		if targetCluster != nil {
+			return nil, fmt.Errorf(
+				"%w: target clusters are not supported by this controller", ErrTargetClusterUnavailable,
+			)
+		}
+
+		return nil, nil
+	}
+
+	if targetCluster == nil {
+		targetClusters.Release(user)
+
+		return nil, nil
+	}
+
+	return targetClusters.Get(ctx, user, namespace, targetCluster.KubeconfigSecretRef)
+}
@@ -0,0 +1,183 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	depclient "github.com/stolostron/kubernetes-dependency-watches/client"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+)
+
+func TestResolveTargetCluster(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+
+	secretReader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "remote", Namespace: "policies"},
+			Data: map[string][]byte{
+				"kubeconfig": []byte("not a kubeconfig"),
+				"empty":      {},
+			},
+		},
+	).Build()
+
+	manager := NewTargetClusterManager(context.TODO(), secretReader, scheme, nil, nil, "")
+	user := depclient.ObjectIdentifier{
+		Group:     policyv1.GroupVersion.Group,
+		Version:   policyv1.GroupVersion.Version,
+		Kind:      "ConfigurationPolicy",
+		Namespace: "policies",
+		Name:      "policy",
+	}
+
+	tests := map[string]struct {
+		manager       *TargetClusterManager
+		targetCluster *policyv1.TargetCluster
+		errMsg        string
+	}{
+		"no target cluster": {
+			manager: manager,
+		},
+		"no target cluster and no manager": {},
+		"no manager": {
+			targetCluster: &policyv1.TargetCluster{
+				KubeconfigSecretRef: policyv1.KubeconfigSecretReference{Name: "remote"},
+			},
+			errMsg: "target clusters are not supported by this controller",
+		},
+		"missing Secret": {
+			manager: manager,
+			targetCluster: &policyv1.TargetCluster{
+				KubeconfigSecretRef: policyv1.KubeconfigSecretReference{Name: "missing"},
+			},
+			errMsg: "the kubeconfig Secret policies/missing was not found",
+		},
+		"missing key": {
+			manager: manager,
+			targetCluster: &policyv1.TargetCluster{
+				KubeconfigSecretRef: policyv1.KubeconfigSecretReference{Name: "remote", Key: "other"},
+			},
+			errMsg: "the kubeconfig Secret policies/remote does not have the other key",
+		},
+		"empty key": {
+			manager: manager,
+			targetCluster: &policyv1.TargetCluster{
+				KubeconfigSecretRef: policyv1.KubeconfigSecretReference{Name: "remote", Key: "empty"},
+			},
+			errMsg: "the kubeconfig Secret policies/remote does not have the empty key",
+		},
+		"invalid kubeconfig": {
+			manager: manager,
+			targetCluster: &policyv1.TargetCluster{
+				KubeconfigSecretRef: policyv1.KubeconfigSecretReference{Name: "remote"},
+			},
+			errMsg: "the kubeconfig is invalid",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			clients, err := resolveTargetCluster(context.TODO(), test.manager, user, "policies", test.targetCluster)
+			assert.Nil(t, clients)
+
+			if test.errMsg == "" {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			assert.True(t, errors.Is(err, ErrTargetClusterUnavailable))
+			assert.ErrorContains(t, err, test.errMsg)
+		})
+	}
+
+	assert.Nil(t, manager.Lookup(user))
+}
+
+func TestRestConfigFromKubeconfig(t *testing.T) {
+	t.Parallel()
+
+	const kubeconfigFmt = `apiVersion: v1
+kind: Config
+clusters:
+- name: remote
+  cluster:
+    server: https://remote.example.com:6443
+%s
+users:
+- name: admin
+  user:
+%s
+contexts:
+- name: remote
+  context:
+    cluster: remote
+    user: admin
+current-context: remote
+`
+
+	tests := map[string]struct {
+		cluster string
+		user    string
+		errMsg  string
+	}{
+		"inline token": {
+			user: "    token: abc123",
+		},
+		"exec plugin": {
+			user:   "    exec:\n      apiVersion: client.authentication.k8s.io/v1\n      command: /bin/sh",
+			errMsg: "the user admin uses an exec plugin",
+		},
+		"auth-provider": {
+			user:   "    auth-provider:\n      name: oidc",
+			errMsg: "the user admin uses an auth-provider",
+		},
+		"token file": {
+			user:   "    tokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token",
+			errMsg: "the user admin references a file",
+		},
+		"client certificate file": {
+			user:   "    client-certificate: /etc/tls/tls.crt\n    client-key: /etc/tls/tls.key",
+			errMsg: "the user admin references a file",
+		},
+		"certificate authority file": {
+			cluster: "    certificate-authority: /etc/tls/ca.crt",
+			user:    "    token: abc123",
+			errMsg:  "the cluster remote references a file",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := restConfigFromKubeconfig([]byte(fmt.Sprintf(kubeconfigFmt, test.cluster, test.user)))
+
+			if test.errMsg == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, "https://remote.example.com:6443", cfg.Host)
+
+				return
+			}
+
+			assert.Nil(t, cfg)
+			assert.ErrorContains(t, err, test.errMsg)
+		})
+	}
+}
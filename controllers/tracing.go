@@ -0,0 +1,75 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+	policyv1beta1 "open-cluster-management.io/config-policy-controller/api/v1beta1"
+)
+
+// tracer creates the spans for the policy evaluation stages. It uses the global tracer provider, which is a
+// no-op unless tracing is configured in main.go.
+var tracer = otel.Tracer("open-cluster-management.io/config-policy-controller/controllers")
+
+// startReconcileSpan starts the root span for the reconcile of the policy.
+func startReconcileSpan(ctx context.Context, kind string, request ctrl.Request) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "Reconcile", trace.WithAttributes(
+		policyAttributes(kind, request.Namespace, request.Name)...,
+	))
+}
+
+// startConfigPolicySpan starts a span for an evaluation stage of the ConfigurationPolicy. The returned
+// context must be passed to the nested stages so that their spans are children of this span.
+func startConfigPolicySpan(
+	ctx context.Context, name string, plc *policyv1.ConfigurationPolicy, attrs ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	attrs = append(policyAttributes("ConfigurationPolicy", plc.Namespace, plc.Name), attrs...)
+
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// startOperatorPolicySpan starts a span for an evaluation stage of the OperatorPolicy. The returned
+// context must be passed to the nested stages so that their spans are children of this span.
+func startOperatorPolicySpan(
+	ctx context.Context, name string, policy *policyv1beta1.OperatorPolicy, attrs ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	attrs = append(policyAttributes("OperatorPolicy", policy.Namespace, policy.Name), attrs...)
+
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan records the error, if any, on the span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+func policyAttributes(kind, namespace, name string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("policy.kind", kind),
+		attribute.String("policy.namespace", namespace),
+		attribute.String("policy.name", name),
+	}
+}
+
+func objectAttributes(obj singleObject) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("object.group", obj.scopedGVR.Group),
+		attribute.String("object.version", obj.scopedGVR.Version),
+		attribute.String("object.resource", obj.scopedGVR.Resource),
+		attribute.String("object.namespace", obj.namespace),
+		attribute.String("object.name", obj.name),
+	}
+}
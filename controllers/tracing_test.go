@@ -0,0 +1,82 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	depclient "github.com/stolostron/kubernetes-dependency-watches/client"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+)
+
+func TestPolicySpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { _ = provider.Shutdown(context.TODO()) })
+
+	plc := &policyv1.ConfigurationPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "tracing-policy", Namespace: "policies"},
+	}
+	obj := singleObject{
+		policy: plc,
+		scopedGVR: depclient.ScopedGVR{
+			GroupVersionResource: policyv1.GroupVersion.WithResource("configurationpolicies"),
+		},
+		name:      "my-object",
+		namespace: "default",
+	}
+
+	ctx, parent := startConfigPolicySpan(context.TODO(), "handleObjectTemplates", plc)
+	_, child := startConfigPolicySpan(ctx, "handleSingleObj", plc, objectAttributes(obj)...)
+
+	endSpan(child, nil)
+	endSpan(parent, errors.New("some failure"))
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+
+	for _, span := range recorder.Ended() {
+		if attributeValue(span, "policy.name") == "tracing-policy" {
+			spans[span.Name()] = span
+		}
+	}
+
+	if !assert.Len(t, spans, 2) {
+		return
+	}
+
+	parentSpan := spans["handleObjectTemplates"]
+	childSpan := spans["handleSingleObj"]
+
+	assert.Equal(t, parentSpan.SpanContext().SpanID(), childSpan.Parent().SpanID())
+	assert.Equal(t, codes.Error, parentSpan.Status().Code)
+	assert.Equal(t, "some failure", parentSpan.Status().Description)
+	assert.Equal(t, codes.Unset, childSpan.Status().Code)
+
+	assert.Equal(t, "ConfigurationPolicy", attributeValue(childSpan, "policy.kind"))
+	assert.Equal(t, "policies", attributeValue(childSpan, "policy.namespace"))
+	assert.Equal(t, "configurationpolicies", attributeValue(childSpan, "object.resource"))
+	assert.Equal(t, "default", attributeValue(childSpan, "object.namespace"))
+	assert.Equal(t, "my-object", attributeValue(childSpan, "object.name"))
+}
+
+func attributeValue(span sdktrace.ReadOnlySpan, key attribute.Key) string {
+	for _, attr := range span.Attributes() {
+		if attr.Key == key {
+			return attr.Value.AsString()
+		}
+	}
+
+	return ""
+}
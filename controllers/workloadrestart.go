@@ -0,0 +1,163 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/client-go/dynamic"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+)
+
+const restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// dependentWorkloadGVRs maps the supported dependent workload kinds to their resources.
+var dependentWorkloadGVRs = map[string]schema.GroupVersionResource{
+	"Deployment":  {Group: "apps", Version: "v1", Resource: "deployments"},
+	"StatefulSet": {Group: "apps", Version: "v1", Resource: "statefulsets"},
+	"DaemonSet":   {Group: "apps", Version: "v1", Resource: "daemonsets"},
+}
+
+// restartDependentWorkloads triggers a rollout restart on each of the dependent workloads in the object template by
+// setting the restartedAt annotation on the pod template, the same way `kubectl rollout restart` does. The
+// defaultNamespace is used for dependent workloads without a namespace set. It returns the related objects for the
+// restarted workloads, and an error if any workload could not be restarted. Workloads specified by name that do not
+// exist are skipped.
+func restartDependentWorkloads(
+	ctx context.Context,
+	dynamicClient dynamic.Interface,
+	workloads []policyv1.DependentWorkload,
+	defaultNamespace string,
+) ([]policyv1.RelatedObject, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{
+						restartedAtAnnotation: time.Now().UTC().Format(time.RFC3339),
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var restarted []policyv1.RelatedObject
+	var errs []error
+
+	for _, workload := range workloads {
+		gvr, ok := dependentWorkloadGVRs[workload.Kind]
+		if !ok {
+			errs = append(errs, fmt.Errorf("the dependent workload kind %s is not supported", workload.Kind))
+
+			continue
+		}
+
+		namespace := workload.Namespace
+		if namespace == "" {
+			namespace = defaultNamespace
+		}
+
+		if namespace == "" {
+			errs = append(errs, fmt.Errorf("the dependent %s must specify a namespace", workload.Kind))
+
+			continue
+		}
+
+		res := dynamicClient.Resource(gvr).Namespace(namespace)
+
+		var names []string
+
+		switch {
+		case workload.Name != "" && workload.Selector != nil:
+			errs = append(errs, fmt.Errorf(
+				"the dependent %s must specify only one of name or selector", workload.Kind,
+			))
+
+			continue
+		case workload.Name != "":
+			names = []string{workload.Name}
+		case workload.Selector != nil:
+			selector, err := metav1.LabelSelectorAsSelector(workload.Selector)
+			if err != nil {
+				errs = append(errs, fmt.Errorf(
+					"the dependent %s selector is invalid: %w", workload.Kind, err,
+				))
+
+				continue
+			}
+
+			list, err := res.List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+			if err != nil {
+				errs = append(errs, fmt.Errorf(
+					"failed to list the dependent %s workloads in namespace %s: %w", gvr.Resource, namespace, err,
+				))
+
+				continue
+			}
+
+			for _, item := range list.Items {
+				names = append(names, item.GetName())
+			}
+
+			sort.Strings(names)
+		default:
+			errs = append(errs, fmt.Errorf("the dependent %s must specify a name or selector", workload.Kind))
+
+			continue
+		}
+
+		for _, name := range names {
+			_, err := res.Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{
+				FieldManager: ControllerName,
+			})
+			if err != nil {
+				if k8serrors.IsNotFound(err) {
+					log.Info("The dependent workload was not found, skipping the rollout restart",
+						"kind", workload.Kind, "namespace", namespace, "name", name)
+
+					continue
+				}
+
+				errs = append(errs, fmt.Errorf(
+					"failed to restart the %s %s in namespace %s: %w", workload.Kind, name, namespace, err,
+				))
+
+				continue
+			}
+
+			log.Info("Triggered a rollout restart of a dependent workload",
+				"kind", workload.Kind, "namespace", namespace, "name", name)
+
+			restarted = addOrUpdateRelatedObject(restarted, policyv1.RelatedObject{
+				Object: policyv1.ObjectResource{
+					Kind:       workload.Kind,
+					APIVersion: gvr.GroupVersion().String(),
+					Metadata: policyv1.ObjectMetadata{
+						Name:      name,
+						Namespace: namespace,
+					},
+				},
+				Compliant: string(policyv1.Compliant),
+				Reason:    reasonRolloutRestarted,
+			})
+		}
+	}
+
+	return restarted, errors.Join(errs...)
+}
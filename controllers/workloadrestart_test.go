@@ -0,0 +1,141 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+)
+
+func workloadForRestart(kind, namespace, name string, labels map[string]string) *unstructured.Unstructured {
+	workload := &unstructured.Unstructured{}
+	workload.SetAPIVersion("apps/v1")
+	workload.SetKind(kind)
+	workload.SetNamespace(namespace)
+	workload.SetName(name)
+	workload.SetLabels(labels)
+
+	return workload
+}
+
+func TestRestartDependentWorkloads(t *testing.T) {
+	t.Parallel()
+
+	dynamicClient := fake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			dependentWorkloadGVRs["Deployment"]:  "DeploymentList",
+			dependentWorkloadGVRs["StatefulSet"]: "StatefulSetList",
+			dependentWorkloadGVRs["DaemonSet"]:   "DaemonSetList",
+		},
+		workloadForRestart("Deployment", "default", "web", nil),
+		workloadForRestart("StatefulSet", "db", "db-1", map[string]string{"app": "db"}),
+		workloadForRestart("StatefulSet", "db", "db-2", map[string]string{"app": "db"}),
+		workloadForRestart("StatefulSet", "db", "cache", map[string]string{"app": "cache"}),
+	)
+
+	workloads := []policyv1.DependentWorkload{
+		{Kind: "Deployment", Name: "web"},
+		{Kind: "Deployment", Name: "missing"},
+		{
+			Kind:      "StatefulSet",
+			Namespace: "db",
+			Selector:  &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+		},
+	}
+
+	restarted, err := restartDependentWorkloads(context.TODO(), dynamicClient, workloads, "default")
+	assert.NoError(t, err)
+
+	restartedNames := []string{}
+
+	for _, related := range restarted {
+		assert.Equal(t, reasonRolloutRestarted, related.Reason)
+		assert.Equal(t, "apps/v1", related.Object.APIVersion)
+
+		restartedNames = append(restartedNames,
+			related.Object.Kind+"/"+related.Object.Metadata.Namespace+"/"+related.Object.Metadata.Name)
+	}
+
+	assert.Equal(t, []string{"Deployment/default/web", "StatefulSet/db/db-1", "StatefulSet/db/db-2"}, restartedNames)
+
+	for _, tc := range []struct {
+		kind      string
+		namespace string
+		name      string
+		restarted bool
+	}{
+		{"Deployment", "default", "web", true},
+		{"StatefulSet", "db", "db-1", true},
+		{"StatefulSet", "db", "db-2", true},
+		{"StatefulSet", "db", "cache", false},
+	} {
+		obj, err := dynamicClient.Resource(dependentWorkloadGVRs[tc.kind]).Namespace(tc.namespace).Get(
+			context.TODO(), tc.name, metav1.GetOptions{},
+		)
+		assert.NoError(t, err)
+
+		_, found, _ := unstructured.NestedString(
+			obj.Object, "spec", "template", "metadata", "annotations", restartedAtAnnotation,
+		)
+		assert.Equal(t, tc.restarted, found, "unexpected restart state for %s %s", tc.kind, tc.name)
+	}
+}
+
+func TestRestartDependentWorkloadsInvalid(t *testing.T) {
+	t.Parallel()
+
+	dynamicClient := fake.NewSimpleDynamicClient(runtime.NewScheme())
+
+	workloads := []policyv1.DependentWorkload{
+		{Kind: "Deployment"},
+		{
+			Kind:     "Deployment",
+			Name:     "web",
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+		{Kind: "DaemonSet", Name: "agent"},
+	}
+
+	restarted, err := restartDependentWorkloads(context.TODO(), dynamicClient, workloads, "")
+	assert.Empty(t, restarted)
+	assert.ErrorContains(t, err, "the dependent Deployment must specify a namespace")
+	assert.ErrorContains(t, err, "the dependent DaemonSet must specify a namespace")
+
+	restarted, err = restartDependentWorkloads(context.TODO(), dynamicClient, workloads[:2], "default")
+	assert.Empty(t, restarted)
+	assert.ErrorContains(t, err, "the dependent Deployment must specify a name or selector")
+	assert.ErrorContains(t, err, "the dependent Deployment must specify only one of name or selector")
+}
+
+func TestRestartPending(t *testing.T) {
+	t.Parallel()
+
+	policy := &policyv1.ConfigurationPolicy{
+		Status: policyv1.ConfigurationPolicyStatus{
+			RelatedObjects: []policyv1.RelatedObject{
+				{Properties: &policyv1.ObjectProperties{UID: "configmap-uid"}},
+				{Properties: &policyv1.ObjectProperties{UID: "secret-uid", PendingRestart: true}},
+				{Object: policyv1.ObjectResource{Kind: "Deployment"}},
+			},
+		},
+	}
+
+	assert.False(t, restartPending(policy, "configmap-uid"))
+	assert.True(t, restartPending(policy, "secret-uid"))
+	assert.False(t, restartPending(policy, "missing-uid"))
+	assert.True(t, hasPendingRestarts(policy))
+
+	policy.Status.RelatedObjects[1].Properties.PendingRestart = false
+
+	assert.False(t, hasPendingRestarts(policy))
+}
@@ -30,6 +30,7 @@ import (
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -45,6 +46,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -54,7 +56,9 @@ import (
 	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
 	policyv1beta1 "open-cluster-management.io/config-policy-controller/api/v1beta1"
 	"open-cluster-management.io/config-policy-controller/controllers"
+	"open-cluster-management.io/config-policy-controller/pkg/audit"
 	"open-cluster-management.io/config-policy-controller/pkg/common"
+	"open-cluster-management.io/config-policy-controller/pkg/notifications"
 	"open-cluster-management.io/config-policy-controller/pkg/triggeruninstall"
 	"open-cluster-management.io/config-policy-controller/version"
 )
@@ -96,12 +100,24 @@ type ctrlOpts struct {
 	enableLease              bool
 	enableLeaderElection     bool
 	enableMetrics            bool
+	enableObjectMetrics      bool
+	objectMetricsMaxSeries   int
 	enableOperatorPolicy     bool
 	enableOcmPolicyNamespace bool
 
 	standaloneHubTemplateKubeConfigPath string
 	defaultTerminatingNSInclusion       string
 	templateFuncDenylist                []string
+	objectMetricsLabels                 []string
+	policyReportMode                    string
+	notificationConfigPath              string
+	auditLogSink                        string
+	auditLogMaxConfigMaps               int
+	debugAddr                           string
+	objectEvents                        bool
+	objectEventsQPS                     float64
+	objectEventsBurst                   int
+	tracing                             common.TracingOptions
 }
 
 func main() {
@@ -165,6 +181,12 @@ func main() {
 	log.Info("Using", "OperatorVersion", version.Version, "GoVersion", runtime.Version(),
 		"GOOS", runtime.GOOS, "GOARCH", runtime.GOARCH)
 
+	shutdownTracing, err := common.SetupTracing(context.TODO(), opts.tracing, "config-policy-controller")
+	if err != nil {
+		log.Error(err, "Failed to set up tracing")
+		os.Exit(1)
+	}
+
 	// Get a config to talk to the apiserver
 	cfg, err := config.GetConfig()
 	if err != nil {
@@ -174,6 +196,7 @@ func main() {
 
 	cfg.Burst = int(opts.clientBurst)
 	cfg.QPS = opts.clientQPS
+	cfg.Wrap(controllers.CountAPIRequests)
 
 	nsTransform := func(obj interface{}) (interface{}, error) {
 		ns := obj.(*corev1.Namespace)
@@ -181,6 +204,8 @@ func main() {
 			ObjectMeta: metav1.ObjectMeta{
 				Name:              ns.Name,
 				Labels:            ns.Labels,
+				Annotations:       ns.Annotations,
+				CreationTimestamp: ns.CreationTimestamp,
 				DeletionTimestamp: ns.DeletionTimestamp,
 			},
 		}
@@ -391,6 +416,7 @@ func main() {
 
 		targetK8sConfig.Burst = int(opts.clientBurst)
 		targetK8sConfig.QPS = opts.clientQPS
+		targetK8sConfig.Wrap(controllers.CountAPIRequests)
 
 		targetK8sClient = kubernetes.NewForConfigOrDie(targetK8sConfig)
 		targetK8sDynamicClient = dynamic.NewForConfigOrDie(targetK8sConfig)
@@ -434,6 +460,7 @@ func main() {
 	var dynamicWatcher depclient.DynamicWatcher
 	var standaloneHubCfg *rest.Config
 	var configPolHubDynamicWatcher depclient.DynamicWatcher
+	var configPolTargetClusters *controllers.TargetClusterManager
 	var hubClient *kubernetes.Clientset
 
 	managerCtx, managerCancel := context.WithCancel(terminatingCtx)
@@ -483,6 +510,15 @@ func main() {
 		// Wait until the dynamic watcher has started
 		<-dynamicWatcher.Started()
 
+		configPolTargetClusters = controllers.NewTargetClusterManager(
+			terminatingCtx,
+			mgr.GetAPIReader(),
+			mgr.GetScheme(),
+			watcherReconciler,
+			nsSelUpdatesChan,
+			opts.defaultTerminatingNSInclusion,
+		)
+
 		if opts.standaloneHubTemplateKubeConfigPath != "" {
 			standaloneHubCfg, err = clientcmd.BuildConfigFromFlags("", opts.standaloneHubTemplateKubeConfigPath)
 			if err != nil {
@@ -513,6 +549,92 @@ func main() {
 		}
 	}
 
+	var objectMetrics *controllers.ObjectComplianceMetrics
+
+	if opts.enableObjectMetrics {
+		objectMetrics, err = controllers.NewObjectComplianceMetrics(
+			metrics.Registry, opts.objectMetricsLabels, opts.objectMetricsMaxSeries,
+		)
+		if err != nil {
+			log.Error(err, "Unable to set up the related object compliance metrics")
+			os.Exit(1)
+		}
+	}
+
+	var policyReporter *controllers.PolicyReporter
+
+	if opts.policyReportMode != "" {
+		policyReporter, err = controllers.NewPolicyReporter(opts.policyReportMode, mgr.GetClient(), targetClient)
+		if err != nil {
+			log.Error(err, "Unable to set up the policy reports")
+			os.Exit(1)
+		}
+	}
+
+	var notifier *notifications.Notifier
+
+	if opts.notificationConfigPath != "" {
+		notificationConfig, err := notifications.LoadConfig(opts.notificationConfigPath)
+		if err != nil {
+			log.Error(err, "Unable to load the notification configuration", "path", opts.notificationConfigPath)
+			os.Exit(1)
+		}
+
+		notificationSource := "config-policy-controller"
+		if opts.clusterName != "" {
+			notificationSource += "/" + opts.clusterName
+		}
+
+		notifier, err = notifications.New(notificationConfig, notificationSource)
+		if err != nil {
+			log.Error(err, "Unable to set up the notifications", "path", opts.notificationConfigPath)
+			os.Exit(1)
+		}
+
+		if err := mgr.Add(notifier); err != nil {
+			log.Error(err, "Unable to add the notifier to the manager")
+			os.Exit(1)
+		}
+
+		// Restart the controller when the notification configuration changes
+		configFiles = append(configFiles, opts.notificationConfigPath)
+	}
+
+	var auditLog *audit.Logger
+
+	if opts.auditLogSink != "" {
+		auditSink, err := audit.NewSink(
+			managerCtx, opts.auditLogSink, kubernetes.NewForConfigOrDie(cfg), opts.auditLogMaxConfigMaps,
+		)
+		if err != nil {
+			log.Error(err, "Unable to set up the audit log", "sink", opts.auditLogSink)
+			os.Exit(1)
+		}
+
+		identity, err := audit.Identity(managerCtx, targetK8sClient)
+		if err != nil {
+			log.Info("Unable to determine the identity for the audit log, it will be omitted", "error", err.Error())
+		}
+
+		auditLog = audit.NewLogger(auditSink, identity)
+	}
+
+	var objectEvents *controllers.ObjectEventRecorder
+
+	if opts.objectEvents {
+		objectEventBroadcaster := record.NewBroadcaster(record.WithContext(managerCtx))
+		objectEventBroadcaster.StartRecordingToSink(
+			&typedcorev1.EventSinkImpl{Interface: targetK8sClient.CoreV1().Events("")},
+		)
+
+		objectEvents = controllers.NewObjectEventRecorder(
+			objectEventBroadcaster.NewRecorder(scheme, corev1.EventSource{Component: controllers.ControllerName}),
+			opts.objectEventsQPS,
+			opts.objectEventsBurst,
+			10*time.Minute,
+		)
+	}
+
 	reconciler := controllers.ConfigurationPolicyReconciler{
 		Client:                 mgr.GetClient(),
 		DecryptionConcurrency:  opts.decryptionConcurrency,
@@ -523,7 +645,13 @@ func main() {
 		TargetK8sClient:        targetK8sClient,
 		TargetK8sDynamicClient: targetK8sDynamicClient,
 		SelectorReconciler:     &nsSelReconciler,
+		TargetClusters:         configPolTargetClusters,
 		EnableMetrics:          opts.enableMetrics,
+		ObjectMetrics:          objectMetrics,
+		PolicyReporter:         policyReporter,
+		Notifier:               notifier,
+		AuditLog:               auditLog,
+		ObjectEvents:           objectEvents,
 		UninstallMode:          beingUninstalled,
 		EvalBackoffSeconds:     opts.evalBackoffSeconds,
 		ItemLimiters:           controllers.NewPerItemRateLimiter[reconcile.Request](opts.evalBackoffSeconds, 1),
@@ -541,6 +669,8 @@ func main() {
 		os.Exit(1)
 	}
 
+	var opReconciler *controllers.OperatorPolicyReconciler
+
 	if opts.enableOperatorPolicy {
 		depReconciler, depEvents := depclient.NewControllerRuntimeSource()
 
@@ -589,6 +719,11 @@ func main() {
 			<-opPolHubDynamicWatcher.Started()
 		}
 
+		// OperatorPolicies don't use namespace selection, so no selector updates are needed
+		opPolTargetClusters := controllers.NewTargetClusterManager(
+			managerCtx, mgr.GetAPIReader(), mgr.GetScheme(), depReconciler, nil, "",
+		)
+
 		OpReconciler := controllers.OperatorPolicyReconciler{
 			Client:            mgr.GetClient(),
 			DynamicClient:     targetK8sDynamicClient,
@@ -597,6 +732,12 @@ func main() {
 			DefaultNamespace:  opts.operatorPolDefaultNS,
 			MaxHistoryLength:  int(opts.operatorPolHistoryLength),
 			TargetClient:      targetClient,
+			TargetClusters:    opPolTargetClusters,
+			ObjectMetrics:     objectMetrics,
+			PolicyReporter:    policyReporter,
+			Notifier:          notifier,
+			AuditLog:          auditLog,
+			ObjectEvents:      objectEvents,
 			HubDynamicWatcher: opPolHubDynamicWatcher,
 			HubClient:         hubClient,
 			ClusterName:       opts.clusterName,
@@ -606,6 +747,34 @@ func main() {
 			log.Error(err, "Unable to create controller", "controller", "OperatorPolicy")
 			os.Exit(1)
 		}
+
+		opReconciler = &OpReconciler
+	}
+
+	if opts.debugAddr != "" {
+		debugServer := &controllers.DebugServer{
+			BindAddress: opts.debugAddr,
+			Handler: &controllers.DebugHandler{
+				ConfigPolicies:   &reconciler,
+				OperatorPolicies: opReconciler,
+			},
+		}
+
+		// Protect the debug endpoint the same way as the secure metrics endpoint
+		if opts.secureMetrics {
+			debugServer.Filter, err = filters.WithAuthenticationAndAuthorization(cfg, mgr.GetHTTPClient())
+			if err != nil {
+				log.Error(err, "Unable to set up the authentication and authorization of the debug endpoint")
+				os.Exit(1)
+			}
+
+			debugServer.CertDir = "/var/run/metrics-cert"
+		}
+
+		if err := mgr.Add(debugServer); err != nil {
+			log.Error(err, "Unable to add the debug server")
+			os.Exit(1)
+		}
 	}
 
 	// This lease is not related to leader election. This is to report the status of the controller
@@ -735,6 +904,15 @@ func main() {
 
 	wg.Wait()
 
+	// Flush the remaining spans before exiting
+	tracingCtx, tracingCancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+	if err := shutdownTracing(tracingCtx); err != nil {
+		log.Error(err, "Failed to shut down tracing")
+	}
+
+	tracingCancel()
+
 	if errorExit {
 		os.Exit(1)
 	}
@@ -856,6 +1034,15 @@ func parseOpts(flags *pflag.FlagSet, args []string) *ctrlOpts {
 		"Enable secure metrics endpoint with certificates at /var/run/metrics-cert",
 	)
 
+	flags.StringVar(
+		&opts.debugAddr,
+		"debug-bind-address",
+		"",
+		"The address the debug endpoint binds to, which serves the evaluation state of the policies as JSON at "+
+			controllers.DebugPath+". When --secure-metrics is set, it is protected the same way as the metrics "+
+			"endpoint and requires the get verb on the non-resource URL. The debug endpoint is disabled by default.",
+	)
+
 	flags.StringVar(
 		&opts.probeAddr,
 		"health-probe-bind-address",
@@ -893,6 +1080,85 @@ func parseOpts(flags *pflag.FlagSet, args []string) *ctrlOpts {
 		"Disable custom metrics collection",
 	)
 
+	flags.BoolVar(
+		&opts.enableObjectMetrics,
+		"enable-object-metrics",
+		false,
+		"Enable the policy_related_object_compliance metric, which reports the compliance of each related "+
+			"object of the policies",
+	)
+
+	flags.StringSliceVar(
+		&opts.objectMetricsLabels,
+		"object-metrics-labels",
+		controllers.ObjectMetricLabels,
+		"A comma-separated list of the related object labels to include in the policy_related_object_compliance "+
+			"metric. The policy labels are always included. Valid labels are: "+
+			strings.Join(controllers.ObjectMetricLabels, ", "),
+	)
+
+	flags.IntVar(
+		&opts.objectMetricsMaxSeries,
+		"object-metrics-max-series",
+		10000,
+		"The maximum number of series reported in the policy_related_object_compliance metric",
+	)
+
+	flags.StringVar(
+		&opts.policyReportMode,
+		"policy-report-mode",
+		"",
+		"Write the compliance of the related objects of the policies to the PolicyReport resources of the "+
+			"Kubernetes Policy WG. The valid modes are \"namespace\" for a report per namespace of the related "+
+			"objects and \"policy\" for a report per policy. The reports are disabled by default.",
+	)
+
+	flags.StringVar(
+		&opts.notificationConfigPath,
+		"notification-config",
+		"",
+		"The path to a YAML file that configures the HTTP sinks that CloudEvents notifications are sent to when "+
+			"the compliance of a policy changes or a policy is enforced. The notifications are disabled by default.",
+	)
+
+	flags.StringVar(
+		&opts.auditLogSink,
+		"audit-log",
+		"",
+		"Write an audit log of the objects that the policies create, update, and delete as JSON lines. The valid "+
+			"sinks are \"stdout\", \"file:<path>\", and \"configmap:<namespace>/<name prefix>\" for a rotating "+
+			"set of ConfigMaps. The audit log is disabled by default.",
+	)
+
+	flags.IntVar(
+		&opts.auditLogMaxConfigMaps,
+		"audit-log-max-configmaps",
+		5,
+		"The maximum number of ConfigMaps kept by the configmap audit log sink before the oldest is deleted",
+	)
+
+	flags.BoolVar(
+		&opts.objectEvents,
+		"object-events",
+		false,
+		"Emit events on the objects that the policies create, update, or find in violation, in addition to the "+
+			"events on the policies. Identical events on an object are emitted at most once every 10 minutes.",
+	)
+
+	flags.Float64Var(
+		&opts.objectEventsQPS,
+		"object-events-qps",
+		5,
+		"The maximum number of events per second emitted on the objects when --object-events is set",
+	)
+
+	flags.IntVar(
+		&opts.objectEventsBurst,
+		"object-events-burst",
+		25,
+		"The maximum burst of events emitted on the objects when --object-events is set",
+	)
+
 	flags.Float32Var(
 		&opts.clientQPS,
 		"client-max-qps",
@@ -962,6 +1228,28 @@ func parseOpts(flags *pflag.FlagSet, args []string) *ctrlOpts {
 			"The default deny list will remain active regardless of this setting.",
 	)
 
+	flags.StringVar(
+		&opts.tracing.Endpoint,
+		"tracing-otlp-endpoint",
+		"",
+		"The host and port of the OTLP gRPC collector to send OpenTelemetry traces of policy evaluations to. "+
+			"If not set, tracing is disabled.",
+	)
+
+	flags.BoolVar(
+		&opts.tracing.Insecure,
+		"tracing-otlp-insecure",
+		false,
+		"Disable TLS when connecting to the OTLP collector.",
+	)
+
+	flags.Float64Var(
+		&opts.tracing.SampleRatio,
+		"tracing-sample-ratio",
+		1,
+		"The fraction of policy evaluations to trace, from 0 to 1.",
+	)
+
 	_ = flags.Parse(args)
 
 	// Scale QPS and Burst with concurrency, when they aren't explicitly set.
@@ -0,0 +1,169 @@
+// Copyright Contributors to the Open Cluster Management project
+
+// Package audit writes an append-only log of the changes that policies make on the cluster as JSON lines.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Reference identifies a policy or an object in an audit entry.
+type Reference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	UID        string `json:"uid,omitempty"`
+}
+
+// Entry is a change that a policy made on the cluster.
+type Entry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Policy    Reference `json:"policy"`
+	// Action is either "created", "updated", "recreated", "deleted", or "approved".
+	Action string    `json:"action"`
+	Object Reference `json:"object"`
+	// Identity is the user that the controller made the change as.
+	Identity string `json:"identity"`
+	// Diff is the difference between the object before and after the change. For a ConfigurationPolicy, it is
+	// redacted when the diff of the object template is censored, and it is empty when the diff of the object
+	// template is disabled or not available. For an OperatorPolicy, it is empty for deleted objects, which are
+	// mostly ClusterServiceVersions and CustomResourceDefinitions managed by OLM.
+	Diff    string `json:"diff,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Sink stores the JSON lines of the audit log. Each call to Append is a single JSON line with the trailing
+// newline. Append may be called concurrently.
+type Sink interface {
+	Append(ctx context.Context, line []byte) error
+}
+
+// Logger writes the entries of the audit log to a sink. It is safe for concurrent use, and the sink is
+// responsible for serializing its writes.
+type Logger struct {
+	sink     Sink
+	identity string
+}
+
+// NewLogger returns a Logger that writes to the sink. The identity is recorded in the entries that don't
+// specify one.
+func NewLogger(sink Sink, identity string) *Logger {
+	return &Logger{sink: sink, identity: identity}
+}
+
+// Enabled returns true if the audit log is configured, so that callers can skip computing diffs otherwise.
+func (l *Logger) Enabled() bool {
+	return l != nil
+}
+
+// Record appends the entry to the audit log. The timestamp and identity are set if they are empty. It is a
+// no-op if the audit log is not enabled.
+func (l *Logger) Record(ctx context.Context, entry Entry) error {
+	if l == nil {
+		return nil
+	}
+
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+
+	if entry.Identity == "" {
+		entry.Identity = l.identity
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the audit entry: %w", err)
+	}
+
+	line = append(line, '\n')
+
+	if err := l.sink.Append(ctx, line); err != nil {
+		return fmt.Errorf("failed to write the audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// WriterSink writes the audit log to a writer, such as standard output.
+type WriterSink struct {
+	Writer io.Writer
+	lock   sync.Mutex
+}
+
+func (s *WriterSink) Append(_ context.Context, line []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	_, err := s.Writer.Write(line)
+
+	return err
+}
+
+// NewFileSink opens the file for appending, creating it if it doesn't exist.
+func NewFileSink(path string) (*WriterSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the audit log file: %w", err)
+	}
+
+	return &WriterSink{Writer: file}, nil
+}
+
+// NewSink returns the sink for the value of the --audit-log flag, which is either "stdout", "file:<path>",
+// or "configmap:<namespace>/<name prefix>". The client and maxConfigMaps are only used by the ConfigMap sink.
+func NewSink(ctx context.Context, value string, client kubernetes.Interface, maxConfigMaps int) (Sink, error) {
+	kind, arg, _ := strings.Cut(value, ":")
+
+	switch kind {
+	case "stdout":
+		if arg != "" {
+			break
+		}
+
+		return &WriterSink{Writer: os.Stdout}, nil
+	case "file":
+		if arg == "" {
+			return nil, errors.New("the audit log file path must not be empty")
+		}
+
+		return NewFileSink(arg)
+	case "configmap":
+		namespace, prefix, ok := strings.Cut(arg, "/")
+		if !ok || namespace == "" || prefix == "" {
+			return nil, fmt.Errorf("the audit log ConfigMap must be in the format namespace/prefix, got %s", arg)
+		}
+
+		return NewConfigMapSink(ctx, client, namespace, prefix, maxConfigMaps)
+	}
+
+	return nil, fmt.Errorf(
+		`the audit log sink %s is invalid, valid sinks are: stdout, file:<path>, configmap:<namespace>/<prefix>`,
+		value,
+	)
+}
+
+// Identity returns the user that the client authenticates as, using a SelfSubjectReview.
+func Identity(ctx context.Context, client kubernetes.Interface) (string, error) {
+	review, err := client.AuthenticationV1().SelfSubjectReviews().Create(
+		ctx, &authenticationv1.SelfSubjectReview{}, metav1.CreateOptions{},
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to determine the identity of the controller: %w", err)
+	}
+
+	return review.Status.UserInfo.Username, nil
+}
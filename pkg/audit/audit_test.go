@@ -0,0 +1,255 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func testEntry(name string) Entry {
+	return Entry{
+		Policy: Reference{
+			APIVersion: "policy.open-cluster-management.io/v1",
+			Kind:       "ConfigurationPolicy",
+			Namespace:  "policies",
+			Name:       "policy",
+		},
+		Action: "updated",
+		Object: Reference{APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: name, UID: "uid"},
+		Diff:   "@@ -1 +1 @@\n-a\n+b\n",
+	}
+}
+
+func TestLogger(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	logger := NewLogger(&WriterSink{Writer: buf}, "system:serviceaccount:ocm:config-policy-controller")
+
+	assert.NoError(t, logger.Record(context.TODO(), testEntry("cm-1")))
+
+	entry := testEntry("cm-2")
+	entry.Identity = "kubeconfig Secret policies/remote"
+	entry.Timestamp = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	assert.NoError(t, logger.Record(context.TODO(), entry))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Len(t, lines, 2)
+
+	first := Entry{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "system:serviceaccount:ocm:config-policy-controller", first.Identity)
+	assert.False(t, first.Timestamp.IsZero())
+	assert.Equal(t, "cm-1", first.Object.Name)
+	assert.Equal(t, "@@ -1 +1 @@\n-a\n+b\n", first.Diff)
+
+	assert.JSONEq(t, `{
+		"timestamp": "2024-01-02T03:04:05Z",
+		"policy": {
+			"apiVersion": "policy.open-cluster-management.io/v1",
+			"kind": "ConfigurationPolicy",
+			"namespace": "policies",
+			"name": "policy"
+		},
+		"action": "updated",
+		"object": {"apiVersion": "v1", "kind": "ConfigMap", "namespace": "default", "name": "cm-2", "uid": "uid"},
+		"identity": "kubeconfig Secret policies/remote",
+		"diff": "@@ -1 +1 @@\n-a\n+b\n"
+	}`, lines[1])
+
+	var disabled *Logger
+
+	assert.False(t, disabled.Enabled())
+	assert.NoError(t, disabled.Record(context.TODO(), testEntry("cm-1")))
+}
+
+func TestFileSink(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	for _, name := range []string{"cm-1", "cm-2"} {
+		// Reopening the file appends to it
+		sink, err := NewSink(context.TODO(), "file:"+path, nil, 0)
+		assert.NoError(t, err)
+		assert.NoError(t, NewLogger(sink, "").Record(context.TODO(), testEntry(name)))
+	}
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "\n"))
+}
+
+func TestNewSink(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"stdout":           "",
+		"file:":            "the audit log file path must not be empty",
+		"configmap:ns":     "the audit log ConfigMap must be in the format namespace/prefix, got ns",
+		"configmap:/audit": "the audit log ConfigMap must be in the format namespace/prefix, got /audit",
+		"stdout:extra":     "the audit log sink stdout:extra is invalid",
+		"syslog":           "the audit log sink syslog is invalid",
+	}
+
+	for value, errMsg := range tests {
+		t.Run(value, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewSink(context.TODO(), value, fake.NewClientset(), 1)
+			if errMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, errMsg)
+			}
+		})
+	}
+}
+
+func TestConfigMapSink(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	client := fake.NewClientset()
+
+	sink, err := newConfigMapSink(ctx, client, "audit", "policy-audit", 2)
+	assert.NoError(t, err)
+
+	logger := NewLogger(sink, "")
+	line, err := json.Marshal(testEntry("cm-1"))
+	assert.NoError(t, err)
+
+	// Fit two entries in each ConfigMap, with room for the longer timestamps of the recorded entries
+	sink.maxSize = 2*(len(line)+1) + 50
+
+	for range 5 {
+		assert.NoError(t, logger.Record(ctx, testEntry("cm-1")))
+	}
+
+	// The entries are only written when the sink is flushed
+	configMaps, err := client.CoreV1().ConfigMaps("audit").List(ctx, metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, configMaps.Items)
+
+	assert.NoError(t, sink.Flush(ctx))
+
+	configMaps, err = client.CoreV1().ConfigMaps("audit").List(ctx, metav1.ListOptions{})
+	assert.NoError(t, err)
+
+	entries := map[string]int{}
+	for _, configMap := range configMaps.Items {
+		assert.Equal(t, "policy-audit", configMap.Labels[ConfigMapLabel])
+		entries[configMap.Name] = strings.Count(configMap.Data[ConfigMapKey], "\n")
+	}
+
+	// The first ConfigMap was deleted after the third one was created
+	assert.Equal(t, map[string]int{"policy-audit-2": 2, "policy-audit-3": 1}, entries)
+
+	// A new sink continues in the latest ConfigMap
+	sink, err = newConfigMapSink(ctx, client, "audit", "policy-audit", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, sink.sequence)
+
+	_, err = NewConfigMapSink(ctx, client, "audit", "policy-audit", 0)
+	assert.ErrorContains(t, err, "the maximum number of audit log ConfigMaps must be positive, got 0")
+}
+
+func TestConfigMapSinkRetry(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	client := fake.NewClientset()
+
+	sink, err := newConfigMapSink(ctx, client, "audit", "policy-audit", 2)
+	assert.NoError(t, err)
+
+	logger := NewLogger(sink, "")
+
+	assert.NoError(t, logger.Record(ctx, testEntry("cm-1")))
+	assert.NoError(t, sink.Flush(ctx))
+
+	var failVerb string
+
+	var failure error
+
+	// Fail the next request with the verb once
+	client.PrependReactor("*", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetVerb() != failVerb {
+			return false, nil, nil
+		}
+
+		failVerb = ""
+
+		return true, nil, failure
+	})
+
+	// A conflict is retried
+	failVerb = "update"
+	failure = k8serrors.NewConflict(
+		schema.GroupResource{Resource: "configmaps"}, "policy-audit-1", errors.New("modified"),
+	)
+
+	assert.NoError(t, logger.Record(ctx, testEntry("cm-2")))
+	assert.NoError(t, sink.Flush(ctx))
+
+	failVerb = "get"
+	failure = k8serrors.NewServiceUnavailable("unavailable")
+
+	assert.NoError(t, logger.Record(ctx, testEntry("cm-3")))
+	assert.ErrorContains(t, sink.Flush(ctx), "unavailable")
+
+	// The entry that failed to be written is kept for the next flush
+	assert.NoError(t, logger.Record(ctx, testEntry("cm-4")))
+	assert.NoError(t, sink.Flush(ctx))
+
+	configMap, err := client.CoreV1().ConfigMaps("audit").Get(ctx, "policy-audit-1", metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	names := []string{}
+
+	for _, line := range strings.Split(strings.TrimSuffix(configMap.Data[ConfigMapKey], "\n"), "\n") {
+		entry := Entry{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &entry))
+
+		names = append(names, entry.Object.Name)
+	}
+
+	assert.Equal(t, []string{"cm-1", "cm-2", "cm-3", "cm-4"}, names)
+}
+
+func TestConfigMapSinkBackground(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	client := fake.NewClientset()
+
+	sink, err := NewConfigMapSink(ctx, client, "audit", "policy-audit", 2)
+	assert.NoError(t, err)
+
+	assert.NoError(t, NewLogger(sink, "").Record(ctx, testEntry("cm-1")))
+
+	assert.Eventually(t, func() bool {
+		configMap, err := client.CoreV1().ConfigMaps("audit").Get(ctx, "policy-audit-1", metav1.GetOptions{})
+
+		return err == nil && strings.Count(configMap.Data[ConfigMapKey], "\n") == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
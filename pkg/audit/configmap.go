@@ -0,0 +1,305 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// ConfigMapLabel is set on the ConfigMaps of the audit log to the name prefix of the ConfigMaps.
+	ConfigMapLabel = "policy.open-cluster-management.io/audit-log"
+	// ConfigMapKey is the key in the data of the ConfigMaps that has the JSON lines of the audit log.
+	ConfigMapKey = "audit.jsonl"
+	// maxConfigMapSize keeps the ConfigMaps well under the 1 MiB limit of Kubernetes objects.
+	maxConfigMapSize = 512 * 1024
+	// flushRetryInterval is how often the pending lines are written again after a failure.
+	flushRetryInterval = 10 * time.Second
+)
+
+var log = ctrl.Log.WithName("audit")
+
+// ConfigMapSink writes the audit log to a rotating set of ConfigMaps named <prefix>-<sequence>. When the
+// current ConfigMap is full, the next one in the sequence is created, and the oldest ConfigMaps beyond the
+// maximum count are deleted. The lines are buffered and written in the background, so that appending a line
+// doesn't wait on the API server.
+type ConfigMapSink struct {
+	client        kubernetes.Interface
+	namespace     string
+	prefix        string
+	maxConfigMaps int
+	maxSize       int
+	// sequence is the sequence number of the current ConfigMap, which is 0 when there is none. It is only
+	// used while holding flushLock.
+	sequence int
+	// flushLock serializes the writes to the ConfigMaps.
+	flushLock sync.Mutex
+	// lock protects pending, which has the lines that are not written yet.
+	lock    sync.Mutex
+	pending [][]byte
+	// flushNeeded signals the background goroutine that there are pending lines.
+	flushNeeded chan struct{}
+}
+
+// NewConfigMapSink returns a ConfigMapSink that continues the audit log in the existing ConfigMaps with the
+// prefix. The lines are written in the background until the context is canceled, at which point the pending
+// lines are written one last time.
+func NewConfigMapSink(
+	ctx context.Context, client kubernetes.Interface, namespace, prefix string, maxConfigMaps int,
+) (*ConfigMapSink, error) {
+	sink, err := newConfigMapSink(ctx, client, namespace, prefix, maxConfigMaps)
+	if err != nil {
+		return nil, err
+	}
+
+	go sink.run(ctx)
+
+	return sink, nil
+}
+
+// newConfigMapSink returns a ConfigMapSink without starting the background goroutine that writes the lines.
+func newConfigMapSink(
+	ctx context.Context, client kubernetes.Interface, namespace, prefix string, maxConfigMaps int,
+) (*ConfigMapSink, error) {
+	if maxConfigMaps < 1 {
+		return nil, fmt.Errorf("the maximum number of audit log ConfigMaps must be positive, got %d", maxConfigMaps)
+	}
+
+	sink := &ConfigMapSink{
+		client:        client,
+		namespace:     namespace,
+		prefix:        prefix,
+		maxConfigMaps: maxConfigMaps,
+		maxSize:       maxConfigMapSize,
+		flushNeeded:   make(chan struct{}, 1),
+	}
+
+	sequences, err := sink.sequences(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sequence := range sequences {
+		sink.sequence = max(sink.sequence, sequence)
+	}
+
+	return sink, nil
+}
+
+// Append buffers the line to be written by the background goroutine. It doesn't return write errors, which
+// are logged instead.
+func (s *ConfigMapSink) Append(_ context.Context, line []byte) error {
+	s.lock.Lock()
+	s.pending = append(s.pending, line)
+	s.lock.Unlock()
+
+	select {
+	case s.flushNeeded <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// run writes the pending lines when they are appended, and retries periodically after a failure, until the
+// context is canceled.
+func (s *ConfigMapSink) run(ctx context.Context) {
+	ticker := time.NewTicker(flushRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Write the remaining lines with a new context since the original one is canceled
+			flushCtx, cancel := context.WithTimeout(context.Background(), flushRetryInterval)
+
+			if err := s.Flush(flushCtx); err != nil {
+				log.Error(err, "Failed to write the audit log entries before exiting")
+			}
+
+			cancel()
+
+			return
+		case <-s.flushNeeded:
+		case <-ticker.C:
+		}
+
+		if err := s.Flush(ctx); err != nil {
+			log.Error(err, "Failed to write the audit log entries, will retry", "retryIn", flushRetryInterval)
+		}
+	}
+}
+
+// Flush writes the pending lines to the ConfigMaps. The lines that couldn't be written stay pending.
+func (s *ConfigMapSink) Flush(ctx context.Context) error {
+	s.flushLock.Lock()
+	defer s.flushLock.Unlock()
+
+	s.lock.Lock()
+	lines := s.pending
+	s.pending = nil
+	s.lock.Unlock()
+
+	for len(lines) != 0 {
+		written, err := s.write(ctx, lines)
+		lines = lines[written:]
+
+		if err != nil {
+			// Keep the order of the lines for the next attempt
+			s.lock.Lock()
+			s.pending = append(lines, s.pending...)
+			s.lock.Unlock()
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// write appends as many of the lines as fit to the current ConfigMap, or to the next one when the current
+// one is full, and returns how many of the lines were written. The caller must hold flushLock.
+func (s *ConfigMapSink) write(ctx context.Context, lines [][]byte) (int, error) {
+	if s.sequence != 0 {
+		written := 0
+
+		// The ConfigMap isn't expected to be modified by anything else, but a conflict would lose the lines
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			written = 0
+
+			configMap, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(
+				ctx, s.name(s.sequence), metav1.GetOptions{},
+			)
+			if err != nil {
+				return err
+			}
+
+			data, count := appendLines(configMap.Data[ConfigMapKey], lines, s.maxSize)
+			if count == 0 {
+				return nil
+			}
+
+			if configMap.Data == nil {
+				configMap.Data = map[string]string{}
+			}
+
+			configMap.Data[ConfigMapKey] = data
+
+			_, err = s.client.CoreV1().ConfigMaps(s.namespace).Update(ctx, configMap, metav1.UpdateOptions{})
+			if err == nil {
+				written = count
+			}
+
+			return err
+		})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return 0, err
+		}
+
+		if written != 0 {
+			return written, nil
+		}
+	}
+
+	// Rotate to the next ConfigMap
+	data, count := appendLines("", lines, s.maxSize)
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s.name(s.sequence + 1),
+			Namespace: s.namespace,
+			Labels:    map[string]string{ConfigMapLabel: s.prefix},
+		},
+		Data: map[string]string{ConfigMapKey: data},
+	}
+
+	if _, err := s.client.CoreV1().ConfigMaps(s.namespace).Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
+		return 0, err
+	}
+
+	s.sequence++
+
+	return count, s.prune(ctx)
+}
+
+// appendLines appends the lines to the data as long as the data stays within the maximum size, and returns
+// how many of the lines were appended. A line is always appended to empty data, even if it is too large.
+func appendLines(data string, lines [][]byte, maxSize int) (string, int) {
+	var builder strings.Builder
+
+	builder.WriteString(data)
+
+	count := 0
+
+	for _, line := range lines {
+		if builder.Len() != 0 && builder.Len()+len(line) > maxSize {
+			break
+		}
+
+		builder.Write(line)
+
+		count++
+	}
+
+	return builder.String(), count
+}
+
+// prune deletes the oldest ConfigMaps beyond the maximum count.
+func (s *ConfigMapSink) prune(ctx context.Context) error {
+	sequences, err := s.sequences(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, sequence := range sequences {
+		if sequence > s.sequence-s.maxConfigMaps {
+			continue
+		}
+
+		err := s.client.CoreV1().ConfigMaps(s.namespace).Delete(ctx, s.name(sequence), metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete the rotated audit log ConfigMap %s: %w", s.name(sequence), err)
+		}
+	}
+
+	return nil
+}
+
+// sequences returns the sequence numbers of the existing ConfigMaps with the prefix.
+func (s *ConfigMapSink) sequences(ctx context.Context) ([]int, error) {
+	configMaps, err := s.client.CoreV1().ConfigMaps(s.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: ConfigMapLabel + "=" + s.prefix,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the audit log ConfigMaps: %w", err)
+	}
+
+	sequences := make([]int, 0, len(configMaps.Items))
+
+	for _, configMap := range configMaps.Items {
+		sequence, err := strconv.Atoi(strings.TrimPrefix(configMap.Name, s.prefix+"-"))
+		if err != nil || sequence < 1 {
+			continue
+		}
+
+		sequences = append(sequences, sequence)
+	}
+
+	return sequences, nil
+}
+
+func (s *ConfigMapSink) name(sequence int) string {
+	return fmt.Sprintf("%s-%d", s.prefix, sequence)
+}
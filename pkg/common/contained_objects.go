@@ -0,0 +1,222 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/metadata/metadatainformer"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+)
+
+// containedObjectInformers runs a metadata-only informer for each kind referenced in the `containsObjects`
+// of the namespace selections, so that only the object metadata of those kinds is cached, and only while a
+// selection references the kind. An informer event sends a GenericEvent on the events channel so that the
+// selections are recalculated.
+type containedObjectInformers struct {
+	client     metadata.Interface
+	restMapper meta.RESTMapper
+	events     chan event.GenericEvent
+
+	informers map[schema.GroupVersionKind]*containedObjectInformer
+	// inUse counts the selections being calculated that reference each kind, so that an informer isn't stopped
+	// before the selection that started it is cached.
+	inUse map[schema.GroupVersionKind]int
+	lock  sync.Mutex
+}
+
+type containedObjectInformer struct {
+	informer toolscache.SharedIndexInformer
+	// namespaced is false for cluster-scoped kinds, which are never in a namespace
+	namespaced bool
+	stop       context.CancelFunc
+}
+
+func newContainedObjectInformers(
+	client metadata.Interface, restMapper meta.RESTMapper, events chan event.GenericEvent,
+) *containedObjectInformers {
+	return &containedObjectInformers{
+		client:     client,
+		restMapper: restMapper,
+		events:     events,
+		informers:  map[schema.GroupVersionKind]*containedObjectInformer{},
+		inUse:      map[schema.GroupVersionKind]int{},
+	}
+}
+
+// namespacesContaining returns the names of the namespaces that contain at least one object of the kind that
+// matches the label selector. The informer for the kind is started if it isn't running yet.
+func (c *containedObjectInformers) namespacesContaining(
+	ctx context.Context, gvk schema.GroupVersionKind, selector labels.Selector,
+) (sets.Set[string], error) {
+	inf, err := c.informerFor(gvk)
+	if err != nil {
+		return nil, err
+	}
+
+	if !toolscache.WaitForCacheSync(ctx.Done(), inf.informer.HasSynced) {
+		return nil, fmt.Errorf("timed out waiting for the %s cache to sync", gvk.Kind)
+	}
+
+	namespaces := sets.New[string]()
+
+	if !inf.namespaced {
+		return namespaces, nil
+	}
+
+	for _, item := range inf.informer.GetStore().List() {
+		obj, err := meta.Accessor(item)
+		if err != nil {
+			continue
+		}
+
+		if obj.GetNamespace() != "" && selector.Matches(labels.Set(obj.GetLabels())) {
+			namespaces.Insert(obj.GetNamespace())
+		}
+	}
+
+	return namespaces, nil
+}
+
+// informerFor returns the running informer for the kind, and starts one if there isn't one.
+func (c *containedObjectInformers) informerFor(gvk schema.GroupVersionKind) (*containedObjectInformer, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if inf, ok := c.informers[gvk]; ok {
+		return inf, nil
+	}
+
+	mapping, err := c.restMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	informer := metadatainformer.NewFilteredMetadataInformer(
+		c.client, mapping.Resource, metav1.NamespaceAll, 0, toolscache.Indexers{}, nil,
+	).Informer()
+
+	_, err = informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(_ interface{}) { c.notify() },
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldMeta, oldErr := meta.Accessor(oldObj)
+			newMeta, newErr := meta.Accessor(newObj)
+
+			if oldErr != nil || newErr != nil || !reflect.DeepEqual(oldMeta.GetLabels(), newMeta.GetLabels()) {
+				c.notify()
+			}
+		},
+		DeleteFunc: func(_ interface{}) { c.notify() },
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	informerCtx, stop := context.WithCancel(context.Background())
+
+	go informer.Run(informerCtx.Done())
+
+	inf := &containedObjectInformer{
+		informer:   informer,
+		namespaced: mapping.Scope.Name() == meta.RESTScopeNameNamespace,
+		stop:       stop,
+	}
+
+	c.informers[gvk] = inf
+
+	return inf, nil
+}
+
+// notify requests a recalculation of the selections. The send is skipped when the channel is full, since an
+// event that is already queued causes the same recalculation.
+func (c *containedObjectInformers) notify() {
+	select {
+	case c.events <- event.GenericEvent{Object: &metav1.PartialObjectMetadata{}}:
+	default:
+	}
+}
+
+// acquire marks the kinds referenced by the target as in use while its selection is calculated.
+func (c *containedObjectInformers) acquire(t policyv1.Target) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, gvk := range containedGVKs(t) {
+		c.inUse[gvk]++
+	}
+}
+
+// release undoes acquire for the kinds referenced by the target.
+func (c *containedObjectInformers) release(t policyv1.Target) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, gvk := range containedGVKs(t) {
+		c.inUse[gvk]--
+
+		if c.inUse[gvk] <= 0 {
+			delete(c.inUse, gvk)
+		}
+	}
+}
+
+// prune stops the informers of the kinds that aren't referenced by the given targets or in use.
+func (c *containedObjectInformers) prune(targets []policyv1.Target) {
+	referenced := sets.New[schema.GroupVersionKind]()
+
+	for _, t := range targets {
+		referenced.Insert(containedGVKs(t)...)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for gvk, inf := range c.informers {
+		if referenced.Has(gvk) || c.inUse[gvk] > 0 {
+			continue
+		}
+
+		inf.stop()
+		delete(c.informers, gvk)
+	}
+}
+
+// stopAll stops all of the informers.
+func (c *containedObjectInformers) stopAll() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for gvk, inf := range c.informers {
+		inf.stop()
+		delete(c.informers, gvk)
+	}
+}
+
+// containedGVKs returns the kinds referenced in the `containsObjects` of the target. Entries with an invalid
+// apiVersion are skipped.
+func containedGVKs(t policyv1.Target) []schema.GroupVersionKind {
+	gvks := make([]schema.GroupVersionKind, 0, len(t.ContainsObjects))
+
+	for _, contained := range t.ContainsObjects {
+		gv, err := schema.ParseGroupVersion(contained.APIVersion)
+		if err != nil {
+			continue
+		}
+
+		gvks = append(gvks, gv.WithKind(contained.Kind))
+	}
+
+	return gvks
+}
@@ -0,0 +1,145 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	metadatafake "k8s.io/client-go/metadata/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+)
+
+var (
+	testDeploymentGVK  = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	testClusterRoleGVK = schema.GroupVersionKind{
+		Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole",
+	}
+)
+
+func testObjMeta(
+	gvk schema.GroupVersionKind, namespace, name string, lbls map[string]string,
+) *metav1.PartialObjectMetadata {
+	obj := &metav1.PartialObjectMetadata{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: lbls},
+	}
+	obj.SetGroupVersionKind(gvk)
+
+	return obj
+}
+
+func newTestContainedObjectInformers(
+	t *testing.T, objs ...runtime.Object,
+) (*containedObjectInformers, *metadatafake.FakeMetadataClient, chan event.GenericEvent) {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := metav1.AddMetaToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	client := metadatafake.NewSimpleMetadataClient(scheme, objs...)
+
+	restMapper := meta.NewDefaultRESTMapper(nil)
+	restMapper.Add(testDeploymentGVK, meta.RESTScopeNamespace)
+	restMapper.Add(testClusterRoleGVK, meta.RESTScopeRoot)
+
+	events := make(chan event.GenericEvent, 1)
+	informers := newContainedObjectInformers(client, restMapper, events)
+
+	t.Cleanup(informers.stopAll)
+
+	return informers, client, events
+}
+
+func TestContainedObjectInformersNamespaces(t *testing.T) {
+	t.Parallel()
+
+	informers, client, events := newTestContainedObjectInformers(t,
+		testObjMeta(testDeploymentGVK, "web-1", "frontend", map[string]string{"tier": "web"}),
+		testObjMeta(testDeploymentGVK, "db-1", "database", nil),
+		testObjMeta(testClusterRoleGVK, "", "viewer", map[string]string{"tier": "web"}),
+	)
+
+	selector := labels.SelectorFromSet(labels.Set{"tier": "web"})
+
+	namespaces, err := informers.namespacesContaining(t.Context(), testDeploymentGVK, selector)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"web-1"}, namespaces.UnsortedList())
+
+	namespaces, err = informers.namespacesContaining(t.Context(), testDeploymentGVK, labels.Everything())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"web-1", "db-1"}, namespaces.UnsortedList())
+
+	namespaces, err = informers.namespacesContaining(t.Context(), testClusterRoleGVK, selector)
+	assert.NoError(t, err)
+	assert.Empty(t, namespaces)
+
+	_, err = informers.namespacesContaining(
+		t.Context(), schema.GroupVersionKind{Version: "v1", Kind: "Fake"}, selector,
+	)
+	assert.Error(t, err)
+
+	// Drain the events from the initial list before checking that a new object triggers one
+	select {
+	case <-events:
+	default:
+	}
+
+	deployments := testDeploymentGVK.GroupVersion().WithResource("deployments")
+
+	_, err = client.Resource(deployments).Namespace("web-2").(metadatafake.MetadataClient).CreateFake(
+		testObjMeta(testDeploymentGVK, "web-2", "frontend", map[string]string{"tier": "web"}),
+		metav1.CreateOptions{},
+	)
+	assert.NoError(t, err)
+
+	select {
+	case <-events:
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected an event after the Deployment was created")
+	}
+
+	assert.Eventually(t, func() bool {
+		namespaces, err := informers.namespacesContaining(t.Context(), testDeploymentGVK, selector)
+
+		return err == nil && namespaces.Has("web-2")
+	}, 10*time.Second, 100*time.Millisecond)
+}
+
+func TestContainedObjectInformersPrune(t *testing.T) {
+	t.Parallel()
+
+	informers, _, _ := newTestContainedObjectInformers(t)
+
+	target := policyv1.Target{
+		ContainsObjects: []policyv1.ContainedObjectSelector{{APIVersion: "apps/v1", Kind: "Deployment"}},
+	}
+
+	_, err := informers.namespacesContaining(t.Context(), testDeploymentGVK, labels.Everything())
+	assert.NoError(t, err)
+
+	_, err = informers.namespacesContaining(t.Context(), testClusterRoleGVK, labels.Everything())
+	assert.NoError(t, err)
+
+	informers.prune([]policyv1.Target{target})
+	assert.Len(t, informers.informers, 1)
+	assert.Contains(t, informers.informers, testDeploymentGVK)
+
+	// A kind that is in use by a selection being calculated isn't stopped
+	informers.acquire(target)
+	informers.prune(nil)
+	assert.Len(t, informers.informers, 1)
+
+	informers.release(target)
+	informers.prune(nil)
+	assert.Empty(t, informers.informers)
+}
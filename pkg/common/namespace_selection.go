@@ -17,21 +17,26 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/metadata"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
 )
 
 // SelectorReconciler keeps a cache of NamespaceSelector results, which it should update when
-// namespaces are created, deleted, or re-labeled.
+// namespaces are created, deleted, re-labeled, or re-annotated, or when the objects in them change.
 type SelectorReconciler interface {
 	// Get returns the items matching the given Target for the given object. If there's  no selection for that object,
 	// and Target has been calculated, it will be calculated now. Otherwise, a cached value may be used.
@@ -45,12 +50,20 @@ type SelectorReconciler interface {
 	Stop(namespace string, name string)
 }
 
+// selectionTimeout bounds how long a selection waits for the namespaces and the caches of the objects
+// referenced in `containsObjects`, so that a cache that never syncs doesn't block the reconciler.
+var selectionTimeout = 10 * time.Second
+
 type NamespaceSelectorReconciler struct {
 	client        client.Client
 	updateChannel chan<- event.GenericEvent
 	selections    map[string]namespaceSelection
 	lock          sync.RWMutex
 
+	// containedObjects is only set after SetupWithManager is called. It caches the metadata of the kinds
+	// of objects referenced in `containsObjects`, and triggers a Reconcile when they change.
+	containedObjects *containedObjectInformers
+
 	defaultIncludeTerminating string
 }
 
@@ -79,21 +92,33 @@ type namespaceSelection struct {
 	namespaces []string
 	hasUpdate  bool
 	err        error
+	// recheckAt is when a namespace will become old enough to match the `minAge` of the target. It is
+	// zero when no recheck is needed.
+	recheckAt time.Time
+}
+
+// Instead of reconciling for each Namespace, just reconcile once
+// - that reconcile will do a list on all the Namespaces anyway.
+func mapToSingleton(context.Context, client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{
+		Name: "NamespaceSelector",
+	}}}
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *NamespaceSelectorReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	neverEnqueue := predicate.NewPredicateFuncs(func(_ client.Object) bool { return false })
 
-	// Instead of reconciling for each Namespace, just reconcile once
-	// - that reconcile will do a list on all the Namespaces anyway.
-	mapToSingleton := func(context.Context, client.Object) []reconcile.Request {
-		return []reconcile.Request{{NamespacedName: types.NamespacedName{
-			Name: "NamespaceSelector",
-		}}}
+	metadataClient, err := metadata.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
 	}
 
-	return ctrl.NewControllerManagedBy(mgr).
+	// Set the buffer to 1 since any queued event causes the same Reconcile of all of the selections
+	containedEvents := make(chan event.GenericEvent, 1)
+	containedObjects := newContainedObjectInformers(metadataClient, mgr.GetRESTMapper(), containedEvents)
+
+	err = ctrl.NewControllerManagedBy(mgr).
 		Named("NamespaceSelector").
 		For( // This is a workaround because a `For` is required, but doesn't allow the enqueueing to be customized
 			&corev1.Namespace{},
@@ -101,12 +126,34 @@ func (r *NamespaceSelectorReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Watches(
 			&corev1.Namespace{},
 			handler.EnqueueRequestsFromMapFunc(mapToSingleton),
-			builder.WithPredicates(predicate.LabelChangedPredicate{})).
+			builder.WithPredicates(predicate.Or(
+				predicate.LabelChangedPredicate{}, predicate.AnnotationChangedPredicate{},
+			))).
+		WatchesRawSource(source.Channel(containedEvents, handler.EnqueueRequestsFromMapFunc(mapToSingleton))).
 		Complete(r)
+	if err != nil {
+		return err
+	}
+
+	// Stop the informers of the contained objects when the manager stops
+	err = mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		containedObjects.stopAll()
+
+		return nil
+	}))
+	if err != nil {
+		return err
+	}
+
+	r.containedObjects = containedObjects
+
+	return nil
 }
 
 // Reconcile runs whenever a namespace on the target cluster is created, deleted, or has a change in
-// labels. It updates the cached selections for NamespaceSelectors that it knows about.
+// labels or annotations, or when an object of a kind referenced in `containsObjects` is created, deleted,
+// or re-labeled. It updates the cached selections for NamespaceSelectors that it knows about.
 func (r *NamespaceSelectorReconciler) Reconcile(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
 	log := logf.Log.WithValues("Reconciler", "NamespaceSelector")
 	oldSelections := make(map[string]namespaceSelection)
@@ -129,10 +176,20 @@ func (r *NamespaceSelectorReconciler) Reconcile(ctx context.Context, _ ctrl.Requ
 		return ctrl.Result{}, err
 	}
 
+	var nextRecheck time.Time
+
 	for nsName, oldSelection := range oldSelections {
 		policyNs, policyName := splitKey(nsName)
 
-		newNamespaces, err := filter(namespaces, oldSelection.target, r.defaultIncludeTerminating)
+		selectCtx, cancel := context.WithTimeout(ctx, selectionTimeout)
+		newNamespaces, recheckAt, err := r.selectNamespaces(selectCtx, namespaces, oldSelection.target)
+
+		cancel()
+
+		if !recheckAt.IsZero() && (nextRecheck.IsZero() || recheckAt.Before(nextRecheck)) {
+			nextRecheck = recheckAt
+		}
+
 		if err != nil {
 			log.Error(err, "Unable to filter namespaces for policy", "namespace", policyNs, "name", policyName)
 
@@ -141,6 +198,7 @@ func (r *NamespaceSelectorReconciler) Reconcile(ctx context.Context, _ ctrl.Requ
 				namespaces: newNamespaces,
 				hasUpdate:  oldSelection.err == nil, // it has an update if the error state changed
 				err:        err,
+				recheckAt:  recheckAt,
 			})
 
 			continue
@@ -159,10 +217,20 @@ func (r *NamespaceSelectorReconciler) Reconcile(ctx context.Context, _ ctrl.Requ
 				namespaces: newNamespaces,
 				hasUpdate:  true,
 				err:        nil,
+				recheckAt:  recheckAt,
 			})
+		} else if !recheckAt.Equal(oldSelection.recheckAt) {
+			r.setRecheckAt(policyNs, policyName, oldSelection.target, recheckAt)
 		}
 	}
 
+	r.pruneContainedObjects()
+
+	// Reconcile again when a namespace becomes old enough to match a `minAge`
+	if !nextRecheck.IsZero() {
+		return ctrl.Result{RequeueAfter: max(time.Until(nextRecheck), time.Second)}, nil
+	}
+
 	return ctrl.Result{}, nil
 }
 
@@ -175,8 +243,9 @@ func (r *NamespaceSelectorReconciler) Get(objNS string, objName string, t policy
 
 	key := getKey(objNS, objName)
 
-	// If found, and target has not been changed
-	if selection, found := r.selections[key]; found && selection.target.String() == t.String() {
+	// If found, and target has not been changed, and no namespace has since become old enough to match
+	selection, found := r.selections[key]
+	if found && selection.target.String() == t.String() && !selection.needsRecheck() {
 		selection.hasUpdate = false
 		r.selections[key] = selection
 
@@ -205,7 +274,7 @@ func (r *NamespaceSelectorReconciler) Get(objNS string, objName string, t policy
 
 	nsList := corev1.NamespaceList{}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), selectionTimeout)
 	defer cancel()
 
 	// Fetch namespaces -- this List will be from the controller-runtime cache
@@ -215,7 +284,14 @@ func (r *NamespaceSelectorReconciler) Get(objNS string, objName string, t policy
 		return nil, err
 	}
 
-	selected, err := filter(nsList, t, r.defaultIncludeTerminating)
+	if r.containedObjects != nil {
+		// Keep the informers for the target running until its selection is cached
+		r.containedObjects.acquire(t)
+		defer r.containedObjects.release(t)
+		defer r.pruneContainedObjects()
+	}
+
+	selected, recheckAt, err := r.selectNamespaces(ctx, nsList, t)
 	if err != nil {
 		log.Error(err, "Unable to filter namespaces for policy", "namespace", objNS, "policy", objName)
 	}
@@ -228,13 +304,15 @@ func (r *NamespaceSelectorReconciler) Get(objNS string, objName string, t policy
 		namespaces: selected,
 		hasUpdate:  false,
 		err:        err,
+		recheckAt:  recheckAt,
 	})
 
 	return selected, err
 }
 
 // HasUpdate indicates when the cached selection for this policy has been changed since the last
-// time that Get was called for that policy. Returns true if the selection isn't cached.
+// time that Get was called for that policy. Returns true if the selection isn't cached or if a
+// namespace may have become old enough to be selected.
 func (r *NamespaceSelectorReconciler) HasUpdate(namespace string, name string) bool {
 	r.lock.RLock()
 	defer r.lock.RUnlock()
@@ -244,7 +322,35 @@ func (r *NamespaceSelectorReconciler) HasUpdate(namespace string, name string) b
 		return true
 	}
 
-	return sel.hasUpdate
+	return sel.hasUpdate || sel.needsRecheck()
+}
+
+// SelectionState is a snapshot of the cached namespace selection of a policy, for troubleshooting.
+type SelectionState struct {
+	Target     policyv1.Target
+	Namespaces []string
+	Err        error
+	// RecheckAt is when a namespace will become old enough to match the `minAge` of the target. It is
+	// zero when no recheck is needed.
+	RecheckAt time.Time
+}
+
+// Selection returns the cached namespace selection for the namespace and name, and false if there is none.
+func (r *NamespaceSelectorReconciler) Selection(namespace string, name string) (SelectionState, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	sel, ok := r.selections[getKey(namespace, name)]
+	if !ok {
+		return SelectionState{}, false
+	}
+
+	return SelectionState{
+		Target:     sel.target,
+		Namespaces: slices.Clone(sel.namespaces),
+		Err:        sel.err,
+		RecheckAt:  sel.recheckAt,
+	}, true
 }
 
 // Stop tells the SelectorReconciler to stop updating the cached selection for the name.
@@ -253,6 +359,35 @@ func (r *NamespaceSelectorReconciler) Stop(namespace string, name string) {
 	defer r.lock.Unlock()
 
 	delete(r.selections, getKey(namespace, name))
+
+	if r.containedObjects != nil {
+		r.containedObjects.prune(r.targets())
+	}
+}
+
+// pruneContainedObjects stops caching the kinds of objects that are no longer referenced in the
+// `containsObjects` of any selection.
+func (r *NamespaceSelectorReconciler) pruneContainedObjects() {
+	if r.containedObjects == nil {
+		return
+	}
+
+	r.lock.RLock()
+	targets := r.targets()
+	r.lock.RUnlock()
+
+	r.containedObjects.prune(targets)
+}
+
+// targets returns the targets of the cached selections. The caller must hold the lock.
+func (r *NamespaceSelectorReconciler) targets() []policyv1.Target {
+	targets := make([]policyv1.Target, 0, len(r.selections))
+
+	for _, sel := range r.selections {
+		targets = append(targets, sel.target)
+	}
+
+	return targets
 }
 
 func getKey(namespace string, name string) string {
@@ -269,6 +404,101 @@ func splitKey(key string) (string, string) {
 	return parts[0], parts[1]
 }
 
+// setRecheckAt updates when the cached selection should be recalculated without signaling an update.
+// It is a no-op if the selection was removed or its target has changed.
+func (r *NamespaceSelectorReconciler) setRecheckAt(
+	namespace string, name string, target policyv1.Target, recheckAt time.Time,
+) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	key := getKey(namespace, name)
+
+	sel, ok := r.selections[key]
+	if !ok || sel.target.String() != target.String() {
+		return
+	}
+
+	sel.recheckAt = recheckAt
+	r.selections[key] = sel
+}
+
+func (sel namespaceSelection) needsRecheck() bool {
+	return !sel.recheckAt.IsZero() && !time.Now().Before(sel.recheckAt)
+}
+
+// selectNamespaces returns the sorted names of the namespaces matching the Target, and the next time
+// that the selection should be recalculated because a namespace will be old enough to match `minAge`.
+func (r *NamespaceSelectorReconciler) selectNamespaces(
+	ctx context.Context, allNSList corev1.NamespaceList, t policyv1.Target,
+) ([]string, time.Time, error) {
+	containing := make([]sets.Set[string], 0, len(t.ContainsObjects))
+
+	for _, contained := range t.ContainsObjects {
+		namespaces, err := r.namespacesContaining(ctx, contained)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+
+		containing = append(containing, namespaces)
+	}
+
+	now := time.Now()
+
+	selected, err := filter(allNSList, t, r.defaultIncludeTerminating, containing, now)
+
+	return selected, nextAgeMatch(allNSList, t, now), err
+}
+
+// namespacesContaining returns the names of the namespaces that contain at least one object matching
+// the ContainedObjectSelector. When the reconciler was set up with a manager, the objects are read from a
+// metadata-only informer for the kind, which triggers a Reconcile when the objects change. Otherwise, they
+// are listed with the client.
+func (r *NamespaceSelectorReconciler) namespacesContaining(
+	ctx context.Context, contained policyv1.ContainedObjectSelector,
+) (sets.Set[string], error) {
+	gv, err := schema.ParseGroupVersion(contained.APIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing containsObjects apiVersion: %w", err)
+	}
+
+	selector := labels.Everything()
+
+	if contained.Selector != nil {
+		selector, err = metav1.LabelSelectorAsSelector(contained.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing containsObjects selector: %w", err)
+		}
+	}
+
+	if r.containedObjects != nil {
+		namespaces, err := r.containedObjects.namespacesContaining(ctx, gv.WithKind(contained.Kind), selector)
+		if err != nil {
+			return nil, fmt.Errorf("error listing %s objects for containsObjects: %w", contained.Kind, err)
+		}
+
+		return namespaces, nil
+	}
+
+	objList := metav1.PartialObjectMetadataList{}
+	objList.SetGroupVersionKind(gv.WithKind(contained.Kind + "List"))
+
+	err = r.client.List(ctx, &objList, client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("error listing %s objects for containsObjects: %w", contained.Kind, err)
+	}
+
+	namespaces := sets.New[string]()
+
+	for _, obj := range objList.Items {
+		if obj.GetNamespace() != "" {
+			namespaces.Insert(obj.GetNamespace())
+		}
+	}
+
+	return namespaces, nil
+}
+
 func (r *NamespaceSelectorReconciler) update(namespace string, name string, sel namespaceSelection) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
@@ -291,7 +521,15 @@ func (r *NamespaceSelectorReconciler) update(namespace string, name string, sel
 	}
 }
 
-func filter(allNSList corev1.NamespaceList, t policyv1.Target, defaultIncludeTerminating string) ([]string, error) {
+// filter returns the sorted names of the namespaces matching the Target. The containing argument has
+// the names of the namespaces containing matching objects for each entry in `containsObjects`.
+func filter(
+	allNSList corev1.NamespaceList,
+	t policyv1.Target,
+	defaultIncludeTerminating string,
+	containing []sets.Set[string],
+	now time.Time,
+) ([]string, error) {
 	// If MatchLabels and MatchExpressions are nil, the resulting label selector
 	// matches all namespaces. This is to guard against that.
 	if t.IsEmpty() {
@@ -322,7 +560,29 @@ func filter(allNSList corev1.NamespaceList, t policyv1.Target, defaultIncludeTer
 			continue
 		}
 
-		if selector.Matches(labels.Set(ns.GetLabels())) {
+		if !selector.Matches(labels.Set(ns.GetLabels())) {
+			continue
+		}
+
+		if !annotationsMatch(ns.GetAnnotations(), t.MatchAnnotations) {
+			continue
+		}
+
+		if t.MinAge != nil && now.Sub(ns.CreationTimestamp.Time) < t.MinAge.Duration {
+			continue
+		}
+
+		containsAll := true
+
+		for _, nsSet := range containing {
+			if !nsSet.Has(ns.Name) {
+				containsAll = false
+
+				break
+			}
+		}
+
+		if containsAll {
 			nsToFilter = append(nsToFilter, ns.Name)
 		}
 	}
@@ -332,3 +592,33 @@ func filter(allNSList corev1.NamespaceList, t policyv1.Target, defaultIncludeTer
 
 	return namespaces, err
 }
+
+func annotationsMatch(annotations map[string]string, matchAnnotations map[string]string) bool {
+	for key, value := range matchAnnotations {
+		if actual, ok := annotations[key]; !ok || actual != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// nextAgeMatch returns the earliest time that a namespace that is currently too young will be old
+// enough to match the `minAge` of the Target. It returns the zero time if there is no such namespace.
+func nextAgeMatch(allNSList corev1.NamespaceList, t policyv1.Target, now time.Time) time.Time {
+	var next time.Time
+
+	if t.MinAge == nil {
+		return next
+	}
+
+	for _, ns := range allNSList.Items {
+		matchesAt := ns.CreationTimestamp.Add(t.MinAge.Duration)
+
+		if matchesAt.After(now) && (next.IsZero() || matchesAt.Before(next)) {
+			next = matchesAt
+		}
+	}
+
+	return next
+}
@@ -4,12 +4,21 @@
 package common
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clienttesting "k8s.io/client-go/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
 )
@@ -126,7 +135,7 @@ func TestFilter(t *testing.T) {
 			func(t *testing.T) {
 				t.Parallel()
 
-				actual, err := filter(sampleNamespaceList(), test.target, test.controllerTI)
+				actual, err := filter(sampleNamespaceList(), test.target, test.controllerTI, nil, time.Now())
 				if err != nil {
 					t.Fatalf("Unexpected error occurred: %v", err)
 				}
@@ -137,6 +146,98 @@ func TestFilter(t *testing.T) {
 	}
 }
 
+func TestFilterAnnotationsAgeAndContents(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	namespace := func(name string, age time.Duration, annotations map[string]string) corev1.Namespace {
+		return corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Annotations:       annotations,
+			CreationTimestamp: metav1.Time{Time: now.Add(-age)},
+		}}
+	}
+
+	nsList := corev1.NamespaceList{Items: []corev1.Namespace{
+		namespace("frontend", 2*time.Hour, map[string]string{"team": "web"}),
+		namespace("backend", 2*time.Hour, map[string]string{"team": "api"}),
+		namespace("new-frontend", 10*time.Minute, map[string]string{"team": "web", "new": "true"}),
+		namespace("new-backend", 20*time.Minute, nil),
+	}}
+
+	tests := map[string]struct {
+		target     policyv1.Target
+		containing []sets.Set[string]
+		expected   []string
+		recheckAt  time.Time
+	}{
+		"Matching annotations should work": {
+			policyv1.Target{MatchAnnotations: map[string]string{"team": "web"}},
+			nil,
+			[]string{"frontend", "new-frontend"},
+			time.Time{},
+		},
+		"Matching multiple annotations should work": {
+			policyv1.Target{MatchAnnotations: map[string]string{"team": "web", "new": "true"}},
+			nil,
+			[]string{"new-frontend"},
+			time.Time{},
+		},
+		"A minimum age should exclude newer namespaces": {
+			policyv1.Target{MinAge: &metav1.Duration{Duration: time.Hour}},
+			nil,
+			[]string{"backend", "frontend"},
+			now.Add(40 * time.Minute),
+		},
+		"Containing objects should work": {
+			policyv1.Target{ContainsObjects: []policyv1.ContainedObjectSelector{
+				{APIVersion: "apps/v1", Kind: "Deployment"},
+			}},
+			[]sets.Set[string]{sets.New("frontend", "new-frontend", "not-listed")},
+			[]string{"frontend", "new-frontend"},
+			time.Time{},
+		},
+		"Containing multiple kinds of objects should require all of them": {
+			policyv1.Target{ContainsObjects: []policyv1.ContainedObjectSelector{
+				{APIVersion: "apps/v1", Kind: "Deployment"},
+				{APIVersion: "v1", Kind: "Service"},
+			}},
+			[]sets.Set[string]{sets.New("frontend", "new-frontend"), sets.New("frontend", "backend")},
+			[]string{"frontend"},
+			time.Time{},
+		},
+		"All criteria should be combined": {
+			policyv1.Target{
+				Exclude:          []policyv1.NonEmptyString{"backend"},
+				MatchAnnotations: map[string]string{"team": "web"},
+				MinAge:           &metav1.Duration{Duration: 5 * time.Minute},
+				ContainsObjects:  []policyv1.ContainedObjectSelector{{APIVersion: "apps/v1", Kind: "Deployment"}},
+			},
+			[]sets.Set[string]{sets.New("new-frontend", "backend")},
+			[]string{"new-frontend"},
+			time.Time{},
+		},
+	}
+
+	for description, test := range tests {
+		t.Run(
+			description,
+			func(t *testing.T) {
+				t.Parallel()
+
+				actual, err := filter(nsList, test.target, "IfMatch", test.containing, now)
+				if err != nil {
+					t.Fatalf("Unexpected error occurred: %v", err)
+				}
+
+				assert.Equal(t, test.expected, actual)
+				assert.Equal(t, test.recheckAt, nextAgeMatch(nsList, test.target, now))
+			},
+		)
+	}
+}
+
 // Returns a pared-down NamespaceList with much of the metadata missing, but should contain enough
 // information for some tests. Contains these namespaces:
 //   - alfalfa:         food:vegetable, terminating
@@ -242,3 +343,54 @@ func sampleNamespaceList() corev1.NamespaceList {
 		},
 	}}}
 }
+
+func TestReconcileContainedObjectsNeverSync(t *testing.T) {
+	originalTimeout := selectionTimeout
+	selectionTimeout = 100 * time.Millisecond
+
+	t.Cleanup(func() { selectionTimeout = originalTimeout })
+
+	informers, metadataClient, _ := newTestContainedObjectInformers(t)
+
+	// The deployments can never be listed, so their cache never syncs
+	metadataClient.PrependReactor("list", "deployments",
+		func(_ clienttesting.Action) (bool, runtime.Object, error) {
+			return true, nil, k8serrors.NewForbidden(
+				schema.GroupResource{Group: "apps", Resource: "deployments"}, "", errors.New("not allowed"),
+			)
+		},
+	)
+
+	k8sClient := fake.NewClientBuilder().
+		WithObjects(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "frontend"}}).
+		Build()
+
+	target := policyv1.Target{ContainsObjects: []policyv1.ContainedObjectSelector{
+		{APIVersion: "apps/v1", Kind: "Deployment"},
+	}}
+
+	r := &NamespaceSelectorReconciler{
+		client:           k8sClient,
+		selections:       map[string]namespaceSelection{getKey("policies", "policy"): {target: target}},
+		containedObjects: informers,
+	}
+
+	done := make(chan error)
+
+	go func() {
+		_, err := r.Reconcile(context.Background(), ctrl.Request{})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("Reconcile did not return while the cache was not synced")
+	}
+
+	selection, found := r.Selection("policies", "policy")
+	assert.True(t, found)
+	assert.ErrorContains(t, selection.Err, "timed out waiting for the Deployment cache to sync")
+	assert.True(t, r.HasUpdate("policies", "policy"))
+}
@@ -0,0 +1,133 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+)
+
+// ObjectSelection is a parsed ObjectSelector. The label selector can be used when listing objects, and
+// Filter must be called on the results to apply the name patterns and field selector.
+type ObjectSelection struct {
+	Labels  labels.Selector
+	fields  fields.Selector
+	include []policyv1.NonEmptyString
+	exclude []policyv1.NonEmptyString
+}
+
+// ParseObjectSelector parses the given ObjectSelector. An ObjectSelector without a label selector
+// selects objects with any labels. The returned error for an invalid label selector is not wrapped
+// so that it can be reported as is.
+func ParseObjectSelector(sel *policyv1.ObjectSelector) (ObjectSelection, error) {
+	if sel == nil {
+		return ObjectSelection{Labels: labels.Everything(), fields: fields.Everything()}, nil
+	}
+
+	parsed := ObjectSelection{
+		Labels:  labels.Everything(),
+		fields:  fields.Everything(),
+		include: sel.Include,
+		exclude: sel.Exclude,
+	}
+
+	if sel.LabelSelector != nil {
+		var err error
+
+		parsed.Labels, err = metav1.LabelSelectorAsSelector(sel.LabelSelector)
+		if err != nil {
+			return parsed, err
+		}
+	}
+
+	if sel.FieldSelector != "" {
+		var err error
+
+		parsed.fields, err = fields.ParseSelector(sel.FieldSelector)
+		if err != nil {
+			return parsed, fmt.Errorf("error parsing fieldSelector: %w", err)
+		}
+	}
+
+	// Validate the patterns up front so that errors are reported even when there are no objects
+	if _, err := Matches([]string{""}, parsed.include, nil); err != nil {
+		return parsed, err
+	}
+
+	if _, err := Matches([]string{""}, nil, parsed.exclude); err != nil {
+		return parsed, err
+	}
+
+	return parsed, nil
+}
+
+// Filter returns the objects that match the label selector, name patterns, and field selector.
+func (s ObjectSelection) Filter(objs []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	filtered := make([]unstructured.Unstructured, 0, len(objs))
+
+	for _, obj := range objs {
+		if s.Labels != nil && !s.Labels.Matches(labels.Set(obj.GetLabels())) {
+			continue
+		}
+
+		if s.fields != nil && !s.fields.Empty() && !fieldsMatch(s.fields, obj) {
+			continue
+		}
+
+		if len(s.include) != 0 || len(s.exclude) != 0 {
+			matched, err := Matches([]string{obj.GetName()}, s.include, s.exclude)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(matched) == 0 {
+				continue
+			}
+		}
+
+		filtered = append(filtered, obj)
+	}
+
+	return filtered, nil
+}
+
+// fieldsMatch determines whether the object matches every requirement in the field selector. Since
+// field selectors are only supported on a few fields by the Kubernetes API server, the fields are read
+// from the object directly.
+func fieldsMatch(selector fields.Selector, obj unstructured.Unstructured) bool {
+	for _, req := range selector.Requirements() {
+		value, found, err := unstructured.NestedFieldNoCopy(obj.Object, strings.Split(req.Field, ".")...)
+		if err != nil {
+			return false
+		}
+
+		var strValue string
+
+		if found && value != nil {
+			strValue = fmt.Sprint(value)
+		}
+
+		switch req.Operator {
+		case selection.Equals, selection.DoubleEquals:
+			if strValue != req.Value {
+				return false
+			}
+		case selection.NotEquals:
+			if strValue == req.Value {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,138 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+)
+
+func TestObjectSelectionFilter(t *testing.T) {
+	t.Parallel()
+
+	service := func(name string, svcType string, labels map[string]string) unstructured.Unstructured {
+		obj := unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+			"spec":       map[string]interface{}{"type": svcType, "ports": int64(1)},
+		}}
+		obj.SetLabels(labels)
+
+		return obj
+	}
+
+	objs := []unstructured.Unstructured{
+		service("frontend-lb", "LoadBalancer", map[string]string{"tier": "frontend"}),
+		service("frontend-internal", "ClusterIP", map[string]string{"tier": "frontend"}),
+		service("backend-lb", "LoadBalancer", map[string]string{"tier": "backend"}),
+		service("backend-test", "LoadBalancer", nil),
+	}
+
+	tests := []struct {
+		testDescription string
+		selector        *policyv1.ObjectSelector
+		expected        []string
+		errMsg          string
+	}{
+		{
+			"Nil selector",
+			nil,
+			[]string{"frontend-lb", "frontend-internal", "backend-lb", "backend-test"},
+			"",
+		},
+		{
+			"Empty selector",
+			&policyv1.ObjectSelector{},
+			[]string{"frontend-lb", "frontend-internal", "backend-lb", "backend-test"},
+			"",
+		},
+		{
+			"Label selector",
+			&policyv1.ObjectSelector{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "frontend"}},
+			},
+			[]string{"frontend-lb", "frontend-internal"},
+			"",
+		},
+		{
+			"Name patterns",
+			&policyv1.ObjectSelector{
+				Include: []policyv1.NonEmptyString{"backend-*"},
+				Exclude: []policyv1.NonEmptyString{"*-test"},
+			},
+			[]string{"backend-lb"},
+			"",
+		},
+		{
+			"Field selector",
+			&policyv1.ObjectSelector{FieldSelector: "spec.type=LoadBalancer,metadata.name!=backend-test"},
+			[]string{"frontend-lb", "backend-lb"},
+			"",
+		},
+		{
+			"Field selector on a non-string field",
+			&policyv1.ObjectSelector{FieldSelector: "spec.ports==1"},
+			[]string{"frontend-lb", "frontend-internal", "backend-lb", "backend-test"},
+			"",
+		},
+		{
+			"Field selector on a missing field",
+			&policyv1.ObjectSelector{FieldSelector: "status.loadBalancer!="},
+			[]string{},
+			"",
+		},
+		{
+			"All criteria",
+			&policyv1.ObjectSelector{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "frontend"}},
+				Include:       []policyv1.NonEmptyString{"frontend-*"},
+				FieldSelector: "spec.type=LoadBalancer",
+			},
+			[]string{"frontend-lb"},
+			"",
+		},
+		{
+			"Invalid field selector",
+			&policyv1.ObjectSelector{FieldSelector: "spec.type"},
+			nil,
+			"error parsing fieldSelector: invalid selector: 'spec.type'; can't understand 'spec.type'",
+		},
+		{
+			"Invalid exclude pattern",
+			&policyv1.ObjectSelector{Exclude: []policyv1.NonEmptyString{"[backend"}},
+			nil,
+			"error parsing 'exclude' pattern '[backend': syntax error in pattern",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testDescription, func(t *testing.T) {
+			t.Parallel()
+
+			selection, err := ParseObjectSelector(test.selector)
+			if test.errMsg != "" {
+				assert.EqualError(t, err, test.errMsg)
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			filtered, err := selection.Filter(objs)
+			assert.NoError(t, err)
+
+			names := []string{}
+			for _, obj := range filtered {
+				names = append(names, obj.GetName())
+			}
+
+			assert.Equal(t, test.expected, names)
+		})
+	}
+}
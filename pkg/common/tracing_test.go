@@ -0,0 +1,21 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetupTracing(t *testing.T) {
+	t.Parallel()
+
+	shutdown, err := SetupTracing(context.TODO(), TracingOptions{}, "test")
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.TODO()))
+
+	_, err = SetupTracing(context.TODO(), TracingOptions{Endpoint: "localhost:4317", SampleRatio: 2}, "test")
+	assert.ErrorContains(t, err, "the tracing sample ratio must be between 0 and 1")
+}
@@ -0,0 +1,447 @@
+// Copyright Contributors to the Open Cluster Management project
+
+// Package notifications posts CloudEvents to HTTP sinks when the compliance of policies changes and when
+// policies are enforced.
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// EventTypeComplianceChanged is the CloudEvents type sent when the compliance of a policy changes.
+	EventTypeComplianceChanged = "io.open-cluster-management.policy.compliance.changed"
+	// EventTypeEnforced is the CloudEvents type sent when a policy changes an object on the cluster.
+	EventTypeEnforced = "io.open-cluster-management.policy.enforced"
+
+	cloudEventsSpecVersion = "1.0"
+	cloudEventsContentType = "application/cloudevents+json"
+
+	defaultQueueSize      = 1000
+	defaultMaxRetries     = 5
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = time.Minute
+	defaultTimeout        = 10 * time.Second
+)
+
+// severities are the policy severities from lowest to highest.
+var severities = []string{"low", "medium", "high", "critical"}
+
+var log = ctrl.Log.WithName("notifications")
+
+var (
+	notificationsSentCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "policy_notifications_sent_total",
+			Help: "The number of CloudEvents notifications successfully delivered to a sink",
+		},
+		[]string{"sink"},
+	)
+	notificationsFailedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "policy_notifications_failed_total",
+			Help: "The number of CloudEvents notifications that could not be delivered to a sink after retrying",
+		},
+		[]string{"sink"},
+	)
+	notificationsDroppedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "policy_notifications_dropped_total",
+			Help: "The number of CloudEvents notifications dropped because the queue of a sink was full",
+		},
+		[]string{"sink"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		notificationsSentCounter,
+		notificationsFailedCounter,
+		notificationsDroppedCounter,
+	)
+}
+
+// Config configures the sinks that notifications are sent to.
+type Config struct {
+	// Sinks are the HTTP endpoints that receive the notifications.
+	Sinks []SinkConfig `json:"sinks"`
+	// QueueSize is the maximum number of notifications waiting to be sent to each sink. When the queue of a
+	// sink is full, new notifications for it are dropped. Defaults to 1000.
+	QueueSize int `json:"queueSize,omitempty"`
+	// MaxRetries is the number of times the delivery of a notification is retried. Defaults to 5.
+	MaxRetries *int `json:"maxRetries,omitempty"`
+	// InitialBackoff is the time to wait before the first retry, which doubles on every retry up to
+	// MaxBackoff. Defaults to 1s.
+	InitialBackoff metav1.Duration `json:"initialBackoff,omitempty"`
+	// MaxBackoff is the maximum time to wait between retries. Defaults to 1m.
+	MaxBackoff metav1.Duration `json:"maxBackoff,omitempty"`
+	// Timeout is the timeout of each HTTP request. Defaults to 10s.
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// SinkConfig is an HTTP endpoint that receives notifications.
+type SinkConfig struct {
+	// Name identifies the sink in logs and metrics. Defaults to the host of the URL.
+	Name string `json:"name,omitempty"`
+	// URL is the HTTP or HTTPS URL that the CloudEvents are posted to.
+	URL string `json:"url"`
+	// Headers are additional HTTP headers sent with each request, such as for authorization.
+	Headers map[string]string `json:"headers,omitempty"`
+	// MinSeverity only sends the notifications of policies with at least this severity. Policies without a
+	// severity are treated as low. Defaults to sending the notifications of all policies.
+	MinSeverity string `json:"minSeverity,omitempty"`
+	// Namespaces only sends the notifications of policies in these namespaces. Defaults to all namespaces.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Types only sends the notifications of these CloudEvents types. Defaults to all types.
+	Types []string `json:"types,omitempty"`
+}
+
+// LoadConfig reads the notification configuration from a YAML or JSON file.
+func LoadConfig(path string) (Config, error) {
+	config := Config{}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("failed to read the notification configuration: %w", err)
+	}
+
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse the notification configuration: %w", err)
+	}
+
+	return config, nil
+}
+
+// PolicyReference identifies the policy that a notification is for.
+type PolicyReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace"`
+	Name       string `json:"name"`
+	UID        string `json:"uid,omitempty"`
+}
+
+// ObjectReference identifies the object that a policy enforced.
+type ObjectReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+// ComplianceChangedData is the data of an EventTypeComplianceChanged notification.
+type ComplianceChangedData struct {
+	Policy     PolicyReference `json:"policy"`
+	Severity   string          `json:"severity,omitempty"`
+	Compliance string          `json:"compliance"`
+	Message    string          `json:"message"`
+}
+
+// EnforcedData is the data of an EventTypeEnforced notification.
+type EnforcedData struct {
+	Policy   PolicyReference `json:"policy"`
+	Severity string          `json:"severity,omitempty"`
+	// Action is either "created", "updated", "recreated", "deleted", or "approved".
+	Action  string          `json:"action"`
+	Object  ObjectReference `json:"object"`
+	Message string          `json:"message,omitempty"`
+}
+
+// CloudEvent is a notification in the structured content mode of the CloudEvents HTTP binding.
+type CloudEvent struct {
+	SpecVersion     string      `json:"specversion"`
+	ID              string      `json:"id"`
+	Source          string      `json:"source"`
+	Type            string      `json:"type"`
+	Subject         string      `json:"subject,omitempty"`
+	Time            time.Time   `json:"time"`
+	DataContentType string      `json:"datacontenttype"`
+	Data            interface{} `json:"data"`
+}
+
+// notification is a queued CloudEvent with the fields used for filtering.
+type notification struct {
+	event     CloudEvent
+	namespace string
+	severity  string
+}
+
+type sink struct {
+	SinkConfig
+	queue chan notification
+}
+
+// Notifier sends notifications to the configured sinks. Each sink has a bounded queue and a worker that
+// delivers its notifications in order, retrying failures with an exponential backoff.
+type Notifier struct {
+	source         string
+	sinks          []*sink
+	client         *http.Client
+	maxRetries     int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+// New validates the configuration and returns a Notifier, which must be started with Start. The source is
+// the CloudEvents source of the notifications.
+func New(config Config, source string) (*Notifier, error) {
+	notifier := &Notifier{
+		source:         source,
+		client:         &http.Client{Timeout: defaultTimeout},
+		maxRetries:     defaultMaxRetries,
+		initialBackoff: defaultInitialBackoff,
+		maxBackoff:     defaultMaxBackoff,
+	}
+
+	queueSize := defaultQueueSize
+
+	if config.QueueSize < 0 {
+		return nil, fmt.Errorf("the notification queue size must not be negative, got %d", config.QueueSize)
+	} else if config.QueueSize > 0 {
+		queueSize = config.QueueSize
+	}
+
+	if config.MaxRetries != nil {
+		if *config.MaxRetries < 0 {
+			return nil, fmt.Errorf("the notification maximum retries must not be negative, got %d", *config.MaxRetries)
+		}
+
+		notifier.maxRetries = *config.MaxRetries
+	}
+
+	if config.InitialBackoff.Duration > 0 {
+		notifier.initialBackoff = config.InitialBackoff.Duration
+	}
+
+	if config.MaxBackoff.Duration > 0 {
+		notifier.maxBackoff = config.MaxBackoff.Duration
+	}
+
+	if config.Timeout.Duration > 0 {
+		notifier.client.Timeout = config.Timeout.Duration
+	}
+
+	if len(config.Sinks) == 0 {
+		return nil, errors.New("at least one notification sink must be configured")
+	}
+
+	for i, sinkConfig := range config.Sinks {
+		sinkURL, err := url.Parse(sinkConfig.URL)
+		if err != nil || (sinkURL.Scheme != "http" && sinkURL.Scheme != "https") || sinkURL.Host == "" {
+			return nil, fmt.Errorf("the notification sink at index %d has an invalid URL: %s", i, sinkConfig.URL)
+		}
+
+		if sinkConfig.MinSeverity != "" && !slices.Contains(severities, strings.ToLower(sinkConfig.MinSeverity)) {
+			return nil, fmt.Errorf(
+				"the notification sink at index %d has an invalid minSeverity %s, valid values are: %s",
+				i, sinkConfig.MinSeverity, strings.Join(severities, ", "),
+			)
+		}
+
+		if sinkConfig.Name == "" {
+			sinkConfig.Name = sinkURL.Host
+		}
+
+		notifier.sinks = append(notifier.sinks, &sink{
+			SinkConfig: sinkConfig,
+			queue:      make(chan notification, queueSize),
+		})
+	}
+
+	return notifier, nil
+}
+
+// Start delivers the queued notifications until the context is canceled. It satisfies the
+// controller-runtime manager.Runnable interface.
+func (n *Notifier) Start(ctx context.Context) error {
+	var wg sync.WaitGroup
+
+	for _, s := range n.sinks {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case notif := <-s.queue:
+					n.deliver(ctx, s, notif.event)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return nil
+}
+
+// ComplianceChanged queues an EventTypeComplianceChanged notification. It is a no-op if notifications
+// are not enabled.
+func (n *Notifier) ComplianceChanged(data ComplianceChangedData) {
+	if n == nil {
+		return
+	}
+
+	n.enqueue(EventTypeComplianceChanged, data.Policy, data.Severity, data)
+}
+
+// Enforced queues an EventTypeEnforced notification. It is a no-op if notifications are not enabled.
+func (n *Notifier) Enforced(data EnforcedData) {
+	if n == nil {
+		return
+	}
+
+	n.enqueue(EventTypeEnforced, data.Policy, data.Severity, data)
+}
+
+func (n *Notifier) enqueue(eventType string, policy PolicyReference, severity string, data interface{}) {
+	notif := notification{
+		event: CloudEvent{
+			SpecVersion:     cloudEventsSpecVersion,
+			ID:              string(uuid.NewUUID()),
+			Source:          n.source,
+			Type:            eventType,
+			Subject:         policy.Kind + "/" + policy.Namespace + "/" + policy.Name,
+			Time:            time.Now().UTC(),
+			DataContentType: "application/json",
+			Data:            data,
+		},
+		namespace: policy.Namespace,
+		severity:  strings.ToLower(severity),
+	}
+
+	for _, s := range n.sinks {
+		if !s.matches(notif) {
+			continue
+		}
+
+		select {
+		case s.queue <- notif:
+		default:
+			log.Info("Dropping the notification because the queue of the sink is full",
+				"sink", s.Name, "type", eventType, "subject", notif.event.Subject)
+			notificationsDroppedCounter.WithLabelValues(s.Name).Inc()
+		}
+	}
+}
+
+// matches returns true if the notification passes the filters of the sink.
+func (s *sink) matches(notif notification) bool {
+	if len(s.Namespaces) != 0 && !slices.Contains(s.Namespaces, notif.namespace) {
+		return false
+	}
+
+	if len(s.Types) != 0 && !slices.Contains(s.Types, notif.event.Type) {
+		return false
+	}
+
+	if s.MinSeverity != "" {
+		severity := slices.Index(severities, notif.severity)
+		if severity == -1 {
+			severity = 0
+		}
+
+		if severity < slices.Index(severities, strings.ToLower(s.MinSeverity)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// deliver posts the CloudEvent to the sink, retrying with an exponential backoff on connection errors,
+// server errors, and rate limiting.
+func (n *Notifier) deliver(ctx context.Context, s *sink, event CloudEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Error(err, "Failed to marshal the notification", "sink", s.Name, "type", event.Type)
+		notificationsFailedCounter.WithLabelValues(s.Name).Inc()
+
+		return
+	}
+
+	backoff := n.initialBackoff
+
+	for attempt := 0; ; attempt++ {
+		retry, err := n.post(ctx, s, body)
+		if err == nil {
+			notificationsSentCounter.WithLabelValues(s.Name).Inc()
+
+			return
+		}
+
+		if !retry || attempt >= n.maxRetries {
+			log.Error(err, "Failed to deliver the notification", "sink", s.Name, "type", event.Type,
+				"subject", event.Subject, "attempts", attempt+1)
+			notificationsFailedCounter.WithLabelValues(s.Name).Inc()
+
+			return
+		}
+
+		log.V(1).Info("Retrying the delivery of the notification", "sink", s.Name, "type", event.Type,
+			"subject", event.Subject, "backoff", backoff.String(), "error", err.Error())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, n.maxBackoff)
+	}
+}
+
+// post sends a single request to the sink. It returns whether the request should be retried when it fails.
+func (n *Notifier) post(ctx context.Context, s *sink, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	for key, value := range s.Headers {
+		req.Header.Set(key, value)
+	}
+
+	req.Header.Set("Content-Type", cloudEventsContentType)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+
+	defer resp.Body.Close()
+
+	// Drain the body so that the connection can be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	err = fmt.Errorf("the sink responded with the HTTP status %s", resp.Status)
+
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode == http.StatusRequestTimeout, err
+}
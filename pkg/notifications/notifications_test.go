@@ -0,0 +1,310 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// testSink is a local HTTP stand-in for a CloudEvents sink. It responds with the queued status codes and
+// then with 202.
+type testSink struct {
+	*httptest.Server
+	lock     sync.Mutex
+	statuses []int
+	requests []*http.Request
+	events   []map[string]interface{}
+}
+
+func newTestSink(t *testing.T, statuses ...int) *testSink {
+	t.Helper()
+
+	s := &testSink{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		s.requests = append(s.requests, r)
+
+		if len(s.statuses) != 0 {
+			status := s.statuses[0]
+			s.statuses = s.statuses[1:]
+			w.WriteHeader(status)
+
+			return
+		}
+
+		event := map[string]interface{}{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+
+		s.events = append(s.events, event)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *testSink) received() (requests int, events []map[string]interface{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(s.requests), s.events
+}
+
+func startNotifier(t *testing.T, config Config) *Notifier {
+	t.Helper()
+
+	notifier, err := New(config, "config-policy-controller/local-cluster")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		assert.NoError(t, notifier.Start(ctx))
+		close(done)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	return notifier
+}
+
+func testPolicy(namespace string) PolicyReference {
+	return PolicyReference{
+		APIVersion: "policy.open-cluster-management.io/v1",
+		Kind:       "ConfigurationPolicy",
+		Namespace:  namespace,
+		Name:       "policy",
+	}
+}
+
+func TestNotifierRetries(t *testing.T) {
+	t.Parallel()
+
+	sink := newTestSink(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+
+	notifier := startNotifier(t, Config{
+		Sinks: []SinkConfig{{
+			Name:    "retries",
+			URL:     sink.URL,
+			Headers: map[string]string{"Authorization": "Bearer token"},
+		}},
+		InitialBackoff: metav1.Duration{Duration: 10 * time.Millisecond},
+	})
+
+	notifier.ComplianceChanged(ComplianceChangedData{
+		Policy:     testPolicy("policies"),
+		Severity:   "high",
+		Compliance: "NonCompliant",
+		Message:    "NonCompliant; violation - configmaps [cm] not found in namespace default",
+	})
+
+	assert.Eventually(t, func() bool {
+		_, events := sink.received()
+
+		return len(events) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	requests, events := sink.received()
+	assert.Equal(t, 3, requests)
+	assert.Equal(t, "Bearer token", sink.requests[2].Header.Get("Authorization"))
+	assert.Equal(t, "application/cloudevents+json", sink.requests[2].Header.Get("Content-Type"))
+
+	event := events[0]
+	assert.Equal(t, "1.0", event["specversion"])
+	assert.Equal(t, EventTypeComplianceChanged, event["type"])
+	assert.Equal(t, "config-policy-controller/local-cluster", event["source"])
+	assert.Equal(t, "ConfigurationPolicy/policies/policy", event["subject"])
+	assert.NotEmpty(t, event["id"])
+	assert.Equal(t, map[string]interface{}{
+		"policy": map[string]interface{}{
+			"apiVersion": "policy.open-cluster-management.io/v1",
+			"kind":       "ConfigurationPolicy",
+			"namespace":  "policies",
+			"name":       "policy",
+		},
+		"severity":   "high",
+		"compliance": "NonCompliant",
+		"message":    "NonCompliant; violation - configmaps [cm] not found in namespace default",
+	}, event["data"])
+
+	assert.InDelta(t, 1, testutil.ToFloat64(notificationsSentCounter.WithLabelValues("retries")), 0)
+}
+
+func TestNotifierGivesUp(t *testing.T) {
+	t.Parallel()
+
+	// A client error is not retried
+	badRequestSink := newTestSink(t, http.StatusBadRequest)
+	// A server error is retried up to the maximum retries
+	unavailableSink := newTestSink(t, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+
+	notifier := startNotifier(t, Config{
+		Sinks: []SinkConfig{
+			{Name: "bad-request", URL: badRequestSink.URL},
+			{Name: "unavailable", URL: unavailableSink.URL},
+		},
+		MaxRetries:     ptr.To(1),
+		InitialBackoff: metav1.Duration{Duration: 10 * time.Millisecond},
+	})
+
+	notifier.Enforced(EnforcedData{
+		Policy: testPolicy("policies"),
+		Action: "created",
+		Object: ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: "cm"},
+	})
+
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(notificationsFailedCounter.WithLabelValues("bad-request")) == 1 &&
+			testutil.ToFloat64(notificationsFailedCounter.WithLabelValues("unavailable")) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	requests, _ := badRequestSink.received()
+	assert.Equal(t, 1, requests)
+
+	requests, _ = unavailableSink.received()
+	assert.Equal(t, 2, requests)
+}
+
+func TestNotifierFilters(t *testing.T) {
+	t.Parallel()
+
+	notifier, err := New(Config{
+		Sinks: []SinkConfig{
+			{Name: "all", URL: "http://localhost:8080"},
+			{Name: "severity", URL: "http://localhost:8080", MinSeverity: "High"},
+			{Name: "namespace", URL: "http://localhost:8080", Namespaces: []string{"prod"}},
+			{Name: "type", URL: "http://localhost:8080", Types: []string{EventTypeEnforced}},
+		},
+	}, "test")
+	assert.NoError(t, err)
+
+	notifier.ComplianceChanged(ComplianceChangedData{Policy: testPolicy("prod"), Severity: "medium"})
+	notifier.ComplianceChanged(ComplianceChangedData{Policy: testPolicy("dev"), Severity: "critical"})
+	notifier.ComplianceChanged(ComplianceChangedData{Policy: testPolicy("dev")})
+	notifier.Enforced(EnforcedData{Policy: testPolicy("prod"), Severity: "high", Action: "deleted"})
+
+	queued := map[string]int{}
+	for _, s := range notifier.sinks {
+		queued[s.Name] = len(s.queue)
+	}
+
+	assert.Equal(t, map[string]int{"all": 4, "severity": 2, "namespace": 2, "type": 1}, queued)
+}
+
+func TestNotifierBoundedQueue(t *testing.T) {
+	t.Parallel()
+
+	// The notifier isn't started, so the queue is never drained
+	notifier, err := New(Config{
+		Sinks:     []SinkConfig{{Name: "bounded", URL: "http://localhost:8080"}},
+		QueueSize: 2,
+	}, "test")
+	assert.NoError(t, err)
+
+	for range 5 {
+		notifier.ComplianceChanged(ComplianceChangedData{Policy: testPolicy("policies")})
+	}
+
+	assert.Len(t, notifier.sinks[0].queue, 2)
+	assert.InDelta(t, 3, testutil.ToFloat64(notificationsDroppedCounter.WithLabelValues("bounded")), 0)
+
+	var disabled *Notifier
+
+	assert.NotPanics(t, func() {
+		disabled.ComplianceChanged(ComplianceChangedData{Policy: testPolicy("policies")})
+		disabled.Enforced(EnforcedData{Policy: testPolicy("policies")})
+	})
+}
+
+func TestNewNotifier(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config Config
+		errMsg string
+	}{
+		"no sinks": {
+			config: Config{},
+			errMsg: "at least one notification sink must be configured",
+		},
+		"invalid URL": {
+			config: Config{Sinks: []SinkConfig{{URL: "localhost:8080"}}},
+			errMsg: "the notification sink at index 0 has an invalid URL: localhost:8080",
+		},
+		"invalid severity": {
+			config: Config{Sinks: []SinkConfig{{URL: "https://sink", MinSeverity: "urgent"}}},
+			errMsg: "the notification sink at index 0 has an invalid minSeverity urgent",
+		},
+		"negative queue size": {
+			config: Config{Sinks: []SinkConfig{{URL: "https://sink"}}, QueueSize: -1},
+			errMsg: "the notification queue size must not be negative, got -1",
+		},
+		"negative retries": {
+			config: Config{Sinks: []SinkConfig{{URL: "https://sink"}}, MaxRetries: ptr.To(-1)},
+			errMsg: "the notification maximum retries must not be negative, got -1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := New(test.config, "test")
+			assert.ErrorContains(t, err, test.errMsg)
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "notifications.yaml")
+
+	err := os.WriteFile(path, []byte(`
+sinks:
+- url: https://sink.example.com/events
+  minSeverity: high
+  namespaces: [policies]
+maxRetries: 3
+initialBackoff: 2s
+`), 0o600)
+	assert.NoError(t, err)
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, Config{
+		Sinks: []SinkConfig{{
+			URL:         "https://sink.example.com/events",
+			MinSeverity: "high",
+			Namespaces:  []string{"policies"},
+		}},
+		MaxRetries:     ptr.To(3),
+		InitialBackoff: metav1.Duration{Duration: 2 * time.Second},
+	}, config)
+
+	err = os.WriteFile(path, []byte("sinks: []\nunknown: true\n"), 0o600)
+	assert.NoError(t, err)
+
+	_, err = LoadConfig(path)
+	assert.ErrorContains(t, err, "failed to parse the notification configuration")
+}
@@ -67,6 +67,44 @@ var _ = Describe("Test config policy metrics", Ordered, func() {
 		).WithArguments("cluster_policy_governance_info", "policy", policyName).Should(BeNumerically("==", 0))
 	})
 
+	It("should report the evaluation duration distribution", func() {
+		By("Checking metric endpoint for the evaluation duration histograms")
+		Eventually(
+			metricCheck, defaultTimeoutSeconds, 1,
+		).WithArguments("policy_evaluation_duration_seconds_count", "kind", "ConfigurationPolicy").Should(
+			BeNumerically(">", 0),
+		)
+		Eventually(
+			metricCheck, defaultTimeoutSeconds, 1,
+		).WithArguments("policy_evaluation_duration_seconds_count", "kind", "OperatorPolicy").Should(
+			BeNumerically(">", 0),
+		)
+		Eventually(
+			utils.GetMetrics, defaultTimeoutSeconds, 1,
+		).WithArguments("compare_objects_duration_seconds_count").ShouldNot(BeEmpty())
+	})
+
+	It("should report the API requests made for the configurationpolicy", func() {
+		By("Checking metric endpoint for the API requests of the policy")
+		Eventually(
+			metricCheck, defaultTimeoutSeconds, 1,
+		).WithArguments("policy_api_requests_total", "policy", policyName).Should(BeNumerically(">", 0))
+	})
+
+	It("should report the work queue metrics per controller", func() {
+		By("Checking metric endpoint for the work queue depth and wait time")
+		for _, controller := range []string{"configuration-policy-controller", "operator-policy-controller"} {
+			Eventually(
+				utils.GetMetrics, defaultTimeoutSeconds, 1,
+			).WithArguments("workqueue_depth", fmt.Sprintf(`name=\"%s\"`, controller)).ShouldNot(BeEmpty())
+			Eventually(
+				utils.GetMetrics, defaultTimeoutSeconds, 1,
+			).WithArguments(
+				"workqueue_queue_duration_seconds_count", fmt.Sprintf(`name=\"%s\"`, controller),
+			).ShouldNot(BeEmpty())
+		}
+	})
+
 	It("should report status for the operatorpolicy", func() {
 		By("Checking metric endpoint for operator policy status")
 		Eventually(
@@ -83,6 +121,7 @@ var _ = Describe("Test config policy metrics", Ordered, func() {
 			"config_policy_evaluation_total":         "name",
 			"config_policy_evaluation_seconds_total": "name",
 			"cluster_policy_governance_info":         "policy",
+			"policy_api_requests_total":              "policy",
 		} {
 			Eventually(
 				utils.GetMetrics, defaultTimeoutSeconds, 1,
@@ -21,6 +21,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	policyv1 "open-cluster-management.io/config-policy-controller/api/v1"
 	policyv1beta1 "open-cluster-management.io/config-policy-controller/api/v1beta1"
@@ -1646,6 +1647,610 @@ var _ = Describe("Testing OperatorPolicy", Label("supports-hosted"), func() {
 		})
 	})
 
+	Describe("Testing InstallPlan approval with a version range", Ordered, func() {
+		const (
+			opPolYAML = "../resources/case38_operator_install/operator-policy-version-range.yaml"
+			subName   = "strimzi-kafka-operator"
+		)
+		var (
+			opPolTestNS           string
+			opPolName             string
+			parentPolicyName      string
+			secondInstallPlanName string
+		)
+
+		BeforeAll(func() {
+			opPolTestNS = getOpPolTestNS()
+			opPolName = "oppol-version-range" + getTestSuffix()
+			parentPolicyName = getParentPolicyName()
+
+			preFunc()
+			setupPolicy(opPolYAML, opPolName, parentPolicyName)
+		})
+
+		It("Should install the starting CSV and not approve the upgrade outside of the range", func(ctx SpecContext) {
+			Eventually(func(ctx SpecContext) int {
+				ipList, _ := targetK8sDynamic.Resource(gvrInstallPlan).Namespace(opPolTestNS).
+					List(ctx, metav1.ListOptions{})
+
+				return len(ipList.Items)
+			}, olmWaitTimeout*2, 5, ctx).Should(Equal(2))
+
+			ipList, err := targetK8sDynamic.Resource(gvrInstallPlan).Namespace(opPolTestNS).
+				List(ctx, metav1.ListOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, ip := range ipList.Items {
+				csvNames, _, _ := unstructured.NestedStringSlice(ip.Object, "spec", "clusterServiceVersionNames")
+				if slices.Contains(csvNames, "strimzi-cluster-operator.v0.36.1") {
+					secondInstallPlanName = ip.GetName()
+				}
+			}
+
+			Expect(secondInstallPlanName).NotTo(BeEmpty())
+
+			check(
+				opPolName,
+				false,
+				[]policyv1.RelatedObject{{
+					Object: policyv1.ObjectResource{
+						Kind:       "InstallPlan",
+						APIVersion: "operators.coreos.com/v1alpha1",
+						Metadata: policyv1.ObjectMetadata{
+							Namespace: opPolTestNS,
+							Name:      secondInstallPlanName,
+						},
+					},
+					Compliant: "Compliant",
+					Reason:    "The InstallPlan is RequiresApproval",
+				}},
+				metav1.Condition{
+					Type:   "InstallPlanCompliant",
+					Status: metav1.ConditionTrue,
+					Reason: "InstallPlanRequiresApproval",
+					Message: "an InstallPlan to update to [strimzi-cluster-operator.v0.36.1] is available for " +
+						"approval but approval for [strimzi-cluster-operator.v0.36.1] is required",
+				},
+				"an InstallPlan to update .* is available for approval",
+			)
+		})
+		It("Should report an invalid version range", func() {
+			utils.Kubectl("patch", "operatorpolicy", opPolName, "-n", testNamespace, "--type=json", "-p",
+				`[{"op": "replace", "path": "/spec/versionRange", "value": "not-a-range"}]`)
+
+			check(
+				opPolName,
+				true,
+				[]policyv1.RelatedObject{},
+				metav1.Condition{
+					Type:    "ValidPolicySpec",
+					Status:  metav1.ConditionFalse,
+					Reason:  "InvalidPolicySpec",
+					Message: `the spec.versionRange "not-a-range" is invalid: improper constraint: not-a-range`,
+				},
+				"the spec.versionRange .* is invalid",
+			)
+		})
+		It("Should approve the upgrade when the range includes it", func(ctx SpecContext) {
+			utils.Kubectl("patch", "operatorpolicy", opPolName, "-n", testNamespace, "--type=json", "-p",
+				`[{"op": "replace", "path": "/spec/versionRange", "value": ">=0.36.0 <0.37.0"}]`)
+
+			Eventually(func(ctx SpecContext) string {
+				ip, _ := targetK8sDynamic.Resource(gvrInstallPlan).Namespace(opPolTestNS).
+					Get(ctx, secondInstallPlanName, metav1.GetOptions{})
+				phase, _, _ := unstructured.NestedString(ip.Object, "status", "phase")
+
+				return phase
+			}, olmWaitTimeout, 5, ctx).Should(Equal("Complete"))
+
+			check(
+				opPolName,
+				false,
+				[]policyv1.RelatedObject{{
+					Object: policyv1.ObjectResource{
+						Kind:       "InstallPlan",
+						APIVersion: "operators.coreos.com/v1alpha1",
+						Metadata: policyv1.ObjectMetadata{
+							Namespace: opPolTestNS,
+							Name:      secondInstallPlanName,
+						},
+					},
+					Compliant: "Compliant",
+					Reason:    "The InstallPlan is Complete",
+				}},
+				metav1.Condition{
+					Type:    "InstallPlanCompliant",
+					Status:  metav1.ConditionTrue,
+					Reason:  "NoInstallPlansRequiringApproval",
+					Message: "no InstallPlans requiring approval were found",
+				},
+				"the InstallPlan.*36.*was approved",
+			)
+		})
+	})
+
+	Describe("Testing InstallPlan approval with an upgrade soak time", Ordered, func() {
+		const (
+			opPolYAML = "../resources/case38_operator_install/operator-policy-upgrade-soak.yaml"
+		)
+		var (
+			opPolTestNS           string
+			opPolName             string
+			parentPolicyName      string
+			secondInstallPlanName string
+		)
+
+		BeforeAll(func() {
+			opPolTestNS = getOpPolTestNS()
+			opPolName = "oppol-upgrade-soak" + getTestSuffix()
+			parentPolicyName = getParentPolicyName()
+
+			preFunc()
+			setupPolicy(opPolYAML, opPolName, parentPolicyName)
+		})
+
+		It("Should defer the approval of the upgrade until the soak time elapses", func(ctx SpecContext) {
+			Eventually(func(ctx SpecContext) string {
+				ipList, _ := targetK8sDynamic.Resource(gvrInstallPlan).Namespace(opPolTestNS).
+					List(ctx, metav1.ListOptions{})
+
+				for _, ip := range ipList.Items {
+					csvNames, _, _ := unstructured.NestedStringSlice(ip.Object, "spec", "clusterServiceVersionNames")
+					if slices.Contains(csvNames, "strimzi-cluster-operator.v0.36.1") {
+						secondInstallPlanName = ip.GetName()
+					}
+				}
+
+				return secondInstallPlanName
+			}, olmWaitTimeout*2, 5, ctx).ShouldNot(BeEmpty())
+
+			check(
+				opPolName,
+				false,
+				[]policyv1.RelatedObject{{
+					Object: policyv1.ObjectResource{
+						Kind:       "InstallPlan",
+						APIVersion: "operators.coreos.com/v1alpha1",
+						Metadata: policyv1.ObjectMetadata{
+							Namespace: opPolTestNS,
+							Name:      secondInstallPlanName,
+						},
+					},
+					Compliant: "Compliant",
+					Reason:    "The InstallPlan is RequiresApproval",
+				}},
+				metav1.Condition{
+					Type:   "InstallPlanCompliant",
+					Status: metav1.ConditionTrue,
+					Reason: "InstallPlanApprovalDeferred",
+					Message: "an InstallPlan to update to [strimzi-cluster-operator.v0.36.1] is available for " +
+						"approval but the approval is deferred until ",
+				},
+				"the approval is deferred until",
+			)
+
+			policy, err := clientManagedDynamic.Resource(gvrOperatorPolicy).Namespace(testNamespace).
+				Get(ctx, opPolName, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			version, _, _ := unstructured.NestedString(policy.Object, "status", "pendingUpgrade", "version")
+			Expect(version).To(Equal("strimzi-cluster-operator.v0.36.1"))
+
+			nextApproval, _, _ := unstructured.NestedString(
+				policy.Object, "status", "pendingUpgrade", "nextApprovalTime",
+			)
+			nextApprovalTime, err := time.Parse(time.RFC3339, nextApproval)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(nextApprovalTime).To(BeTemporally(">", time.Now().Add(999*time.Hour)))
+		})
+		It("Should approve the upgrade when the soak time is removed", func(ctx SpecContext) {
+			utils.Kubectl("patch", "operatorpolicy", opPolName, "-n", testNamespace, "--type=json", "-p",
+				`[{"op": "remove", "path": "/spec/upgradeSoakTime"}]`)
+
+			Eventually(func(ctx SpecContext) string {
+				ip, _ := targetK8sDynamic.Resource(gvrInstallPlan).Namespace(opPolTestNS).
+					Get(ctx, secondInstallPlanName, metav1.GetOptions{})
+				phase, _, _ := unstructured.NestedString(ip.Object, "status", "phase")
+
+				return phase
+			}, olmWaitTimeout, 5, ctx).Should(Equal("Complete"))
+
+			Eventually(func(ctx SpecContext) interface{} {
+				policy, _ := clientManagedDynamic.Resource(gvrOperatorPolicy).Namespace(testNamespace).
+					Get(ctx, opPolName, metav1.GetOptions{})
+				pending, _, _ := unstructured.NestedFieldNoCopy(policy.Object, "status", "pendingUpgrade")
+
+				return pending
+			}, eventuallyTimeout, 3, ctx).Should(BeNil())
+		})
+	})
+
+	Describe("Testing OperatorPolicy operands", Ordered, func() {
+		const (
+			opPolYAML   = "../resources/case38_operator_install/operator-policy-operands.yaml"
+			operandName = "operand-topic"
+		)
+		var (
+			opPolTestNS      string
+			opPolName        string
+			parentPolicyName string
+			gvrKafkaTopic    = schema.GroupVersionResource{
+				Group:    "kafka.strimzi.io",
+				Version:  "v1beta2",
+				Resource: "kafkatopics",
+			}
+		)
+
+		getPartitions := func(ctx SpecContext) int64 {
+			topic, err := targetK8sDynamic.Resource(gvrKafkaTopic).Namespace(opPolTestNS).
+				Get(ctx, operandName, metav1.GetOptions{})
+			if err != nil {
+				return 0
+			}
+
+			partitions, _, _ := unstructured.NestedInt64(topic.Object, "spec", "partitions")
+
+			return partitions
+		}
+
+		BeforeAll(func() {
+			opPolTestNS = getOpPolTestNS()
+			opPolName = "oppol-operands" + getTestSuffix()
+			parentPolicyName = getParentPolicyName()
+
+			preFunc()
+			setupPolicy(opPolYAML, opPolName, parentPolicyName)
+		})
+
+		It("Should create the operand once the operator is installed", func(ctx SpecContext) {
+			check(
+				opPolName,
+				false,
+				[]policyv1.RelatedObject{{
+					Object: policyv1.ObjectResource{
+						Kind:       "KafkaTopic",
+						APIVersion: "kafka.strimzi.io/v1beta2",
+						Metadata: policyv1.ObjectMetadata{
+							Namespace: opPolTestNS,
+							Name:      operandName,
+						},
+					},
+					Compliant: "Compliant",
+					Reason:    "Resource found as expected",
+				}},
+				metav1.Condition{
+					Type:    "OperandsCompliant",
+					Status:  metav1.ConditionTrue,
+					Reason:  "OperandsMatch",
+					Message: "the operands match what is required by the policy",
+				},
+				"the operands match what is required by the policy",
+				skipConsistently,
+			)
+
+			Expect(getPartitions(ctx)).To(Equal(int64(1)))
+		})
+
+		It("Should update the operand when it does not match", func(ctx SpecContext) {
+			KubectlTarget("patch", "kafkatopic", operandName, "-n", opPolTestNS, "--type=merge", "-p",
+				`{"spec": {"partitions": 3}}`)
+
+			Eventually(getPartitions, eventuallyTimeout, 3, ctx).Should(Equal(int64(1)))
+
+			check(
+				opPolName,
+				false,
+				[]policyv1.RelatedObject{{
+					Object: policyv1.ObjectResource{
+						Kind:       "KafkaTopic",
+						APIVersion: "kafka.strimzi.io/v1beta2",
+						Metadata: policyv1.ObjectMetadata{
+							Namespace: opPolTestNS,
+							Name:      operandName,
+						},
+					},
+					Compliant: "Compliant",
+					Reason:    "Resource found as expected",
+				}},
+				metav1.Condition{
+					Type:    "OperandsCompliant",
+					Status:  metav1.ConditionTrue,
+					Reason:  "OperandsMatch",
+					Message: "the operands match what is required by the policy",
+				},
+				"",
+				skipConsistently,
+			)
+		})
+
+		It("Should report a mismatched operand in inform mode", func(ctx SpecContext) {
+			utils.Kubectl("patch", "operatorpolicy", opPolName, "-n", testNamespace, "--type=json", "-p",
+				`[{"op": "replace", "path": "/spec/remediationAction", "value": "inform"}]`)
+			KubectlTarget("patch", "kafkatopic", operandName, "-n", opPolTestNS, "--type=merge", "-p",
+				`{"spec": {"partitions": 3}}`)
+
+			check(
+				opPolName,
+				true,
+				[]policyv1.RelatedObject{{
+					Object: policyv1.ObjectResource{
+						Kind:       "KafkaTopic",
+						APIVersion: "kafka.strimzi.io/v1beta2",
+						Metadata: policyv1.ObjectMetadata{
+							Namespace: opPolTestNS,
+							Name:      operandName,
+						},
+					},
+					Compliant: "NonCompliant",
+					Reason:    "Resource found but does not match",
+				}},
+				metav1.Condition{
+					Type:   "OperandsCompliant",
+					Status: metav1.ConditionFalse,
+					Reason: "OperandsNotCompliant",
+					Message: "the operands KafkaTopic " + opPolTestNS + "/" + operandName +
+						" do not match the policy",
+				},
+				"do not match the policy",
+			)
+
+			Expect(getPartitions(ctx)).To(Equal(int64(3)))
+		})
+	})
+
+	Describe("Testing OperatorPolicy operand removal", Ordered, func() {
+		const (
+			opPolYAML   = "../resources/case38_operator_install/operator-policy-operands.yaml"
+			operandName = "operand-topic"
+			finalizer   = "policy.open-cluster-management.io/test"
+		)
+		var (
+			opPolTestNS      string
+			opPolName        string
+			parentPolicyName string
+			gvrKafkaTopic    = schema.GroupVersionResource{
+				Group:    "kafka.strimzi.io",
+				Version:  "v1beta2",
+				Resource: "kafkatopics",
+			}
+		)
+
+		BeforeAll(func() {
+			opPolTestNS = getOpPolTestNS()
+			opPolName = "oppol-operands" + getTestSuffix()
+			parentPolicyName = getParentPolicyName()
+
+			preFunc()
+			setupPolicy(opPolYAML, opPolName, parentPolicyName)
+
+			DeferCleanup(func() {
+				KubectlTarget("patch", "kafkatopic", operandName, "-n", opPolTestNS, "--type=json", "-p",
+					`[{"op": "remove", "path": "/metadata/finalizers"}]`)
+			})
+		})
+
+		It("Should create the operand with a finalizer", func(ctx SpecContext) {
+			check(
+				opPolName,
+				false,
+				nil,
+				metav1.Condition{
+					Type:    "OperandsCompliant",
+					Status:  metav1.ConditionTrue,
+					Reason:  "OperandsMatch",
+					Message: "the operands match what is required by the policy",
+				},
+				"the operands match what is required by the policy",
+				skipConsistently,
+			)
+
+			KubectlTarget("patch", "kafkatopic", operandName, "-n", opPolTestNS, "--type=merge", "-p",
+				`{"metadata": {"finalizers": ["`+finalizer+`"]}}`)
+		})
+
+		It("Should delete the operand before removing the operator", func(ctx SpecContext) {
+			utils.Kubectl("patch", "operatorpolicy", opPolName, "-n", testNamespace, "--type=json", "-p",
+				`[{"op": "replace", "path": "/spec/complianceType", "value": "mustnothave"},`+
+					`{"op": "replace", "path": "/spec/removalBehavior", "value": `+
+					`{"operands": "DeleteAll", "customResourceDefinitions": "Delete"}}]`)
+
+			check(
+				opPolName,
+				true,
+				[]policyv1.RelatedObject{{
+					Object: policyv1.ObjectResource{
+						Kind:       "KafkaTopic",
+						APIVersion: "kafka.strimzi.io/v1beta2",
+						Metadata: policyv1.ObjectMetadata{
+							Namespace: opPolTestNS,
+							Name:      operandName,
+						},
+					},
+					Compliant: "NonCompliant",
+					Reason:    "The object is being deleted but has not been removed yet",
+				}},
+				metav1.Condition{
+					Type:   "OperandsCompliant",
+					Status: metav1.ConditionFalse,
+					Reason: "OperandsDeleting",
+					Message: "the operands KafkaTopic " + opPolTestNS + "/" + operandName +
+						" are being deleted and the operator will be removed once they are gone",
+				},
+				"are being deleted and the operator will be removed once they are gone",
+			)
+
+			By("Verifying the operator is kept while the operand is being deleted")
+			_, err := targetK8sDynamic.Resource(gvrSubscription).Namespace(opPolTestNS).
+				Get(ctx, "strimzi-kafka-operator", metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			policy, err := clientManagedDynamic.Resource(gvrOperatorPolicy).Namespace(testNamespace).
+				Get(ctx, opPolName, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			deadline, _, _ := unstructured.NestedString(policy.Object, "status", "operandDeletionDeadline")
+			Expect(deadline).NotTo(BeEmpty())
+		})
+
+		It("Should remove the operator once the operand is gone", func(ctx SpecContext) {
+			KubectlTarget("patch", "kafkatopic", operandName, "-n", opPolTestNS, "--type=json", "-p",
+				`[{"op": "remove", "path": "/metadata/finalizers"}]`)
+
+			Eventually(func(ctx SpecContext) bool {
+				_, err := targetK8sDynamic.Resource(gvrKafkaTopic).Namespace(opPolTestNS).
+					Get(ctx, operandName, metav1.GetOptions{})
+
+				return k8serrors.IsNotFound(err)
+			}, eventuallyTimeout, 3, ctx).Should(BeTrue())
+
+			check(
+				opPolName,
+				false,
+				nil,
+				metav1.Condition{
+					Type:    "OperandsCompliant",
+					Status:  metav1.ConditionTrue,
+					Reason:  "NoOperandsFound",
+					Message: "no operands of the operator were found",
+				},
+				"no operands of the operator were found",
+				skipConsistently,
+			)
+
+			Eventually(func(ctx SpecContext) bool {
+				_, err := targetK8sDynamic.Resource(gvrSubscription).Namespace(opPolTestNS).
+					Get(ctx, "strimzi-kafka-operator", metav1.GetOptions{})
+
+				return k8serrors.IsNotFound(err)
+			}, eventuallyTimeout, 3, ctx).Should(BeTrue())
+		})
+	})
+
+	Describe("Testing OperatorPolicy managing the CatalogSource", Ordered, func() {
+		const (
+			opPolYAML   = "../resources/case38_operator_install/operator-policy-catalog-source.yaml"
+			catalogName = "grc-policy-source"
+		)
+		var (
+			opPolTestNS      string
+			opPolName        string
+			parentPolicyName string
+			gvrCatalogSource = schema.GroupVersionResource{
+				Group:    "operators.coreos.com",
+				Version:  "v1alpha1",
+				Resource: "catalogsources",
+			}
+		)
+
+		catalogSourceObj := func(compliant, reason string) policyv1.RelatedObject {
+			return policyv1.RelatedObject{
+				Object: policyv1.ObjectResource{
+					Kind:       "CatalogSource",
+					APIVersion: "operators.coreos.com/v1alpha1",
+					Metadata: policyv1.ObjectMetadata{
+						Namespace: opPolTestNS,
+						Name:      catalogName,
+					},
+				},
+				Compliant: compliant,
+				Reason:    reason,
+			}
+		}
+
+		BeforeAll(func() {
+			opPolTestNS = getOpPolTestNS()
+			opPolName = "oppol-catalog-source" + getTestSuffix()
+			parentPolicyName = getParentPolicyName()
+
+			preFunc()
+			setupPolicy(opPolYAML, opPolName, parentPolicyName)
+		})
+
+		It("Should report the missing CatalogSource in inform mode", func() {
+			check(
+				opPolName,
+				true,
+				[]policyv1.RelatedObject{catalogSourceObj("NonCompliant", "Resource not found but should exist")},
+				metav1.Condition{
+					Type:    "CatalogSourcesUnhealthy",
+					Status:  metav1.ConditionTrue,
+					Reason:  "CatalogSourceMissing",
+					Message: "the CatalogSource required by the policy was not found",
+				},
+				"the CatalogSource required by the policy was not found",
+			)
+		})
+
+		It("Should create the CatalogSource when enforced", func(ctx SpecContext) {
+			utils.Kubectl("patch", "operatorpolicy", opPolName, "-n", testNamespace, "--type=json", "-p",
+				`[{"op": "replace", "path": "/spec/remediationAction", "value": "enforce"}]`)
+
+			check(
+				opPolName,
+				false,
+				[]policyv1.RelatedObject{catalogSourceObj("Compliant", "Resource found as expected")},
+				metav1.Condition{
+					Type:    "CatalogSourcesUnhealthy",
+					Status:  metav1.ConditionFalse,
+					Reason:  "CatalogSourcesFound",
+					Message: "CatalogSource was found",
+				},
+				"the CatalogSource required by the policy was created",
+				skipConsistently,
+			)
+
+			By("Verifying the Subscription uses the CatalogSource")
+			sub, err := targetK8sDynamic.Resource(gvrSubscription).Namespace(opPolTestNS).
+				Get(ctx, "example-operator", metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			source, _, _ := unstructured.NestedString(sub.Object, "spec", "source")
+			Expect(source).To(Equal(catalogName))
+		})
+
+		It("Should update the CatalogSource when it does not match the policy", func(ctx SpecContext) {
+			KubectlTarget("patch", "catalogsource", catalogName, "-n", opPolTestNS, "--type=merge", "-p",
+				`{"spec": {"updateStrategy": {"registryPoll": {"interval": "10m"}}}}`)
+
+			Eventually(func(ctx SpecContext) string {
+				catalog, err := targetK8sDynamic.Resource(gvrCatalogSource).Namespace(opPolTestNS).
+					Get(ctx, catalogName, metav1.GetOptions{})
+				if err != nil {
+					return ""
+				}
+
+				interval, _, _ := unstructured.NestedString(
+					catalog.Object, "spec", "updateStrategy", "registryPoll", "interval",
+				)
+
+				return interval
+			}, eventuallyTimeout, 3, ctx).Should(Equal("60m"))
+		})
+
+		It("Should delete the CatalogSource when the policy is mustnothave", func(ctx SpecContext) {
+			utils.Kubectl("patch", "operatorpolicy", opPolName, "-n", testNamespace, "--type=json", "-p",
+				`[{"op": "replace", "path": "/spec/complianceType", "value": "mustnothave"}]`)
+
+			check(
+				opPolName,
+				false,
+				[]policyv1.RelatedObject{catalogSourceObj("Compliant", "Resource not found as expected")},
+				metav1.Condition{
+					Type:    "CatalogSourcesUnhealthy",
+					Status:  metav1.ConditionFalse,
+					Reason:  "CatalogSourceNotPresent",
+					Message: "the CatalogSource is not present",
+				},
+				"the CatalogSource was deleted",
+				skipConsistently,
+			)
+
+			_, err := targetK8sDynamic.Resource(gvrCatalogSource).Namespace(opPolTestNS).
+				Get(ctx, catalogName, metav1.GetOptions{})
+			Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
 	Describe("Testing OperatorPolicy validation messages", Ordered, func() {
 		const (
 			opPolYAML = "../resources/case38_operator_install/operator-policy-validity-test.yaml"
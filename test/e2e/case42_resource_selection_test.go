@@ -119,6 +119,32 @@ var _ = Describe("Test results of resource selection", Ordered, func() {
 			fmt.Sprintf(filterErrMsgFmt,
 				"values: Invalid value: null: for 'in', 'notin' operators, values set can't be empty"),
 		),
+		Entry("Include and exclude name patterns",
+			`{"include":["case42-[1-3]-e2e"],"exclude":["case42-2-*"]}`,
+			"fakeapis [case42-1-e2e, case42-3-e2e] found as specified in namespace "+targetNs,
+		),
+		Entry("Name patterns combined with a LabelSelector",
+			`{"matchExpressions":[{"key":"case42","operator":"NotIn","values":["case42-5-e2e"]}],`+
+				`"exclude":["case42-1-e2e"]}`,
+			"fakeapis [case42-2-e2e, case42-3-e2e, case42-4-e2e] found as specified in namespace "+targetNs,
+		),
+		Entry("Non-matching name pattern",
+			`{"include":["not-a-fakeapi-*"]}`,
+			noMatchesMsg,
+		),
+		Entry("Invalid name pattern",
+			`{"include":["[case42"]}`,
+			fmt.Sprintf(filterErrMsgFmt, "error parsing 'include' pattern '[case42': syntax error in pattern"),
+		),
+		Entry("Field selector",
+			`{"fieldSelector":"metadata.name!=case42-4-e2e,metadata.labels.case42!=case42-5-e2e"}`,
+			"fakeapis [case42-1-e2e, case42-2-e2e, case42-3-e2e] found as specified in namespace "+targetNs,
+		),
+		Entry("Invalid field selector",
+			`{"fieldSelector":"metadata.name"}`,
+			fmt.Sprintf(filterErrMsgFmt,
+				"error parsing fieldSelector: invalid selector: 'metadata.name'; can't understand 'metadata.name'"),
+		),
 	)
 })
 
@@ -0,0 +1,82 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package e2e
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"open-cluster-management.io/config-policy-controller/test/utils"
+)
+
+var _ = Describe("Test restarting dependent workloads", Ordered, func() {
+	const (
+		setupYAML  = "../resources/case48_dependent_workloads/setup.yaml"
+		policyName = "case48-policy"
+		policyYAML = "../resources/case48_dependent_workloads/policy.yaml"
+	)
+
+	restartedAt := func(name string) string {
+		GinkgoHelper()
+
+		deployment := utils.GetWithTimeout(
+			clientManagedDynamic, gvrDeployment, name, "default", true, defaultTimeoutSeconds,
+		)
+
+		annotation, _, err := unstructured.NestedString(
+			deployment.Object, "spec", "template", "metadata", "annotations", "kubectl.kubernetes.io/restartedAt",
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		return annotation
+	}
+
+	BeforeAll(func() {
+		By("Creating the ConfigMap and the Deployments in the default namespace")
+		utils.Kubectl("apply", "-f", setupYAML)
+	})
+
+	AfterAll(func() {
+		deleteConfigPolicies([]string{policyName})
+		utils.KubectlDelete("-f", setupYAML)
+	})
+
+	It("restarts the dependent Deployment after the ConfigMap is updated", func() {
+		Expect(restartedAt("case48-app")).To(BeEmpty())
+
+		By("Creating the " + policyName + " policy")
+		utils.Kubectl("apply", "-f", policyYAML, "-n", testNamespace)
+
+		By("Verifying the ConfigMap update and the rollout restart are recorded in the history")
+		Eventually(func() []string {
+			return utils.GetHistoryMessages(clientManagedDynamic, gvrConfigPolicy, policyName, testNamespace,
+				"rollout restart")
+		}, defaultTimeoutSeconds, 1).Should(ContainElement(ContainSubstring(
+			"configmaps [case48-config] was updated successfully and a rollout restart of its dependent workloads " +
+				"was triggered in namespace default",
+		)))
+
+		By("Verifying the policy is Compliant")
+		Eventually(func(g Gomega) {
+			managedPlc := utils.GetWithTimeout(
+				clientManagedDynamic, gvrConfigPolicy, policyName, testNamespace, true, defaultTimeoutSeconds,
+			)
+
+			utils.CheckComplianceStatus(g, managedPlc, "Compliant")
+		}, defaultTimeoutSeconds, 1).Should(Succeed())
+
+		By("Verifying only the Deployment matching the selector was restarted")
+		Expect(restartedAt("case48-app")).ToNot(BeEmpty())
+		Expect(restartedAt("case48-other")).To(BeEmpty())
+	})
+
+	It("does not prune the restarted Deployment when the policy is deleted", func() {
+		deleteConfigPolicies([]string{policyName})
+
+		utils.GetWithTimeout(clientManagedDynamic, gvrConfigMap, "case48-config", "default", false,
+			defaultTimeoutSeconds)
+		utils.GetWithTimeout(clientManagedDynamic, gvrDeployment, "case48-app", "default", true,
+			defaultTimeoutSeconds)
+	})
+})
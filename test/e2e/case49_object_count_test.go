@@ -0,0 +1,59 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package e2e
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"open-cluster-management.io/config-policy-controller/test/utils"
+)
+
+var _ = Describe("Test the object count of an object template", Ordered, func() {
+	const (
+		policyName     = "case49-policy"
+		policyYAML     = "../resources/case49_object_count/policy.yaml"
+		configMapsYAML = "../resources/case49_object_count/configmaps.yaml"
+	)
+
+	checkStatus := func(expectedCompliance, expectedMsg string) {
+		GinkgoHelper()
+
+		Eventually(func(g Gomega) {
+			managedPlc := utils.GetWithTimeout(
+				clientManagedDynamic, gvrConfigPolicy, policyName, testNamespace, true, defaultTimeoutSeconds,
+			)
+
+			utils.CheckComplianceStatus(g, managedPlc, expectedCompliance)
+			g.Expect(utils.GetStatusMessage(managedPlc)).To(Equal(expectedMsg))
+		}, defaultTimeoutSeconds, 1).Should(Succeed())
+	}
+
+	AfterAll(func() {
+		deleteConfigPolicies([]string{policyName})
+		utils.KubectlDelete("-f", configMapsYAML)
+	})
+
+	It("is NonCompliant when there are fewer matching objects than the minimum", func() {
+		utils.Kubectl("apply", "-f", policyYAML, "-n", testNamespace)
+
+		checkStatus("NonCompliant", "0 matching configmaps in namespace default found, expected between 1 and 2")
+	})
+
+	It("is Compliant when the number of matching objects is within the range", func() {
+		utils.Kubectl("-n", "default", "create", "configmap", "case49-a", "--from-literal=role=tenant")
+		utils.Kubectl("-n", "default", "label", "configmap", "case49-a", "case49=true")
+		utils.Kubectl("-n", "default", "create", "configmap", "case49-other", "--from-literal=role=other")
+		utils.Kubectl("-n", "default", "label", "configmap", "case49-other", "case49=true")
+
+		checkStatus("Compliant",
+			"1 matching configmaps [case49-a] in namespace default found, expected between 1 and 2")
+	})
+
+	It("is NonCompliant when there are more matching objects than the maximum", func() {
+		utils.Kubectl("apply", "-f", configMapsYAML)
+
+		checkStatus("NonCompliant", "3 matching configmaps [case49-a, case49-b, case49-c] in namespace default "+
+			"found, expected between 1 and 2")
+	})
+})
@@ -0,0 +1,126 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package e2e
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"open-cluster-management.io/config-policy-controller/test/utils"
+)
+
+var _ = Describe("Test namespace selection by annotations, age, and contents", Ordered, func() {
+	const (
+		prereqYaml = "../resources/case50_ns_selector_contents/prereq.yaml"
+		policyYaml = "../resources/case50_ns_selector_contents/policy.yaml"
+		policyName = "case50-selector-contents-e2e"
+
+		notFoundMsg  = "configmaps [case50-configmap] not found in namespaces: "
+		noMatchesMsg = "namespaced object case50-configmap of kind ConfigMap has no " +
+			"namespace specified from the policy namespaceSelector nor the object metadata"
+	)
+
+	statusMessage := func() interface{} {
+		managedPlc := utils.GetWithTimeout(clientManagedDynamic, gvrConfigPolicy,
+			policyName, testNamespace, true, defaultTimeoutSeconds)
+
+		return utils.GetStatusMessage(managedPlc)
+	}
+
+	// Test setup:
+	// - Namespaces `case50-[1-2]-e2e` are annotated with `case50/team: web`, `case50-3-e2e` is not
+	// - A ConfigMap labeled `tier: frontend` is in `case50-1-e2e` and `case50-3-e2e`
+	// - A Deployment labeled `app: case50-web` is in `case50-3-e2e`
+	// - The policy has a long evaluation interval, so it is only evaluated again when the selection changes
+	BeforeAll(func() {
+		By("Applying prerequisites")
+		utils.Kubectl("apply", "-f", prereqYaml)
+		DeferCleanup(func() {
+			utils.KubectlDelete("-f", prereqYaml)
+		})
+
+		utils.Kubectl("apply", "-f", policyYaml, "-n", testNamespace)
+		DeferCleanup(func() {
+			utils.KubectlDelete("-f", policyYaml, "-n", testNamespace)
+		})
+	})
+
+	It("selects the annotated namespaces containing a matching object", func() {
+		Eventually(statusMessage, defaultTimeoutSeconds, 1).Should(Equal(notFoundMsg + "case50-1-e2e"))
+	})
+
+	It("should evaluate when a matching object is added to an annotated namespace", func() {
+		utils.Kubectl("create", "configmap", "case50-frontend", "-n", "case50-2-e2e")
+		utils.Kubectl("label", "configmap", "case50-frontend", "-n", "case50-2-e2e", "tier=frontend")
+
+		Eventually(statusMessage, defaultTimeoutSeconds, 1).Should(
+			Equal(notFoundMsg + "case50-1-e2e, case50-2-e2e"),
+		)
+	})
+
+	It("should evaluate when a namespace is annotated to match", func() {
+		utils.Kubectl("annotate", "ns", "case50-3-e2e", "case50/team=web")
+
+		Eventually(statusMessage, defaultTimeoutSeconds, 1).Should(
+			Equal(notFoundMsg + "case50-1-e2e, case50-2-e2e, case50-3-e2e"),
+		)
+	})
+
+	It("should evaluate when a matching object is re-labeled", func() {
+		utils.Kubectl("label", "configmap", "case50-frontend", "-n", "case50-1-e2e", "tier=backend", "--overwrite")
+
+		Eventually(statusMessage, defaultTimeoutSeconds, 1).Should(
+			Equal(notFoundMsg + "case50-2-e2e, case50-3-e2e"),
+		)
+	})
+
+	It("should evaluate when a matching annotation is removed", func() {
+		utils.Kubectl("annotate", "ns", "case50-2-e2e", "case50/team-")
+
+		Eventually(statusMessage, defaultTimeoutSeconds, 1).Should(Equal(notFoundMsg + "case50-3-e2e"))
+	})
+
+	It("selects the namespaces containing a matching Deployment", func() {
+		utils.Kubectl("patch", "--namespace=managed", "configurationpolicy", policyName, "--type=json",
+			fmt.Sprintf(nsSelectorPatchFmt, `{"include":["case50-*"],"matchAnnotations":{"case50/team":"web"},`+
+				`"containsObjects":[{"apiVersion":"apps/v1","kind":"Deployment",`+
+				`"selector":{"matchLabels":{"app":"case50-web"}}}]}`))
+
+		Eventually(statusMessage, defaultTimeoutSeconds, 1).Should(Equal(notFoundMsg + "case50-3-e2e"))
+	})
+
+	It("should evaluate when a matching Deployment is added to an annotated namespace", func() {
+		utils.Kubectl("create", "deployment", "case50-web", "-n", "case50-1-e2e", "--image=nginx:1.7.9",
+			"--replicas=0")
+
+		Eventually(statusMessage, defaultTimeoutSeconds, 1).Should(
+			Equal(notFoundMsg + "case50-1-e2e, case50-3-e2e"),
+		)
+	})
+
+	It("should evaluate when a matching Deployment is deleted", func() {
+		utils.KubectlDelete("deployment", "case50-web", "-n", "case50-3-e2e")
+
+		Eventually(statusMessage, defaultTimeoutSeconds, 1).Should(Equal(notFoundMsg + "case50-1-e2e"))
+	})
+
+	It("should not select namespaces newer than the minimum age", func() {
+		utils.Kubectl("patch", "--namespace=managed", "configurationpolicy", policyName, "--type=json",
+			`--patch=[{"op":"add","path":"/spec/namespaceSelector/minAge","value":"1h"}]`)
+
+		Eventually(statusMessage, defaultTimeoutSeconds, 1).Should(Equal(noMatchesMsg))
+	})
+
+	It("reports an error for an unknown kind in containsObjects", func() {
+		utils.Kubectl("patch", "--namespace=managed", "configurationpolicy", policyName, "--type=json",
+			fmt.Sprintf(nsSelectorPatchFmt,
+				`{"include":["case50-*"],"containsObjects":[{"apiVersion":"v1","kind":"Fake"}]}`))
+
+		Eventually(statusMessage, defaultTimeoutSeconds, 1).Should(HavePrefix(
+			"Error filtering namespaces with provided namespaceSelector: " +
+				"error listing Fake objects for containsObjects: ",
+		))
+	})
+})
@@ -0,0 +1,69 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package e2e
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"open-cluster-management.io/config-policy-controller/test/utils"
+)
+
+var _ = Describe("Test a policy with a target cluster kubeconfig Secret", Ordered, func() {
+	const (
+		policyYaml = "../resources/case51_target_cluster/policy.yaml"
+		policyName = "case51-target-cluster-e2e"
+		secretName = "case51-kubeconfig"
+	)
+
+	statusMessage := func() interface{} {
+		managedPlc := utils.GetWithTimeout(clientManagedDynamic, gvrConfigPolicy,
+			policyName, testNamespace, true, defaultTimeoutSeconds)
+
+		return utils.GetStatusMessage(managedPlc)
+	}
+
+	BeforeAll(func() {
+		utils.Kubectl("apply", "-f", policyYaml, "-n", testNamespace)
+		DeferCleanup(func() {
+			utils.KubectlDelete("-f", policyYaml, "-n", testNamespace)
+			utils.KubectlDelete("secret", secretName, "-n", testNamespace)
+		})
+	})
+
+	It("should be NonCompliant when the kubeconfig Secret is missing", func() {
+		Eventually(statusMessage, defaultTimeoutSeconds, 1).Should(Equal(
+			"the target cluster is unavailable: the kubeconfig Secret " + testNamespace + "/" + secretName +
+				" was not found",
+		))
+	})
+
+	It("should be NonCompliant when the Secret doesn't have the kubeconfig key", func() {
+		utils.Kubectl("create", "secret", "generic", secretName, "-n", testNamespace, "--from-literal=other=value")
+
+		Eventually(statusMessage, defaultTimeoutSeconds, 1).Should(Equal(
+			"the target cluster is unavailable: the kubeconfig Secret " + testNamespace + "/" + secretName +
+				" does not have the kubeconfig key",
+		))
+	})
+
+	It("should be NonCompliant when the kubeconfig is invalid", func() {
+		utils.KubectlDelete("secret", secretName, "-n", testNamespace)
+		utils.Kubectl(
+			"create", "secret", "generic", secretName, "-n", testNamespace, "--from-literal=kubeconfig=invalid",
+		)
+
+		Eventually(statusMessage, defaultTimeoutSeconds, 1).Should(
+			HavePrefix("the target cluster is unavailable: the kubeconfig is invalid"),
+		)
+	})
+
+	It("should evaluate on the default cluster when the target cluster is removed", func() {
+		utils.Kubectl("patch", "configurationpolicy", policyName, "-n", testNamespace, "--type=json",
+			`-p=[{"op":"remove","path":"/spec/targetCluster"}]`)
+
+		Eventually(statusMessage, defaultTimeoutSeconds, 1).Should(
+			Equal("configmaps [case51-configmap] not found in namespace default"),
+		)
+	})
+})
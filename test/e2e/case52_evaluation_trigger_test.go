@@ -0,0 +1,89 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package e2e
+
+import (
+	"regexp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"open-cluster-management.io/config-policy-controller/test/utils"
+)
+
+var _ = Describe("Test triggering an evaluation on demand", Ordered, func() {
+	const (
+		policyName    = "case52-policy"
+		policyYAML    = "../resources/case52_evaluation_trigger/policy.yaml"
+		configMapName = "case52-configmap"
+		trigger       = "2024-01-02T03:04:05Z"
+		annotation    = "policy.open-cluster-management.io/trigger-evaluation"
+	)
+
+	getCity := func() string {
+		configMap := utils.GetWithTimeout(
+			clientManagedDynamic, gvrConfigMap, configMapName, "default", true, defaultTimeoutSeconds,
+		)
+
+		city, _, _ := unstructured.NestedString(configMap.Object, "data", "city")
+
+		return city
+	}
+
+	AfterAll(func() {
+		deleteConfigPolicies([]string{policyName})
+		utils.KubectlDelete("configmap", configMapName, "-n", "default")
+	})
+
+	It("creates the ConfigMap and is never evaluated again", func() {
+		utils.Kubectl("apply", "-f", policyYAML, "-n", testNamespace)
+
+		Eventually(func(g Gomega) {
+			managedPlc := utils.GetWithTimeout(
+				clientManagedDynamic, gvrConfigPolicy, policyName, testNamespace, true, defaultTimeoutSeconds,
+			)
+
+			utils.CheckComplianceStatus(g, managedPlc, "Compliant")
+		}, defaultTimeoutSeconds, 1).Should(Succeed())
+
+		utils.Kubectl("patch", "configmap", configMapName, "-n", "default", "--type=merge",
+			"-p", `{"data":{"city":"Durham"}}`)
+
+		Consistently(getCity, "10s", 1).Should(Equal("Durham"))
+	})
+
+	It("enforces the policy again when the trigger annotation is set", func() {
+		utils.Kubectl("annotate", "configurationpolicy", policyName, "-n", testNamespace,
+			annotation+"="+trigger)
+
+		Eventually(getCity, defaultTimeoutSeconds, 1).Should(Equal("Raleigh"))
+
+		Eventually(func(g Gomega) {
+			managedPlc := utils.GetWithTimeout(
+				clientManagedDynamic, gvrConfigPolicy, policyName, testNamespace, true, defaultTimeoutSeconds,
+			)
+
+			lastTrigger, _, _ := unstructured.NestedString(managedPlc.Object, "status", "lastEvaluationTrigger")
+			g.Expect(lastTrigger).To(Equal(trigger))
+
+			history, _, _ := unstructured.NestedSlice(managedPlc.Object, "status", "history")
+			g.Expect(len(history)).To(BeNumerically(">=", 3))
+			g.Expect(history[0]).To(HaveKeyWithValue("message", ContainSubstring("Compliant")))
+			g.Expect(history[1]).To(HaveKeyWithValue("message",
+				"The evaluation was triggered on demand by the "+annotation+
+					" annotation with the value "+trigger))
+		}, defaultTimeoutSeconds, 1).Should(Succeed())
+
+		triggerEvents := utils.GetMatchingEvents(clientManaged, testNamespace, policyName, "EvaluationTriggered",
+			regexp.QuoteMeta("by the "+annotation+" annotation with the value "+trigger), defaultTimeoutSeconds)
+		Expect(triggerEvents).To(HaveLen(1))
+	})
+
+	It("isn't evaluated again for the same trigger", func() {
+		utils.Kubectl("patch", "configmap", configMapName, "-n", "default", "--type=merge",
+			"-p", `{"data":{"city":"Durham"}}`)
+
+		Consistently(getCity, "10s", 1).Should(Equal("Durham"))
+	})
+})
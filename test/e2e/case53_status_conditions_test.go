@@ -0,0 +1,75 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package e2e
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"open-cluster-management.io/config-policy-controller/test/utils"
+)
+
+var _ = Describe("Test the standard status conditions of a ConfigurationPolicy", Ordered, func() {
+	const (
+		policyName            = "case53-policy"
+		policyYAML            = "../resources/case53_status_conditions/policy.yaml"
+		templateErrPolicyName = "case53-policy-template-error"
+		templateErrPolicyYAML = "../resources/case53_status_conditions/policy-template-error.yaml"
+	)
+
+	getConditions := func(g Gomega, name string) map[string]string {
+		managedPlc := utils.GetWithTimeout(
+			clientManagedDynamic, gvrConfigPolicy, name, testNamespace, true, defaultTimeoutSeconds,
+		)
+
+		conditions, _, _ := unstructured.NestedSlice(managedPlc.Object, "status", "conditions")
+		statuses := map[string]string{}
+
+		for _, cond := range conditions {
+			condMap, ok := cond.(map[string]interface{})
+			g.Expect(ok).To(BeTrue())
+			g.Expect(condMap["observedGeneration"]).To(BeEquivalentTo(managedPlc.GetGeneration()))
+
+			statuses[condMap["type"].(string)] = condMap["status"].(string)
+		}
+
+		return statuses
+	}
+
+	AfterAll(func() {
+		deleteConfigPolicies([]string{policyName, templateErrPolicyName})
+	})
+
+	It("reports the conditions of a compliant policy in inform mode", func() {
+		utils.Kubectl("apply", "-f", policyYAML, "-n", testNamespace)
+
+		Eventually(func(g Gomega) {
+			g.Expect(getConditions(g, policyName)).To(Equal(map[string]string{
+				"Compliant":        "True",
+				"Enforced":         "False",
+				"TemplateResolved": "True",
+				"Progressing":      "False",
+				"Degraded":         "False",
+			}))
+		}, defaultTimeoutSeconds, 1).Should(Succeed())
+
+		// The conditions can be used with kubectl wait
+		utils.Kubectl("wait", "configurationpolicy", policyName, "-n", testNamespace,
+			"--for=condition=Compliant", "--timeout=10s")
+	})
+
+	It("reports the conditions of a policy with a template error", func() {
+		utils.Kubectl("apply", "-f", templateErrPolicyYAML, "-n", testNamespace)
+
+		Eventually(func(g Gomega) {
+			g.Expect(getConditions(g, templateErrPolicyName)).To(Equal(map[string]string{
+				"Compliant":        "False",
+				"Enforced":         "False",
+				"TemplateResolved": "False",
+				"Progressing":      "False",
+				"Degraded":         "True",
+			}))
+		}, defaultTimeoutSeconds, 1).Should(Succeed())
+	})
+})